@@ -0,0 +1,51 @@
+'use client';
+
+import React, { Suspense, useEffect, useRef, useState } from 'react';
+import Link from 'next/link';
+import { useSearchParams } from 'next/navigation';
+import { confirmEmailChange } from '@/services/api';
+
+function ConfirmEmail() {
+  const token = useSearchParams().get('token');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState(token ? '' : 'This link is incomplete.');
+  const confirmed = useRef(false);
+
+  useEffect(() => {
+    if (!token || confirmed.current) return;
+    confirmed.current = true;
+    confirmEmailChange(token)
+      .then(() => setMessage('Your email address has been changed. Use the new address to sign in from now on.'))
+      .catch((err: any) => setError(err.response?.data?.fields?.token || err.response?.data?.error || 'Something went wrong'));
+  }, [token]);
+
+  return (
+    <div className="w-full max-w-sm bg-white dark:bg-gray-900 rounded-2xl shadow-2xl p-8">
+      <h2 className="text-2xl font-bold text-gray-900 dark:text-white mb-6 text-center">Confirm your new email</h2>
+
+      {!message && !error && <p className="text-gray-500 dark:text-gray-400 text-center text-sm">Checking your link...</p>}
+      {error && (
+        <div className="bg-red-50 dark:bg-red-900/20 text-red-600 dark:text-red-400 p-3 rounded-lg text-sm">{error}</div>
+      )}
+      {message && (
+        <div className="bg-green-50 dark:bg-green-900/20 text-green-700 dark:text-green-400 p-3 rounded-lg text-sm">{message}</div>
+      )}
+
+      <p className="mt-6 text-center text-sm">
+        <Link href="/login" className="font-semibold text-blue-600 hover:underline dark:text-blue-400">
+          Back to sign in
+        </Link>
+      </p>
+    </div>
+  );
+}
+
+export default function ConfirmEmailPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 dark:bg-gray-950 p-4 transition-colors">
+      <Suspense>
+        <ConfirmEmail />
+      </Suspense>
+    </div>
+  );
+}
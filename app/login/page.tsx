@@ -1,21 +1,42 @@
 'use client';
 
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
+import Link from 'next/link';
 import { useRouter } from 'next/navigation';
 import { useAuth } from '@/context/AuthContext';
 import { FiMail, FiLock, FiArrowRight, FiUser, FiCheckCircle } from 'react-icons/fi';
 import { motion, AnimatePresence } from 'framer-motion';
 import clsx from 'clsx';
+import { getOIDCProviders, startOIDCLogin, OIDCProvider } from '@/services/api';
 
 export default function LoginPage() {
   const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
+  // Set while the login waits for a two-factor code
+  const [mfaToken, setMfaToken] = useState('');
+  const [code, setCode] = useState('');
   const [isLogin, setIsLogin] = useState(true);
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
+  const [providers, setProviders] = useState<OIDCProvider[]>([]);
 
   const router = useRouter();
-  const { login, register } = useAuth();
+  const { login, completeMfa, register } = useAuth();
+
+  useEffect(() => {
+    getOIDCProviders().then(setProviders).catch(() => setProviders([]));
+  }, []);
+
+  const signInWith = async (provider: string) => {
+    setError('');
+    setLoading(true);
+    try {
+      window.location.assign(await startOIDCLogin(provider));
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Could not reach the sign-in provider');
+      setLoading(false);
+    }
+  };
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
@@ -24,8 +45,14 @@ export default function LoginPage() {
 
     try {
       let result;
-      if (isLogin) {
+      if (mfaToken) {
+        result = await completeMfa(mfaToken, code);
+      } else if (isLogin) {
         result = await login(email, password);
+        if (result.mfaToken) {
+          setMfaToken(result.mfaToken);
+          return;
+        }
       } else {
         const username = email.split('@')[0];
         result = await register(username, email, password);
@@ -47,6 +74,8 @@ export default function LoginPage() {
   const toggleMode = () => {
     setIsLogin(!isLogin);
     setError('');
+    setMfaToken('');
+    setCode('');
     setEmail('');
     setPassword('');
   };
@@ -142,40 +171,73 @@ export default function LoginPage() {
                 </motion.div>
               )}
 
-              <div>
-                <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1.5">Email Address</label>
-                <div className="relative group">
-                  <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
-                    <FiMail className="text-gray-400 group-focus-within:text-blue-500 transition-colors" />
+              {mfaToken ? (
+                <div>
+                  <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1.5">Authentication code</label>
+                  <div className="relative group">
+                    <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+                      <FiLock className="text-gray-400 group-focus-within:text-blue-500 transition-colors" />
+                    </div>
+                    <input
+                      type="text"
+                      inputMode="numeric"
+                      autoComplete="one-time-code"
+                      autoFocus
+                      value={code}
+                      onChange={(e) => setCode(e.target.value)}
+                      required
+                      className="w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400"
+                      placeholder="123456"
+                    />
                   </div>
-                  <input
-                    type="email"
-                    value={email}
-                    onChange={(e) => setEmail(e.target.value)}
-                    required
-                    className="w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400"
-                    placeholder="name@example.com"
-                  />
+                  <p className="text-[10px] text-gray-400 mt-1 ml-1">* The code from your authenticator app, or a recovery code</p>
                 </div>
-              </div>
+              ) : (
+                <>
+                  <div>
+                    <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1.5">Email Address</label>
+                    <div className="relative group">
+                      <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+                        <FiMail className="text-gray-400 group-focus-within:text-blue-500 transition-colors" />
+                      </div>
+                      <input
+                        type="email"
+                        value={email}
+                        onChange={(e) => setEmail(e.target.value)}
+                        required
+                        className="w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400"
+                        placeholder="name@example.com"
+                      />
+                    </div>
+                  </div>
 
-              <div>
-                <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1.5">Password</label>
-                <div className="relative group">
-                  <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
-                    <FiLock className="text-gray-400 group-focus-within:text-blue-500 transition-colors" />
+                  <div>
+                    <label className="block text-sm font-medium text-gray-700 dark:text-gray-300 mb-1.5">Password</label>
+                    <div className="relative group">
+                      <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+                        <FiLock className="text-gray-400 group-focus-within:text-blue-500 transition-colors" />
+                      </div>
+                      <input
+                        type="password"
+                        value={password}
+                        onChange={(e) => setPassword(e.target.value)}
+                        required
+                        minLength={6}
+                        className="w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400"
+                        placeholder="••••••••"
+                      />
+                    </div>
                   </div>
-                  <input
-                    type="password"
-                    value={password}
-                    onChange={(e) => setPassword(e.target.value)}
-                    required
-                    minLength={6}
-                    className="w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400"
-                    placeholder="••••••••"
-                  />
+                </>
+              )}
+
+              {isLogin && !mfaToken && (
+                <div className="text-right -mt-2">
+                  <Link href="/reset-password" className="text-sm text-blue-600 hover:underline dark:text-blue-400">
+                    Forgot password?
+                  </Link>
                 </div>
-              </div>
+              )}
 
               <button
                 type="submit"
@@ -201,6 +263,22 @@ export default function LoginPage() {
               </button>
             </form>
 
+            {!mfaToken && providers.length > 0 && (
+              <div className="mt-6 space-y-3">
+                {providers.map((provider) => (
+                  <button
+                    key={provider.id}
+                    type="button"
+                    disabled={loading}
+                    onClick={() => signInWith(provider.id)}
+                    className="w-full py-3 px-4 font-medium rounded-xl border border-gray-200 dark:border-gray-700 text-gray-700 dark:text-gray-200 hover:bg-gray-50 dark:hover:bg-gray-800 transition-colors"
+                  >
+                    Continue with {provider.name}
+                  </button>
+                ))}
+              </div>
+            )}
+
             <div className="mt-8 text-center text-sm">
               <p className="text-gray-500 dark:text-gray-400">
                 {isLogin ? "Don't have an account yet?" : "Already have an account?"}{' '}
@@ -0,0 +1,115 @@
+'use client';
+
+import React, { Suspense, useEffect, useRef, useState } from 'react';
+import Link from 'next/link';
+import { useRouter, useSearchParams } from 'next/navigation';
+import { FiLock, FiArrowRight } from 'react-icons/fi';
+import clsx from 'clsx';
+import { useAuth } from '@/context/AuthContext';
+
+const inputClass =
+  'w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400';
+
+function OIDCCallback() {
+  // The provider sends the browser back here with state and code, or with an error
+  const params = useSearchParams();
+  const router = useRouter();
+  const { completeOIDC, completeMfa } = useAuth();
+  const [mfaToken, setMfaToken] = useState('');
+  const [code, setCode] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(true);
+  const completed = useRef(false);
+
+  useEffect(() => {
+    if (completed.current) return;
+    completed.current = true;
+
+    const state = params.get('state');
+    const authCode = params.get('code');
+    if (!state || !authCode) {
+      setError(params.get('error_description') || params.get('error') || 'The sign-in was cancelled.');
+      setLoading(false);
+      return;
+    }
+
+    completeOIDC(state, authCode)
+      .then((result) => {
+        if (result.success) {
+          router.push('/');
+        } else if (result.mfaToken) {
+          setMfaToken(result.mfaToken);
+        } else {
+          setError(result.error || 'Login failed');
+        }
+      })
+      .finally(() => setLoading(false));
+  }, [params, completeOIDC, router]);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setLoading(true);
+
+    const result = await completeMfa(mfaToken, code);
+    setLoading(false);
+    if (result.success) {
+      router.push('/');
+    } else {
+      setError(result.error || 'Login failed');
+    }
+  };
+
+  return (
+    <div className="w-full max-w-sm bg-white dark:bg-gray-900 rounded-2xl shadow-2xl p-8">
+      <h2 className="text-2xl font-bold text-gray-900 dark:text-white mb-2 text-center">Signing you in</h2>
+      <p className="text-gray-500 dark:text-gray-400 text-center mb-6 text-sm">
+        {mfaToken ? 'Enter the code from your authenticator app or a recovery code.' : loading ? 'Just a moment...' : ''}
+      </p>
+
+      {error && (
+        <div className="bg-red-50 dark:bg-red-900/20 text-red-600 dark:text-red-400 p-3 rounded-lg text-sm mb-4">{error}</div>
+      )}
+
+      {mfaToken && (
+        <form onSubmit={handleSubmit} className="space-y-5">
+          <div className="relative group">
+            <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+              <FiLock className="text-gray-400" />
+            </div>
+            <input value={code} onChange={(e) => setCode(e.target.value)} required autoComplete="one-time-code" className={inputClass} placeholder="123456" />
+          </div>
+
+          <button
+            type="submit"
+            disabled={loading}
+            className={clsx(
+              'w-full py-3.5 px-4 text-white font-medium rounded-xl transition-all duration-200 shadow-lg shadow-blue-500/30',
+              loading ? 'bg-blue-400 cursor-not-allowed' : 'bg-blue-600 hover:bg-blue-700 active:scale-[0.98]'
+            )}
+          >
+            <span className="flex items-center justify-center gap-2">
+              {loading ? 'Processing...' : 'Verify'} <FiArrowRight />
+            </span>
+          </button>
+        </form>
+      )}
+
+      <p className="mt-6 text-center text-sm">
+        <Link href="/login" className="font-semibold text-blue-600 hover:underline dark:text-blue-400">
+          Back to sign in
+        </Link>
+      </p>
+    </div>
+  );
+}
+
+export default function OIDCCallbackPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 dark:bg-gray-950 p-4 transition-colors">
+      <Suspense>
+        <OIDCCallback />
+      </Suspense>
+    </div>
+  );
+}
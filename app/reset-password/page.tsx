@@ -0,0 +1,103 @@
+'use client';
+
+import React, { Suspense, useState } from 'react';
+import Link from 'next/link';
+import { useSearchParams } from 'next/navigation';
+import { FiMail, FiLock, FiArrowRight } from 'react-icons/fi';
+import clsx from 'clsx';
+import { forgotPassword, resetPassword } from '@/services/api';
+
+const inputClass =
+  'w-full pl-10 pr-4 py-3 bg-gray-50 dark:bg-gray-800 border border-gray-200 dark:border-gray-700 rounded-xl focus:ring-2 focus:ring-blue-500/20 focus:border-blue-500 outline-none transition-all text-gray-900 dark:text-white placeholder:text-gray-400';
+
+function ResetPasswordForm() {
+  // With a token from the email we set the new password, otherwise we ask for the email
+  const token = useSearchParams().get('token');
+  const [email, setEmail] = useState('');
+  const [password, setPassword] = useState('');
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+  const [loading, setLoading] = useState(false);
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+    setMessage('');
+    setLoading(true);
+
+    try {
+      if (token) {
+        await resetPassword(token, password);
+        setMessage('Your password has been changed. You can now sign in with it.');
+      } else {
+        const data = await forgotPassword(email);
+        setMessage(data.message);
+      }
+    } catch (err: any) {
+      const fields = err.response?.data?.fields;
+      setError((fields && (fields.password || fields.token || fields.email)) || err.response?.data?.error || 'Something went wrong');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="w-full max-w-sm bg-white dark:bg-gray-900 rounded-2xl shadow-2xl p-8">
+      <h2 className="text-2xl font-bold text-gray-900 dark:text-white mb-2 text-center">
+        {token ? 'Choose a new password' : 'Forgot your password?'}
+      </h2>
+      <p className="text-gray-500 dark:text-gray-400 text-center mb-6 text-sm">
+        {token ? 'Signing in with the new password signs out all your devices.' : "We'll email you a link to reset it."}
+      </p>
+
+      {error && (
+        <div className="bg-red-50 dark:bg-red-900/20 text-red-600 dark:text-red-400 p-3 rounded-lg text-sm mb-4">{error}</div>
+      )}
+      {message && (
+        <div className="bg-green-50 dark:bg-green-900/20 text-green-700 dark:text-green-400 p-3 rounded-lg text-sm mb-4">{message}</div>
+      )}
+
+      <form onSubmit={handleSubmit} className="space-y-5">
+        <div className="relative group">
+          <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+            {token ? <FiLock className="text-gray-400" /> : <FiMail className="text-gray-400" />}
+          </div>
+          {token ? (
+            <input type="password" value={password} onChange={(e) => setPassword(e.target.value)} required minLength={8} className={inputClass} placeholder="New password" />
+          ) : (
+            <input type="email" value={email} onChange={(e) => setEmail(e.target.value)} required className={inputClass} placeholder="name@example.com" />
+          )}
+        </div>
+
+        <button
+          type="submit"
+          disabled={loading}
+          className={clsx(
+            'w-full py-3.5 px-4 text-white font-medium rounded-xl transition-all duration-200 shadow-lg shadow-blue-500/30',
+            loading ? 'bg-blue-400 cursor-not-allowed' : 'bg-blue-600 hover:bg-blue-700 active:scale-[0.98]'
+          )}
+        >
+          <span className="flex items-center justify-center gap-2">
+            {loading ? 'Processing...' : token ? 'Change password' : 'Send reset link'} <FiArrowRight />
+          </span>
+        </button>
+      </form>
+
+      <p className="mt-6 text-center text-sm">
+        <Link href="/login" className="font-semibold text-blue-600 hover:underline dark:text-blue-400">
+          Back to sign in
+        </Link>
+      </p>
+    </div>
+  );
+}
+
+export default function ResetPasswordPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 dark:bg-gray-950 p-4 transition-colors">
+      <Suspense>
+        <ResetPasswordForm />
+      </Suspense>
+    </div>
+  );
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"aplikasi-todolist/internal/config"
+	"aplikasi-todolist/internal/jwtkeys"
+)
+
+const keysUsage = "usage: server keys list | generate [EdDSA|RS256] | activate <key ID> | rotate [EdDSA|RS256] | remove <key ID>"
+
+// runKeys implements the "server keys" subcommand, which manages the keys in
+// JWT_KEYS_DIR. Running servers pick up changes within a minute.
+func runKeys(cfg *config.Config, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return errors.New(keysUsage)
+	}
+	if cfg.JWTKeysDir == "" {
+		return errors.New("JWT_KEYS_DIR must be set")
+	}
+	dir := cfg.JWTKeysDir
+
+	// The algorithm of new keys defaults to Ed25519
+	alg := jwtkeys.AlgEdDSA
+	if len(args) > 1 {
+		alg = args[1]
+	}
+
+	switch args[0] {
+	case "list":
+		keys, err := jwtkeys.Load(dir)
+		if err != nil {
+			return err
+		}
+		signing := keys.Signing()
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY ID\tALGORITHM\tCREATED AT\tSTATE")
+		for _, key := range keys.Keys() {
+			state := "verifying"
+			if key.ID == signing.ID {
+				state = "signing"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key.ID, key.Algorithm, key.CreatedAt.Format("2006-01-02 15:04:05"), state)
+		}
+		return w.Flush()
+
+	case "generate":
+		key, err := jwtkeys.Generate(dir, alg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "generated %s key %s\n", key.Algorithm, key.ID)
+
+	case "activate":
+		if len(args) != 2 {
+			return errors.New(keysUsage)
+		}
+		if err := jwtkeys.Activate(dir, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "key %s signs new tokens\n", args[1])
+
+	case "rotate":
+		key, err := jwtkeys.Generate(dir, alg)
+		if err != nil {
+			return err
+		}
+		if err := jwtkeys.Activate(dir, key.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "generated %s key %s, it signs new tokens\n", key.Algorithm, key.ID)
+
+	case "remove":
+		if len(args) != 2 {
+			return errors.New(keysUsage)
+		}
+		if err := jwtkeys.Remove(dir, args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "removed key %s\n", args[1])
+
+	default:
+		return errors.New(keysUsage)
+	}
+
+	return nil
+}
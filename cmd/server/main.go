@@ -1,30 +1,214 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/joho/godotenv"
 
+	"aplikasi-todolist/internal/config"
 	"aplikasi-todolist/internal/handler"
+	"aplikasi-todolist/internal/jwtkeys"
+	"aplikasi-todolist/internal/mail"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/oidc"
 	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/service"
 )
 
 func main() {
 	// Load .env file
 	_ = godotenv.Load()
 
-	// Initialize database
-	err := repository.InitDB()
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal("Invalid configuration: ", err)
 	}
-	defer repository.CloseDB()
 
+	// "server migrate ..." manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), cfg, os.Stdout, os.Args[2:]); err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+
+	// "server keys ..." manages the access token signing keys and exits
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		if err := runKeys(cfg, os.Stdout, os.Args[2:]); err != nil {
+			log.Fatal("Key management failed: ", err)
+		}
+		return
+	}
+
+	// "server unlock <email or IP>" lifts a login lockout and exits
+	if len(os.Args) > 1 && os.Args[1] == "unlock" {
+		if err := runUnlock(context.Background(), cfg, os.Stdout, os.Args[2:]); err != nil {
+			log.Fatal("Unlock failed: ", err)
+		}
+		return
+	}
+
+	// "server role <email> <user|admin>" sets the role of a user and exits
+	if len(os.Args) > 1 && os.Args[1] == "role" {
+		if err := runRole(context.Background(), cfg, os.Stdout, os.Args[2:]); err != nil {
+			log.Fatal("Setting the role failed: ", err)
+		}
+		return
+	}
+
+	// Fail fast on missing or inconsistent settings
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+
+	// Stop accepting requests on SIGINT/SIGTERM and drain the in-flight ones
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the API until ctx is cancelled, then shuts down gracefully
+func run(ctx context.Context, cfg *config.Config) error {
+	if err := initJWT(cfg); err != nil {
+		return err
+	}
+
+	// Initialize repositories. The DATABASE_URL scheme selects the backend:
+	// PostgreSQL, sqlite:///path/to/todo.db for single-user setups, or
+	// memory:// to keep all data in process memory (development only).
+	stores, err := repository.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	// Deferred first so the database outlives the HTTP server's drain
+	defer stores.Close()
+	// Work the requests left running in the background, such as emails
+	// being sent, finishes before the database is closed
+	var background sync.WaitGroup
+	defer background.Wait()
+
+	if stores.Backend == repository.BackendMemory {
+		log.Println("Using in-memory storage, data will be lost on shutdown")
+	} else if cfg.AutoMigrate {
+		// Apply pending migrations at boot unless disabled
+		migrator, err := stores.NewMigrator()
+		if err != nil {
+			return fmt.Errorf("failed to load migrations: %w", err)
+		}
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to migrate database: %w", err)
+		}
+		for _, m := range applied {
+			log.Printf("Applied migration %03d_%s", m.Version, m.Name)
+		}
+	}
+
+	if stores.Backend == repository.BackendPostgres {
+		// Select the text search language, re-indexing todos if it changed
+		reindexed, err := repository.ConfigureSearch(ctx, cfg.SearchConfig)
+		if err != nil {
+			return fmt.Errorf("failed to configure search: %w", err)
+		}
+		if reindexed > 0 {
+			log.Printf("Re-indexed %d todos for text search configuration %s", reindexed, cfg.SearchConfig)
+		}
+	}
+
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Purge the accounts whose deletion grace period is over
+	go service.RunAccountPurge(ctx, stores.Users)
+
+	// Fire due reminders unless this instance is configured not to
+	if cfg.ReminderPollInterval > 0 {
+		scheduler := service.NewReminderScheduler(stores.Reminders, reminderChannels(cfg, stores, mailer), service.ReminderSchedulerConfig{
+			PollInterval: cfg.ReminderPollInterval,
+			Lease:        cfg.ReminderLease,
+		})
+		go scheduler.Run(ctx)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr(),
+		Handler:           newRouter(cfg, stores, mailer, &background),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down, waiting for in-flight requests to finish")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+
+	log.Println("Server stopped")
+	return nil
+}
+
+// newMailer selects how emails are delivered: SMTP in production, an outbox
+// directory or the log in development
+func newMailer(cfg *config.Config) (mail.Mailer, error) {
+	switch {
+	case cfg.SMTPHost != "":
+		return mail.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case cfg.MailOutboxDir != "":
+		log.Printf("Writing emails to %s", cfg.MailOutboxDir)
+		return mail.NewFileMailer(cfg.MailOutboxDir, cfg.MailFrom)
+	default:
+		log.Println("SMTP_HOST is not set, emails will only be logged")
+		return mail.LogMailer{}, nil
+	}
+}
+
+// reminderChannels are the channels reminders are delivered through
+func reminderChannels(cfg *config.Config, stores *repository.Stores, mailer mail.Mailer) map[string]service.ReminderChannel {
+	return map[string]service.ReminderChannel{
+		model.ReminderChannelInApp:   service.NewInAppChannel(stores.Notifications),
+		model.ReminderChannelEmail:   service.NewEmailChannel(mailer, cfg.AppURL),
+		model.ReminderChannelWebhook: service.NewWebhookChannel(cfg.ReminderWebhookTimeout),
+	}
+}
+
+// newRouter wires the middleware and routes. Work outliving a request is
+// tracked in background.
+func newRouter(cfg *config.Config, stores *repository.Stores, mailer mail.Mailer, background *sync.WaitGroup) http.Handler {
 	// Create router
 	r := chi.NewRouter()
 
@@ -34,7 +218,7 @@ func main() {
 	// Add CORS middleware
 	corsMiddleware := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://127.0.0.1:3000", "http://localhost:3001", "http://127.0.0.1:3001", "http://192.168.1.21:3000", "*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -43,41 +227,145 @@ func main() {
 	r.Use(corsMiddleware.Handler)
 
 	// Middleware
+	r.Use(handler.ClientIPMiddleware(cfg.TrustedProxies))
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.AllowContentType("application/json"))
 
-	// Initialize repositories
-	userRepo := &repository.UserRepository{}
-	todoRepo := &repository.TodoRepository{}
+	// Bound the time each request may spend on database queries
+	r.Use(handler.DeadlineMiddleware(cfg.QueryTimeout))
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(userRepo)
-	todoHandler := handler.NewTodoHandler(todoRepo)
+	tokenService := service.NewTokenService(stores.Users, stores.Tokens, cfg.RefreshTokenExpiry)
+	loginThrottle := service.NewLoginThrottle(stores.LoginAttempts, loginThrottleConfig(cfg))
+	twoFactorService := service.NewTwoFactorService(stores.Users, stores.TwoFactor, tokenService, loginThrottle, cfg.TOTPIssuer)
+	mailSender := service.NewMailSender(mailer, background)
+	authService := service.NewAuthService(stores.Users, stores.Tokens, tokenService, twoFactorService, loginThrottle, mailSender, service.AuthConfig{
+		AppURL:                  cfg.AppURL,
+		PasswordResetExpiry:     cfg.PasswordResetExpiry,
+		LinkSecret:              []byte(cfg.JWTSecret),
+		EmailVerificationExpiry: cfg.EmailVerificationExpiry,
+		RequireVerifiedEmail:    cfg.EmailVerification == config.EmailVerificationRequired,
+	})
+	oidcService := service.NewOIDCService(authService, stores.Users, stores.OIDC, oidcProviders(cfg), cfg.OIDCRedirectURL)
+	authHandler := handler.NewAuthHandler(authService, tokenService)
+	accountService := service.NewAccountService(tokenService, mailSender, stores.Users, stores.Todos, stores.TodoItems, stores.Tags, stores.Lists, stores.Reminders, stores.Notifications, loginThrottle, cfg.AccountDeletionGracePeriod)
+	userHandler := handler.NewUserHandler(authService, accountService)
+	accessTokenService := service.NewAccessTokenService(stores.Users, stores.AccessTokens)
+	twoFactorHandler := handler.NewTwoFactorHandler(twoFactorService)
+	accessTokenHandler := handler.NewAccessTokenHandler(accessTokenService)
+	oidcHandler := handler.NewOIDCHandler(oidcService)
+	todoHandler := handler.NewTodoHandler(stores.Todos, stores.TodoItems, stores.Tags, stores.Lists, stores.Reminders)
+	notificationHandler := handler.NewNotificationHandler(service.NewNotificationService(stores.Notifications))
+	adminService := service.NewAdminService(authService, tokenService, stores.Users, stores.Admin, loginThrottle)
+	adminHandler := handler.NewAdminHandler(adminService)
 
 	// Public routes
+	r.Get("/.well-known/jwks.json", handler.JWKS)
 	r.Post("/api/auth/register", authHandler.Register)
 	r.Post("/api/auth/login", authHandler.Login)
+	r.Post("/api/auth/login/mfa", twoFactorHandler.LoginMFA)
+	r.Post("/api/auth/refresh", authHandler.Refresh)
+	r.Post("/api/auth/logout", authHandler.Logout)
+	r.Post("/api/auth/forgot-password", authHandler.ForgotPassword)
+	r.Post("/api/auth/reset-password", authHandler.ResetPassword)
+	r.Post("/api/auth/verify-email", authHandler.VerifyEmail)
+	r.Post("/api/auth/verify-email/resend", authHandler.ResendVerification)
+	r.Get("/api/auth/oidc/providers", oidcHandler.Providers)
+	r.Post("/api/auth/oidc/{provider}/authorize", oidcHandler.Authorize)
+	r.Post("/api/auth/oidc/callback", oidcHandler.Callback)
+	r.Post("/api/users/confirm-email", userHandler.ConfirmEmail)
 
-	// Protected routes
+	// Protected routes. Personal access tokens only reach the groups of
+	// their scopes.
 	r.Group(func(r chi.Router) {
-		r.Use(handler.AuthMiddleware)
+		r.Use(handler.AuthMiddleware(tokenService, accessTokenService))
 
-		r.Get("/api/todos", todoHandler.GetTodos)
-		r.Post("/api/todos", todoHandler.CreateTodo)
-		r.Get("/api/todos/{id}", todoHandler.GetTodo)
-		r.Put("/api/todos/{id}", todoHandler.UpdateTodo)
-		r.Delete("/api/todos/{id}", todoHandler.DeleteTodo)
-	})
+		r.Group(func(r chi.Router) {
+			r.Use(handler.RequireScope(model.ScopeAccount))
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+			r.Get("/api/auth/sessions", authHandler.ListSessions)
+			r.Delete("/api/auth/sessions", authHandler.RevokeAllSessions)
+			r.Delete("/api/auth/sessions/{id}", authHandler.RevokeSession)
+
+			r.Get("/api/auth/2fa", twoFactorHandler.Status)
+			r.Post("/api/auth/2fa/enroll", twoFactorHandler.Enroll)
+			r.Post("/api/auth/2fa/confirm", twoFactorHandler.Confirm)
+			r.Post("/api/auth/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+			r.Post("/api/auth/2fa/disable", twoFactorHandler.Disable)
+
+			r.Get("/api/auth/tokens", accessTokenHandler.ListTokens)
+			r.Post("/api/auth/tokens", accessTokenHandler.CreateToken)
+			r.Delete("/api/auth/tokens/{id}", accessTokenHandler.RevokeToken)
+
+			r.Get("/api/users/me", userHandler.Me)
+			r.Patch("/api/users/me", userHandler.UpdateMe)
+			r.Delete("/api/users/me", userHandler.DeleteMe)
+			r.Get("/api/users/me/export", userHandler.Export)
+			r.Post("/api/users/me/password", userHandler.ChangePassword)
+			r.Post("/api/users/me/email", userHandler.ChangeEmail)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(handler.RequireScope(model.ScopeTodosRead))
 
-	log.Printf("Server starting on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+			r.Get("/api/todos", todoHandler.GetTodos)
+			r.Get("/api/todos/search", todoHandler.SearchTodos)
+			r.Get("/api/todos/{id}", todoHandler.GetTodo)
+			r.Get("/api/todos/{id}/items", todoHandler.ListItems)
+			r.Get("/api/todos/{id}/reminders", todoHandler.ListReminders)
+			r.Get("/api/tags", todoHandler.ListTags)
+			r.Get("/api/lists", todoHandler.ListLists)
+			r.Get("/api/lists/{id}", todoHandler.GetList)
+			r.Get("/api/notifications", notificationHandler.ListNotifications)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(handler.RequireScope(model.ScopeTodosWrite))
+
+			// Unverified users can only read their to-dos in limited mode
+			if cfg.EmailVerification == config.EmailVerificationLimited {
+				r.Use(handler.RequireVerifiedEmail(authService))
+			}
+
+			r.Post("/api/todos", todoHandler.CreateTodo)
+			r.Put("/api/todos/{id}", todoHandler.UpdateTodo)
+			r.Delete("/api/todos/{id}", todoHandler.DeleteTodo)
+			r.Post("/api/todos/{id}/recurrence/skip", todoHandler.SkipOccurrence)
+			r.Delete("/api/todos/{id}/recurrence", todoHandler.StopRecurrence)
+			r.Post("/api/todos/{id}/items", todoHandler.CreateItem)
+			r.Put("/api/todos/{id}/items/order", todoHandler.ReorderItems)
+			r.Put("/api/todos/{id}/items/{itemID}", todoHandler.UpdateItem)
+			r.Delete("/api/todos/{id}/items/{itemID}", todoHandler.DeleteItem)
+			r.Post("/api/todos/{id}/reminders", todoHandler.CreateReminder)
+			r.Delete("/api/todos/{id}/reminders/{reminderID}", todoHandler.DeleteReminder)
+			r.Post("/api/tags", todoHandler.CreateTag)
+			r.Patch("/api/tags/{id}", todoHandler.UpdateTag)
+			r.Delete("/api/tags/{id}", todoHandler.DeleteTag)
+			r.Post("/api/tags/{id}/merge", todoHandler.MergeTag)
+			r.Post("/api/lists", todoHandler.CreateList)
+			r.Put("/api/lists/order", todoHandler.ReorderLists)
+			r.Patch("/api/lists/{id}", todoHandler.UpdateList)
+			r.Delete("/api/lists/{id}", todoHandler.DeleteList)
+			r.Post("/api/notifications/read-all", notificationHandler.MarkAllRead)
+			r.Post("/api/notifications/{id}/read", notificationHandler.MarkRead)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(handler.RequireScope(model.ScopeAdmin))
+			r.Use(handler.RequireRole(adminService, model.RoleAdmin))
+
+			r.Get("/api/admin/users", adminHandler.ListUsers)
+			r.Get("/api/admin/users/{id}", adminHandler.GetUser)
+			r.Post("/api/admin/users/{id}/disable", adminHandler.DisableUser)
+			r.Post("/api/admin/users/{id}/enable", adminHandler.EnableUser)
+			r.Post("/api/admin/users/{id}/password-reset", adminHandler.ForcePasswordReset)
+			r.Post("/api/admin/unlock", adminHandler.Unlock)
+			r.Get("/api/admin/stats", adminHandler.Stats)
+		})
+	})
+
+	return r
 }
 
 // securityHeadersMiddleware adds security headers to all responses
@@ -107,3 +395,48 @@ func securityHeadersMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// oidcProviders converts the configured OpenID providers for the service
+func oidcProviders(cfg *config.Config) []service.OIDCProviderConfig {
+	var providers []service.OIDCProviderConfig
+	for _, p := range cfg.OIDCProviders {
+		providers = append(providers, service.OIDCProviderConfig{
+			ID:   p.ID,
+			Name: p.Name,
+			Config: oidc.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				Scopes:       p.Scopes,
+			},
+		})
+	}
+	return providers
+}
+
+// initJWT configures the access tokens, signed with the keys in
+// JWT_KEYS_DIR if set and with JWT_SECRET otherwise
+func initJWT(cfg *config.Config) error {
+	jwtCfg := service.JWTConfig{
+		Secret:   cfg.JWTSecret,
+		Expiry:   cfg.JWTExpiry,
+		Audience: cfg.JWTAudience,
+	}
+	if cfg.JWTKeysDir != "" {
+		keys, err := jwtkeys.Load(cfg.JWTKeysDir)
+		if err != nil {
+			return fmt.Errorf("failed to load JWT signing keys: %w", err)
+		}
+		jwtCfg.Keys = keys
+	}
+	return service.InitJWT(jwtCfg)
+}
+
+// loginThrottleConfig returns the login throttling settings of cfg
+func loginThrottleConfig(cfg *config.Config) service.LoginThrottleConfig {
+	return service.LoginThrottleConfig{
+		EmailThreshold: cfg.LoginLockoutThreshold,
+		IPThreshold:    cfg.LoginIPLockoutThreshold,
+		Lockout:        cfg.LoginLockoutDuration,
+	}
+}
@@ -1,23 +1,1662 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"aplikasi-todolist/internal/config"
 	"aplikasi-todolist/internal/handler"
+	"aplikasi-todolist/internal/jwtkeys"
+	"aplikasi-todolist/internal/mail"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/oidc"
+	"aplikasi-todolist/internal/oidc/oidctest"
 	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/service"
+	"aplikasi-todolist/internal/totp"
 )
 
 func TestInitialization(t *testing.T) {
 	// Test that handlers can be initialized without errors
 	userRepo := &repository.UserRepository{}
 	todoRepo := &repository.TodoRepository{}
-	
-	authHandler := handler.NewAuthHandler(userRepo)
-	todoHandler := handler.NewTodoHandler(todoRepo)
+	tokenRepo := &repository.TokenRepository{}
+
+	tokenService := service.NewTokenService(userRepo, tokenRepo, time.Hour)
+	loginThrottle := service.NewLoginThrottle(&repository.LoginAttemptRepository{}, service.LoginThrottleConfig{})
+	twoFactorService := service.NewTwoFactorService(userRepo, &repository.TwoFactorRepository{}, tokenService, loginThrottle, "Todo List")
+	mailSender := service.NewMailSender(mail.LogMailer{}, &sync.WaitGroup{})
+	authService := service.NewAuthService(userRepo, tokenRepo, tokenService, twoFactorService, loginThrottle, mailSender, service.AuthConfig{})
+	accessTokenService := service.NewAccessTokenService(userRepo, &repository.AccessTokenRepository{})
+	authHandler := handler.NewAuthHandler(authService, tokenService)
+	accessTokenHandler := handler.NewAccessTokenHandler(accessTokenService)
+	accountService := service.NewAccountService(tokenService, mailSender, userRepo, todoRepo, &repository.TodoItemRepository{}, &repository.TagRepository{}, &repository.ListRepository{}, &repository.ReminderRepository{}, &repository.NotificationRepository{}, loginThrottle, time.Hour)
+	userHandler := handler.NewUserHandler(authService, accountService)
+	todoHandler := handler.NewTodoHandler(todoRepo, &repository.TodoItemRepository{}, &repository.TagRepository{}, &repository.ListRepository{}, &repository.ReminderRepository{})
+	notificationHandler := handler.NewNotificationHandler(service.NewNotificationService(&repository.NotificationRepository{}))
+	adminService := service.NewAdminService(authService, tokenService, userRepo, &repository.AdminRepository{}, loginThrottle)
+	adminHandler := handler.NewAdminHandler(adminService)
 
 	// Verify handlers are created
 	assert.NotNil(t, authHandler)
+	assert.NotNil(t, accessTokenHandler)
+	assert.NotNil(t, userHandler)
 	assert.NotNil(t, todoHandler)
-}
\ No newline at end of file
+	assert.NotNil(t, notificationHandler)
+	assert.NotNil(t, adminHandler)
+}
+
+// apiClient sends JSON requests to the router under test
+type apiClient struct {
+	t      *testing.T
+	router http.Handler
+	token  string
+	// header is sent with every request
+	header http.Header
+	outbox *outbox
+	stores *repository.Stores
+}
+
+// outbox is a Mailer collecting the sent messages
+type outbox struct {
+	messages chan mail.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mail.Message) error {
+	o.messages <- msg
+	return nil
+}
+
+// nextMail waits for the next sent message with the given subject, skipping
+// any others. Mails are sent in the background, so their order isn't fixed.
+func (c *apiClient) nextMail(subject string) mail.Message {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg := <-c.outbox.messages:
+			if msg.Subject == subject {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("no mail %q was sent", subject)
+			return mail.Message{}
+		}
+	}
+}
+
+// linkToken returns the token of the link with the given prefix in a mail
+func (c *apiClient) linkToken(msg mail.Message, prefix string) string {
+	require.Contains(c.t, msg.Body, prefix)
+	return strings.Fields(msg.Body[strings.Index(msg.Body, prefix)+len(prefix):])[0]
+}
+
+func (c *apiClient) do(method, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(c.t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	rec := httptest.NewRecorder()
+	c.router.ServeHTTP(rec, req)
+
+	var decoded map[string]interface{}
+	if rec.Body.Len() > 0 {
+		require.NoError(c.t, json.Unmarshal(rec.Body.Bytes(), &decoded), rec.Body.String())
+	}
+	return rec, decoded
+}
+
+// newTestClient creates a client for a router on in-memory stores, with
+// opts adjusting the configuration
+func newTestClient(t *testing.T, opts ...func(*config.Config)) *apiClient {
+	cfg := &config.Config{
+		JWTSecret:               "test-secret",
+		JWTAudience:             "todolist-api",
+		JWTExpiry:               time.Hour,
+		QueryTimeout:            time.Second,
+		RefreshTokenExpiry:      24 * time.Hour,
+		AppURL:                  "http://app.example.com",
+		PasswordResetExpiry:     time.Hour,
+		EmailVerification:       config.EmailVerificationOptional,
+		EmailVerificationExpiry: time.Hour,
+		TOTPIssuer:              "Todo List",
+		LoginLockoutThreshold:   10,
+		LoginIPLockoutThreshold: 100,
+		LoginLockoutDuration:    15 * time.Minute,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	require.NoError(t, initJWT(cfg))
+	mailer := &outbox{messages: make(chan mail.Message, 100)}
+	stores := repository.NewMemoryStores()
+	router := newRouter(cfg, stores, mailer, &sync.WaitGroup{})
+	return &apiClient{t: t, router: router, outbox: mailer, stores: stores}
+}
+
+func TestErrorResponses(t *testing.T) {
+	c := newTestClient(t)
+
+	register := map[string]string{"username": "budi", "email": "budi@example.com", "password": "Passw0rd1"}
+	rec, _ := c.do("POST", "/api/auth/register", register)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec, body := c.do("POST", "/api/auth/register", register)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "conflict", body["code"])
+
+	rec, body = c.do("POST", "/api/auth/register", map[string]string{"username": "x", "email": "bad", "password": "short"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "validation_failed", body["code"])
+	assert.Len(t, body["fields"], 3)
+
+	rec, body = c.do("POST", "/api/auth/login", map[string]string{"email": "budi@example.com", "password": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "unauthorized", body["code"])
+
+	_, body = c.do("POST", "/api/auth/login", map[string]string{"email": "budi@example.com", "password": "Passw0rd1"})
+	c.token = body["token"].(string)
+
+	rec, body = c.do("PUT", "/api/todos/999", map[string]string{"title": `quote " inside`})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "todo not found", body["error"])
+
+	rec, body = c.do("POST", "/api/todos", map[string]string{"title": `say "hi"`, "due_date": "tomorrow"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "due_date")
+
+	rec, body = c.do("POST", "/api/todos", map[string]string{"title": `say "hi"`})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `say "hi"`, body["title"])
+
+	rec, body = c.do("GET", "/api/todos/search", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "q")
+
+	rec, body = c.do("GET", "/api/todos/search?q=%22say+hi%22", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body["results"], 1)
+}
+
+func TestTodoItems(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "wati", "email": "wati@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	_, todo := c.do("POST", "/api/todos", map[string]string{"title": "Pack for trip"})
+	assert.Equal(t, map[string]interface{}{"done": 0.0, "total": 0.0}, todo["progress"])
+	todoPath := fmt.Sprintf("/api/todos/%d", int(todo["id"].(float64)))
+
+	var ids []int
+	for _, title := range []string{"Passport", "Charger", "Socks"} {
+		rec, item := c.do("POST", todoPath+"/items", map[string]string{"title": title})
+		require.Equal(t, http.StatusCreated, rec.Code)
+		ids = append(ids, int(item["id"].(float64)))
+	}
+	rec, body := c.do("POST", todoPath+"/items", map[string]string{"title": ""})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "title")
+
+	rec, item := c.do("PUT", fmt.Sprintf("%s/items/%d", todoPath, ids[1]), map[string]bool{"is_done": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, true, item["is_done"])
+	rec, _ = c.do("PUT", fmt.Sprintf("/api/todos/999/items/%d", ids[1]), map[string]bool{"is_done": true})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// The list has progress counts, a single todo its items
+	_, body = c.do("GET", "/api/todos", nil)
+	listed := body["todos"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"done": 1.0, "total": 3.0}, listed["progress"])
+	assert.NotContains(t, listed, "items")
+	_, body = c.do("GET", "/api/todos?include=items", nil)
+	assert.Len(t, body["todos"].([]interface{})[0].(map[string]interface{})["items"], 3)
+	_, body = c.do("GET", todoPath, nil)
+	assert.Len(t, body["items"], 3)
+
+	// Reordering must list every item exactly once
+	rec, body = c.do("PUT", todoPath+"/items/order", map[string][]int{"item_ids": {ids[2], ids[0]}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "item_ids")
+	rec, body = c.do("PUT", todoPath+"/items/order", map[string][]int{"item_ids": {ids[2], ids[0], ids[1]}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	var titles []string
+	for _, item := range body["items"].([]interface{}) {
+		titles = append(titles, item.(map[string]interface{})["title"].(string))
+	}
+	assert.Equal(t, []string{"Socks", "Passport", "Charger"}, titles)
+
+	// Completing the todo can complete its items
+	rec, body = c.do("PUT", todoPath, map[string]bool{"is_done": true, "complete_items": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]interface{}{"done": 3.0, "total": 3.0}, body["progress"])
+
+	rec, _ = c.do("DELETE", fmt.Sprintf("%s/items/%d", todoPath, ids[0]), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec, body = c.do("GET", todoPath+"/items", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body["items"], 2)
+
+	// The export has the checklists
+	_, body = c.do("GET", "/api/users/me/export", nil)
+	assert.Len(t, body["todos"].([]interface{})[0].(map[string]interface{})["items"], 2)
+}
+
+func TestTags(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "dewi", "email": "dewi@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "dewi@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	rec, body := c.do("POST", "/api/tags", map[string]string{"name": "Work", "color": "#FF0000"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "#ff0000", body["color"])
+	workID := int(body["id"].(float64))
+	rec, body = c.do("POST", "/api/tags", map[string]string{"name": "work"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec, body = c.do("POST", "/api/tags", map[string]string{"name": "a,b"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "name")
+	rec, body = c.do("POST", "/api/tags", map[string]string{"name": "Home", "color": "red"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "color")
+
+	// Tags given on todos are matched in any case or created
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Report", "tags": []string{"WORK", "urgent", "Urgent"}})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Len(t, body["tags"], 2)
+	_, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Groceries", "tags": []string{"home"}})
+	groceries := fmt.Sprintf("/api/todos/%d", int(body["id"].(float64)))
+	c.do("POST", "/api/todos", map[string]interface{}{"title": "Standup", "tags": []string{"work"}})
+
+	titles := func(path string) []string {
+		rec, body := c.do("GET", path, nil)
+		require.Equal(t, http.StatusOK, rec.Code, body)
+		var out []string
+		for _, todo := range body["todos"].([]interface{}) {
+			out = append(out, todo.(map[string]interface{})["title"].(string))
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Report", "Standup"}, titles("/api/todos?sort=title&tags=work"))
+	assert.Equal(t, []string{"Report"}, titles("/api/todos?sort=title&tags=work,urgent"))
+	assert.Equal(t, []string{"Groceries", "Report"}, titles("/api/todos?sort=title&any_tags=home,urgent"))
+	assert.Equal(t, []string{"Groceries", "Standup"}, titles("/api/todos?sort=title&exclude_tags=urgent"))
+
+	// Updating replaces the tags; an empty list removes them
+	rec, body = c.do("PUT", groceries, map[string]interface{}{"tags": []string{"Home", "errands"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body["tags"], 2)
+	rec, body = c.do("PUT", groceries, map[string]interface{}{"tags": []string{}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, body, "tags")
+
+	// A rename shows on every todo; a taken name must be merged instead
+	rec, body = c.do("PATCH", fmt.Sprintf("/api/tags/%d", workID), map[string]string{"name": "Office"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"Report", "Standup"}, titles("/api/todos?sort=title&tags=office"))
+	rec, _ = c.do("PATCH", fmt.Sprintf("/api/tags/%d", workID), map[string]string{"name": "URGENT"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	_, body = c.do("GET", "/api/tags", nil)
+	ids := map[string]int{}
+	for _, tag := range body["tags"].([]interface{}) {
+		tag := tag.(map[string]interface{})
+		ids[tag["name"].(string)] = int(tag["id"].(float64))
+	}
+	assert.Len(t, ids, 4)
+
+	rec, _ = c.do("POST", fmt.Sprintf("/api/tags/%d/merge", workID), map[string]int{"into": workID})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, body = c.do("POST", fmt.Sprintf("/api/tags/%d/merge", ids["urgent"]), map[string]int{"into": workID})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Office", body["name"])
+	assert.Equal(t, 2.0, body["todo_count"])
+	rec, _ = c.do("GET", "/api/todos?tags=urgent", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec, _ = c.do("DELETE", fmt.Sprintf("/api/tags/%d", workID), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, titles("/api/todos?tags=office"))
+}
+
+func TestLists(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "putri", "email": "putri@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "putri@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	rec, body := c.do("POST", "/api/lists", map[string]string{"name": "Work", "color": "#3366FF", "icon": "briefcase"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "#3366ff", body["color"])
+	assert.Equal(t, 0.0, body["todo_count"])
+	workID := int(body["id"].(float64))
+	rec, _ = c.do("POST", "/api/lists", map[string]string{"name": "WORK"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec, body = c.do("POST", "/api/lists", map[string]string{"name": ""})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "name")
+
+	// Todos join a list by ID, or by name as a category for older clients
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Report", "list_id": workID})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, float64(workID), body["list_id"])
+	assert.Equal(t, "Work", body["category"])
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Groceries", "category": "Home"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	homeID := int(body["list_id"].(float64))
+	groceries := fmt.Sprintf("/api/todos/%d", int(body["id"].(float64)))
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Inbox"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, body["list_id"])
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Lost", "list_id": 9999})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "list_id")
+
+	titles := func(path string) []string {
+		rec, body := c.do("GET", path, nil)
+		require.Equal(t, http.StatusOK, rec.Code, body)
+		var out []string
+		for _, todo := range body["todos"].([]interface{}) {
+			out = append(out, todo.(map[string]interface{})["title"].(string))
+		}
+		return out
+	}
+	assert.Equal(t, []string{"Report"}, titles(fmt.Sprintf("/api/todos?list_id=%d", workID)))
+	assert.Equal(t, []string{"Groceries"}, titles("/api/todos?category=home"))
+	assert.Empty(t, titles("/api/todos?category=Missing"))
+
+	// Renaming a list renames the category of its todos
+	rec, body = c.do("PATCH", fmt.Sprintf("/api/lists/%d", workID), map[string]string{"name": "Office"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1.0, body["todo_count"])
+	assert.Equal(t, []string{"Report"}, titles("/api/todos?category=office"))
+
+	rec, body = c.do("PUT", "/api/lists/order", map[string][]int{"list_ids": {homeID}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, body = c.do("PUT", "/api/lists/order", map[string][]int{"list_ids": {homeID, workID}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	lists := body["lists"].([]interface{})
+	require.Len(t, lists, 2)
+	assert.Equal(t, "Home", lists[0].(map[string]interface{})["name"])
+
+	// Archived lists are hidden unless asked for
+	rec, _ = c.do("PATCH", fmt.Sprintf("/api/lists/%d", homeID), map[string]bool{"archived": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	_, body = c.do("GET", "/api/lists", nil)
+	assert.Len(t, body["lists"], 1)
+	_, body = c.do("GET", "/api/lists?include_archived=true", nil)
+	assert.Len(t, body["lists"], 2)
+
+	// Moving a todo out of its list
+	rec, body = c.do("PUT", groceries, map[string]interface{}{"list_id": 0})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, body["list_id"])
+	assert.Equal(t, "", body["category"])
+	rec, body = c.do("PUT", groceries, map[string]interface{}{"category": "home"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, float64(homeID), body["list_id"])
+
+	// Deleting a list moves its todos or deletes them
+	rec, _ = c.do("DELETE", fmt.Sprintf("/api/lists/%d?move_to=%d", homeID, homeID), nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = c.do("DELETE", fmt.Sprintf("/api/lists/%d?move_to=%d", homeID, workID), nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, []string{"Groceries", "Report"}, titles(fmt.Sprintf("/api/todos?sort=title&list_id=%d", workID)))
+	rec, _ = c.do("GET", fmt.Sprintf("/api/lists/%d", homeID), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec, _ = c.do("DELETE", fmt.Sprintf("/api/lists/%d?delete_todos=true", workID), nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, []string{"Inbox"}, titles("/api/todos"))
+}
+
+func TestRecurringTodos(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "rina", "email": "rina@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "rina@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	rec, body := c.do("POST", "/api/todos", map[string]interface{}{"title": "Rent", "recurrence_rule": "FREQ=MONTHLY;BYMONTHDAY=1"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "due_date")
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Rent", "due_date": "2026-11-01", "recurrence_rule": "FREQ=HOURLY"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "recurrence_rule")
+
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{
+		"title": "Rent", "due_date": "2026-11-01", "recurrence_rule": "rrule:freq=monthly;bymonthday=1;count=3", "tags": []string{"bills"},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "FREQ=MONTHLY;COUNT=3;BYMONTHDAY=1", body["recurrence_rule"])
+	assert.Equal(t, "due_date", body["recurrence_mode"])
+	rent := fmt.Sprintf("/api/todos/%d", int(body["id"].(float64)))
+	rec, _ = c.do("POST", rent+"/items", map[string]string{"title": "Transfer"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// Completing it creates the next occurrence with the tags and checklist
+	rec, body = c.do("PUT", rent, map[string]interface{}{"is_done": true, "complete_items": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, body["recurrence_rule"])
+	next := body["next_occurrence"].(map[string]interface{})
+	assert.Equal(t, "2026-12-01T00:00:00Z", next["due_date"])
+	assert.Equal(t, "FREQ=MONTHLY;COUNT=2;BYMONTHDAY=1", next["recurrence_rule"])
+	assert.Equal(t, false, next["is_done"])
+	assert.Len(t, next["tags"], 1)
+	assert.Equal(t, map[string]interface{}{"done": 0.0, "total": 1.0}, next["progress"])
+	nextPath := fmt.Sprintf("/api/todos/%d", int(next["id"].(float64)))
+
+	// Completing a done todo again doesn't repeat it
+	rec, body = c.do("PUT", rent, map[string]interface{}{"is_done": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, body["next_occurrence"])
+
+	// Skipping moves to the last occurrence, which can't be skipped
+	rec, body = c.do("POST", nextPath+"/recurrence/skip", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2027-01-01T00:00:00Z", body["due_date"])
+	assert.Equal(t, "FREQ=MONTHLY;COUNT=1;BYMONTHDAY=1", body["recurrence_rule"])
+	rec, _ = c.do("POST", nextPath+"/recurrence/skip", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec, body = c.do("PUT", nextPath, map[string]interface{}{"is_done": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, body["next_occurrence"])
+
+	// Repeating from completion counts from today
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{
+		"title": "Water plants", "due_date": "2020-01-01", "recurrence_rule": "FREQ=DAILY;INTERVAL=3", "recurrence_mode": "completion",
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	plants := fmt.Sprintf("/api/todos/%d", int(body["id"].(float64)))
+	rec, body = c.do("PUT", plants, map[string]interface{}{"is_done": true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	want := time.Now().UTC().AddDate(0, 0, 3).Format("2006-01-02") + "T00:00:00Z"
+	assert.Equal(t, want, body["next_occurrence"].(map[string]interface{})["due_date"])
+
+	// Stopping the recurrence keeps the due date
+	plants = fmt.Sprintf("/api/todos/%d", int(body["next_occurrence"].(map[string]interface{})["id"].(float64)))
+	rec, body = c.do("DELETE", plants+"/recurrence", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, body["recurrence_rule"])
+	assert.Equal(t, want, body["due_date"])
+	rec, _ = c.do("POST", plants+"/recurrence/skip", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// Only a todo that doesn't repeat can lose its due date
+	rec, body = c.do("PUT", nextPath, map[string]interface{}{"due_date": "", "recurrence_rule": "FREQ=WEEKLY"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "due_date")
+	rec, body = c.do("PUT", plants, map[string]interface{}{"due_date": ""})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, body["due_date"])
+	_, body = c.do("GET", plants, nil)
+	assert.Nil(t, body["due_date"])
+}
+
+func TestReminders(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "dewi", "email": "dewi@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "dewi@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	due := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+	rec, body := c.do("POST", "/api/todos", map[string]interface{}{"title": "Dentist", "due_date": due.Format(time.RFC3339)})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	todo := fmt.Sprintf("/api/todos/%d", int(body["id"].(float64)))
+	rec, body = c.do("POST", "/api/todos", map[string]interface{}{"title": "Someday"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	someday := fmt.Sprintf("/api/todos/%d", int(body["id"].(float64)))
+
+	// Reminders fire either at a time or before the due date
+	for _, payload := range []map[string]interface{}{
+		{},
+		{"remind_at": due.Format(time.RFC3339), "offset_minutes": 10},
+		{"offset_minutes": -5},
+		{"offset_minutes": 10, "channels": []string{"sms"}},
+		{"offset_minutes": 10, "channels": []string{"webhook"}},
+		{"offset_minutes": 10, "channels": []string{"webhook"}, "webhook_url": "ftp://example.com"},
+		{"offset_minutes": 10, "webhook_url": "https://example.com"},
+		{"offset_minutes": 10, "channels": []string{"webhook"}, "webhook_url": "http://127.0.0.1:8080/hook"},
+		{"offset_minutes": 10, "channels": []string{"webhook"}, "webhook_url": "http://10.0.0.5/hook"},
+		{"offset_minutes": 10, "channels": []string{"webhook"}, "webhook_url": "http://169.254.169.254/latest/meta-data"},
+		{"offset_minutes": 10, "channels": []string{"webhook"}, "webhook_url": "http://[::1]/hook"},
+		{"offset_minutes": 10, "channels": []string{"webhook"}, "webhook_url": "http://LocalHost./hook"},
+	} {
+		rec, _ = c.do("POST", todo+"/reminders", payload)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, payload)
+	}
+	rec, body = c.do("POST", todo+"/reminders", map[string]interface{}{"remind_at": "2020-01-01T00:00:00Z"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "remind_at")
+	rec, body = c.do("POST", someday+"/reminders", map[string]interface{}{"offset_minutes": 10})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "offset_minutes")
+
+	rec, body = c.do("POST", todo+"/reminders", map[string]interface{}{"offset_minutes": 60, "channels": []string{"in_app", "email", "in_app"}})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, []interface{}{"in_app", "email"}, body["channels"])
+	assert.Equal(t, due.Add(-time.Hour).Format(time.RFC3339), body["fire_at"])
+	relative := int(body["id"].(float64))
+	remindAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	rec, body = c.do("POST", todo+"/reminders", map[string]interface{}{"remind_at": remindAt.Format(time.RFC3339)})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, []interface{}{"in_app"}, body["channels"])
+	absolute := int(body["id"].(float64))
+
+	// Moving the due date moves the relative reminder only
+	due = due.Add(24 * time.Hour)
+	rec, _ = c.do("PUT", todo, map[string]interface{}{"due_date": due.Format(time.RFC3339)})
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec, body = c.do("GET", todo+"/reminders", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	reminders := body["reminders"].([]interface{})
+	require.Len(t, reminders, 2)
+	assert.Equal(t, due.Add(-time.Hour).Format(time.RFC3339), reminders[0].(map[string]interface{})["fire_at"])
+	assert.Equal(t, remindAt.Format(time.RFC3339), reminders[1].(map[string]interface{})["fire_at"])
+
+	// The scheduler sends each due reminder once
+	scheduler := service.NewReminderScheduler(c.stores.Reminders, reminderChannels(&config.Config{
+		AppURL:                 "http://app.example.com",
+		ReminderWebhookTimeout: time.Second,
+	}, c.stores, c.outbox), service.ReminderSchedulerConfig{PollInterval: time.Minute, Lease: 5 * time.Minute})
+	sent, err := scheduler.DeliverDue(context.Background(), remindAt)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = scheduler.DeliverDue(context.Background(), due)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = scheduler.DeliverDue(context.Background(), due)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	msg := c.nextMail("Reminder: Dentist")
+	assert.Equal(t, "dewi@example.com", msg.To)
+	assert.Contains(t, msg.Body, "http://app.example.com")
+
+	rec, body = c.do("GET", "/api/notifications?unread=true", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	notifications := body["notifications"].([]interface{})
+	require.Len(t, notifications, 2)
+	latest := notifications[0].(map[string]interface{})
+	assert.Equal(t, "Dentist", latest["title"])
+	assert.Nil(t, latest["read_at"])
+
+	rec, _ = c.do("POST", fmt.Sprintf("/api/notifications/%d/read", int(latest["id"].(float64))), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	_, body = c.do("GET", "/api/notifications?unread=true", nil)
+	assert.Len(t, body["notifications"], 1)
+	rec, _ = c.do("POST", "/api/notifications/read-all", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	_, body = c.do("GET", "/api/notifications?unread=true", nil)
+	assert.Empty(t, body["notifications"])
+	rec, _ = c.do("GET", "/api/notifications?limit=0", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Other users can't see or change the reminders
+	rec, _ = c.do("POST", "/api/auth/register", map[string]string{"username": "eko", "email": "eko@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login = c.do("POST", "/api/auth/login", map[string]string{"email": "eko@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+	rec, _ = c.do("GET", todo+"/reminders", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec, _ = c.do("DELETE", fmt.Sprintf("%s/reminders/%d", todo, absolute), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec, _ = c.do("POST", fmt.Sprintf("/api/notifications/%d/read", int(latest["id"].(float64))), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	_, login = c.do("POST", "/api/auth/login", map[string]string{"email": "dewi@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+	rec, _ = c.do("DELETE", fmt.Sprintf("%s/reminders/%d", todo, relative), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec, _ = c.do("DELETE", fmt.Sprintf("%s/reminders/%d", todo, relative), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	c := newTestClient(t)
+
+	register := map[string]string{"username": "sari", "email": "sari@example.com", "password": "Passw0rd1"}
+	rec, _ := c.do("POST", "/api/auth/register", register)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	first := login["refresh_token"].(string)
+	require.NotEmpty(t, first)
+	assert.Positive(t, login["expires_in"])
+
+	// Refreshing rotates the refresh token
+	rec, refreshed := c.do("POST", "/api/auth/refresh", map[string]string{"refresh_token": first})
+	require.Equal(t, http.StatusOK, rec.Code)
+	second := refreshed["refresh_token"].(string)
+	assert.NotEqual(t, first, second)
+
+	c.token = refreshed["token"].(string)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Reusing the old token revokes the whole family, including the new tokens
+	rec, body := c.do("POST", "/api/auth/refresh", map[string]string{"refresh_token": first})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "unauthorized", body["code"])
+
+	rec, _ = c.do("POST", "/api/auth/refresh", map[string]string{"refresh_token": second})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Logging out revokes the login's access token
+	_, login = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec, _ = c.do("POST", "/api/auth/logout", map[string]string{"refresh_token": login["refresh_token"].(string)})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Logging out again still succeeds
+	rec, _ = c.do("POST", "/api/auth/logout", map[string]string{"refresh_token": login["refresh_token"].(string)})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestSigningKeys(t *testing.T) {
+	// Tokens signed with the secret have no public keys
+	rec, body := newTestClient(t).do("GET", "/.well-known/jwks.json", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, body["keys"])
+
+	dir := t.TempDir()
+	key, err := jwtkeys.Generate(dir, jwtkeys.AlgEdDSA)
+	require.NoError(t, err)
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.JWTKeysDir = dir
+	})
+
+	rec, _ = c.do("POST", "/api/auth/register", map[string]string{"username": "yanti", "email": "yanti@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "yanti@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	rec, _ = c.do("GET", "/.well-known/jwks.json", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var jwks oidc.JSONWebKeySet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, key.ID, jwks.Keys[0].Kid)
+
+	// Other services can verify access tokens with the published key
+	token, err := jwt.Parse(c.token, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, key.ID, token.Header["kid"])
+		return jwks.Keys[0].PublicKey()
+	})
+	require.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "todolist-api", claims["aud"])
+
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Tokens for another audience are rejected, and so is the secret once
+	// keys are configured
+	claims["aud"] = "other-service"
+	forged := jwt.NewWithClaims(jwtkeys.SigningMethodEdDSA, claims)
+	forged.Header["kid"] = key.ID
+	c.token, err = forged.SignedString(key.Private)
+	require.NoError(t, err)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	claims["aud"] = "todolist-api"
+	forged = jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	forged.Header["kid"] = key.ID
+	c.token, err = forged.SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSessions(t *testing.T) {
+	c := newTestClient(t)
+
+	for _, username := range []string{"dewi", "eko"} {
+		rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": username, "email": username + "@example.com", "password": "Passw0rd1"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+	login := func(email string) string {
+		rec, body := c.do("POST", "/api/auth/login", map[string]string{"email": email, "password": "Passw0rd1"})
+		require.Equal(t, http.StatusOK, rec.Code)
+		return body["token"].(string)
+	}
+	laptop, phone, tablet := login("dewi@example.com"), login("dewi@example.com"), login("dewi@example.com")
+	intruder := login("eko@example.com")
+
+	c.token = laptop
+	rec, body := c.do("GET", "/api/auth/sessions", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	sessions := body["sessions"].([]interface{})
+	require.Len(t, sessions, 3)
+
+	var currentID, otherID string
+	for _, s := range sessions {
+		session := s.(map[string]interface{})
+		assert.Equal(t, "192.0.2.1", session["ip_address"])
+		if session["current"] == true {
+			currentID = session["id"].(string)
+		} else {
+			otherID = session["id"].(string)
+		}
+	}
+	require.NotEmpty(t, currentID)
+
+	// Other users can't sign the device out
+	c.token = intruder
+	rec, _ = c.do("DELETE", "/api/auth/sessions/"+currentID, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Signing one device out rejects its tokens right away
+	c.token = laptop
+	rec, _ = c.do("DELETE", "/api/auth/sessions/"+otherID, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	_, body = c.do("GET", "/api/auth/sessions", nil)
+	assert.Len(t, body["sessions"], 2)
+
+	rejected := 0
+	for _, token := range []string{phone, tablet} {
+		c.token = token
+		if rec, _ := c.do("GET", "/api/todos", nil); rec.Code == http.StatusUnauthorized {
+			rejected++
+		}
+	}
+	assert.Equal(t, 1, rejected)
+
+	// Signing the other devices out keeps the current one
+	c.token = laptop
+	rec, _ = c.do("DELETE", "/api/auth/sessions?keep_current=true", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	for _, token := range []string{phone, tablet} {
+		c.token = token
+		rec, _ = c.do("GET", "/api/todos", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	c.token = laptop
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = c.do("DELETE", "/api/auth/sessions", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The other user is still signed in
+	c.token = intruder
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPasswordReset(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "fitri", "email": "fitri@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "fitri@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	// Unknown and known emails get the same response
+	unknown, unknownBody := c.do("POST", "/api/auth/forgot-password", map[string]string{"email": "nobody@example.com"})
+	known, knownBody := c.do("POST", "/api/auth/forgot-password", map[string]string{"email": "fitri@example.com"})
+	assert.Equal(t, http.StatusAccepted, known.Code)
+	assert.Equal(t, unknown.Code, known.Code)
+	assert.Equal(t, unknownBody, knownBody)
+
+	msg := c.nextMail("Reset your password")
+	assert.Equal(t, "fitri@example.com", msg.To)
+	token := c.linkToken(msg, "http://app.example.com/reset-password?token=")
+
+	// Requests are rate limited the same way for unknown addresses
+	for _, email := range []string{"fitri@example.com", "nobody@example.com"} {
+		for i := 0; i < 2; i++ {
+			rec, _ = c.do("POST", "/api/auth/forgot-password", map[string]string{"email": email})
+			require.Equal(t, http.StatusAccepted, rec.Code)
+		}
+		rec, _ = c.do("POST", "/api/auth/forgot-password", map[string]string{"email": email})
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	}
+
+	rec, body := c.do("POST", "/api/auth/reset-password", map[string]string{"token": token, "password": "weak"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "password")
+
+	rec, _ = c.do("POST", "/api/auth/reset-password", map[string]string{"token": token, "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	// The token is single use and the old sessions are gone
+	rec, body = c.do("POST", "/api/auth/reset-password", map[string]string{"token": token, "password": "OtherPassw0rd"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "token")
+
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/refresh", map[string]string{"refresh_token": login["refresh_token"].(string)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "fitri@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "fitri@example.com", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestProfile(t *testing.T) {
+	c := newTestClient(t)
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "sari", "email": "sari@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/register", map[string]string{"username": "dewi", "email": "dewi@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, other := c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	rec, body := c.do("GET", "/api/users/me", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "sari", body["username"])
+	assert.Equal(t, "sari@example.com", body["email"])
+	assert.NotContains(t, body, "password")
+
+	// Usernames are validated and unique
+	rec, body = c.do("PATCH", "/api/users/me", map[string]string{"username": "a b"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "username")
+	rec, _ = c.do("PATCH", "/api/users/me", map[string]string{"username": "dewi"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec, body = c.do("PATCH", "/api/users/me", map[string]string{"username": "sari_w"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "sari_w", body["username"])
+
+	// Changing the password needs the current one and signs out other sessions
+	rec, body = c.do("POST", "/api/users/me/password", map[string]string{"current_password": "wrong", "new_password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "current_password")
+	rec, body = c.do("POST", "/api/users/me/password", map[string]string{"current_password": "Passw0rd1", "new_password": "weak"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "new_password")
+	rec, _ = c.do("POST", "/api/users/me/password", map[string]string{"current_password": "Passw0rd1", "new_password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "sari@example.com", c.nextMail("Your password was changed").To)
+
+	rec, _ = c.do("POST", "/api/auth/refresh", map[string]string{"refresh_token": other["refresh_token"].(string)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = c.do("GET", "/api/users/me", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Taken and unchanged addresses are refused
+	rec, _ = c.do("POST", "/api/users/me/email", map[string]string{"email": "dewi@example.com", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec, body = c.do("POST", "/api/users/me/email", map[string]string{"email": "Sari@example.com", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "email")
+	rec, body = c.do("POST", "/api/users/me/email", map[string]string{"email": "sari@example.org", "password": "wrong"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "password")
+
+	// The address only changes once the new one is confirmed
+	rec, _ = c.do("POST", "/api/users/me/email", map[string]string{"email": "sari@example.org", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	msg := c.nextMail("Confirm your new email address")
+	assert.Equal(t, "sari@example.org", msg.To)
+	token := c.linkToken(msg, "http://app.example.com/confirm-email?token=")
+
+	_, body = c.do("GET", "/api/users/me", nil)
+	assert.Equal(t, "sari@example.com", body["email"])
+
+	rec, _ = c.do("POST", "/api/users/confirm-email", map[string]string{"token": token + "x"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = c.do("POST", "/api/users/confirm-email", map[string]string{"token": token})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "sari@example.com", c.nextMail("Your email address was changed").To)
+
+	_, body = c.do("GET", "/api/users/me", nil)
+	assert.Equal(t, "sari@example.org", body["email"])
+	assert.NotNil(t, body["email_verified_at"])
+
+	// The link is single use
+	rec, body = c.do("POST", "/api/users/confirm-email", map[string]string{"token": token})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "token")
+
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.org", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Confirmation emails are rate limited per address and per user
+	for i := 0; i < 3; i++ {
+		rec, _ = c.do("POST", "/api/users/me/email", map[string]string{"email": "target@example.net", "password": "NewPassw0rd"})
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+	rec, body = c.do("POST", "/api/users/me/email", map[string]string{"email": "target@example.net", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "rate_limited", body["code"])
+	for i := 0; i < 4; i++ {
+		rec, _ = c.do("POST", "/api/users/me/email", map[string]string{"email": fmt.Sprintf("other%d@example.net", i), "password": "NewPassw0rd"})
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+	rec, _ = c.do("POST", "/api/users/me/email", map[string]string{"email": "another@example.net", "password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestAccountDeletion(t *testing.T) {
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.AccountDeletionGracePeriod = 0
+	})
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "joko", "email": "joko@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "joko@example.com", "password": "Passw0rd1"})
+	c.token = login["token"].(string)
+
+	rec, body := c.do("POST", "/api/todos", map[string]string{"title": "Pack boxes"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	todoID := int(body["id"].(float64))
+	remindAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	rec, _ = c.do("POST", fmt.Sprintf("/api/todos/%d/reminders", todoID), map[string]string{"remind_at": remindAt.Format(time.RFC3339)})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, c.stores.Notifications.CreateNotification(context.Background(), &model.Notification{
+		UserID: 1, TodoID: &todoID, Title: "Pack boxes", Body: "Reminder",
+	}))
+	_, pat := c.do("POST", "/api/auth/tokens", map[string]interface{}{"name": "script", "scopes": []string{"todos:read"}})
+
+	// The export has the profile, every todo with its reminders and the
+	// notifications
+	rec, body = c.do("GET", "/api/users/me/export", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), `attachment; filename="todolist-export-`)
+	assert.Equal(t, "joko", body["user"].(map[string]interface{})["username"])
+	assert.NotContains(t, body["user"], "password")
+	require.Len(t, body["todos"], 1)
+	todo := body["todos"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Pack boxes", todo["title"])
+	require.Len(t, todo["reminders"], 1)
+	assert.Equal(t, remindAt.Format(time.RFC3339), todo["reminders"].([]interface{})[0].(map[string]interface{})["remind_at"])
+	require.Len(t, body["notifications"], 1)
+	assert.Equal(t, "Reminder", body["notifications"].([]interface{})[0].(map[string]interface{})["body"])
+
+	rec, body = c.do("DELETE", "/api/users/me", map[string]string{"password": "wrong"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "password")
+
+	// Deleting signs out every session and disables personal access tokens
+	rec, body = c.do("DELETE", "/api/users/me", map[string]string{"password": "Passw0rd1"})
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.NotEmpty(t, body["delete_after"])
+	assert.Equal(t, "joko@example.com", c.nextMail("Your account will be deleted").To)
+
+	rec, _ = c.do("GET", "/api/users/me", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/refresh", map[string]string{"refresh_token": login["refresh_token"].(string)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	c.token = pat["token"].(string)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Logging in during the grace period restores the account with its data
+	rec, login = c.do("POST", "/api/auth/login", map[string]string{"email": "joko@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, login["user"], "delete_after")
+	rec, body = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body["todos"], 1)
+
+	// Once the grace period is over the account is purged
+	c.token = login["token"].(string)
+	rec, _ = c.do("DELETE", "/api/users/me", map[string]string{"password": "Passw0rd1"})
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	purged, err := c.stores.Users.PurgeDeletedUsers(context.Background(), time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "joko@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	todos, err := c.stores.Todos.GetTodosByUserID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+}
+
+func TestAdmin(t *testing.T) {
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.LoginLockoutThreshold = 1
+	})
+
+	for _, name := range []string{"rina", "sari"} {
+		rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": name, "email": name + "@example.com", "password": "Passw0rd1"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+	_, login := c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	sari := login["token"].(string)
+	c.token = sari
+	_, pat := c.do("POST", "/api/auth/tokens", map[string]interface{}{"name": "script", "scopes": []string{"todos:read"}})
+	rec, _ := c.do("POST", "/api/todos", map[string]string{"title": "Water plants"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// Only admins may use the admin API
+	rec, body := c.do("GET", "/api/admin/stats", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "forbidden", body["code"])
+
+	require.NoError(t, c.stores.Users.SetRole(context.Background(), 1, model.RoleAdmin))
+	_, login = c.do("POST", "/api/auth/login", map[string]string{"email": "rina@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, "admin", login["user"].(map[string]interface{})["role"])
+	c.token = login["token"].(string)
+
+	rec, body = c.do("GET", "/api/admin/users?q=SARI", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, float64(1), body["total"])
+	listed := body["users"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "sari", listed["username"])
+	assert.Equal(t, float64(1), listed["todo_count"])
+	assert.NotContains(t, listed, "password")
+
+	rec, body = c.do("GET", "/api/admin/users?role=owner&limit=0", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Len(t, body["fields"], 2)
+
+	rec, body = c.do("GET", "/api/admin/stats", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, float64(2), body["users"])
+	assert.Equal(t, float64(1), body["admins"])
+	assert.Equal(t, float64(1), body["todos"])
+
+	rec, _ = c.do("POST", "/api/admin/users/1/disable", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec, _ = c.do("POST", "/api/admin/users/99/disable", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Disabled users are signed out and can't log in or use their tokens
+	rec, body = c.do("POST", "/api/admin/users/2/disable", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, body["disabled_at"])
+
+	admin := c.token
+	for _, token := range []string{sari, pat["token"].(string)} {
+		c.token = token
+		rec, _ = c.do("GET", "/api/todos", nil)
+		assert.NotEqual(t, http.StatusOK, rec.Code)
+	}
+	rec, body = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "this account has been disabled", body["error"])
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Enabling and unlocking lets them back in
+	c.token = admin
+	rec, body = c.do("POST", "/api/admin/users/2/enable", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, body, "disabled_at")
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	rec, _ = c.do("POST", "/api/admin/unlock", map[string]string{"key": "sari@example.com"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// A forced reset invalidates the password and mails a reset link
+	rec, _ = c.do("POST", "/api/admin/users/2/password-reset", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	token := c.linkToken(c.nextMail("Choose a new password"), "http://app.example.com/reset-password?token=")
+	rec, _ = c.do("POST", "/api/auth/reset-password", map[string]string{"token": token, "password": "N3wPassword"})
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	rec, body = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "N3wPassword"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "sari@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Tokens of a disabled user are refused even if their session outlived
+	// the revocation, e.g. when disabling raced with a request
+	disabledAt := time.Now().UTC()
+	require.NoError(t, c.stores.Users.SetDisabled(context.Background(), 2, &disabledAt))
+	c.token = body["token"].(string)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestLoginThrottling(t *testing.T) {
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.LoginLockoutThreshold = 2
+	})
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "wati", "email": "wati@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// A successful login forgets earlier failures
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Reaching the threshold locks the account, even for the right password
+	for i := 0; i < 2; i++ {
+		rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	known, knownBody := c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusTooManyRequests, known.Code)
+	assert.Equal(t, "rate_limited", knownBody["code"])
+	retryAfter, err := strconv.Atoi(known.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 15*60, retryAfter, 5)
+
+	// Unknown emails are throttled the same way
+	for i := 0; i < 2; i++ {
+		rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "nobody@example.com", "password": "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	unknown, unknownBody := c.do("POST", "/api/auth/login", map[string]string{"email": "nobody@example.com", "password": "wrong"})
+	assert.Equal(t, known.Code, unknown.Code)
+	assert.Equal(t, knownBody, unknownBody)
+
+	// Re-entering the password in a session counts like a login
+	require.NoError(t, c.stores.LoginAttempts.ClearLoginAttempts(context.Background(), "email:wati@example.com"))
+	_, body := c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "Passw0rd1"})
+	c.token = body["token"].(string)
+	for i := 0; i < 2; i++ {
+		rec, _ = c.do("POST", "/api/users/me/password", map[string]string{"current_password": "wrong", "new_password": "NewPassw0rd"})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	}
+	rec, _ = c.do("POST", "/api/users/me/password", map[string]string{"current_password": "Passw0rd1", "new_password": "NewPassw0rd"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	c.token = ""
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "wati@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestClientIP(t *testing.T) {
+	sessionIP := func(c *apiClient, email string) string {
+		rec, body := c.do("POST", "/api/auth/login", map[string]string{"email": email, "password": "Passw0rd1"})
+		require.Equal(t, http.StatusOK, rec.Code)
+		token := c.token
+		c.token = body["token"].(string)
+		defer func() { c.token = token }()
+
+		_, body = c.do("GET", "/api/auth/sessions", nil)
+		for _, s := range body["sessions"].([]interface{}) {
+			if session := s.(map[string]interface{}); session["current"] == true {
+				return session["ip_address"].(string)
+			}
+		}
+		t.Fatal("no current session")
+		return ""
+	}
+
+	// Forwarding headers are ignored unless the peer is a trusted proxy
+	c := newTestClient(t)
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "yuni", "email": "yuni@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	c.header = http.Header{"X-Forwarded-For": {"203.0.113.9"}, "X-Real-Ip": {"203.0.113.9"}}
+	assert.Equal(t, "192.0.2.1", sessionIP(c, "yuni@example.com"))
+
+	c = newTestClient(t, func(cfg *config.Config) {
+		cfg.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24"), netip.MustParsePrefix("10.0.0.0/8")}
+		cfg.LoginIPLockoutThreshold = 2
+	})
+	rec, _ = c.do("POST", "/api/auth/register", map[string]string{"username": "yuni", "email": "yuni@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// Behind a trusted proxy the client is the last address no trusted proxy
+	// has, whatever the client put in front of it
+	c.header = http.Header{"X-Forwarded-For": {"198.51.100.66, 203.0.113.9", "10.0.0.2"}}
+	assert.Equal(t, "203.0.113.9", sessionIP(c, "yuni@example.com"))
+	c.header = http.Header{"X-Real-Ip": {"203.0.113.10"}}
+	assert.Equal(t, "203.0.113.10", sessionIP(c, "yuni@example.com"))
+
+	// Clients behind the same proxy are throttled separately
+	c.header = http.Header{"X-Forwarded-For": {"203.0.113.9"}}
+	for i := 0; i < 2; i++ {
+		rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": fmt.Sprintf("guess%d@example.com", i), "password": "wrong"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "yuni@example.com", "password": "Passw0rd1"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	c.header = http.Header{"X-Forwarded-For": {"203.0.113.10"}}
+	assert.Equal(t, "203.0.113.10", sessionIP(c, "yuni@example.com"))
+}
+
+func TestEmailVerificationRequired(t *testing.T) {
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.EmailVerification = config.EmailVerificationRequired
+	})
+
+	credentials := map[string]string{"email": "gita@example.com", "password": "Passw0rd1"}
+	rec, body := c.do("POST", "/api/auth/register", map[string]string{"username": "gita", "email": "gita@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Nil(t, body["email_verified_at"])
+
+	// Unverified users can't sign in, but a wrong password doesn't tell
+	rec, body = c.do("POST", "/api/auth/login", credentials)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "email_not_verified", body["code"])
+	rec, _ = c.do("POST", "/api/auth/login", map[string]string{"email": "gita@example.com", "password": "Wr0ngPassword"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	token := c.linkToken(c.nextMail("Verify your email address"), "http://app.example.com/verify-email?token=")
+
+	// Tampering with the token invalidates it
+	parts := strings.Split(token, ".")
+	forged := parts[0] + "." + parts[1] + "9." + parts[2]
+	rec, body = c.do("POST", "/api/auth/verify-email", map[string]string{"token": forged})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "token")
+
+	rec, _ = c.do("POST", "/api/auth/verify-email", map[string]string{"token": token})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/verify-email", map[string]string{"token": token})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec, body = c.do("POST", "/api/auth/login", credentials)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotNil(t, body["user"].(map[string]interface{})["email_verified_at"])
+}
+
+func TestEmailVerificationLimited(t *testing.T) {
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.EmailVerification = config.EmailVerificationLimited
+	})
+
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "hadi", "email": "hadi@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec, login := c.do("POST", "/api/auth/login", map[string]string{"email": "hadi@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	c.token = login["token"].(string)
+
+	// Reading works, writing needs a verified address
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, body := c.do("POST", "/api/todos", map[string]string{"title": "Unverified"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "email_not_verified", body["code"])
+
+	// Resending is rate limited the same way for unknown addresses
+	c.token = ""
+	for i := 0; i < 3; i++ {
+		rec, _ = c.do("POST", "/api/auth/verify-email/resend", map[string]string{"email": "hadi@example.com"})
+		require.Equal(t, http.StatusAccepted, rec.Code)
+	}
+	rec, _ = c.do("POST", "/api/auth/verify-email/resend", map[string]string{"email": "hadi@example.com"})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	token := c.linkToken(c.nextMail("Verify your email address"), "http://app.example.com/verify-email?token=")
+	rec, _ = c.do("POST", "/api/auth/verify-email", map[string]string{"token": token})
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	c.token = login["token"].(string)
+	rec, _ = c.do("POST", "/api/todos", map[string]string{"title": "Verified"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestTwoFactorAuthentication(t *testing.T) {
+	c := newTestClient(t)
+
+	credentials := map[string]string{"email": "indah@example.com", "password": "Passw0rd1"}
+	rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": "indah", "email": "indah@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	_, login := c.do("POST", "/api/auth/login", credentials)
+	c.token = login["token"].(string)
+
+	rec, body := c.do("POST", "/api/auth/2fa/enroll", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	secret := body["secret"].(string)
+	assert.Contains(t, body["otpauth_uri"], "otpauth://totp/Todo%20List:indah@example.com?")
+
+	// Nothing changes until the first code is confirmed
+	rec, _ = c.do("POST", "/api/auth/2fa/confirm", map[string]string{"code": "000000"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	code := func(offset int64) string {
+		code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+		require.NoError(t, err)
+		return code
+	}
+	first := code(0)
+	rec, body = c.do("POST", "/api/auth/2fa/confirm", map[string]string{"code": first})
+	require.Equal(t, http.StatusOK, rec.Code)
+	recoveryCodes := body["recovery_codes"].([]interface{})
+	require.Len(t, recoveryCodes, 10)
+
+	rec, body = c.do("GET", "/api/auth/2fa", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, true, body["enabled"])
+	assert.Equal(t, float64(10), body["recovery_codes_remaining"])
+
+	// The password alone only gets a challenge
+	c.token = ""
+	rec, body = c.do("POST", "/api/auth/login", credentials)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, true, body["mfa_required"])
+	assert.Nil(t, body["token"])
+	mfaToken := body["mfa_token"].(string)
+
+	// An access token can't be obtained with the challenge itself
+	c.token = mfaToken
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	c.token = ""
+
+	// The code used for confirming can't be replayed
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "code": first})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, body = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "code": code(1)})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, body["token"])
+	assert.NotEmpty(t, body["refresh_token"])
+
+	// Challenges are single use
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "code": code(1)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Recovery codes work once, however they are typed
+	_, body = c.do("POST", "/api/auth/login", credentials)
+	mfaToken = body["mfa_token"].(string)
+	recovery := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0].(string), "-", " "))
+	rec, body = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "recovery_code": recovery})
+	require.Equal(t, http.StatusOK, rec.Code)
+	c.token = body["token"].(string)
+
+	_, body = c.do("POST", "/api/auth/login", credentials)
+	mfaToken = body["mfa_token"].(string)
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "recovery_code": recoveryCodes[0].(string)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Too many wrong codes void the challenge
+	for i := 0; i < 4; i++ {
+		rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "code": "000000"})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "recovery_code": recoveryCodes[1].(string)})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Wrong codes are throttled per account, so a new challenge doesn't give
+	// more guesses
+	_, body = c.do("POST", "/api/auth/login", credentials)
+	mfaToken = body["mfa_token"].(string)
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "code": "000000"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, body = c.do("POST", "/api/auth/login", credentials)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "rate_limited", body["code"])
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "recovery_code": recoveryCodes[1].(string)})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	require.NoError(t, c.stores.LoginAttempts.ClearLoginAttempts(context.Background(), "email:indah@example.com"))
+	rec, _ = c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "recovery_code": recoveryCodes[1].(string)})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Of concurrent submissions of one challenge only the one that wins it
+	// uses up its recovery code
+	_, body = c.do("POST", "/api/auth/login", credentials)
+	mfaToken = body["mfa_token"].(string)
+	codes := make(chan int, 2)
+	var wg sync.WaitGroup
+	for _, recovery := range recoveryCodes[3:5] {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec, _ := c.do("POST", "/api/auth/login/mfa", map[string]string{"mfa_token": mfaToken, "recovery_code": recovery.(string)})
+			codes <- rec.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	var results []int
+	for code := range codes {
+		results = append(results, code)
+	}
+	assert.ElementsMatch(t, []int{http.StatusOK, http.StatusUnauthorized}, results)
+	rec, body = c.do("GET", "/api/auth/2fa", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, float64(7), body["recovery_codes_remaining"])
+
+	// Disabling needs the password
+	rec, body = c.do("POST", "/api/auth/2fa/disable", map[string]string{"password": "Wr0ngPassword"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "password")
+	rec, _ = c.do("POST", "/api/auth/2fa/disable", map[string]string{"password": "Passw0rd1"})
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec, body = c.do("POST", "/api/auth/login", credentials)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, body["token"])
+}
+
+func TestPersonalAccessTokens(t *testing.T) {
+	c := newTestClient(t)
+
+	for _, username := range []string{"fajar", "gita"} {
+		rec, _ := c.do("POST", "/api/auth/register", map[string]string{"username": username, "email": username + "@example.com", "password": "Passw0rd1"})
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+	login := func(email string) string {
+		rec, body := c.do("POST", "/api/auth/login", map[string]string{"email": email, "password": "Passw0rd1"})
+		require.Equal(t, http.StatusOK, rec.Code)
+		return body["token"].(string)
+	}
+	session, intruder := login("fajar@example.com"), login("gita@example.com")
+
+	c.token = session
+	rec, _ := c.do("POST", "/api/todos", map[string]interface{}{"title": "Water the plants"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	for _, req := range []map[string]interface{}{
+		{"scopes": []string{"todos:read"}},
+		{"name": "script"},
+		{"name": "script", "scopes": []string{"todos:delete"}},
+		{"name": "script", "scopes": []string{"todos:read"}, "expires_at": time.Now().Add(-time.Hour)},
+	} {
+		rec, _ := c.do("POST", "/api/auth/tokens", req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%v", req)
+	}
+
+	create := func(name string, scopes []string, expiresAt *time.Time) (string, float64) {
+		c.token = session
+		rec, body := c.do("POST", "/api/auth/tokens", map[string]interface{}{"name": name, "scopes": scopes, "expires_at": expiresAt})
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		return body["token"].(string), body["id"].(float64)
+	}
+	reader, readerID := create("dashboard", []string{"todos:read", "todos:read"}, nil)
+	writer, _ := create("importer", []string{"todos:read", "todos:write"}, nil)
+	assert.True(t, strings.HasPrefix(reader, "pat_"))
+
+	// Tokens are only shown when they are created
+	rec, body := c.do("GET", "/api/auth/tokens", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	tokens := body["tokens"].([]interface{})
+	require.Len(t, tokens, 2)
+	for _, tk := range tokens {
+		assert.NotContains(t, tk, "token")
+	}
+	assert.Equal(t, "importer", tokens[0].(map[string]interface{})["name"])
+	assert.Equal(t, []interface{}{"todos:read"}, tokens[1].(map[string]interface{})["scopes"])
+	assert.Nil(t, tokens[1].(map[string]interface{})["last_used_at"])
+
+	// Scopes are enforced per route
+	c.token = reader
+	rec, body = c.do("GET", "/api/todos", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, body["todos"], 1)
+	rec, _ = c.do("POST", "/api/todos", map[string]interface{}{"title": "Not allowed"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = c.do("GET", "/api/auth/tokens", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec, _ = c.do("GET", "/api/auth/sessions", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	c.token = writer
+	rec, _ = c.do("POST", "/api/todos", map[string]interface{}{"title": "Imported"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	c.token = session
+	_, body = c.do("GET", "/api/auth/tokens", nil)
+	assert.NotNil(t, body["tokens"].([]interface{})[1].(map[string]interface{})["last_used_at"])
+
+	// Tokens can only be revoked by their owner and stop working right away
+	c.token = intruder
+	rec, _ = c.do("DELETE", fmt.Sprintf("/api/auth/tokens/%d", int(readerID)), nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	c.token = session
+	rec, _ = c.do("DELETE", fmt.Sprintf("/api/auth/tokens/%d", int(readerID)), nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	c.token = reader
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// A token can only issue tokens with its own scopes, expiring no later
+	accountExpiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	account, _ := create("manager", []string{"account"}, &accountExpiry)
+	c.token = account
+	for _, scopes := range [][]string{{"todos:write"}, {"account", "todos:read"}, {"admin"}} {
+		rec, _ = c.do("POST", "/api/auth/tokens", map[string]interface{}{"name": "escalated", "scopes": scopes})
+		assert.Equal(t, http.StatusForbidden, rec.Code, "%v", scopes)
+	}
+	rec, body = c.do("POST", "/api/auth/tokens", map[string]interface{}{"name": "later", "scopes": []string{"account"}, "expires_at": accountExpiry.Add(time.Hour)})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, body["fields"], "expires_at")
+	rec, body = c.do("POST", "/api/auth/tokens", map[string]interface{}{"name": "copy", "scopes": []string{"account"}})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, accountExpiry.Format(time.RFC3339), body["expires_at"])
+
+	// Expired tokens are rejected
+	expiresAt := time.Now().Add(time.Second)
+	expiring, _ := create("short lived", []string{"todos:read"}, &expiresAt)
+	c.token = expiring
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	time.Sleep(time.Until(expiresAt) + 10*time.Millisecond)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	c.token = "pat_unknown"
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	idp := oidctest.NewServer(t, "todo-app", "s3cret")
+	c := newTestClient(t, func(cfg *config.Config) {
+		cfg.OIDCProviders = []config.OIDCProvider{{ID: "mock", Name: "Mock IdP", Issuer: idp.Issuer, ClientID: "todo-app", ClientSecret: "s3cret", Scopes: []string{"email"}}}
+		cfg.OIDCRedirectURL = "http://app.example.com/oidc/callback"
+	})
+
+	rec, body := c.do("GET", "/api/auth/oidc/providers", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "mock", "name": "Mock IdP"}}, body["providers"])
+
+	rec, _ = c.do("POST", "/api/auth/oidc/other/authorize", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// signIn goes through the whole flow as the given provider account
+	signIn := func(user oidctest.User) (*httptest.ResponseRecorder, map[string]interface{}) {
+		idp.SignIn(user)
+		rec, body := c.do("POST", "/api/auth/oidc/mock/authorize", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		authURL := body["authorization_url"].(string)
+		require.True(t, strings.HasPrefix(authURL, idp.Issuer+"/authorize?"), authURL)
+
+		back := idp.Authorize(t, authURL)
+		require.Equal(t, "http://app.example.com/oidc/callback", back.Scheme+"://"+back.Host+back.Path)
+		return c.do("POST", "/api/auth/oidc/callback", map[string]string{"state": back.Query().Get("state"), "code": back.Query().Get("code")})
+	}
+
+	// First sign-in creates a user with a verified address
+	rec, body = signIn(oidctest.User{Subject: "ivan-1", Email: "ivan@example.com", EmailVerified: true, PreferredUsername: "ivan"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotEmpty(t, body["token"])
+	user := body["user"].(map[string]interface{})
+	assert.Equal(t, "ivan", user["username"])
+	assert.NotNil(t, user["email_verified_at"])
+	ivanID := user["id"]
+
+	c.token = body["token"].(string)
+	rec, _ = c.do("GET", "/api/todos", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	c.token = ""
+
+	// Later sign-ins find the user by the subject, even with a new address
+	rec, body = signIn(oidctest.User{Subject: "ivan-1", Email: "ivan@new.example.com", EmailVerified: true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ivanID, body["user"].(map[string]interface{})["id"])
+
+	// Addresses the provider hasn't verified are neither linked nor used
+	rec, _ = signIn(oidctest.User{Subject: "mallory-1", Email: "ivan@example.com"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Existing users are linked by email once they verified the address
+	rec, _ = c.do("POST", "/api/auth/register", map[string]string{"username": "hana", "email": "hana@example.com", "password": "Passw0rd1"})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	hana := oidctest.User{Subject: "hana-1", Email: "hana@example.com", EmailVerified: true, PreferredUsername: "hana"}
+	rec, _ = signIn(hana)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	token := c.linkToken(c.nextMail("Verify your email address"), "/verify-email?token=")
+	rec, _ = c.do("POST", "/api/auth/verify-email", map[string]string{"token": token})
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	rec, body = signIn(hana)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "hana", body["user"].(map[string]interface{})["username"])
+
+	// Taken usernames get a suffix
+	rec, body = signIn(oidctest.User{Subject: "hana-2", Email: "hana2@example.com", EmailVerified: true, PreferredUsername: "hana"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.True(t, strings.HasPrefix(body["user"].(map[string]interface{})["username"].(string), "hana-"))
+
+	// A login can only be completed once, and only with its own state
+	idp.SignIn(hana)
+	_, body = c.do("POST", "/api/auth/oidc/mock/authorize", nil)
+	back := idp.Authorize(t, body["authorization_url"].(string))
+	rec, _ = c.do("POST", "/api/auth/oidc/callback", map[string]string{"state": "forged", "code": back.Query().Get("code")})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	callback := map[string]string{"state": back.Query().Get("state"), "code": back.Query().Get("code")}
+	rec, _ = c.do("POST", "/api/auth/oidc/callback", callback)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec, _ = c.do("POST", "/api/auth/oidc/callback", callback)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Codes are bound to the login they were issued for
+	_, body = c.do("POST", "/api/auth/oidc/mock/authorize", nil)
+	first := idp.Authorize(t, body["authorization_url"].(string))
+	_, body = c.do("POST", "/api/auth/oidc/mock/authorize", nil)
+	second := idp.Authorize(t, body["authorization_url"].(string))
+	rec, _ = c.do("POST", "/api/auth/oidc/callback", map[string]string{"state": first.Query().Get("state"), "code": second.Query().Get("code")})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/config"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+)
+
+const roleUsage = "usage: server role <email address> <user | admin>"
+
+// runRole implements the "server role" subcommand, which sets the role of a
+// user. It is how the first admin of an instance is made.
+func runRole(ctx context.Context, cfg *config.Config, out io.Writer, args []string) error {
+	if len(args) != 2 {
+		return errors.New(roleUsage)
+	}
+	email, role := args[0], args[1]
+	if role != model.RoleUser && role != model.RoleAdmin {
+		return errors.New(roleUsage)
+	}
+	if cfg.DatabaseURL == "" || cfg.DatabaseURL == "memory://" {
+		return errors.New("DATABASE_URL must point to a PostgreSQL or SQLite database")
+	}
+
+	stores, err := repository.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer stores.Close()
+
+	user, err := stores.Users.GetUserByEmail(ctx, email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return fmt.Errorf("no user with email address %s", email)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := stores.Users.SetRole(ctx, user.ID, role); err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	fmt.Fprintf(out, "%s is now %s\n", user.Email, role)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"aplikasi-todolist/internal/config"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/service"
+)
+
+const unlockUsage = "usage: server unlock <email address | client IP>"
+
+// runUnlock implements the "server unlock" subcommand, which lifts the login
+// lockout of an email address or a client IP
+func runUnlock(ctx context.Context, cfg *config.Config, out io.Writer, args []string) error {
+	if len(args) != 1 {
+		return errors.New(unlockUsage)
+	}
+	if cfg.DatabaseURL == "" || cfg.DatabaseURL == "memory://" {
+		return errors.New("DATABASE_URL must point to a PostgreSQL or SQLite database")
+	}
+
+	stores, err := repository.Open(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer stores.Close()
+
+	throttle := service.NewLoginThrottle(stores.LoginAttempts, loginThrottleConfig(cfg))
+	if err := throttle.Unlock(ctx, args[0]); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "unlocked logins for %s\n", args[0])
+	return nil
+}
@@ -13,7 +13,11 @@ interface User {
 interface AuthContextType {
   user: User | null;
   token: string | null;
-  login: (email: string, password: string) => Promise<{ success: boolean; error?: string }>;
+  // With two-factor authentication, login returns mfaToken and completeMfa finishes it
+  login: (email: string, password: string) => Promise<{ success: boolean; error?: string; mfaToken?: string }>;
+  completeMfa: (mfaToken: string, code: string) => Promise<{ success: boolean; error?: string }>;
+  // Finishes a sign-in with an OpenID provider; like login it may ask for a two-factor code
+  completeOIDC: (state: string, code: string) => Promise<{ success: boolean; error?: string; mfaToken?: string }>;
   register: (username: string, email: string, password: string) => Promise<{ success: boolean; error?: string }>;
   logout: () => void;
   isAuthenticated: boolean;
@@ -55,15 +59,63 @@ export function AuthProvider({ children }: { children: ReactNode }) {
 
       const data = await response.json();
 
+      if (response.ok && data.mfa_required) {
+        return { success: false, mfaToken: data.mfa_token as string };
+      } else if (response.ok) {
+        storeSession(data);
+        return { success: true };
+      } else {
+        return { success: false, error: data.error || 'Login failed' };
+      }
+    } catch (error) {
+      console.error('Login error:', error);
+      return { success: false, error: 'Network error. Please try again.' };
+    }
+  };
+
+  // Recovery codes contain a dash, authenticator codes are six digits
+  const completeMfa = async (mfaToken: string, code: string) => {
+    try {
+      const response = await fetch('http://localhost:8080/api/auth/login/mfa', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(
+          /^\d{6}$/.test(code.trim()) ? { mfa_token: mfaToken, code: code.trim() } : { mfa_token: mfaToken, recovery_code: code }
+        ),
+      });
+
+      const data = await response.json();
+
       if (response.ok) {
-        const { token, user } = data;
-        localStorage.setItem('token', token);
-        localStorage.setItem('user', JSON.stringify(user));
+        storeSession(data);
+        return { success: true };
+      } else {
+        return { success: false, error: data.error || 'Login failed' };
+      }
+    } catch (error) {
+      console.error('Login error:', error);
+      return { success: false, error: 'Network error. Please try again.' };
+    }
+  };
 
-        setToken(token);
-        setUser(user);
-        setIsAuthenticated(true);
+  const completeOIDC = async (state: string, code: string) => {
+    try {
+      const response = await fetch('http://localhost:8080/api/auth/oidc/callback', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ state, code }),
+      });
+
+      const data = await response.json();
 
+      if (response.ok && data.mfa_required) {
+        return { success: false, mfaToken: data.mfa_token as string };
+      } else if (response.ok) {
+        storeSession(data);
         return { success: true };
       } else {
         return { success: false, error: data.error || 'Login failed' };
@@ -74,6 +126,17 @@ export function AuthProvider({ children }: { children: ReactNode }) {
     }
   };
 
+  const storeSession = (data: { token: string; refresh_token: string; user: User }) => {
+    const { token, refresh_token, user } = data;
+    localStorage.setItem('token', token);
+    localStorage.setItem('refresh_token', refresh_token);
+    localStorage.setItem('user', JSON.stringify(user));
+
+    setToken(token);
+    setUser(user);
+    setIsAuthenticated(true);
+  };
+
   const register = async (username: string, email: string, password: string) => {
     try {
       const response = await fetch('http://localhost:8080/api/auth/register', {
@@ -106,7 +169,20 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   };
 
   const logout = () => {
+    const refreshToken = localStorage.getItem('refresh_token');
+    if (refreshToken) {
+      // Revoke the tokens server-side; the local session ends regardless
+      fetch('http://localhost:8080/api/auth/logout', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({ refresh_token: refreshToken }),
+      }).catch(() => undefined);
+    }
+
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
     localStorage.removeItem('user');
 
     setToken(null);
@@ -115,7 +191,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   };
 
   return (
-    <AuthContext.Provider value={{ user, token, login, register, logout, isAuthenticated, isLoading }}>
+    <AuthContext.Provider value={{ user, token, login, completeMfa, completeOIDC, register, logout, isAuthenticated, isLoading }}>
       {children}
     </AuthContext.Provider>
   );
@@ -0,0 +1,160 @@
+// Package apperror defines the typed errors returned by the repositories and
+// services. Handlers translate them into HTTP responses in one place, so the
+// layers below never need to know about status codes.
+package apperror
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Code is a stable, machine-readable error identifier sent to clients
+type Code string
+
+const (
+	CodeBadRequest   Code = "bad_request"
+	CodeValidation   Code = "validation_failed"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeNotFound     Code = "not_found"
+	CodeConflict     Code = "conflict"
+	CodeRateLimited  Code = "rate_limited"
+	CodeTimeout      Code = "timeout"
+	CodeCanceled     Code = "request_cancelled"
+	CodeInternal     Code = "internal_error"
+
+	// CodeEmailNotVerified is a forbidden error the client can resolve by
+	// verifying the account's email address
+	CodeEmailNotVerified Code = "email_not_verified"
+)
+
+// Error is an error that is safe to show to the client
+type Error struct {
+	Code    Code
+	Message string
+	// Fields holds per-field messages for validation errors
+	Fields map[string]string
+	// RetryAfter tells rate limited clients when to try again
+	RetryAfter time.Duration
+	// Err is the underlying cause; it is never sent to the client
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes errors.Is(err, apperror.ErrNotFound) match any error with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Sentinels for use with errors.Is
+var (
+	ErrValidation   = &Error{Code: CodeValidation, Message: "validation failed"}
+	ErrUnauthorized = &Error{Code: CodeUnauthorized, Message: "unauthorized"}
+	ErrForbidden    = &Error{Code: CodeForbidden, Message: "forbidden"}
+	ErrNotFound     = &Error{Code: CodeNotFound, Message: "not found"}
+	ErrConflict     = &Error{Code: CodeConflict, Message: "conflict"}
+)
+
+// BadRequest reports a malformed request, such as unparsable JSON
+func BadRequest(message string) *Error {
+	return &Error{Code: CodeBadRequest, Message: message}
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error {
+	return &Error{Code: CodeNotFound, Message: message}
+}
+
+// Conflict reports a uniqueness or state conflict
+func Conflict(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+// Unauthorized reports missing or invalid credentials
+func Unauthorized(message string) *Error {
+	return &Error{Code: CodeUnauthorized, Message: message}
+}
+
+// Forbidden reports an authenticated caller that may not perform the action
+func Forbidden(message string) *Error {
+	return &Error{Code: CodeForbidden, Message: message}
+}
+
+// RateLimited reports a client that has to wait retryAfter before trying again
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	return &Error{Code: CodeRateLimited, Message: message, RetryAfter: retryAfter}
+}
+
+// EmailNotVerified reports an action that needs a verified email address
+func EmailNotVerified(message string) *Error {
+	return &Error{Code: CodeEmailNotVerified, Message: message}
+}
+
+// Invalid reports a single invalid field
+func Invalid(field, message string) *Error {
+	v := Validation{}
+	v.Add(field, message)
+	return v.Err().(*Error)
+}
+
+// Validation collects per-field validation failures
+type Validation struct {
+	fields   []string
+	messages map[string]string
+}
+
+// Add records a failure for field; only the first message per field is kept
+func (v *Validation) Add(field, message string) {
+	if v.messages == nil {
+		v.messages = make(map[string]string)
+	}
+	if _, ok := v.messages[field]; ok {
+		return
+	}
+	v.fields = append(v.fields, field)
+	v.messages[field] = message
+}
+
+// AddError records err for field if it is not nil
+func (v *Validation) AddError(field string, err error) {
+	if err != nil {
+		v.Add(field, err.Error())
+	}
+}
+
+// Err returns the collected failures as an *Error, or nil if there are none
+func (v *Validation) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	msgs := make([]string, len(v.fields))
+	fields := make(map[string]string, len(v.fields))
+	for i, f := range v.fields {
+		msgs[i] = v.messages[f]
+		fields[f] = v.messages[f]
+	}
+
+	return &Error{Code: CodeValidation, Message: strings.Join(msgs, "; "), Fields: fields}
+}
+
+// As returns the *Error in err's chain, if any
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,438 @@
+// Package config loads the server configuration from the environment and an
+// optional KEY=VALUE file, and validates it at startup.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Email verification modes
+const (
+	// EmailVerificationOptional lets unverified users do everything
+	EmailVerificationOptional = "optional"
+	// EmailVerificationLimited lets unverified users sign in and read, but
+	// not change their to-dos
+	EmailVerificationLimited = "limited"
+	// EmailVerificationRequired rejects logins of unverified users
+	EmailVerificationRequired = "required"
+)
+
+// providerIDPattern restricts provider IDs to what fits an environment
+// variable name and a URL path segment
+var providerIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// OIDCProvider is an OpenID provider users can sign in with
+type OIDCProvider struct {
+	// ID names the provider in URLs and in the OIDC_<ID>_* variables
+	ID string
+	// Name is shown on the sign-in button
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes are requested besides openid
+	Scopes []string
+}
+
+// Config holds every setting the server needs
+type Config struct {
+	Port        string
+	DatabaseURL string
+	AutoMigrate bool
+
+	// QueryTimeout bounds the time a request may spend on database queries
+	QueryTimeout time.Duration
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers name the client; requests from anywhere else are
+	// attributed to their peer address
+	TrustedProxies []netip.Prefix
+
+	JWTSecret string
+	// JWTKeysDir holds the asymmetric keys that sign access tokens; without
+	// it tokens are signed with JWTSecret
+	JWTKeysDir string
+	// JWTAudience is the aud claim of access tokens
+	JWTAudience string
+	// JWTExpiry is the lifetime of access tokens; RefreshTokenExpiry that of
+	// the refresh tokens used to renew them
+	JWTExpiry          time.Duration
+	RefreshTokenExpiry time.Duration
+
+	// AppURL is the base URL of the web app, used for links in emails
+	AppURL string
+	// PasswordResetExpiry is how long a password reset link stays valid
+	PasswordResetExpiry time.Duration
+	// EmailVerification is one of the EmailVerification* modes;
+	// EmailVerificationExpiry is how long a verification link stays valid
+	EmailVerification       string
+	EmailVerificationExpiry time.Duration
+	// TOTPIssuer names the app in authenticator apps
+	TOTPIssuer string
+	// OIDCProviders are the OpenID providers users can sign in with;
+	// OIDCRedirectURL is the page of the web app they send users back to
+	OIDCProviders   []OIDCProvider
+	OIDCRedirectURL string
+	// LoginLockoutThreshold failed logins for an email address, or
+	// LoginIPLockoutThreshold from a client IP, lock logins for
+	// LoginLockoutDuration; 0 disables the lockout
+	LoginLockoutThreshold   int
+	LoginIPLockoutThreshold int
+	LoginLockoutDuration    time.Duration
+	// AccountDeletionGracePeriod is how long deleted accounts stay
+	// deactivated, and can be restored by logging in, before they are purged
+	AccountDeletionGracePeriod time.Duration
+	// ReminderPollInterval is how often the server looks for due reminders,
+	// 0 to deliver none; ReminderLease is how long a claimed reminder is
+	// reserved for the server delivering it
+	ReminderPollInterval time.Duration
+	ReminderLease        time.Duration
+	// ReminderWebhookTimeout bounds the requests of webhook reminders
+	ReminderWebhookTimeout time.Duration
+
+	// Outgoing mail goes through SMTPHost if set, otherwise to .eml files in
+	// MailOutboxDir, otherwise to the log
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUsername  string
+	SMTPPassword  string
+	MailFrom      string
+	MailOutboxDir string
+
+	// SearchConfig is the PostgreSQL text search configuration, e.g. "simple",
+	// "english" or "indonesian"
+	SearchConfig string
+
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// ShutdownTimeout is how long in-flight requests may take to drain
+	ShutdownTimeout time.Duration
+}
+
+// Load builds a Config from defaults, the file named by CONFIG_FILE (if any)
+// and the environment, in increasing order of precedence. It only fails on
+// malformed values; call Validate to check the result is usable.
+func Load() (*Config, error) {
+	src := source{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		src.file = values
+	}
+
+	cfg := &Config{
+		Port:                       src.string("PORT", "8080"),
+		DatabaseURL:                src.databaseURL(),
+		AutoMigrate:                src.bool("AUTO_MIGRATE", true),
+		QueryTimeout:               src.duration("QUERY_TIMEOUT", 10*time.Second),
+		JWTSecret:                  src.string("JWT_SECRET", ""),
+		JWTKeysDir:                 src.string("JWT_KEYS_DIR", ""),
+		JWTAudience:                src.string("JWT_AUDIENCE", "todolist-api"),
+		JWTExpiry:                  src.duration("JWT_EXPIRY", 15*time.Minute),
+		RefreshTokenExpiry:         src.duration("REFRESH_TOKEN_EXPIRY", 30*24*time.Hour),
+		AppURL:                     src.string("APP_URL", "http://localhost:3000"),
+		PasswordResetExpiry:        src.duration("PASSWORD_RESET_EXPIRY", time.Hour),
+		EmailVerification:          src.string("EMAIL_VERIFICATION", EmailVerificationOptional),
+		EmailVerificationExpiry:    src.duration("EMAIL_VERIFICATION_EXPIRY", 48*time.Hour),
+		TOTPIssuer:                 src.string("TOTP_ISSUER", "Todo List"),
+		LoginLockoutThreshold:      src.int("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginIPLockoutThreshold:    src.int("LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+		LoginLockoutDuration:       src.duration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		AccountDeletionGracePeriod: src.duration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		ReminderPollInterval:       src.duration("REMINDER_POLL_INTERVAL", 30*time.Second),
+		ReminderLease:              src.duration("REMINDER_LEASE", 5*time.Minute),
+		ReminderWebhookTimeout:     src.duration("REMINDER_WEBHOOK_TIMEOUT", 10*time.Second),
+		SMTPHost:                   src.string("SMTP_HOST", ""),
+		SMTPPort:                   src.string("SMTP_PORT", "587"),
+		SMTPUsername:               src.string("SMTP_USERNAME", ""),
+		SMTPPassword:               src.string("SMTP_PASSWORD", ""),
+		MailFrom:                   src.string("MAIL_FROM", "Todo List <no-reply@localhost>"),
+		MailOutboxDir:              src.string("MAIL_OUTBOX_DIR", ""),
+		SearchConfig:               src.string("SEARCH_CONFIG", "simple"),
+		ReadTimeout:                src.duration("HTTP_READ_TIMEOUT", 15*time.Second),
+		ReadHeaderTimeout:          src.duration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:               src.duration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:                src.duration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownTimeout:            src.duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+	}
+	cfg.TrustedProxies = src.prefixes("TRUSTED_PROXIES")
+	cfg.OIDCProviders = src.oidcProviders()
+	cfg.OIDCRedirectURL = src.string("OIDC_REDIRECT_URL", strings.TrimRight(cfg.AppURL, "/")+"/oidc/callback")
+
+	if err := errors.Join(src.errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate reports every setting that would prevent the server from running
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.DatabaseURL == "" {
+		errs = append(errs, errors.New("DATABASE_URL (or DB_HOST, DB_USER and DB_NAME) is required"))
+	} else if c.DatabaseURL == "sqlite://" {
+		errs = append(errs, errors.New("DATABASE_URL sqlite:// needs a file path, e.g. sqlite:///var/lib/todo/todo.db"))
+	}
+	if c.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET is required"))
+	}
+	if c.JWTAudience == "" {
+		errs = append(errs, errors.New("JWT_AUDIENCE must not be empty"))
+	}
+	if c.JWTExpiry <= 0 {
+		errs = append(errs, errors.New("JWT_EXPIRY must be positive"))
+	}
+	if c.RefreshTokenExpiry <= c.JWTExpiry {
+		errs = append(errs, errors.New("REFRESH_TOKEN_EXPIRY must be longer than JWT_EXPIRY"))
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT %q is not a valid port", c.Port))
+	}
+	if u, err := url.Parse(c.AppURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("APP_URL %q must be an absolute http(s) URL", c.AppURL))
+	}
+	if c.PasswordResetExpiry <= 0 {
+		errs = append(errs, errors.New("PASSWORD_RESET_EXPIRY must be positive"))
+	}
+	switch c.EmailVerification {
+	case EmailVerificationOptional, EmailVerificationLimited, EmailVerificationRequired:
+	default:
+		errs = append(errs, fmt.Errorf("EMAIL_VERIFICATION %q must be optional, limited or required", c.EmailVerification))
+	}
+	if c.TOTPIssuer == "" || strings.Contains(c.TOTPIssuer, ":") {
+		errs = append(errs, fmt.Errorf("TOTP_ISSUER %q must not be empty or contain a colon", c.TOTPIssuer))
+	}
+	if c.LoginLockoutThreshold < 0 {
+		errs = append(errs, errors.New("LOGIN_LOCKOUT_THRESHOLD must not be negative"))
+	}
+	if c.LoginIPLockoutThreshold < 0 {
+		errs = append(errs, errors.New("LOGIN_IP_LOCKOUT_THRESHOLD must not be negative"))
+	}
+	if c.LoginLockoutDuration <= 0 {
+		errs = append(errs, errors.New("LOGIN_LOCKOUT_DURATION must be positive"))
+	}
+	if c.EmailVerificationExpiry <= 0 {
+		errs = append(errs, errors.New("EMAIL_VERIFICATION_EXPIRY must be positive"))
+	}
+	if c.AccountDeletionGracePeriod < 0 {
+		errs = append(errs, errors.New("ACCOUNT_DELETION_GRACE_PERIOD must not be negative"))
+	}
+	if c.ReminderPollInterval < 0 {
+		errs = append(errs, errors.New("REMINDER_POLL_INTERVAL must not be negative"))
+	}
+	// A delivery may take up to 30s, which the lease must outlast
+	if c.ReminderLease <= 30*time.Second {
+		errs = append(errs, errors.New("REMINDER_LEASE must be longer than 30s"))
+	}
+	if c.ReminderWebhookTimeout <= 0 || c.ReminderWebhookTimeout > 30*time.Second {
+		errs = append(errs, errors.New("REMINDER_WEBHOOK_TIMEOUT must be positive and at most 30s"))
+	}
+	if _, err := mail.ParseAddress(c.MailFrom); err != nil {
+		errs = append(errs, fmt.Errorf("MAIL_FROM %q is not a valid address", c.MailFrom))
+	}
+	if port, err := strconv.Atoi(c.SMTPPort); c.SMTPHost != "" && (err != nil || port <= 0 || port > 65535) {
+		errs = append(errs, fmt.Errorf("SMTP_PORT %q is not a valid port", c.SMTPPort))
+	}
+	seen := make(map[string]bool)
+	for _, p := range c.OIDCProviders {
+		if !providerIDPattern.MatchString(p.ID) || seen[p.ID] {
+			errs = append(errs, fmt.Errorf("OIDC_PROVIDERS: %q must be a unique lowercase ID of letters, digits and hyphens", p.ID))
+			continue
+		}
+		seen[p.ID] = true
+
+		prefix := oidcPrefix(p.ID)
+		if u, err := url.Parse(p.Issuer); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%sISSUER %q must be an absolute http(s) URL", prefix, p.Issuer))
+		}
+		if p.ClientID == "" {
+			errs = append(errs, fmt.Errorf("%sCLIENT_ID is required", prefix))
+		}
+	}
+	if u, err := url.Parse(c.OIDCRedirectURL); len(c.OIDCProviders) > 0 && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+		errs = append(errs, fmt.Errorf("OIDC_REDIRECT_URL %q must be an absolute http(s) URL", c.OIDCRedirectURL))
+	}
+	if c.QueryTimeout < 0 {
+		errs = append(errs, errors.New("QUERY_TIMEOUT must not be negative"))
+	}
+
+	for _, t := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.ReadTimeout},
+		{"HTTP_READ_HEADER_TIMEOUT", c.ReadHeaderTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+	} {
+		if t.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", t.name))
+		}
+	}
+
+	// A response can't be written once the write deadline passed, so the
+	// query deadline has to expire first to return a proper error
+	if c.QueryTimeout > 0 && c.WriteTimeout > 0 && c.QueryTimeout >= c.WriteTimeout {
+		errs = append(errs, errors.New("QUERY_TIMEOUT must be shorter than HTTP_WRITE_TIMEOUT"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Addr returns the address the HTTP server listens on
+func (c *Config) Addr() string {
+	return ":" + c.Port
+}
+
+// source looks values up in the environment first, then in the config file
+type source struct {
+	file map[string]string
+	errs []error
+}
+
+func (s *source) lookup(key string) (string, bool) {
+	if v := os.Getenv(key); v != "" {
+		return v, true
+	}
+	v, ok := s.file[key]
+	return v, ok && v != ""
+}
+
+func (s *source) string(key, def string) string {
+	if v, ok := s.lookup(key); ok {
+		return v
+	}
+	return def
+}
+
+func (s *source) bool(key string, def bool) bool {
+	v, ok := s.lookup(key)
+	if !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: invalid boolean %q", key, v))
+		return def
+	}
+	return b
+}
+
+func (s *source) int(key string, def int) int {
+	v, ok := s.lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return def
+	}
+	return n
+}
+
+func (s *source) duration(key string, def time.Duration) time.Duration {
+	v, ok := s.lookup(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: invalid duration %q", key, v))
+		return def
+	}
+	return d
+}
+
+// prefixes reads a comma separated list of CIDR prefixes; a bare address
+// stands for itself
+func (s *source) prefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, v := range strings.Split(s.string(key, ""), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(v)
+			if addrErr != nil {
+				s.errs = append(s.errs, fmt.Errorf("%s: invalid address or CIDR prefix %q", key, v))
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// oidcProviders reads the providers listed in OIDC_PROVIDERS, each
+// configured by OIDC_<ID>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _NAME and
+// _SCOPES
+func (s *source) oidcProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, id := range strings.Split(s.string("OIDC_PROVIDERS", ""), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+
+		prefix := oidcPrefix(id)
+		providers = append(providers, OIDCProvider{
+			ID:           id,
+			Name:         s.string(prefix+"NAME", id),
+			Issuer:       s.string(prefix+"ISSUER", ""),
+			ClientID:     s.string(prefix+"CLIENT_ID", ""),
+			ClientSecret: s.string(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(strings.ReplaceAll(s.string(prefix+"SCOPES", "email profile"), ",", " ")),
+		})
+	}
+	return providers
+}
+
+// oidcPrefix returns the prefix of the variables configuring a provider
+func oidcPrefix(id string) string {
+	return "OIDC_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+}
+
+// databaseURL uses DATABASE_URL, or assembles a PostgreSQL URL from the
+// individual DB_* settings
+func (s *source) databaseURL() string {
+	if v, ok := s.lookup("DATABASE_URL"); ok {
+		return v
+	}
+
+	host := s.string("DB_HOST", "")
+	user := s.string("DB_USER", "")
+	dbname := s.string("DB_NAME", "")
+	if host == "" || user == "" || dbname == "" {
+		return ""
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(user, s.string("DB_PASSWORD", "")),
+		Host:     host + ":" + s.string("DB_PORT", "5432"),
+		Path:     "/" + dbname,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
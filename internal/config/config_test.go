@@ -0,0 +1,125 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.env")
+	require.NoError(t, os.WriteFile(path, []byte("PORT=9000\nJWT_SECRET=from-file\nQUERY_TIMEOUT=3s\n"), 0o600))
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("JWT_SECRET", "from-env")
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("DB_HOST", "db")
+	t.Setenv("DB_USER", "todo")
+	t.Setenv("DB_PASSWORD", "p@ss word")
+	t.Setenv("DB_NAME", "todolist")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "9000", cfg.Port)
+	assert.Equal(t, "from-env", cfg.JWTSecret)
+	assert.Equal(t, 3*time.Second, cfg.QueryTimeout)
+	assert.Equal(t, "postgres://todo:p%40ss%20word@db:5432/todolist?sslmode=disable", cfg.DatabaseURL)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestLoadOIDCProviders(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DATABASE_URL", "memory://")
+	t.Setenv("JWT_SECRET", "secret")
+	t.Setenv("APP_URL", "https://todo.example.com/")
+	t.Setenv("OIDC_PROVIDERS", "keycloak, corp-sso")
+	t.Setenv("OIDC_KEYCLOAK_ISSUER", "https://sso.example.com/realms/todo")
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_ID", "todo")
+	t.Setenv("OIDC_KEYCLOAK_CLIENT_SECRET", "s3cret")
+	t.Setenv("OIDC_KEYCLOAK_NAME", "Keycloak")
+	t.Setenv("OIDC_CORP_SSO_ISSUER", "https://corp.example.com")
+	t.Setenv("OIDC_CORP_SSO_SCOPES", "email,groups")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []OIDCProvider{
+		{ID: "keycloak", Name: "Keycloak", Issuer: "https://sso.example.com/realms/todo", ClientID: "todo", ClientSecret: "s3cret", Scopes: []string{"email", "profile"}},
+		{ID: "corp-sso", Name: "corp-sso", Issuer: "https://corp.example.com", Scopes: []string{"email", "groups"}},
+	}, cfg.OIDCProviders)
+	assert.Equal(t, "https://todo.example.com/oidc/callback", cfg.OIDCRedirectURL)
+	assert.ErrorContains(t, cfg.Validate(), "OIDC_CORP_SSO_CLIENT_ID is required")
+}
+
+func TestValidate(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DATABASE_URL", "memory://")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("QUERY_TIMEOUT", "1m")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET is required")
+	assert.Contains(t, err.Error(), "QUERY_TIMEOUT must be shorter than HTTP_WRITE_TIMEOUT")
+
+	cfg.DatabaseURL = "sqlite://"
+	assert.ErrorContains(t, cfg.Validate(), "sqlite:// needs a file path")
+
+	cfg.RefreshTokenExpiry = cfg.JWTExpiry
+	assert.ErrorContains(t, cfg.Validate(), "REFRESH_TOKEN_EXPIRY must be longer than JWT_EXPIRY")
+
+	cfg.AppURL = "localhost:3000"
+	assert.ErrorContains(t, cfg.Validate(), "APP_URL")
+
+	cfg.EmailVerification = "sometimes"
+	assert.ErrorContains(t, cfg.Validate(), "EMAIL_VERIFICATION")
+
+	cfg.OIDCProviders = []OIDCProvider{{ID: "Corp", Issuer: "https://id.example.com", ClientID: "todo"}}
+	assert.ErrorContains(t, cfg.Validate(), "OIDC_PROVIDERS")
+
+	cfg.JWTAudience = ""
+	assert.ErrorContains(t, cfg.Validate(), "JWT_AUDIENCE must not be empty")
+
+	cfg.LoginLockoutDuration = 0
+	assert.ErrorContains(t, cfg.Validate(), "LOGIN_LOCKOUT_DURATION must be positive")
+
+	cfg.AccountDeletionGracePeriod = -time.Hour
+	assert.ErrorContains(t, cfg.Validate(), "ACCOUNT_DELETION_GRACE_PERIOD must not be negative")
+
+	cfg.ReminderLease = 10 * time.Second
+	assert.ErrorContains(t, cfg.Validate(), "REMINDER_LEASE must be longer than 30s")
+
+	t.Setenv("HTTP_IDLE_TIMEOUT", "soon")
+	_, err = Load()
+	assert.Error(t, err)
+
+	t.Setenv("HTTP_IDLE_TIMEOUT", "")
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "ten")
+	_, err = Load()
+	assert.ErrorContains(t, err, "LOGIN_LOCKOUT_THRESHOLD: invalid integer")
+
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, proxy")
+	_, err = Load()
+	assert.ErrorContains(t, err, `TRUSTED_PROXIES: invalid address or CIDR prefix "proxy"`)
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("TRUSTED_PROXIES", "10.1.2.3/8, 192.0.2.7,fd00::/8")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.0.2.7/32"),
+		netip.MustParsePrefix("fd00::/8"),
+	}, cfg.TrustedProxies)
+}
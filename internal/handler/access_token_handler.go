@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/service"
+)
+
+// AccessTokenHandler handles personal access token HTTP requests
+type AccessTokenHandler struct {
+	accessTokenService *service.AccessTokenService
+}
+
+// NewAccessTokenHandler creates a new AccessTokenHandler instance
+func NewAccessTokenHandler(accessTokenService *service.AccessTokenService) *AccessTokenHandler {
+	return &AccessTokenHandler{accessTokenService: accessTokenService}
+}
+
+// ListTokens lists the personal access tokens of the authenticated user
+func (h *AccessTokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	tokens, err := h.accessTokenService.List(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if tokens == nil {
+		tokens = []*model.PersonalAccessToken{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tokens": tokens})
+}
+
+// CreateToken issues a personal access token; the response is the only time
+// the token is shown. A token making the request can only issue tokens with
+// no more rights than its own.
+func (h *AccessTokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+	caller, _ := r.Context().Value(AccessTokenKey).(*model.PersonalAccessToken)
+
+	var req model.CreateAccessTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	token, err := h.accessTokenService.Create(r.Context(), userID, caller, &req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, token)
+}
+
+// RevokeToken deletes one of the personal access tokens of the authenticated
+// user
+func (h *AccessTokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	tokenID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || tokenID <= 0 {
+		writeError(w, r, apperror.BadRequest("invalid token ID"))
+		return
+	}
+
+	if err := h.accessTokenService.Revoke(r.Context(), userID, tokenID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/service"
+	"aplikasi-todolist/internal/utils"
+)
+
+// AdminHandler handles the HTTP requests of the admin API
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+// NewAdminHandler creates a new AdminHandler instance
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// ListUsers lists and searches the users with the size of their data
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	var v apperror.Validation
+	query := model.UserQuery{Search: utils.SanitizeInput(params.Get("q"))}
+	if role := params.Get("role"); role != "" {
+		if !validRole(role) {
+			v.Add("role", "role must be user or admin")
+		}
+		query.Role = role
+	}
+	if value := params.Get("disabled"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			v.Add("disabled", "disabled must be true or false")
+		}
+		query.Disabled = &b
+	}
+	if value := params.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			v.Add("limit", "limit must be a positive number")
+		}
+		query.Limit = n
+	}
+	if value := params.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			v.Add("offset", "offset must not be negative")
+		}
+		query.Offset = n
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	page, err := h.adminService.ListUsers(r.Context(), query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// GetUser returns a single user
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.GetUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// DisableUser disables an account and signs out its sessions
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(UserIDKey).(int)
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.Disable(r.Context(), adminID, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// EnableUser enables a disabled account
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(UserIDKey).(int)
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.Enable(r.Context(), adminID, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// ForcePasswordReset invalidates the password of a user, who gets an email
+// to choose a new one
+func (h *AdminHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(UserIDKey).(int)
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ForcePasswordReset(r.Context(), adminID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Stats returns instance-wide numbers
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.adminService.Stats(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// Unlock lifts the login lockout of an email address or client IP
+func (h *AdminHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(UserIDKey).(int)
+
+	var req model.AdminUnlockRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if req.Key == "" {
+		writeError(w, r, apperror.Invalid("key", "key is required"))
+		return
+	}
+
+	if err := h.adminService.Unlock(r.Context(), adminID, req.Key); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userIDParam parses the user ID of the URL, writing an error if it is
+// invalid
+func userIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || userID <= 0 {
+		writeError(w, r, apperror.BadRequest("invalid user ID"))
+		return 0, false
+	}
+	return userID, true
+}
+
+func validRole(role string) bool {
+	for _, r := range model.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
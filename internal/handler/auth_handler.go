@@ -1,33 +1,36 @@
 package handler
 
 import (
-	"encoding/json"
+	"net"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
 	"aplikasi-todolist/internal/model"
-	"aplikasi-todolist/internal/repository"
 	"aplikasi-todolist/internal/service"
 	"aplikasi-todolist/internal/utils"
 )
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	authService *service.AuthService
+	authService  *service.AuthService
+	tokenService *service.TokenService
 }
 
 // NewAuthHandler creates a new AuthHandler instance
-func NewAuthHandler(userRepo *repository.UserRepository) *AuthHandler {
-	authService := service.NewAuthService(userRepo)
+func NewAuthHandler(authService *service.AuthService, tokenService *service.TokenService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:  authService,
+		tokenService: tokenService,
 	}
 }
 
 // Register handles user registration
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var userReg model.UserRegister
-	if err := json.NewDecoder(r.Body).Decode(&userReg); err != nil {
-		http.Error(w, `{"error": "invalid JSON format"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &userReg); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -37,37 +40,29 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	userReg.Password = utils.SanitizeInput(userReg.Password)
 
 	// Validate inputs
-	if err := utils.ValidateUsername(userReg.Username); err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
-		return
-	}
-
-	if err := utils.ValidateEmail(userReg.Email); err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
-		return
-	}
-
-	if err := utils.ValidatePassword(userReg.Password); err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+	var v apperror.Validation
+	v.AddError("username", utils.ValidateUsername(userReg.Username))
+	v.AddError("email", utils.ValidateEmail(userReg.Email))
+	v.AddError("password", utils.ValidatePassword(userReg.Password))
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	user, err := h.authService.Register(&userReg)
+	user, err := h.authService.Register(r.Context(), &userReg)
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(user)
+	writeJSON(w, http.StatusCreated, user)
 }
 
 // Login handles user login
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var userLogin model.UserLogin
-	if err := json.NewDecoder(r.Body).Decode(&userLogin); err != nil {
-		http.Error(w, `{"error": "invalid JSON format"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &userLogin); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -76,28 +71,249 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	userLogin.Password = utils.SanitizeInput(userLogin.Password)
 
 	// Validate inputs
+	var v apperror.Validation
 	if err := utils.ValidateEmail(userLogin.Email); err != nil {
-		http.Error(w, `{"error": "invalid email format"}`, http.StatusBadRequest)
+		v.Add("email", "invalid email format")
+	}
+	if userLogin.Password == "" {
+		v.Add("password", "password is required")
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if userLogin.Password == "" {
-		http.Error(w, `{"error": "password is required"}`, http.StatusBadRequest)
+	result, err := h.authService.Login(r.Context(), &userLogin, clientInfo(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// The second factor is still missing
+	if result.MFA != nil {
+		writeJSON(w, http.StatusOK, result.MFA)
+		return
+	}
+
+	writeLogin(w, result.Tokens, result.User)
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if req.RefreshToken == "" {
+		writeError(w, r, apperror.Invalid("refresh_token", "refresh_token is required"))
 		return
 	}
 
-	token, user, err := h.authService.Login(&userLogin)
+	tokens, err := h.tokenService.Refresh(r.Context(), req.RefreshToken, clientInfo(r))
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusUnauthorized)
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// Logout revokes the session of the refresh token and every token issued for it
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	if req.RefreshToken == "" {
+		writeError(w, r, apperror.Invalid("refresh_token", "refresh_token is required"))
+		return
+	}
+
+	if err := h.tokenService.Logout(r.Context(), req.RefreshToken); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword emails a password reset link. The response is the same
+// whether or not an account with the email exists.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ForgotPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.Email = utils.SanitizeInput(req.Email)
+	if err := utils.ValidateEmail(req.Email); err != nil {
+		writeError(w, r, apperror.Invalid("email", err.Error()))
+		return
+	}
+
+	if err := h.authService.ForgotPassword(r.Context(), req.Email, clientInfo(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if an account with this email exists, a password reset link has been sent to it",
+	})
+}
+
+// ResetPassword sets a new password using the token from the reset email
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// Sanitized like at registration so the new password works for login
+	req.Password = utils.SanitizeInput(req.Password)
+
+	var v apperror.Validation
+	if req.Token == "" {
+		v.Add("token", "token is required")
+	}
+	v.AddError("password", utils.ValidatePassword(req.Password))
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmail confirms the user's email address with the token from the
+// verification email
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req model.VerifyEmailRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if req.Token == "" {
+		writeError(w, r, apperror.Invalid("token", "token is required"))
+		return
+	}
+
+	if err := h.authService.VerifyEmail(r.Context(), req.Token); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification emails a new verification link. The response is the
+// same whether or not an unverified account with the email exists.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req model.ResendVerificationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.Email = utils.SanitizeInput(req.Email)
+	if err := utils.ValidateEmail(req.Email); err != nil {
+		writeError(w, r, apperror.Invalid("email", err.Error()))
+		return
+	}
+
+	if err := h.authService.ResendVerification(r.Context(), req.Email, clientInfo(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if an unverified account with this email exists, a verification link has been sent to it",
+	})
+}
+
+// ListSessions lists the signed-in devices of the authenticated user
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+	sessionID := r.Context().Value(SessionIDKey).(string)
+
+	sessions, err := h.tokenService.ListSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if sessions == nil {
+		sessions = []*model.Session{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// RevokeSession signs one device of the authenticated user out
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	if err := h.tokenService.RevokeSession(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllSessions signs every device of the authenticated user out. With
+// ?keep_current=true the session making the request stays signed in.
+func (h *AuthHandler) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	exceptID := ""
+	switch r.URL.Query().Get("keep_current") {
+	case "", "false":
+	case "true":
+		exceptID = r.Context().Value(SessionIDKey).(string)
+	default:
+		writeError(w, r, apperror.Invalid("keep_current", "keep_current must be true or false"))
+		return
+	}
+
+	if err := h.tokenService.RevokeAllSessions(r.Context(), userID, exceptID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeLogin writes the response of a completed login
+func writeLogin(w http.ResponseWriter, tokens *model.AuthTokens, user *model.User) {
 	response := map[string]interface{}{
-		"token": token,
-		"user":  user,
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-}
\ No newline at end of file
+	writeJSON(w, http.StatusOK, response)
+}
+
+// clientInfo describes the device making the request. The IP address is the
+// peer address of the connection, or the client a trusted proxy forwarded
+// (see ClientIPMiddleware).
+func clientInfo(r *http.Request) model.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return model.ClientInfo{UserAgent: r.UserAgent(), IPAddress: ip}
+}
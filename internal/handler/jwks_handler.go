@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"aplikasi-todolist/internal/service"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set. New keys are
+// published before they sign, so caches don't need to be fresher.
+const jwksMaxAge = "max-age=300"
+
+// JWKS serves the public keys that verify access tokens, for other services
+// to validate them without a shared secret
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, "+jwksMaxAge)
+	writeJSON(w, http.StatusOK, service.JWKS())
+}
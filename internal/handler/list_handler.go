@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/utils"
+)
+
+// ListLists lists the lists of the authenticated user with their todo
+// counts, leaving out archived lists unless ?include_archived=true
+func (h *TodoHandler) ListLists(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	includeArchived := false
+	if value := r.URL.Query().Get("include_archived"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, r, apperror.Invalid("include_archived", "include_archived must be true or false"))
+			return
+		}
+		includeArchived = b
+	}
+
+	lists, err := h.todoService.ListLists(r.Context(), userID, includeArchived)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"lists": lists})
+}
+
+// GetList retrieves a list of the authenticated user
+func (h *TodoHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	listID, err := listIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	list, err := h.todoService.GetList(r.Context(), userID, listID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// CreateList creates a list for the authenticated user
+func (h *TodoHandler) CreateList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	var listCreate model.ListCreate
+	if err := decodeJSON(r, &listCreate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	listCreate.Name = utils.SanitizeInput(listCreate.Name)
+	listCreate.Icon = utils.SanitizeInput(listCreate.Icon)
+
+	var v apperror.Validation
+	validateListFields(&v, &listCreate.Name, &listCreate.Icon)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	list, err := h.todoService.CreateList(r.Context(), userID, &listCreate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, list)
+}
+
+// UpdateList renames, recolors, changes the icon of or archives a list of
+// the authenticated user
+func (h *TodoHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	listID, err := listIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var listUpdate model.ListUpdate
+	if err := decodeJSON(r, &listUpdate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if listUpdate.Name != nil {
+		*listUpdate.Name = utils.SanitizeInput(*listUpdate.Name)
+	}
+	if listUpdate.Icon != nil {
+		*listUpdate.Icon = utils.SanitizeInput(*listUpdate.Icon)
+	}
+
+	var v apperror.Validation
+	validateListFields(&v, listUpdate.Name, listUpdate.Icon)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	list, err := h.todoService.UpdateList(r.Context(), userID, listID, &listUpdate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// ReorderLists puts the lists of the authenticated user in a new order
+func (h *TodoHandler) ReorderLists(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	var order model.ListOrder
+	if err := decodeJSON(r, &order); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	lists, err := h.todoService.ReorderLists(r.Context(), userID, &order)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"lists": lists})
+}
+
+// DeleteList deletes a list of the authenticated user. Its todos move to the
+// list ?move_to=, are deleted with ?delete_todos=true or are otherwise left
+// without a list.
+func (h *TodoHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	listID, err := listIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	params := r.URL.Query()
+	var deletion model.ListDeletion
+	var v apperror.Validation
+	if value := params.Get("move_to"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			v.Add("move_to", "move_to must be the ID of a list")
+		}
+		deletion.MoveTo = &n
+	}
+	if value := params.Get("delete_todos"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			v.Add("delete_todos", "delete_todos must be true or false")
+		}
+		deletion.DeleteTodos = b
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.todoService.DeleteList(r.Context(), userID, listID, deletion); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listIDParam extracts the list ID from the URL using chi
+func listIDParam(r *http.Request) (int, error) {
+	listID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || listID <= 0 {
+		return 0, apperror.BadRequest("invalid list ID")
+	}
+	return listID, nil
+}
+
+// validateListFields checks the name and icon of a list; nil fields are skipped
+func validateListFields(v *apperror.Validation, name, icon *string) {
+	if name != nil {
+		switch {
+		case *name == "":
+			v.Add("name", "name is required")
+		case len(*name) > 50:
+			v.Add("name", "name must be at most 50 characters")
+		}
+	}
+	if icon != nil && len(*icon) > 32 {
+		v.Add("icon", "icon must be at most 32 characters")
+	}
+}
+
+// validateListID checks the list_id of a todo; zero takes the todo out of
+// its list
+func validateListID(v *apperror.Validation, listID *int) {
+	if listID != nil && *listID < 0 {
+		v.Add("list_id", "list_id must be the ID of a list")
+	}
+}
@@ -2,9 +2,14 @@ package handler
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
+	"time"
 
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
 	"aplikasi-todolist/internal/service"
 )
 
@@ -13,34 +18,186 @@ type contextKey string
 
 const UserIDKey contextKey = "userID"
 const UsernameKey contextKey = "username"
+const SessionIDKey contextKey = "sessionID"
 
-// AuthMiddleware validates JWT tokens and adds user info to the request context
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error": "authorization header required"}`, http.StatusUnauthorized)
-			return
-		}
+// AccessTokenKey holds the personal access token of a request. Requests with
+// a session from a login don't have one and may use every scope.
+const AccessTokenKey contextKey = "accessToken"
+
+// AuthMiddleware validates access tokens, rejecting those whose session was
+// revoked, and adds user and session info to the request context. Personal
+// access tokens are accepted too; they have no session but add their scopes.
+func AuthMiddleware(tokens *service.TokenService, accessTokens *service.AccessTokenService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				writeError(w, r, apperror.Unauthorized("authorization header required"))
+				return
+			}
+
+			// Expecting format: "Bearer <token>"
+			tokenParts := strings.Split(authHeader, " ")
+			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+				writeError(w, r, apperror.Unauthorized("invalid authorization header format"))
+				return
+			}
+
+			tokenString := tokenParts[1]
+
+			if strings.HasPrefix(tokenString, service.AccessTokenPrefix) {
+				token, user, err := accessTokens.Authenticate(r.Context(), tokenString)
+				if err != nil {
+					writeError(w, r, err)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, user.ID)
+				ctx = context.WithValue(ctx, UsernameKey, user.Username)
+				ctx = context.WithValue(ctx, SessionIDKey, "")
+				ctx = context.WithValue(ctx, AccessTokenKey, token)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			claims, err := tokens.Authenticate(r.Context(), tokenString, clientInfo(r))
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			// Add user info to context
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, UsernameKey, claims.Username)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireScope rejects requests made with a personal access token that wasn't
+// granted scope. It must run after AuthMiddleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := r.Context().Value(AccessTokenKey).(*model.PersonalAccessToken)
+			if ok && !token.HasScope(scope) {
+				writeError(w, r, apperror.Forbidden("this token lacks the "+scope+" scope"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireVerifiedEmail rejects requests of users who haven't verified their
+// email address. It must run after AuthMiddleware.
+func RequireVerifiedEmail(auth *service.AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value(UserIDKey).(int)
 
-		// Expecting format: "Bearer <token>"
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			http.Error(w, `{"error": "invalid authorization header format"}`, http.StatusUnauthorized)
-			return
+			if err := auth.EnsureEmailVerified(r.Context(), userID); err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireRole rejects requests of users who don't have role. It must run
+// after AuthMiddleware.
+func RequireRole(admin *service.AdminService, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Context().Value(UserIDKey).(int)
+
+			if err := admin.EnsureRole(r.Context(), userID, role); err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIPMiddleware sets the remote address of requests from trusted
+// proxies to the client they forwarded, as named by X-Forwarded-For or
+// X-Real-IP. Requests from other peers keep their peer address, so clients
+// can't pick the address they are throttled and logged by.
+func ClientIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(trustedProxies) == 0 {
+			return next
 		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if client, ok := forwardedClient(r, trustedProxies); ok {
+				r.RemoteAddr = net.JoinHostPort(client.String(), "0")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		tokenString := tokenParts[1]
+// forwardedClient returns the client a request from a trusted proxy was
+// forwarded for. X-Forwarded-For is read from the right, as only the entries
+// appended by trusted proxies can be relied on; the first address not of a
+// trusted proxy is the client.
+func forwardedClient(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !trusted(peer.Addr(), trustedProxies) {
+		return netip.Addr{}, false
+	}
 
-		claims, err := service.ValidateJWT(tokenString)
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+		return addr.Unmap(), err == nil
+	}
+
+	client := peer.Addr()
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
 		if err != nil {
-			http.Error(w, `{"error": "invalid or expired token"}`, http.StatusUnauthorized)
-			return
+			break
+		}
+		client = addr.Unmap()
+		if !trusted(client, trustedProxies) {
+			break
 		}
+	}
+	return client, true
+}
 
-		// Add user info to context
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
-		ctx = context.WithValue(ctx, UsernameKey, claims.Username)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
\ No newline at end of file
+func trusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeadlineMiddleware bounds how long a request may spend waiting on the
+// database. Queries run with the request context, so they are cancelled once
+// the deadline passes. A zero timeout disables the deadline.
+func DeadlineMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
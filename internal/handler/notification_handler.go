@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/service"
+)
+
+// NotificationHandler handles in-app notification HTTP requests
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// ListNotifications lists the latest notifications of the authenticated
+// user, only the unread ones with ?unread=true
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+	params := r.URL.Query()
+
+	var v apperror.Validation
+	unreadOnly := false
+	if value := params.Get("unread"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			v.Add("unread", "unread must be true or false")
+		}
+		unreadOnly = b
+	}
+	limit := 0
+	if value := params.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			v.Add("limit", "limit must be a positive number")
+		}
+		limit = n
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	notifications, err := h.notificationService.ListNotifications(r.Context(), userID, unreadOnly, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"notifications": notifications})
+}
+
+// MarkRead marks a notification of the authenticated user read
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	notificationID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || notificationID <= 0 {
+		writeError(w, r, apperror.BadRequest("invalid notification ID"))
+		return
+	}
+
+	if err := h.notificationService.MarkRead(r.Context(), userID, notificationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllRead marks every notification of the authenticated user read
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	if err := h.notificationService.MarkAllRead(r.Context(), userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
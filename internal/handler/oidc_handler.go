@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/service"
+)
+
+// OIDCHandler handles sign-in with OpenID providers
+type OIDCHandler struct {
+	oidcService *service.OIDCService
+}
+
+// NewOIDCHandler creates a new OIDCHandler instance
+func NewOIDCHandler(oidcService *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+// Providers lists the providers users can sign in with
+func (h *OIDCHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"providers": h.oidcService.Providers()})
+}
+
+// Authorize starts a sign-in with a provider and returns the URL to send the
+// browser to
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	authorization, err := h.oidcService.Authorize(r.Context(), chi.URLParam(r, "provider"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, authorization)
+}
+
+// Callback completes a sign-in with the state and code the provider
+// redirected back with. The response is the same as that of Login.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	var req model.OIDCCallbackRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.State = strings.TrimSpace(req.State)
+	req.Code = strings.TrimSpace(req.Code)
+
+	var v apperror.Validation
+	if req.State == "" {
+		v.Add("state", "state is required")
+	}
+	if req.Code == "" {
+		v.Add("code", "code is required")
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	result, err := h.oidcService.Callback(r.Context(), &req, clientInfo(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// The second factor is still missing
+	if result.MFA != nil {
+		writeJSON(w, http.StatusOK, result.MFA)
+		return
+	}
+
+	writeLogin(w, result.Tokens, result.User)
+}
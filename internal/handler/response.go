@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+)
+
+// StatusClientClosedRequest is the non-standard status (popularised by nginx)
+// used when the client went away before the response was written
+const StatusClientClosedRequest = 499
+
+// errorResponse is the JSON body of every error response
+type errorResponse struct {
+	Error  string            `json:"error"`
+	Code   apperror.Code     `json:"code"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// statusByCode maps application error codes to HTTP status codes
+var statusByCode = map[apperror.Code]int{
+	apperror.CodeBadRequest:   http.StatusBadRequest,
+	apperror.CodeValidation:   http.StatusBadRequest,
+	apperror.CodeUnauthorized: http.StatusUnauthorized,
+	apperror.CodeForbidden:    http.StatusForbidden,
+	apperror.CodeNotFound:     http.StatusNotFound,
+	apperror.CodeConflict:     http.StatusConflict,
+	apperror.CodeRateLimited:  http.StatusTooManyRequests,
+	apperror.CodeTimeout:      http.StatusGatewayTimeout,
+	apperror.CodeCanceled:     StatusClientClosedRequest,
+	apperror.CodeInternal:     http.StatusInternalServerError,
+
+	apperror.CodeEmailNotVerified: http.StatusForbidden,
+}
+
+// writeJSON encodes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
+
+// writeError translates err into a JSON error response. Application errors
+// keep their message and code, cancellations and timeouts get their own
+// status, and anything else is logged and reported as an internal error
+// without leaking details to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	resp := errorResponse{Code: apperror.CodeInternal, Error: "internal server error"}
+
+	if appErr, ok := apperror.As(err); ok {
+		resp = errorResponse{Error: appErr.Message, Code: appErr.Code, Fields: appErr.Fields}
+		if appErr.RetryAfter > 0 {
+			// Whole seconds, rounded up so clients don't retry too early
+			seconds := (appErr.RetryAfter + time.Second - 1) / time.Second
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	} else {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(r.Context().Err(), context.DeadlineExceeded):
+			resp = errorResponse{Error: "request timed out", Code: apperror.CodeTimeout}
+		case errors.Is(err, context.Canceled) || errors.Is(r.Context().Err(), context.Canceled):
+			resp = errorResponse{Error: "request cancelled", Code: apperror.CodeCanceled}
+		default:
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		}
+	}
+
+	status, ok := statusByCode[resp.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeJSON(w, status, resp)
+}
+
+// decodeJSON decodes the request body into v
+func decodeJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return apperror.BadRequest("invalid JSON format")
+	}
+	return nil
+}
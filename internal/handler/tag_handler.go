@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/utils"
+)
+
+// ListTags lists the tags of the authenticated user with their todo counts
+func (h *TodoHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	tags, err := h.todoService.ListTags(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tags": tags})
+}
+
+// CreateTag creates a tag for the authenticated user
+func (h *TodoHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	var tagCreate model.TagCreate
+	if err := decodeJSON(r, &tagCreate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tagCreate.Name = utils.SanitizeInput(tagCreate.Name)
+
+	var v apperror.Validation
+	validateTagName(&v, "name", tagCreate.Name)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tag, err := h.todoService.CreateTag(r.Context(), userID, &tagCreate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, tag)
+}
+
+// UpdateTag renames or recolors a tag of the authenticated user
+func (h *TodoHandler) UpdateTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	tagID, err := tagIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var tagUpdate model.TagUpdate
+	if err := decodeJSON(r, &tagUpdate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var v apperror.Validation
+	if tagUpdate.Name != nil {
+		*tagUpdate.Name = utils.SanitizeInput(*tagUpdate.Name)
+		validateTagName(&v, "name", *tagUpdate.Name)
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tag, err := h.todoService.UpdateTag(r.Context(), userID, tagID, &tagUpdate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// DeleteTag deletes a tag of the authenticated user
+func (h *TodoHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	tagID, err := tagIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.todoService.DeleteTag(r.Context(), userID, tagID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MergeTag merges a tag into another tag of the authenticated user
+func (h *TodoHandler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	tagID, err := tagIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var merge model.TagMerge
+	if err := decodeJSON(r, &merge); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if merge.Into <= 0 {
+		writeError(w, r, apperror.Invalid("into", "into must be the ID of a tag"))
+		return
+	}
+
+	tag, err := h.todoService.MergeTag(r.Context(), userID, tagID, &merge)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// tagIDParam extracts the tag ID from the URL using chi
+func tagIDParam(r *http.Request) (int, error) {
+	tagID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || tagID <= 0 {
+		return 0, apperror.BadRequest("invalid tag ID")
+	}
+	return tagID, nil
+}
+
+// sanitizeTagNames sanitizes each of the tag names in place
+func sanitizeTagNames(names []string) {
+	for i, name := range names {
+		names[i] = utils.SanitizeInput(name)
+	}
+}
+
+// validateTagNames checks each of the tag names, reporting them under field
+func validateTagNames(v *apperror.Validation, field string, names []string) {
+	for _, name := range names {
+		validateTagName(v, field, name)
+	}
+}
+
+// validateTagName checks a tag name. Commas separate the names in the tag
+// filters of GET /api/todos, so names can't contain them.
+func validateTagName(v *apperror.Validation, field, name string) {
+	switch {
+	case name == "":
+		v.Add(field, "tag names must not be empty")
+	case len(name) > 50:
+		v.Add(field, "tag names must be at most 50 characters")
+	case strings.Contains(name, ","):
+		v.Add(field, "tag names must not contain commas")
+	}
+}
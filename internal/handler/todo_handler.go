@@ -1,12 +1,14 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
+	"aplikasi-todolist/internal/apperror"
 	"aplikasi-todolist/internal/model"
 	"aplikasi-todolist/internal/repository"
 	"aplikasi-todolist/internal/service"
@@ -19,30 +21,69 @@ type TodoHandler struct {
 }
 
 // NewTodoHandler creates a new TodoHandler instance
-func NewTodoHandler(todoRepo *repository.TodoRepository) *TodoHandler {
-	todoService := service.NewTodoService(todoRepo)
+func NewTodoHandler(todoRepo repository.TodoStore, itemRepo repository.TodoItemStore, tagRepo repository.TagStore, listRepo repository.ListStore, reminderRepo repository.ReminderStore) *TodoHandler {
+	todoService := service.NewTodoService(todoRepo, itemRepo, tagRepo, listRepo, reminderRepo)
 	return &TodoHandler{
 		todoService: todoService,
 	}
 }
 
-// GetTodos retrieves all todos for the authenticated user
+// GetTodos retrieves a filtered, sorted page of todos for the authenticated user
 func (h *TodoHandler) GetTodos(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(UserIDKey).(int)
 
-	todos, err := h.todoService.GetTodos(userID)
+	query, err := parseTodoQuery(r)
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	page, err := h.todoService.GetTodos(r.Context(), userID, query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// SearchTodos runs a full-text search over the authenticated user's todos
+func (h *TodoHandler) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+	params := r.URL.Query()
+
+	var v apperror.Validation
+	limit, offset := 0, 0
+	if value := params.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			v.Add("limit", "limit must be a positive number")
+		}
+		limit = n
+	}
+	if value := params.Get("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			v.Add("offset", "offset must not be negative")
+		}
+		offset = n
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	results, err := h.todoService.SearchTodos(r.Context(), userID, utils.SanitizeInput(params.Get("q")), limit, offset)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	response := map[string]interface{}{
-		"todos": todos,
+		"results": results,
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, http.StatusOK, response)
 }
 
 // CreateTodo creates a new todo for the authenticated user
@@ -50,8 +91,8 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(UserIDKey).(int)
 
 	var todoCreate model.TodoCreate
-	if err := json.NewDecoder(r.Body).Decode(&todoCreate); err != nil {
-		http.Error(w, `{"error": "invalid JSON format"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &todoCreate); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -59,150 +100,254 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	todoCreate.Title = utils.SanitizeInput(todoCreate.Title)
 	todoCreate.Description = utils.SanitizeInput(todoCreate.Description)
 	todoCreate.Category = utils.SanitizeInput(todoCreate.Category)
+	sanitizeTagNames(todoCreate.Tags)
 
 	// Validate inputs
+	var v apperror.Validation
 	if todoCreate.Title == "" {
-		http.Error(w, `{"error": "title is required"}`, http.StatusBadRequest)
-		return
-	}
-
-	if len(todoCreate.Title) > 255 {
-		http.Error(w, `{"error": "title too long"}`, http.StatusBadRequest)
-		return
+		v.Add("title", "title is required")
 	}
-
-	if len(todoCreate.Description) > 1000 {
-		http.Error(w, `{"error": "description too long"}`, http.StatusBadRequest)
-		return
+	// An empty priority falls back to the default
+	var priority *string
+	if todoCreate.Priority != "" {
+		priority = &todoCreate.Priority
 	}
-
-	if len(todoCreate.Category) > 50 {
-		http.Error(w, `{"error": "category too long"}`, http.StatusBadRequest)
+	validateTodoFields(&v, &todoCreate.Title, &todoCreate.Description, &todoCreate.Category, priority)
+	validateListID(&v, todoCreate.ListID)
+	validateRecurrenceRule(&v, &todoCreate.RecurrenceRule)
+	validateTagNames(&v, "tags", todoCreate.Tags)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	// Validate priority if provided
-	if todoCreate.Priority != "" && todoCreate.Priority != "Low" && todoCreate.Priority != "Medium" && todoCreate.Priority != "High" {
-		http.Error(w, `{"error": "priority must be Low, Medium, or High"}`, http.StatusBadRequest)
-		return
-	}
-
-	todo, err := h.todoService.CreateTodo(userID, &todoCreate)
+	todo, err := h.todoService.CreateTodo(r.Context(), userID, &todoCreate)
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(todo)
+	writeJSON(w, http.StatusCreated, todo)
 }
 
 // GetTodo retrieves a specific todo by ID for the authenticated user
 func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(UserIDKey).(int)
 
-	// Extract todo ID from URL using chi
-	todoIDStr := chi.URLParam(r, "id")
-	todoID, err := strconv.Atoi(todoIDStr)
-	if err != nil || todoID <= 0 {
-		http.Error(w, `{"error": "invalid todo ID"}`, http.StatusBadRequest)
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	todo, err := h.todoService.GetTodo(todoID, userID)
+	todo, err := h.todoService.GetTodo(r.Context(), todoID, userID)
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(todo)
+	writeJSON(w, http.StatusOK, todo)
 }
 
 // UpdateTodo updates an existing todo for the authenticated user
 func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(UserIDKey).(int)
 
-	// Extract todo ID from URL using chi
-	todoIDStr := chi.URLParam(r, "id")
-	todoID, err := strconv.Atoi(todoIDStr)
-	if err != nil || todoID <= 0 {
-		http.Error(w, `{"error": "invalid todo ID"}`, http.StatusBadRequest)
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	var todoUpdate model.TodoUpdate
-	if err := json.NewDecoder(r.Body).Decode(&todoUpdate); err != nil {
-		http.Error(w, `{"error": "invalid JSON format"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &todoUpdate); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	// Sanitize inputs if they are provided
 	if todoUpdate.Title != nil {
-		sanitizedTitle := utils.SanitizeInput(*todoUpdate.Title)
-		if len(sanitizedTitle) > 255 {
-			http.Error(w, `{"error": "title too long"}`, http.StatusBadRequest)
-			return
-		}
-		*todoUpdate.Title = sanitizedTitle
+		*todoUpdate.Title = utils.SanitizeInput(*todoUpdate.Title)
 	}
-
 	if todoUpdate.Description != nil {
-		sanitizedDesc := utils.SanitizeInput(*todoUpdate.Description)
-		if len(sanitizedDesc) > 1000 {
-			http.Error(w, `{"error": "description too long"}`, http.StatusBadRequest)
-			return
-		}
-		*todoUpdate.Description = sanitizedDesc
+		*todoUpdate.Description = utils.SanitizeInput(*todoUpdate.Description)
 	}
-
 	if todoUpdate.Category != nil {
-		sanitizedCat := utils.SanitizeInput(*todoUpdate.Category)
-		if len(sanitizedCat) > 50 {
-			http.Error(w, `{"error": "category too long"}`, http.StatusBadRequest)
-			return
-		}
-		*todoUpdate.Category = sanitizedCat
+		*todoUpdate.Category = utils.SanitizeInput(*todoUpdate.Category)
+	}
+	if todoUpdate.Tags != nil {
+		sanitizeTagNames(*todoUpdate.Tags)
 	}
 
-	// Validate priority if provided
-	if todoUpdate.Priority != nil {
-		if *todoUpdate.Priority != "Low" && *todoUpdate.Priority != "Medium" && *todoUpdate.Priority != "High" {
-			http.Error(w, `{"error": "priority must be Low, Medium, or High"}`, http.StatusBadRequest)
-			return
-		}
+	var v apperror.Validation
+	if todoUpdate.Title != nil && *todoUpdate.Title == "" {
+		v.Add("title", "title must not be empty")
+	}
+	validateTodoFields(&v, todoUpdate.Title, todoUpdate.Description, todoUpdate.Category, todoUpdate.Priority)
+	validateListID(&v, todoUpdate.ListID)
+	validateRecurrenceRule(&v, todoUpdate.RecurrenceRule)
+	if todoUpdate.Tags != nil {
+		validateTagNames(&v, "tags", *todoUpdate.Tags)
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	todo, err := h.todoService.UpdateTodo(userID, todoID, &todoUpdate)
+	todo, err := h.todoService.UpdateTodo(r.Context(), userID, todoID, &todoUpdate)
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(todo)
+	writeJSON(w, http.StatusOK, todo)
 }
 
 // DeleteTodo deletes a todo for the authenticated user
 func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(UserIDKey).(int)
 
-	// Extract todo ID from URL using chi
-	todoIDStr := chi.URLParam(r, "id")
-	todoID, err := strconv.Atoi(todoIDStr)
-	if err != nil || todoID <= 0 {
-		http.Error(w, `{"error": "invalid todo ID"}`, http.StatusBadRequest)
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	err = h.todoService.DeleteTodo(todoID, userID)
+	err = h.todoService.DeleteTodo(r.Context(), todoID, userID)
 	if err != nil {
-		http.Error(w, `{"error": "`+err.Error()+`"}`, http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
-}
\ No newline at end of file
+}
+
+// todoIDParam extracts the todo ID from the URL using chi
+func todoIDParam(r *http.Request) (int, error) {
+	todoID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || todoID <= 0 {
+		return 0, apperror.BadRequest("invalid todo ID")
+	}
+	return todoID, nil
+}
+
+// validateTodoFields checks the length and value limits of the given fields;
+// nil fields are skipped
+func validateTodoFields(v *apperror.Validation, title, description, category, priority *string) {
+	if title != nil && len(*title) > 255 {
+		v.Add("title", "title too long")
+	}
+	if description != nil && len(*description) > 1000 {
+		v.Add("description", "description too long")
+	}
+	if category != nil && len(*category) > 50 {
+		v.Add("category", "category too long")
+	}
+	if priority != nil && *priority != "Low" && *priority != "Medium" && *priority != "High" {
+		v.Add("priority", "priority must be Low, Medium, or High")
+	}
+}
+
+// parseTodoQuery reads the filter, sort and pagination parameters of GET /api/todos
+func parseTodoQuery(r *http.Request) (model.TodoQuery, error) {
+	params := r.URL.Query()
+	query := model.TodoQuery{
+		Sort:   params.Get("sort"),
+		Order:  params.Get("order"),
+		Cursor: params.Get("cursor"),
+	}
+
+	var v apperror.Validation
+
+	if listID := params.Get("list_id"); listID != "" {
+		n, err := strconv.Atoi(listID)
+		if err != nil || n <= 0 {
+			v.Add("list_id", "list_id must be the ID of a list")
+		}
+		query.ListID = &n
+	}
+	if category := params.Get("category"); category != "" {
+		query.Category = &category
+	}
+	if priority := params.Get("priority"); priority != "" {
+		query.Priorities = strings.Split(priority, ",")
+	}
+	for _, p := range []struct {
+		name   string
+		target *[]string
+	}{
+		{"tags", &query.Tags},
+		{"any_tags", &query.AnyTags},
+		{"exclude_tags", &query.ExcludeTags},
+	} {
+		if value := params.Get(p.name); value != "" {
+			*p.target = strings.Split(value, ",")
+			sanitizeTagNames(*p.target)
+			validateTagNames(&v, p.name, *p.target)
+		}
+	}
+	if isDone := params.Get("is_done"); isDone != "" {
+		b, err := strconv.ParseBool(isDone)
+		if err != nil {
+			v.Add("is_done", "is_done must be true or false")
+		}
+		query.IsDone = &b
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			v.Add("limit", "limit must be a positive number")
+		}
+		query.Limit = n
+	}
+	switch params.Get("include") {
+	case "":
+	case "items":
+		query.IncludeItems = true
+	default:
+		v.Add("include", "include must be items")
+	}
+
+	for _, p := range []struct {
+		name     string
+		target   **time.Time
+		endOfDay bool
+	}{
+		{"due_from", &query.DueFrom, false},
+		{"due_to", &query.DueTo, true},
+		{"created_from", &query.CreatedFrom, false},
+		{"created_to", &query.CreatedTo, true},
+		{"updated_from", &query.UpdatedFrom, false},
+		{"updated_to", &query.UpdatedTo, true},
+	} {
+		value := params.Get(p.name)
+		if value == "" {
+			continue
+		}
+		t, err := parseTimeParam(value, p.endOfDay)
+		if err != nil {
+			v.Add(p.name, p.name+" must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+			continue
+		}
+		*p.target = &t
+	}
+
+	return query, v.Err()
+}
+
+// parseTimeParam parses a date or RFC 3339 timestamp as UTC. With endOfDay
+// set, a plain date covers the whole day, making "to" bounds inclusive.
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfDay {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/utils"
+)
+
+// ListItems lists the checklist items of a todo of the authenticated user
+func (h *TodoHandler) ListItems(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items, err := h.todoService.ListItems(r.Context(), userID, todoID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+// CreateItem adds a checklist item at the end of a todo's checklist
+func (h *TodoHandler) CreateItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var itemCreate model.TodoItemCreate
+	if err := decodeJSON(r, &itemCreate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	itemCreate.Title = utils.SanitizeInput(itemCreate.Title)
+
+	var v apperror.Validation
+	if itemCreate.Title == "" {
+		v.Add("title", "title is required")
+	}
+	validateItemTitle(&v, &itemCreate.Title)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.todoService.CreateItem(r.Context(), userID, todoID, &itemCreate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, item)
+}
+
+// UpdateItem changes the title or done state of a checklist item
+func (h *TodoHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	itemID, err := itemIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var itemUpdate model.TodoItemUpdate
+	if err := decodeJSON(r, &itemUpdate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var v apperror.Validation
+	if itemUpdate.Title != nil {
+		*itemUpdate.Title = utils.SanitizeInput(*itemUpdate.Title)
+		if *itemUpdate.Title == "" {
+			v.Add("title", "title must not be empty")
+		}
+	}
+	validateItemTitle(&v, itemUpdate.Title)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	item, err := h.todoService.UpdateItem(r.Context(), userID, todoID, itemID, &itemUpdate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, item)
+}
+
+// DeleteItem deletes a checklist item
+func (h *TodoHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	itemID, err := itemIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.todoService.DeleteItem(r.Context(), userID, todoID, itemID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderItems puts the checklist of a todo in a new order
+func (h *TodoHandler) ReorderItems(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var order model.TodoItemOrder
+	if err := decodeJSON(r, &order); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items, err := h.todoService.ReorderItems(r.Context(), userID, todoID, &order)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+// itemIDParam extracts the checklist item ID from the URL using chi
+func itemIDParam(r *http.Request) (int, error) {
+	itemID, err := strconv.Atoi(chi.URLParam(r, "itemID"))
+	if err != nil || itemID <= 0 {
+		return 0, apperror.BadRequest("invalid item ID")
+	}
+	return itemID, nil
+}
+
+// validateItemTitle checks the length of a checklist item title unless nil
+func validateItemTitle(v *apperror.Validation, title *string) {
+	if title != nil && len(*title) > 255 {
+		v.Add("title", "title too long")
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"aplikasi-todolist/internal/apperror"
+)
+
+// SkipOccurrence moves a recurring todo of the authenticated user to its
+// next occurrence without completing it
+func (h *TodoHandler) SkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	todo, err := h.todoService.SkipOccurrence(r.Context(), userID, todoID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
+}
+
+// StopRecurrence stops a todo of the authenticated user from repeating
+func (h *TodoHandler) StopRecurrence(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	todo, err := h.todoService.StopRecurrence(r.Context(), userID, todoID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
+}
+
+// validateRecurrenceRule checks the length of a rule; the service parses it
+func validateRecurrenceRule(v *apperror.Validation, rule *string) {
+	if rule != nil && len(*rule) > 255 {
+		v.Add("recurrence_rule", "recurrence_rule too long")
+	}
+}
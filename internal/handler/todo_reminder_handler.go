@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/utils"
+)
+
+// maxReminderOffset is the earliest a reminder may fire before the due
+// date, in minutes: a year
+const maxReminderOffset = 365 * 24 * 60
+
+// ListReminders lists the reminders of a todo of the authenticated user
+func (h *TodoHandler) ListReminders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	reminders, err := h.todoService.ListReminders(r.Context(), userID, todoID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"reminders": reminders})
+}
+
+// CreateReminder adds a reminder to a todo of the authenticated user
+func (h *TodoHandler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var reminderCreate model.ReminderCreate
+	if err := decodeJSON(r, &reminderCreate); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var v apperror.Validation
+	validateReminder(&v, &reminderCreate)
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	reminder, err := h.todoService.CreateReminder(r.Context(), userID, todoID, &reminderCreate)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reminder)
+}
+
+// DeleteReminder deletes a reminder of a todo of the authenticated user
+func (h *TodoHandler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	todoID, err := todoIDParam(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	reminderID, err := strconv.Atoi(chi.URLParam(r, "reminderID"))
+	if err != nil || reminderID <= 0 {
+		writeError(w, r, apperror.BadRequest("invalid reminder ID"))
+		return
+	}
+
+	if err := h.todoService.DeleteReminder(r.Context(), userID, todoID, reminderID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateReminder checks the time, channels and webhook URL of a new
+// reminder, dropping repeated channels
+func validateReminder(v *apperror.Validation, reminder *model.ReminderCreate) {
+	switch {
+	case reminder.RemindAt == nil && reminder.OffsetMinutes == nil:
+		v.Add("remind_at", "either remind_at or offset_minutes is required")
+	case reminder.RemindAt != nil && reminder.OffsetMinutes != nil:
+		v.Add("remind_at", "remind_at and offset_minutes can't both be set")
+	case reminder.OffsetMinutes != nil && (*reminder.OffsetMinutes < 0 || *reminder.OffsetMinutes > maxReminderOffset):
+		v.Add("offset_minutes", "offset_minutes must be between 0 and "+strconv.Itoa(maxReminderOffset))
+	}
+
+	var channels []string
+	for _, channel := range reminder.Channels {
+		if !slices.Contains(model.ReminderChannels, channel) {
+			v.Add("channels", "channels must be in_app, email or webhook")
+			return
+		}
+		if !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	reminder.Channels = channels
+
+	if !slices.Contains(channels, model.ReminderChannelWebhook) {
+		if reminder.WebhookURL != "" {
+			v.Add("webhook_url", "webhook_url needs the webhook channel")
+		}
+		return
+	}
+	u, err := url.Parse(reminder.WebhookURL)
+	switch {
+	case reminder.WebhookURL == "":
+		v.Add("webhook_url", "webhook_url is required for the webhook channel")
+	case len(reminder.WebhookURL) > 2048:
+		v.Add("webhook_url", "webhook_url too long")
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		v.Add("webhook_url", "webhook_url must be an absolute http(s) URL")
+	case !publicHost(u.Hostname()):
+		v.Add("webhook_url", "webhook_url must be a public address")
+	}
+}
+
+// publicHost reports whether a URL host may be public: a name other than
+// localhost, or a public IP address. Names are checked again when webhooks
+// connect, as they may resolve to anything.
+func publicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return utils.PublicAddr(addr)
+	}
+	return true
+}
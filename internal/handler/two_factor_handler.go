@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/service"
+	"aplikasi-todolist/internal/utils"
+)
+
+// TwoFactorHandler handles two-factor authentication HTTP requests
+type TwoFactorHandler struct {
+	twoFactorService *service.TwoFactorService
+}
+
+// NewTwoFactorHandler creates a new TwoFactorHandler instance
+func NewTwoFactorHandler(twoFactorService *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{twoFactorService: twoFactorService}
+}
+
+// Status reports whether two-factor authentication is enabled
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	status, err := h.twoFactorService.Status(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// Enroll creates a TOTP secret to add to an authenticator app
+func (h *TwoFactorHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	enrollment, err := h.twoFactorService.Enroll(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+// Confirm enables two-factor authentication with a first code and returns
+// the recovery codes
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	var req model.TwoFactorCodeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	code := strings.TrimSpace(req.Code)
+	if code == "" {
+		writeError(w, r, apperror.Invalid("code", "code is required"))
+		return
+	}
+
+	codes, err := h.twoFactorService.Confirm(r.Context(), userID, code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, codes)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes after re-entering the
+// password
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	password, ok := decodePassword(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(r.Context(), userID, password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, codes)
+}
+
+// Disable turns two-factor authentication off after re-entering the password
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	password, ok := decodePassword(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.twoFactorService.Disable(r.Context(), userID, password); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LoginMFA completes a login with the challenge token from Login and a code
+// from the authenticator app or a recovery code
+func (h *TwoFactorHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req model.MFALoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.Code = strings.TrimSpace(req.Code)
+	req.RecoveryCode = strings.TrimSpace(req.RecoveryCode)
+
+	var v apperror.Validation
+	if req.MFAToken == "" {
+		v.Add("mfa_token", "mfa_token is required")
+	}
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		v.Add("code", "either code or recovery_code is required")
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tokens, user, err := h.twoFactorService.LoginMFA(r.Context(), &req, clientInfo(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeLogin(w, tokens, user)
+}
+
+// decodePassword reads a password confirmation, writing an error response
+// if it is missing
+func decodePassword(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req model.PasswordConfirmation
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return "", false
+	}
+
+	// Sanitized like at registration so it matches the stored password
+	req.Password = utils.SanitizeInput(req.Password)
+	if req.Password == "" {
+		writeError(w, r, apperror.Invalid("password", "password is required"))
+		return "", false
+	}
+
+	return req.Password, true
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/service"
+	"aplikasi-todolist/internal/utils"
+)
+
+// UserHandler handles the profile HTTP requests of the signed-in user
+type UserHandler struct {
+	authService    *service.AuthService
+	accountService *service.AccountService
+}
+
+// NewUserHandler creates a new UserHandler instance
+func NewUserHandler(authService *service.AuthService, accountService *service.AccountService) *UserHandler {
+	return &UserHandler{authService: authService, accountService: accountService}
+}
+
+// Me returns the profile of the authenticated user
+func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	user, err := h.authService.Profile(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// UpdateMe changes the profile of the authenticated user
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	var req model.UpdateProfileRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if req.Username != nil {
+		username := utils.SanitizeInput(*req.Username)
+		if err := utils.ValidateUsername(username); err != nil {
+			writeError(w, r, apperror.Invalid("username", err.Error()))
+			return
+		}
+		req.Username = &username
+	}
+
+	user, err := h.authService.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
+
+// ChangePassword sets a new password and signs out every other device
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+	sessionID := r.Context().Value(SessionIDKey).(string)
+
+	var req model.ChangePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.CurrentPassword = utils.SanitizeInput(req.CurrentPassword)
+	req.NewPassword = utils.SanitizeInput(req.NewPassword)
+
+	var v apperror.Validation
+	if req.CurrentPassword == "" {
+		v.Add("current_password", "current_password is required")
+	}
+	v.AddError("new_password", utils.ValidatePassword(req.NewPassword))
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.authService.ChangePassword(r.Context(), userID, sessionID, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ChangeEmail sends a confirmation link to a new email address. The address
+// of the account changes once the link is opened.
+func (h *UserHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	var req model.ChangeEmailRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	req.Email = utils.SanitizeInput(req.Email)
+	req.Password = utils.SanitizeInput(req.Password)
+
+	var v apperror.Validation
+	v.AddError("email", utils.ValidateEmail(req.Email))
+	if req.Password == "" {
+		v.Add("password", "password is required")
+	}
+	if err := v.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.authService.RequestEmailChange(r.Context(), userID, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "a confirmation link has been sent to the new email address",
+	})
+}
+
+// ConfirmEmail switches the account to a new email address with the token
+// from the confirmation email
+func (h *UserHandler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	var req model.ConfirmEmailChangeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if req.Token == "" {
+		writeError(w, r, apperror.Invalid("token", "token is required"))
+		return
+	}
+
+	if err := h.authService.ConfirmEmailChange(r.Context(), req.Token); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Export downloads the profile and every todo of the authenticated user as
+// a JSON file
+func (h *UserHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	export, err := h.accountService.Export(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	filename := fmt.Sprintf("todolist-export-%s.json", export.ExportedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	writeJSON(w, http.StatusOK, export)
+}
+
+// DeleteMe deactivates the account of the authenticated user, who is signed
+// out everywhere. It is purged after the grace period unless the user logs
+// in again.
+func (h *UserHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(UserIDKey).(int)
+
+	password, ok := decodePassword(w, r)
+	if !ok {
+		return
+	}
+
+	deletion, err := h.accountService.Delete(r.Context(), userID, password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, deletion)
+}
@@ -0,0 +1,341 @@
+// Package jwtkeys manages the asymmetric keys that sign access tokens. Keys
+// are PKCS #8 PEM files in a directory, named by their key ID, and the file
+// "signing-key" names the key that signs new tokens. The other keys still
+// verify, so tokens issued before a rotation stay valid until they expire.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/oidc"
+)
+
+// Signing algorithms of the keys
+const (
+	AlgEdDSA = "EdDSA"
+	AlgRS256 = "RS256"
+)
+
+const (
+	signingKeyFile = "signing-key"
+	keyFileExt     = ".pem"
+	// rsaKeyBits is the size of generated RSA keys; minRSAKeyBits the
+	// smallest size loaded
+	rsaKeyBits    = 3072
+	minRSAKeyBits = 2048
+	// reloadInterval is how often a Set rereads the directory, picking up
+	// keys generated and activated by other processes
+	reloadInterval = time.Minute
+	// minReloadInterval limits how often unknown key IDs trigger a reload,
+	// so forged tokens can't make every request read the directory
+	minReloadInterval = 5 * time.Second
+)
+
+// kidPattern restricts key IDs to what is safe in a file name
+var kidPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Key is a signing key
+type Key struct {
+	ID        string
+	Algorithm string
+	// Private is an ed25519.PrivateKey or an *rsa.PrivateKey
+	Private   crypto.Signer
+	CreatedAt time.Time
+}
+
+// Public returns the public key that verifies the key's signatures
+func (k *Key) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+// JWK returns the public key as a JSON Web Key
+func (k *Key) JWK() oidc.JSONWebKey {
+	switch pub := k.Public().(type) {
+	case ed25519.PublicKey:
+		return oidc.NewEd25519Key(k.ID, pub)
+	default:
+		return oidc.NewRSAKey(k.ID, pub.(*rsa.PublicKey))
+	}
+}
+
+// Set holds the keys of a directory. It rereads the directory periodically,
+// and when asked for an unknown key, so keys added by other processes are
+// picked up without a restart.
+type Set struct {
+	dir string
+
+	mu       sync.Mutex
+	keys     map[string]*Key
+	signing  *Key
+	loadedAt time.Time
+}
+
+// Load reads the keys in dir, which needs a signing key
+func Load(dir string) (*Set, error) {
+	keys, signing, err := read(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Set{dir: dir, keys: keys, signing: signing, loadedAt: time.Now()}, nil
+}
+
+// Signing returns the key that signs new tokens
+func (s *Set) Signing() *Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reload(reloadInterval)
+	return s.signing
+}
+
+// Get returns the key with the given ID
+func (s *Set) Get(kid string) (*Key, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reload(reloadInterval)
+	if key, ok := s.keys[kid]; ok {
+		return key, true
+	}
+
+	// The key may have been generated since the last reload
+	s.reload(minReloadInterval)
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// Keys returns every key, oldest first
+func (s *Set) Keys() []*Key {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reload(reloadInterval)
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
+
+// JWKS returns the public keys as a JSON Web Key Set
+func (s *Set) JWKS() oidc.JSONWebKeySet {
+	set := oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{}}
+	for _, key := range s.Keys() {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
+
+// reload rereads the directory if it was last read more than maxAge ago.
+// Failures are logged and keep the keys loaded before.
+func (s *Set) reload(maxAge time.Duration) {
+	if time.Since(s.loadedAt) < maxAge {
+		return
+	}
+	s.loadedAt = time.Now()
+
+	keys, signing, err := read(s.dir)
+	if err != nil {
+		log.Printf("Failed to reload JWT signing keys: %v", err)
+		return
+	}
+	s.keys = keys
+	s.signing = signing
+}
+
+// Generate creates a key for the algorithm in dir. The first key of a
+// directory becomes the signing key; later ones have to be activated.
+func Generate(dir, alg string) (*Key, error) {
+	var private crypto.Signer
+	var err error
+	switch alg {
+	case AlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, use %s or %s", alg, AlgEdDSA, AlgRS256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	// Key IDs sort by creation date and are unguessable
+	key := &Key{
+		ID:        time.Now().UTC().Format("20060102") + "-" + strings.ToLower(rand.Text()[:10]),
+		Algorithm: alg,
+		Private:   private,
+		CreatedAt: time.Now(),
+	}
+	f, err := os.OpenFile(keyPath(dir, key.ID), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create key file: %w", err)
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	if _, err := signingKeyID(dir); errors.Is(err, os.ErrNotExist) {
+		if err := Activate(dir, key.ID); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Activate makes the key with the given ID sign new tokens
+func Activate(dir, kid string) error {
+	if _, err := readKey(dir, kid); err != nil {
+		return err
+	}
+
+	// Replace the file atomically so readers never see it half written
+	tmp := filepath.Join(dir, signingKeyFile+".tmp")
+	if err := os.WriteFile(tmp, []byte(kid+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write signing key file: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, signingKeyFile)); err != nil {
+		return fmt.Errorf("failed to write signing key file: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes a key that no longer signs. Tokens it signed are rejected
+// from then on.
+func Remove(dir, kid string) error {
+	if !kidPattern.MatchString(kid) {
+		return fmt.Errorf("invalid key ID %q", kid)
+	}
+	if signing, err := signingKeyID(dir); err == nil && signing == kid {
+		return fmt.Errorf("key %s is the signing key, activate another key first", kid)
+	}
+
+	if err := os.Remove(keyPath(dir, kid)); err != nil {
+		return fmt.Errorf("failed to remove key: %w", err)
+	}
+	return nil
+}
+
+// read loads every key of dir and the signing key
+func read(dir string) (map[string]*Key, *Key, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	keys := make(map[string]*Key)
+	for _, entry := range entries {
+		kid, ok := strings.CutSuffix(entry.Name(), keyFileExt)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		key, err := readKey(dir, kid)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[kid] = key
+	}
+
+	kid, err := signingKeyID(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("%s has no signing key, generate one first", dir)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read signing key file: %w", err)
+	}
+	signing, ok := keys[kid]
+	if !ok {
+		return nil, nil, fmt.Errorf("signing key %q is not in %s", kid, dir)
+	}
+
+	return keys, signing, nil
+}
+
+// readKey loads the key with the given ID from dir
+func readKey(dir, kid string) (*Key, error) {
+	if !kidPattern.MatchString(kid) {
+		return nil, fmt.Errorf("invalid key ID %q", kid)
+	}
+
+	path := keyPath(dir, kid)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", kid, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("key %s is not a PKCS #8 PEM file", kid)
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key %s: %w", kid, err)
+	}
+
+	key := &Key{ID: kid, CreatedAt: info.ModTime()}
+	switch private := private.(type) {
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgEdDSA
+		key.Private = private
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("key %s: RSA keys need at least %d bits", kid, minRSAKeyBits)
+		}
+		key.Algorithm = AlgRS256
+		key.Private = private
+	default:
+		return nil, fmt.Errorf("key %s: only Ed25519 and RSA keys are supported", kid)
+	}
+	return key, nil
+}
+
+// signingKeyID reads the ID of the signing key of dir
+func signingKeyID(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, signingKeyFile))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func keyPath(dir, kid string) string {
+	return filepath.Join(dir, kid+keyFileExt)
+}
@@ -0,0 +1,97 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyRotation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+
+	_, err := Load(dir)
+	assert.Error(t, err)
+
+	// The first key signs right away, later ones only once activated
+	first, err := Generate(dir, AlgEdDSA)
+	require.NoError(t, err)
+	second, err := Generate(dir, AlgRS256)
+	require.NoError(t, err)
+	_, err = Generate(dir, "HS256")
+	assert.Error(t, err)
+
+	info, err := os.Stat(filepath.Join(dir, first.ID+".pem"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	keys, err := Load(dir)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, keys.Signing().ID)
+	assert.Len(t, keys.Keys(), 2)
+
+	jwks := keys.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	for _, jwk := range jwks.Keys {
+		key, ok := keys.Get(jwk.Kid)
+		require.True(t, ok)
+		assert.Equal(t, key.Algorithm, jwk.Alg)
+		pub, err := jwk.PublicKey()
+		require.NoError(t, err)
+		switch pub := pub.(type) {
+		case ed25519.PublicKey:
+			assert.True(t, pub.Equal(key.Public()))
+		case *rsa.PublicKey:
+			assert.True(t, pub.Equal(key.Public()))
+		}
+	}
+
+	// Another process activates the second key; the set notices on reload
+	require.NoError(t, Activate(dir, second.ID))
+	assert.Equal(t, first.ID, keys.Signing().ID)
+	keys.loadedAt = keys.loadedAt.Add(-reloadInterval)
+	assert.Equal(t, second.ID, keys.Signing().ID)
+
+	// Unknown keys make the set look again
+	third, err := Generate(dir, AlgEdDSA)
+	require.NoError(t, err)
+	keys.loadedAt = keys.loadedAt.Add(-minReloadInterval)
+	_, ok := keys.Get(third.ID)
+	assert.True(t, ok)
+	_, ok = keys.Get("unknown")
+	assert.False(t, ok)
+
+	assert.Error(t, Activate(dir, "unknown"))
+	assert.Error(t, Remove(dir, second.ID), "the signing key can't be removed")
+	assert.Error(t, Remove(dir, "../signing-key"))
+	require.NoError(t, Remove(dir, first.ID))
+
+	keys, err = Load(dir)
+	require.NoError(t, err)
+	_, ok = keys.Get(first.ID)
+	assert.False(t, ok)
+}
+
+func TestSigningMethodEdDSA(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	signed, err := jwt.NewWithClaims(SigningMethodEdDSA, jwt.MapClaims{"sub": "1"}).SignedString(priv)
+	require.NoError(t, err)
+
+	token, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return pub, nil })
+	require.NoError(t, err)
+	assert.Equal(t, AlgEdDSA, token.Header["alg"])
+
+	_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return otherPub, nil })
+	assert.Error(t, err)
+	_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil })
+	assert.Error(t, err)
+}
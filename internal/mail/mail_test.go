@@ -0,0 +1,117 @@
+package mail
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, err := format("Todo <no-reply@example.com>", Message{
+		To:      "budi@example.com",
+		Subject: "Atur ulang kata sandi ✓",
+		Body:    "line one\nline two",
+	}, date)
+	require.NoError(t, err)
+
+	text := string(data)
+	assert.Contains(t, text, "From: Todo <no-reply@example.com>\r\n")
+	assert.Contains(t, text, "To: budi@example.com\r\n")
+	assert.Contains(t, text, "Subject: =?utf-8?q?")
+	assert.Contains(t, text, "Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n")
+	assert.True(t, strings.HasSuffix(text, "\r\n\r\nline one\r\nline two\r\n"))
+
+	_, err = format("no-reply@example.com", Message{To: "budi@example.com", Subject: "hi\r\nBcc: evil@example.com"}, date)
+	assert.Error(t, err)
+	_, err = format("no-reply@example.com", Message{To: "not an address"}, date)
+	assert.Error(t, err)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m, err := NewFileMailer(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, m.Send(context.Background(), Message{To: "budi@example.com", Subject: "Hello", Body: "Hi"}))
+	}
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Subject: Hello")
+}
+
+func TestSMTPMailer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan []string, 1)
+	go serveSMTP(t, ln, received)
+
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	m := NewSMTPMailer(host, port, "", "", "Todo <no-reply@example.com>")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, m.Send(ctx, Message{To: "budi@example.com", Subject: "Hello", Body: "Hi there"}))
+
+	commands := <-received
+	assert.Contains(t, commands, "MAIL FROM:<no-reply@example.com>")
+	assert.Contains(t, commands, "RCPT TO:<budi@example.com>")
+	assert.Contains(t, commands, "Hi there")
+}
+
+// serveSMTP accepts one connection and speaks just enough SMTP for a single
+// message, reporting every line it received
+func serveSMTP(t *testing.T, ln net.Listener, received chan<- []string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	var lines []string
+	tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			received <- lines
+			return
+		}
+		lines = append(lines, line)
+		switch {
+		case strings.HasPrefix(line, "EHLO"):
+			tp.PrintfLine("250 localhost")
+		case line == "DATA":
+			tp.PrintfLine("354 go ahead")
+			body, err := tp.ReadDotLines()
+			if err != nil {
+				t.Error(err)
+			}
+			lines = append(lines, body...)
+			tp.PrintfLine("250 queued")
+		case line == "QUIT":
+			tp.PrintfLine("221 bye")
+			received <- lines
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package migrate applies and rolls back the versioned SQL migrations.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of the up script
+}
+
+// AppliedMigration is a row from the schema_migrations table
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// State describes where a migration stands relative to the database
+type State string
+
+const (
+	StatePending  State = "pending"
+	StateApplied  State = "applied"
+	StateModified State = "modified" // applied, but the file changed since
+	StateMissing  State = "missing"  // applied, but no file exists anymore
+)
+
+// Status reports the state of a single migration
+type Status struct {
+	Version   int
+	Name      string
+	State     State
+	AppliedAt *time.Time
+}
+
+// Driver is the database-specific part of the migrator
+type Driver interface {
+	// Lock blocks until this process holds the migration lock
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	// EnsureVersionTable creates schema_migrations if it doesn't exist
+	EnsureVersionTable(ctx context.Context) error
+	Applied(ctx context.Context) ([]AppliedMigration, error)
+	// Apply runs the up script and records the version in one transaction
+	Apply(ctx context.Context, m Migration) error
+	// Revert runs the down script and removes the version in one transaction
+	Revert(ctx context.Context, m Migration) error
+}
+
+// ErrChecksumMismatch is returned when an applied migration file was edited
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+var fileNameRegex = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads all migrations from fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies migrations through a Driver
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+}
+
+// New creates a Migrator for the migrations found in fsys
+func New(driver Driver, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{driver: driver, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+// It refuses to run if an already applied migration has been modified.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if a, ok := applied[mig.Version]; ok {
+				if a.Checksum != mig.Checksum {
+					return fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+				}
+				continue
+			}
+
+			if err := m.driver.Apply(ctx, mig); err != nil {
+				return fmt.Errorf("failed to apply migration %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the most recent steps applied migrations and returns them
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("steps must be positive")
+	}
+
+	var done []Migration
+	err := m.locked(ctx, func() error {
+		applied, err := m.applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %03d_%s has no down script", mig.Version, mig.Name)
+			}
+
+			if err := m.driver.Revert(ctx, mig); err != nil {
+				return fmt.Errorf("failed to roll back migration %03d_%s: %w", mig.Version, mig.Name, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status reports every known and applied migration, sorted by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.driver.EnsureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, mig := range m.migrations {
+		s := Status{Version: mig.Version, Name: mig.Name, State: StatePending}
+		if a, ok := applied[mig.Version]; ok {
+			appliedAt := a.AppliedAt
+			s.AppliedAt = &appliedAt
+			s.State = StateApplied
+			if a.Checksum != mig.Checksum {
+				s.State = StateModified
+			}
+			delete(applied, mig.Version)
+		}
+		statuses = append(statuses, s)
+	}
+
+	for _, a := range applied {
+		appliedAt := a.AppliedAt
+		statuses = append(statuses, Status{Version: a.Version, Name: a.Name, State: StateMissing, AppliedAt: &appliedAt})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// locked runs fn while holding the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func() error) (err error) {
+	if err := m.driver.Lock(ctx); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := m.driver.Unlock(context.Background()); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	if err := m.driver.EnsureVersionTable(ctx); err != nil {
+		return err
+	}
+	return fn()
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]AppliedMigration, error) {
+	rows, err := m.driver.Applied(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	applied := make(map[int]AppliedMigration, len(rows))
+	for _, a := range rows {
+		applied[a.Version] = a
+	}
+	return applied, nil
+}
@@ -0,0 +1,213 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"aplikasi-todolist/migrations"
+)
+
+// fakeDriver records migrations in memory
+type fakeDriver struct {
+	locked  bool
+	applied []AppliedMigration
+	scripts []string
+}
+
+func (d *fakeDriver) Lock(ctx context.Context) error               { d.locked = true; return nil }
+func (d *fakeDriver) Unlock(ctx context.Context) error             { d.locked = false; return nil }
+func (d *fakeDriver) EnsureVersionTable(ctx context.Context) error { return nil }
+func (d *fakeDriver) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	return append([]AppliedMigration(nil), d.applied...), nil
+}
+
+func (d *fakeDriver) Apply(ctx context.Context, m Migration) error {
+	if !d.locked {
+		return errors.New("not locked")
+	}
+	d.scripts = append(d.scripts, m.Up)
+	d.applied = append(d.applied, AppliedMigration{Version: m.Version, Name: m.Name, Checksum: m.Checksum, AppliedAt: time.Now()})
+	return nil
+}
+
+func (d *fakeDriver) Revert(ctx context.Context, m Migration) error {
+	if !d.locked {
+		return errors.New("not locked")
+	}
+	d.scripts = append(d.scripts, m.Down)
+	for i, a := range d.applied {
+		if a.Version == m.Version {
+			d.applied = append(d.applied[:i], d.applied[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"002_second.up.sql":   {Data: []byte("up 2")},
+		"002_second.down.sql": {Data: []byte("down 2")},
+		"001_first.up.sql":    {Data: []byte("up 1")},
+		"001_first.down.sql":  {Data: []byte("down 1")},
+		"README.md":           {Data: []byte("ignored")},
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	driver := &fakeDriver{}
+	m, err := New(driver, testFS())
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	assert.Equal(t, []string{"up 1", "up 2"}, driver.scripts)
+	assert.False(t, driver.locked)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, 2, reverted[0].Version)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, StateApplied, statuses[0].State)
+	assert.Equal(t, StatePending, statuses[1].State)
+}
+
+func TestChecksumDrift(t *testing.T) {
+	ctx := context.Background()
+	driver := &fakeDriver{}
+	m, err := New(driver, testFS())
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	changed := testFS()
+	changed["001_first.up.sql"] = &fstest.MapFile{Data: []byte("up 1 edited")}
+	changed["003_third.up.sql"] = &fstest.MapFile{Data: []byte("up 3")}
+	m, err = New(driver, changed)
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, StateModified, statuses[0].State)
+	assert.Equal(t, StatePending, statuses[2].State)
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	for i, m := range all {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, m.Down, "migration %03d_%s needs a down script", m.Version, m.Name)
+	}
+}
+
+func TestSQLiteMigrationsMirrorPostgres(t *testing.T) {
+	postgres, err := Load(migrations.FS)
+	require.NoError(t, err)
+	sqlite, err := Load(migrations.SQLiteFS)
+	require.NoError(t, err)
+
+	require.Len(t, sqlite, len(postgres), "every migration needs a SQLite version")
+	for i, m := range sqlite {
+		assert.Equal(t, postgres[i].Version, m.Version)
+		assert.Equal(t, postgres[i].Name, m.Name)
+		assert.NotEmpty(t, m.Down, "migration %03d_%s needs a down script", m.Version, m.Name)
+	}
+}
+
+func TestSQLiteDriver(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	m, err := New(NewSQLiteDriver(db), migrations.SQLiteFS)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(m.migrations))
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.Equal(t, StateApplied, s.State)
+	}
+
+	// Reverting lists turns them back into categories, and todos without a
+	// list get none
+	_, err = db.Exec(`
+		INSERT INTO users (id, username, email, password_hash) VALUES (1, 'budi', 'budi@example.com', 'x');
+		INSERT INTO lists (id, user_id, name, name_key, color, position) VALUES (1, 1, 'Work', 'work', '#9e9e9e', 0);
+		INSERT INTO todos (user_id, title, list_id) VALUES (1, 'Listed', 1), (1, 'Unlisted', NULL);
+	`)
+	require.NoError(t, err)
+	_, err = m.Down(ctx, len(m.migrations)-18)
+	require.NoError(t, err)
+	categories := map[string]string{}
+	rows, err := db.Query("SELECT title, category FROM todos")
+	require.NoError(t, err)
+	for rows.Next() {
+		var title, category string
+		require.NoError(t, rows.Scan(&title, &category))
+		categories[title] = category
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, map[string]string{"Listed": "Work", "Unlisted": ""}, categories)
+
+	// Every down script must undo its up script
+	reverted, err := m.Down(ctx, 18)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 18)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(m.migrations))
+
+	// A failing migration is rolled back without losing the earlier ones
+	broken := fstest.MapFS{
+		"001_ok.up.sql":     {Data: []byte("CREATE TABLE ok (id INTEGER)")},
+		"002_broken.up.sql": {Data: []byte("CREATE TABLE half (id INTEGER); SELECT * FROM missing")},
+	}
+	db2, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "broken.db"))
+	require.NoError(t, err)
+	defer db2.Close()
+
+	m, err = New(NewSQLiteDriver(db2), broken)
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	assert.Error(t, err)
+
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, StateApplied, statuses[0].State)
+	assert.Equal(t, StatePending, statuses[1].State)
+
+	var tables int
+	require.NoError(t, db2.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half'").Scan(&tables))
+	assert.Zero(t, tables)
+}
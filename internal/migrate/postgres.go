@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// advisoryLockKey identifies the migration lock across all server instances
+const advisoryLockKey int64 = 7261696001
+
+// PostgresDriver runs migrations against PostgreSQL, serialising concurrent
+// runners with a session-level advisory lock
+type PostgresDriver struct {
+	pool *pgxpool.Pool
+	conn *pgxpool.Conn // held while the advisory lock is taken
+}
+
+// NewPostgresDriver creates a driver for the given pool
+func NewPostgresDriver(pool *pgxpool.Pool) *PostgresDriver {
+	return &PostgresDriver{pool: pool}
+}
+
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+func (d *PostgresDriver) db() querier {
+	if d.conn != nil {
+		return d.conn
+	}
+	return d.pool
+}
+
+// Lock takes the advisory lock on a dedicated connection
+func (d *PostgresDriver) Lock(ctx context.Context) error {
+	if d.conn != nil {
+		return errors.New("migration lock already held")
+	}
+
+	conn, err := d.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey); err != nil {
+		conn.Release()
+		return err
+	}
+
+	d.conn = conn
+	return nil
+}
+
+// Unlock releases the advisory lock and its connection
+func (d *PostgresDriver) Unlock(ctx context.Context) error {
+	if d.conn == nil {
+		return nil
+	}
+
+	_, err := d.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockKey)
+	d.conn.Release()
+	d.conn = nil
+	return err
+}
+
+// EnsureVersionTable creates the schema_migrations table
+func (d *PostgresDriver) EnsureVersionTable(ctx context.Context) error {
+	_, err := d.db().Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// Applied lists the recorded migrations
+func (d *PostgresDriver) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	rows, err := d.db().Query(ctx, `
+		SELECT version, name, checksum, applied_at
+		FROM schema_migrations
+		ORDER BY version
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// Apply runs the up script and records it
+func (d *PostgresDriver) Apply(ctx context.Context, m Migration) error {
+	return d.inTx(ctx, m.Up, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+			m.Version, m.Name, m.Checksum,
+		)
+		return err
+	})
+}
+
+// Revert runs the down script and forgets it
+func (d *PostgresDriver) Revert(ctx context.Context, m Migration) error {
+	return d.inTx(ctx, m.Down, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+		return err
+	})
+}
+
+// inTx executes script and then record inside a single transaction
+func (d *PostgresDriver) inTx(ctx context.Context, script string, record func(pgx.Tx) error) error {
+	tx, err := d.db().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Without arguments pgx uses the simple protocol, which allows the
+	// script to contain multiple statements
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
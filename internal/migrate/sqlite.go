@@ -0,0 +1,171 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// SQLite's LOWER only folds ASCII; title sorting and cursors must agree
+	// with strings.ToLower for every language, and migrations lowercase names
+	// the same way
+	sqlite.MustRegisterDeterministicScalarFunction("unicode_lower", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return strings.ToLower(v), nil
+		case []byte:
+			return strings.ToLower(string(v)), nil
+		default:
+			return v, nil
+		}
+	})
+}
+
+// SQLiteDriver runs migrations against SQLite. Lock opens an IMMEDIATE
+// transaction on a dedicated connection, which holds the database write lock
+// until Unlock, and each migration runs in a savepoint inside it.
+type SQLiteDriver struct {
+	db   *sql.DB
+	conn *sql.Conn // holds the write lock between Lock and Unlock
+}
+
+// NewSQLiteDriver creates a driver for the given database
+func NewSQLiteDriver(db *sql.DB) *SQLiteDriver {
+	return &SQLiteDriver{db: db}
+}
+
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (d *SQLiteDriver) querier() sqlQuerier {
+	if d.conn != nil {
+		return d.conn
+	}
+	return d.db
+}
+
+// Lock takes the database write lock on a dedicated connection
+func (d *SQLiteDriver) Lock(ctx context.Context) error {
+	if d.conn != nil {
+		return errors.New("migration lock already held")
+	}
+
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		conn.Close()
+		return err
+	}
+
+	d.conn = conn
+	return nil
+}
+
+// Unlock commits the migrations applied while locked and releases the lock
+func (d *SQLiteDriver) Unlock(ctx context.Context) error {
+	if d.conn == nil {
+		return nil
+	}
+
+	_, err := d.conn.ExecContext(ctx, "COMMIT")
+	if err != nil {
+		d.conn.ExecContext(ctx, "ROLLBACK")
+	}
+	d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// EnsureVersionTable creates the schema_migrations table
+func (d *SQLiteDriver) EnsureVersionTable(ctx context.Context) error {
+	_, err := d.querier().ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// Applied lists the recorded migrations
+func (d *SQLiteDriver) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	rows, err := d.querier().QueryContext(ctx, `
+		SELECT version, name, checksum, applied_at
+		FROM schema_migrations
+		ORDER BY version
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var a AppliedMigration
+		var appliedAt string
+		if err := rows.Scan(&a.Version, &a.Name, &a.Checksum, &appliedAt); err != nil {
+			return nil, err
+		}
+		if a.AppliedAt, err = time.Parse(time.RFC3339Nano, appliedAt); err != nil {
+			return nil, fmt.Errorf("invalid applied_at for migration %d: %w", a.Version, err)
+		}
+		applied = append(applied, a)
+	}
+	return applied, rows.Err()
+}
+
+// Apply runs the up script and records it
+func (d *SQLiteDriver) Apply(ctx context.Context, m Migration) error {
+	return d.inSavepoint(ctx, m.Up,
+		"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)",
+		m.Version, m.Name, m.Checksum, time.Now().UTC().Format(time.RFC3339Nano),
+	)
+}
+
+// Revert runs the down script and forgets it
+func (d *SQLiteDriver) Revert(ctx context.Context, m Migration) error {
+	return d.inSavepoint(ctx, m.Down, "DELETE FROM schema_migrations WHERE version = ?", m.Version)
+}
+
+// inSavepoint executes script and then the record statement inside a
+// savepoint of the transaction opened by Lock
+func (d *SQLiteDriver) inSavepoint(ctx context.Context, script, record string, args ...any) error {
+	if d.conn == nil {
+		return errors.New("migration lock not held")
+	}
+
+	if _, err := d.conn.ExecContext(ctx, "SAVEPOINT migration"); err != nil {
+		return err
+	}
+
+	// The driver executes every statement of a multi-statement script
+	_, err := d.conn.ExecContext(ctx, script)
+	if err == nil {
+		_, err = d.conn.ExecContext(ctx, record, args...)
+	}
+	if err != nil {
+		d.conn.ExecContext(ctx, "ROLLBACK TO migration")
+		d.conn.ExecContext(ctx, "RELEASE migration")
+		return err
+	}
+
+	_, err = d.conn.ExecContext(ctx, "RELEASE migration")
+	return err
+}
@@ -0,0 +1,57 @@
+package model
+
+import "time"
+
+// Scopes of personal access tokens. Sessions from a login have every scope.
+const (
+	// ScopeTodosRead allows listing, searching and reading to-dos
+	ScopeTodosRead = "todos:read"
+	// ScopeTodosWrite allows creating, updating and deleting to-dos
+	ScopeTodosWrite = "todos:write"
+	// ScopeAccount allows managing the account: sessions, two-factor
+	// authentication and access tokens
+	ScopeAccount = "account"
+	// ScopeAdmin allows using the admin API, for users with the admin role
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists every valid scope
+var Scopes = []string{ScopeTodosRead, ScopeTodosWrite, ScopeAccount, ScopeAdmin}
+
+// PersonalAccessToken lets scripts and integrations call the API without the
+// user's password. Only the hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the token was granted scope
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAccessTokenRequest creates a personal access token. Without
+// ExpiresAt the token never expires.
+type CreateAccessTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedAccessToken is the response to creating a token, the only time the
+// token itself is shown
+type CreatedAccessToken struct {
+	*PersonalAccessToken
+	Token string `json:"token"`
+}
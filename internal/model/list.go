@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+// List groups a user's todos, such as "Work" or "Groceries". Names are
+// unique per user, ignoring case.
+type List struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"-"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	Icon   string `json:"icon"`
+	// Position orders the lists of a user, lowest first
+	Position int `json:"position"`
+	// Archived lists are hidden from the sidebar but keep their todos
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// TodoCount is the number of todos in the list, set when listing lists
+	TodoCount *int `json:"todo_count,omitempty"`
+}
+
+// ListCreate represents data for creating a list
+type ListCreate struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+	Icon  string `json:"icon"`
+}
+
+// ListUpdate represents data for updating a list
+type ListUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	Color    *string `json:"color,omitempty"`
+	Icon     *string `json:"icon,omitempty"`
+	Archived *bool   `json:"archived,omitempty"`
+}
+
+// ListOrder lists every list of a user in the new order
+type ListOrder struct {
+	ListIDs []int `json:"list_ids"`
+}
+
+// ListDeletion says what happens to the todos of a deleted list: they move
+// to the list MoveTo, are deleted with DeleteTodos, or are otherwise left
+// without a list
+type ListDeletion struct {
+	MoveTo      *int
+	DeleteTodos bool
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// LoginAttempts counts the recent failed logins for a throttling key, an
+// email address or a client IP
+type LoginAttempts struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// BlockedUntil is set while further logins for the key are refused
+	BlockedUntil *time.Time
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// OIDCLogin is a login started with an OpenID provider, waiting for the
+// browser to come back with an authorization code. Only the hash of the
+// state is stored; the PKCE code verifier and the nonce never leave the
+// server.
+type OIDCLogin struct {
+	ID           int
+	StateHash    string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// UserIdentity links a local user to an account at an OpenID provider
+type UserIdentity struct {
+	ID       int    `json:"id"`
+	UserID   int    `json:"-"`
+	Provider string `json:"provider"`
+	// Subject is the provider's stable ID of the account
+	Subject string `json:"-"`
+	// Email is the address the provider reported when the link was made
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCProvider is an OpenID provider users can sign in with
+type OIDCProvider struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// OIDCAuthorization is where to send the browser to sign in with a provider
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackRequest completes a provider login with the parameters the
+// provider redirected back with
+type OIDCCallbackRequest struct {
+	State string `json:"state"`
+	Code  string `json:"code"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// Channels reminders are delivered through
+const (
+	// ReminderChannelInApp records a notification the user sees in the app
+	ReminderChannelInApp = "in_app"
+	// ReminderChannelEmail emails the user
+	ReminderChannelEmail = "email"
+	// ReminderChannelWebhook posts the reminder to the reminder's webhook URL
+	ReminderChannelWebhook = "webhook"
+)
+
+// ReminderChannels lists every valid channel
+var ReminderChannels = []string{ReminderChannelInApp, ReminderChannelEmail, ReminderChannelWebhook}
+
+// Reminder fires at an absolute time, or a number of minutes before the
+// due date of its todo
+type Reminder struct {
+	ID     int `json:"id"`
+	TodoID int `json:"todo_id"`
+	// RemindAt is the time of an absolute reminder; OffsetMinutes is set
+	// instead for a reminder relative to the due date
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	OffsetMinutes *int       `json:"offset_minutes,omitempty"`
+	Channels      []string   `json:"channels"`
+	WebhookURL    string     `json:"webhook_url,omitempty"`
+	// FireAt is when the reminder is due, nil while a relative reminder's
+	// todo has no due date
+	FireAt *time.Time `json:"fire_at"`
+	// SentAt is set once the reminder was delivered, FailedAt once delivery
+	// was given up. Both are cleared when the reminder is rescheduled.
+	SentAt    *time.Time `json:"sent_at"`
+	FailedAt  *time.Time `json:"failed_at,omitempty"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ReminderCreate represents data for adding a reminder to a todo. Exactly
+// one of RemindAt and OffsetMinutes must be set.
+type ReminderCreate struct {
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	OffsetMinutes *int       `json:"offset_minutes,omitempty"`
+	// Channels default to in-app only
+	Channels   []string `json:"channels,omitempty"`
+	WebhookURL string   `json:"webhook_url,omitempty"`
+}
+
+// ReminderDelivery is a reminder claimed by the scheduler, with what its
+// channels need to deliver it
+type ReminderDelivery struct {
+	Reminder  *Reminder
+	UserID    int
+	Username  string
+	Email     string
+	TodoTitle string
+	DueDate   *time.Time
+}
+
+// Notification is an in-app message for a user, such as a fired reminder
+type Notification struct {
+	ID     int `json:"id"`
+	UserID int `json:"-"`
+	// TodoID is the todo the notification is about, nil once it is deleted
+	TodoID    *int       `json:"todo_id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
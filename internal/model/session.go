@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Session is a single login on one device. Every access and refresh token
+// issued for the login belongs to it, and revoking it signs the device out.
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     int        `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	// Current marks the session the request was made with
+	Current bool `json:"current"`
+}
+
+// ClientInfo describes the device a request comes from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
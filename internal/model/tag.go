@@ -0,0 +1,28 @@
+package model
+
+// Tag is a label a user puts on todos. Names are unique per user, ignoring case.
+type Tag struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"-"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	// TodoCount is the number of todos with the tag, set when listing tags
+	TodoCount *int `json:"todo_count,omitempty"`
+}
+
+// TagCreate represents data for creating a tag
+type TagCreate struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// TagUpdate represents data for renaming or recoloring a tag
+type TagUpdate struct {
+	Name  *string `json:"name,omitempty"`
+	Color *string `json:"color,omitempty"`
+}
+
+// TagMerge names the tag another tag is merged into
+type TagMerge struct {
+	Into int `json:"into"`
+}
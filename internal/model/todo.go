@@ -8,29 +8,148 @@ type Todo struct {
 	UserID      int       `json:"user_id"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
+	// ListID is the list the todo is in, or nil for none
+	ListID *int `json:"list_id"`
+	// Category is the name of the list, kept for clients that predate lists
 	Category    string    `json:"category"`
 	IsDone      bool      `json:"is_done"`
 	Priority    string    `json:"priority"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	// RecurrenceRule is the RFC 5545 RRULE the todo repeats by, empty when it
+	// doesn't repeat; RecurrenceMode is one of the RecurFrom modes
+	RecurrenceRule string `json:"recurrence_rule,omitempty"`
+	RecurrenceMode string `json:"recurrence_mode,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Items are the checklist items, included where the endpoint says so and
+	// omitted when there are none
+	Items    []*TodoItem   `json:"items,omitempty"`
+	Progress *TodoProgress `json:"progress,omitempty"`
+	Tags     []*Tag        `json:"tags,omitempty"`
+	// Reminders are only included in account exports
+	Reminders []*Reminder `json:"reminders,omitempty"`
+	// NextOccurrence is the todo created by completing a recurring todo
+	NextOccurrence *Todo `json:"next_occurrence,omitempty"`
 }
 
+// Modes of recurring todos: the next occurrence follows the due date of the
+// completed one, or the day it was completed
+const (
+	RecurFromDueDate    = "due_date"
+	RecurFromCompletion = "completion"
+)
+
 // TodoCreate represents data for creating a new todo
 type TodoCreate struct {
 	Title       string  `json:"title"`
 	Description string  `json:"description"`
+	ListID      *int    `json:"list_id,omitempty"`
+	// Category names the list when ListID is not set; a missing list is created
 	Category    string  `json:"category"`
 	Priority    string  `json:"priority"`
 	DueDate     *string `json:"due_date,omitempty"`
+	// RecurrenceRule makes the todo repeat, which needs a due date;
+	// RecurrenceMode defaults to RecurFromDueDate
+	RecurrenceRule string `json:"recurrence_rule,omitempty"`
+	RecurrenceMode string `json:"recurrence_mode,omitempty"`
+	// Tags are tag names; missing tags are created
+	Tags []string `json:"tags,omitempty"`
 }
 
 // TodoUpdate represents data for updating a todo
 type TodoUpdate struct {
 	Title       *string `json:"title,omitempty"`
 	Description *string `json:"description,omitempty"`
+	// ListID moves the todo to a list, or out of its list when 0
+	ListID *int `json:"list_id,omitempty"`
+	// Category names the list when ListID is not set; a missing list is
+	// created and an empty name moves the todo out of its list
 	Category    *string `json:"category,omitempty"`
 	IsDone      *bool   `json:"is_done,omitempty"`
 	Priority    *string `json:"priority,omitempty"`
 	DueDate     *string `json:"due_date,omitempty"`
+	// RecurrenceRule replaces the rule of the todo; an empty rule stops it
+	// from repeating
+	RecurrenceRule *string `json:"recurrence_rule,omitempty"`
+	RecurrenceMode *string `json:"recurrence_mode,omitempty"`
+	// Tags replaces the tags of the todo when set; an empty list removes them
+	Tags *[]string `json:"tags,omitempty"`
+	// CompleteItems marks every checklist item done when IsDone is true
+	CompleteItems bool `json:"complete_items,omitempty"`
+}
+
+
+// Sort fields accepted by TodoQuery
+const (
+	TodoSortCreatedAt = "created_at"
+	TodoSortUpdatedAt = "updated_at"
+	TodoSortDueDate   = "due_date"
+	TodoSortPriority  = "priority"
+	TodoSortTitle     = "title"
+)
+
+// Sort orders accepted by TodoQuery
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// TodoQuery filters, sorts and paginates a user's todos.
+// Nil filters are not applied; time ranges are inclusive.
+type TodoQuery struct {
+	ListID *int
+	// Category names the list to filter by; the service resolves it to ListID
+	Category    *string
+	Priorities  []string
+	IsDone      *bool
+	DueFrom     *time.Time
+	DueTo       *time.Time
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+
+	// Tag names, ignoring case: todos must have every tag of Tags, at least
+	// one of AnyTags and none of ExcludeTags
+	Tags        []string
+	AnyTags     []string
+	ExcludeTags []string
+
+	Sort  string
+	Order string
+
+	// Limit is the page size; Cursor is the opaque next_cursor of the previous page
+	Limit  int
+	Cursor string
+
+	// IncludeItems inlines the checklist items of the todos; otherwise only
+	// their progress is included
+	IncludeItems bool
+}
+
+// TodoPage is a single page of todos
+type TodoPage struct {
+	Todos      []*Todo `json:"todos"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	Total      int     `json:"total"`
+}
+
+// TodoSearch is a parsed full-text search over todo titles and descriptions.
+// A todo matches when it contains every word, prefix and phrase.
+type TodoSearch struct {
+	Words    []string
+	Prefixes []string // matched as the start of a word, e.g. "rep" for "rep*"
+	Phrases  []string // matched as consecutive words
+
+	Limit  int
+	Offset int
+}
+
+// TodoSearchResult is a single ranked search hit. The highlights are HTML
+// escaped, with matched words wrapped in <mark> tags.
+type TodoSearchResult struct {
+	Todo                 *Todo   `json:"todo"`
+	Rank                 float64 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
 }
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// TodoItem is a checklist item under a todo
+type TodoItem struct {
+	ID     int    `json:"id"`
+	TodoID int    `json:"todo_id"`
+	Title  string `json:"title"`
+	IsDone bool   `json:"is_done"`
+	// Position orders the items of a todo, lowest first
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TodoProgress counts the done and total checklist items of a todo
+type TodoProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// TodoItemCreate represents data for adding a checklist item
+type TodoItemCreate struct {
+	Title string `json:"title"`
+}
+
+// TodoItemUpdate represents data for updating a checklist item
+type TodoItemUpdate struct {
+	Title  *string `json:"title,omitempty"`
+	IsDone *bool   `json:"is_done,omitempty"`
+}
+
+// TodoItemOrder lists every item of a todo in the new order
+type TodoItemOrder struct {
+	ItemIDs []int `json:"item_ids"`
+}
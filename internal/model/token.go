@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// RefreshToken is a single-use refresh token. Only its hash is stored.
+type RefreshToken struct {
+	ID        int
+	SessionID string
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	// UsedAt is set once the token has been exchanged for a new one
+	UsedAt *time.Time
+}
+
+// AuthTokens is the token pair returned by login and refresh
+type AuthTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresIn is the access token lifetime in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// RefreshRequest carries the refresh token for refresh and logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// PasswordResetToken is a single-use token sent by email to reset a
+// forgotten password. Only its hash is stored.
+type PasswordResetToken struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// LoginResult is the outcome of a password login: tokens for a new session,
+// or MFA when the second factor is still needed
+type LoginResult struct {
+	Tokens *AuthTokens
+	User   *User
+	MFA    *MFAPending
+}
@@ -0,0 +1,74 @@
+package model
+
+import "time"
+
+// TwoFactor holds a user's TOTP settings. It exists from enrollment on, but
+// two-factor authentication is only on once EnabledAt is set.
+type TwoFactor struct {
+	UserID int
+	Secret string
+	// LastUsedStep is the time step of the last accepted code, so a code
+	// can't be used twice
+	LastUsedStep *int64
+	EnabledAt    *time.Time
+	CreatedAt    time.Time
+}
+
+// MFAChallenge is the short-lived token a password login returns when the
+// user has two-factor authentication enabled. Only its hash is stored.
+type MFAChallenge struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	// Attempts counts the wrong codes entered for this challenge
+	Attempts  int
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// TwoFactorStatus describes the two-factor authentication of a user
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is the secret to add to an authenticator app, both
+// plain for manual entry and as a provisioning URI for QR codes
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are shown to the user once, when they are generated
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFAPending is returned by login instead of tokens when a second factor is
+// needed
+type MFAPending struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	// ExpiresIn is the challenge lifetime in seconds
+	ExpiresIn int `json:"expires_in"`
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// PasswordConfirmation re-authenticates the user before a sensitive change
+type PasswordConfirmation struct {
+	Password string `json:"password"`
+}
+
+// MFALoginRequest completes a login with the challenge token and either an
+// authenticator code or a recovery code
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
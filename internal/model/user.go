@@ -2,14 +2,32 @@ package model
 
 import "time"
 
+// Roles of users
+const (
+	RoleUser = "user"
+	// RoleAdmin may use the admin API to operate the instance
+	RoleAdmin = "admin"
+)
+
+// Roles lists every valid role
+var Roles = []string{RoleUser, RoleAdmin}
+
 // User represents a user in the system
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"password,omitempty"` // Omit from JSON responses
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password,omitempty"` // Omit from JSON responses
+	Role     string `json:"role"`
+	// EmailVerifiedAt is nil until the user confirms the email address
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// DisabledAt is set while an admin has disabled the account
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	// DeleteAfter is set while the user deleted the account; it is purged
+	// after this time unless the user logs in again
+	DeleteAfter *time.Time `json:"delete_after,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // UserLogin represents login credentials
@@ -23,4 +41,113 @@ type UserRegister struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
-}
\ No newline at end of file
+}
+
+// ForgotPasswordRequest asks for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password using a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmailRequest confirms an email address with the token from the
+// verification email
+type VerifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+// ResendVerificationRequest asks for a new verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+// UpdateProfileRequest changes the profile of the signed-in user; omitted
+// fields stay unchanged
+type UpdateProfileRequest struct {
+	Username *string `json:"username"`
+}
+
+// ChangePasswordRequest sets a new password, confirmed with the current one
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangeEmailRequest asks to move the account to a new email address. The
+// change takes effect once confirmed with the link sent to that address.
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// ConfirmEmailChangeRequest confirms a new email address with the token
+// from the confirmation email
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// AccountDeletion tells when a deleted account will be purged
+type AccountDeletion struct {
+	Message     string    `json:"message"`
+	DeleteAfter time.Time `json:"delete_after"`
+}
+
+// AccountExport is the archive of a user's personal data
+type AccountExport struct {
+	ExportedAt    time.Time       `json:"exported_at"`
+	User          *User           `json:"user"`
+	Lists         []*List         `json:"lists"`
+	Todos         []*Todo         `json:"todos"`
+	Notifications []*Notification `json:"notifications"`
+}
+
+// UserQuery searches and paginates the users of the instance
+type UserQuery struct {
+	// Search matches part of the username or email address, ignoring case
+	Search string
+	// Role and Disabled filter the users when set
+	Role     string
+	Disabled *bool
+
+	Limit  int
+	Offset int
+}
+
+// UserSummary is a user as listed for admins, with the size of their data
+type UserSummary struct {
+	User
+	TodoCount          int `json:"todo_count"`
+	CompletedTodoCount int `json:"completed_todo_count"`
+}
+
+// UserPage is a single page of users, newest first
+type UserPage struct {
+	Users []*UserSummary `json:"users"`
+	Total int            `json:"total"`
+}
+
+// SystemStats are instance-wide numbers for admins
+type SystemStats struct {
+	Users           int `json:"users"`
+	Admins          int `json:"admins"`
+	DisabledUsers   int `json:"disabled_users"`
+	UnverifiedUsers int `json:"unverified_users"`
+	// PendingDeletion counts the deleted accounts still in their grace period
+	PendingDeletion int `json:"pending_deletion"`
+	// NewUsers counts the users registered in the last 30 days
+	NewUsers       int `json:"new_users"`
+	Todos          int `json:"todos"`
+	CompletedTodos int `json:"completed_todos"`
+	OverdueTodos   int `json:"overdue_todos"`
+	// ActiveSessions counts the sessions that are neither revoked nor expired
+	ActiveSessions int `json:"active_sessions"`
+}
+
+// AdminUnlockRequest lifts the login lockout of an email address or client IP
+type AdminUnlockRequest struct {
+	Key string `json:"key"`
+}
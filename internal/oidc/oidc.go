@@ -0,0 +1,278 @@
+// Package oidc is a small OpenID Connect relying party: discovery, the
+// authorization code flow with PKCE (RFC 7636) and ID token validation
+// against the provider's JSON Web Key Set.
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	// discoveryPath is appended to the issuer to find its configuration
+	discoveryPath = "/.well-known/openid-configuration"
+	// maxResponseBytes caps the provider responses that are read
+	maxResponseBytes = 1 << 20
+	// httpTimeout bounds each request of clients from NewHTTPClient
+	httpTimeout = 10 * time.Second
+)
+
+// ErrInvalidIDToken is returned for ID tokens that fail validation
+var ErrInvalidIDToken = errors.New("oidc: invalid id token")
+
+// Config describes a provider registered with this app
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// Scopes requested besides openid
+	Scopes []string
+}
+
+// Claims are the ID token claims used to find or create the local user
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// metadata is the part of the provider configuration the flow needs
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one OpenID provider. Its configuration is discovered on
+// first use and kept for the lifetime of the Provider.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	meta *metadata
+	keys *keySet
+}
+
+// NewProvider creates a Provider; client is used for every request to it
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	return &Provider{cfg: cfg, client: client}
+}
+
+// AuthCodeURL returns the authorization endpoint URL to send the browser to.
+// The provider redirects back to redirectURL with state and a code.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURL, state, nonce, codeVerifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("oidc: invalid authorization endpoint: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", redirectURL)
+	q.Set("scope", strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the validated claims
+// of the ID token issued with it
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code, codeVerifier, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic, with the credentials form-encoded as RFC 6749 asks
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := doJSON(p.client, req, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+
+	return p.VerifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature of an ID token against the provider's
+// keys and validates its issuer, audience, lifetime and nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, raw, nonce string) (*Claims, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &jwt.Parser{ValidMethods: []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}}
+	token, err := parser.Parse(raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.get(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if iss, _ := claims["iss"].(string); iss != meta.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q", ErrInvalidIDToken, iss)
+	}
+
+	audiences := audience(claims["aud"])
+	if !contains(audiences, p.cfg.ClientID) {
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	}
+	if azp, ok := claims["azp"].(string); (ok || len(audiences) > 1) && azp != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: authorized party %q", ErrInvalidIDToken, azp)
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, fmt.Errorf("%w: no expiry", ErrInvalidIDToken)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	result := &Claims{}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	result.Name, _ = claims["name"].(string)
+	result.PreferredUsername, _ = claims["preferred_username"].(string)
+	// Some providers send the flag as a string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		result.EmailVerified = v
+	case string:
+		result.EmailVerified = v == "true"
+	}
+	if result.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	return result, nil
+}
+
+// discover fetches the provider configuration once. Failures are not
+// cached, so a provider that was down is retried on the next login.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+discoveryPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: failed to build discovery request: %w", err)
+	}
+
+	var meta metadata
+	if err := doJSON(p.client, req, &meta); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc: discovery returned issuer %q, expected %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document lacks an endpoint")
+	}
+
+	p.meta = &meta
+	p.keys = newKeySet(meta.JWKSURI, p.client)
+	return p.meta, nil
+}
+
+// doJSON sends req and decodes the JSON response into v
+func doJSON(client *http.Client, req *http.Request, v interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// CodeChallenge derives the S256 PKCE challenge of a code verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// audience returns the aud claim, which may be a string or an array
+func audience(v interface{}) []string {
+	switch aud := v.(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		var audiences []string
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+		return audiences
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NewHTTPClient returns a client with a timeout suitable for NewProvider
+func NewHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout}
+}
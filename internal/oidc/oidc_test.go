@@ -0,0 +1,112 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"aplikasi-todolist/internal/oidc"
+	"aplikasi-todolist/internal/oidc/oidctest"
+)
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	srv := oidctest.NewServer(t, "todo-app", "s3cret")
+	srv.SignIn(oidctest.User{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"})
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       srv.Issuer,
+		ClientID:     "todo-app",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"email", "profile"},
+	}, oidc.NewHTTPClient())
+
+	const redirectURL = "http://app.example.com/oidc/callback"
+	authURL, err := provider.AuthCodeURL(ctx, redirectURL, "the-state", "the-nonce", "the-verifier")
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+	assert.Equal(t, oidc.CodeChallenge("the-verifier"), u.Query().Get("code_challenge"))
+
+	back := srv.Authorize(t, authURL)
+	assert.Equal(t, "the-state", back.Query().Get("state"))
+	code := back.Query().Get("code")
+
+	// The code is bound to the verifier
+	_, err = provider.Exchange(ctx, redirectURL, code, "another-verifier", "the-nonce")
+	assert.Error(t, err)
+
+	back = srv.Authorize(t, authURL)
+	claims, err := provider.Exchange(ctx, redirectURL, back.Query().Get("code"), "the-verifier", "the-nonce")
+	require.NoError(t, err)
+	assert.Equal(t, &oidc.Claims{Subject: "alice-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}, claims)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	srv := oidctest.NewServer(t, "todo-app", "s3cret")
+	other := oidctest.NewServer(t, "todo-app", "s3cret")
+	provider := oidc.NewProvider(oidc.Config{Issuer: srv.Issuer, ClientID: "todo-app"}, oidc.NewHTTPClient())
+	user := oidctest.User{Subject: "bob-1", Email: "bob@example.com", EmailVerified: true}
+
+	claims, err := provider.VerifyIDToken(ctx, srv.Sign(srv.Claims(user, "n")), "n")
+	require.NoError(t, err)
+	assert.Equal(t, "bob-1", claims.Subject)
+
+	tests := []struct {
+		name   string
+		token  func() string
+		nonce  string
+		reason string
+	}{
+		{"wrong nonce", func() string { return srv.Sign(srv.Claims(user, "n")) }, "m", "nonce"},
+		{"foreign key", func() string { return other.Sign(srv.Claims(user, "n")) }, "n", "verification"},
+		{"wrong issuer", func() string { return srv.Sign(other.Claims(user, "n")) }, "n", "issuer"},
+		{"wrong audience", func() string {
+			claims := srv.Claims(user, "n")
+			claims["aud"] = "another-app"
+			return srv.Sign(claims)
+		}, "n", "client"},
+		{"other authorized party", func() string {
+			claims := srv.Claims(user, "n")
+			claims["aud"] = []string{"todo-app", "another-app"}
+			claims["azp"] = "another-app"
+			return srv.Sign(claims)
+		}, "n", "authorized party"},
+		{"expired", func() string {
+			claims := srv.Claims(user, "n")
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			return srv.Sign(claims)
+		}, "n", "expired"},
+		{"no subject", func() string {
+			claims := srv.Claims(user, "n")
+			delete(claims, "sub")
+			return srv.Sign(claims)
+		}, "n", "subject"},
+		{"unsigned", func() string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodNone, srv.Claims(user, "n")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			require.NoError(t, err)
+			return token
+		}, "n", "signing method"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(ctx, tt.token(), tt.nonce)
+			require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+
+	// Several audiences are fine when this client is the authorized party
+	claims2 := srv.Claims(user, "n")
+	claims2["aud"] = []string{"todo-app", "another-app"}
+	claims2["azp"] = "todo-app"
+	_, err = provider.VerifyIDToken(ctx, srv.Sign(claims2), "n")
+	assert.NoError(t, err)
+}
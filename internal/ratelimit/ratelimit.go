@@ -0,0 +1,68 @@
+// Package ratelimit limits how often an action may happen per key, such as
+// an email address or client IP. State is kept in memory, so every server
+// instance enforces its own limit.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows at most limit events per key within a sliding window
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+// New creates a Limiter allowing limit events per key every window
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		window:    window,
+		events:    make(map[string][]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow records an event for key if it is within the limit. Otherwise it
+// reports false and how long until the next event would be allowed.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) >= l.window {
+		l.sweep(now)
+	}
+
+	events := l.recent(key, now)
+	if len(events) >= l.limit {
+		l.events[key] = events
+		return false, events[0].Add(l.window).Sub(now)
+	}
+
+	l.events[key] = append(events, now)
+	return true, 0
+}
+
+// recent returns the events of key that are still inside the window
+func (l *Limiter) recent(key string, now time.Time) []time.Time {
+	events := l.events[key]
+	for len(events) > 0 && now.Sub(events[0]) >= l.window {
+		events = events[1:]
+	}
+	return events
+}
+
+// sweep forgets keys without recent events so the map doesn't grow forever
+func (l *Limiter) sweep(now time.Time) {
+	for key := range l.events {
+		if len(l.recent(key, now)) == 0 {
+			delete(l.events, key)
+		}
+	}
+	l.lastSweep = now
+}
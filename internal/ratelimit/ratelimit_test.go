@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	l := New(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		ok, _ := l.Allow("a")
+		assert.True(t, ok)
+	}
+
+	ok, retryAfter := l.Allow("a")
+	assert.False(t, ok)
+	assert.InDelta(t, time.Hour, retryAfter, float64(time.Second))
+
+	// Keys are limited independently
+	ok, _ = l.Allow("b")
+	assert.True(t, ok)
+}
+
+func TestLimiterWindow(t *testing.T) {
+	l := New(1, 20*time.Millisecond)
+
+	ok, _ := l.Allow("a")
+	assert.True(t, ok)
+	ok, _ = l.Allow("a")
+	assert.False(t, ok)
+
+	time.Sleep(25 * time.Millisecond)
+	ok, _ = l.Allow("a")
+	assert.True(t, ok)
+
+	// Sweeping drops keys whose events have expired
+	time.Sleep(25 * time.Millisecond)
+	l.Allow("b")
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert.NotContains(t, l.events, "a")
+}
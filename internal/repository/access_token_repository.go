@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// AccessTokenRepository handles personal access token database operations
+type AccessTokenRepository struct{}
+
+// CreateAccessToken stores a new personal access token
+func (r *AccessTokenRepository) CreateAccessToken(ctx context.Context, token *model.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+	err := DB.QueryRow(ctx, query,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		strings.Join(token.Scopes, " "),
+		utcTime(token.ExpiresAt),
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccessTokenByHash retrieves a personal access token by the hash of its value
+func (r *AccessTokenRepository) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE token_hash = $1
+	`
+
+	token, err := scanAccessToken(DB.QueryRow(ctx, query, tokenHash))
+	if isNoRows(err) {
+		return nil, errAccessTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListAccessTokens retrieves the user's personal access tokens, newest first
+func (r *AccessTokenRepository) ListAccessTokens(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// TouchAccessToken records when the token was last used
+func (r *AccessTokenRepository) TouchAccessToken(ctx context.Context, tokenID int, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = $1 WHERE id = $2`
+
+	commandTag, err := DB.Exec(ctx, query, usedAt.UTC(), tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to touch access token: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteAccessToken deletes one of the user's personal access tokens
+func (r *AccessTokenRepository) DeleteAccessToken(ctx context.Context, tokenID, userID int) error {
+	query := `DELETE FROM personal_access_tokens WHERE id = $1 AND user_id = $2`
+
+	commandTag, err := DB.Exec(ctx, query, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// scanAccessToken scans a row selected with the columns of GetAccessTokenByHash
+func scanAccessToken(row interface{ Scan(...interface{}) error }) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	var scopes string
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&scopes,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	return &token, nil
+}
+
+// utcTime converts an optional time to UTC for storage
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
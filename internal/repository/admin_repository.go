@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// AdminRepository handles the instance-wide queries of the admin API
+type AdminRepository struct{}
+
+// ListUsers retrieves one page of users matching the query with the number
+// of their todos, newest first
+func (r *AdminRepository) ListUsers(ctx context.Context, q model.UserQuery) (*model.UserPage, error) {
+	where, args := userFilterSQL(q)
+
+	page := &model.UserPage{Users: []*model.UserSummary{}}
+	countQuery := "SELECT COUNT(*) FROM users WHERE " + where
+	if err := DB.QueryRow(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `
+		SELECT users.id, username, email, role, email_verified_at, disabled_at, delete_after, users.created_at, users.updated_at,
+			COUNT(todos.id), COUNT(todos.id) FILTER (WHERE todos.is_done)
+		FROM users
+		LEFT JOIN todos ON todos.user_id = users.id
+		WHERE ` + where + `
+		GROUP BY users.id
+		ORDER BY users.created_at DESC, users.id DESC
+	`
+	if q.Limit > 0 {
+		args = append(args, q.Limit, q.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u model.UserSummary
+		if err := rows.Scan(
+			&u.ID,
+			&u.Username,
+			&u.Email,
+			&u.Role,
+			&u.EmailVerifiedAt,
+			&u.DisabledAt,
+			&u.DeleteAfter,
+			&u.CreatedAt,
+			&u.UpdatedAt,
+			&u.TodoCount,
+			&u.CompletedTodoCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		page.Users = append(page.Users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return page, nil
+}
+
+// Stats computes the instance-wide numbers as of now
+func (r *AdminRepository) Stats(ctx context.Context, now time.Time) (*model.SystemStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE role = $1),
+			(SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+			(SELECT COUNT(*) FROM users WHERE email_verified_at IS NULL),
+			(SELECT COUNT(*) FROM users WHERE delete_after IS NOT NULL),
+			(SELECT COUNT(*) FROM users WHERE created_at >= $2),
+			(SELECT COUNT(*) FROM todos),
+			(SELECT COUNT(*) FROM todos WHERE is_done),
+			(SELECT COUNT(*) FROM todos WHERE NOT is_done AND due_date < $3),
+			(SELECT COUNT(*) FROM sessions WHERE revoked_at IS NULL AND expires_at > $3)
+	`
+
+	var stats model.SystemStats
+	err := DB.QueryRow(ctx, query, model.RoleAdmin, now.Add(-newUserWindow).UTC(), now.UTC()).Scan(
+		&stats.Users,
+		&stats.Admins,
+		&stats.DisabledUsers,
+		&stats.UnverifiedUsers,
+		&stats.PendingDeletion,
+		&stats.NewUsers,
+		&stats.Todos,
+		&stats.CompletedTodos,
+		&stats.OverdueTodos,
+		&stats.ActiveSessions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// userFilterSQL builds the WHERE clause and arguments for a UserQuery
+func userFilterSQL(q model.UserQuery) (string, []interface{}) {
+	var args []interface{}
+	conditions := []string{"TRUE"}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	if q.Search != "" {
+		add(`(LOWER(username) LIKE $? ESCAPE '\' OR LOWER(email) LIKE $? ESCAPE '\')`, userSearchPattern(q.Search))
+	}
+	if q.Role != "" {
+		add("role = $?", q.Role)
+	}
+	if q.Disabled != nil {
+		if *q.Disabled {
+			conditions = append(conditions, "disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "disabled_at IS NULL")
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,1668 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// The conformance suite runs the same behavioural tests against every storage
+// backend. PostgreSQL is only tested when TEST_DATABASE_URL points to a
+// disposable database; its tables are truncated before each test.
+func TestStoreConformance(t *testing.T) {
+	backends := []struct {
+		name string
+		open func(t *testing.T) *Stores
+	}{
+		{BackendMemory, func(t *testing.T) *Stores { return NewMemoryStores() }},
+		{BackendSQLite, openSQLiteStores},
+		{BackendPostgres, openPostgresStores},
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, s *Stores)
+	}{
+		{"Users", testUserStore},
+		{"UserRoles", testUserRoles},
+		{"UserDeletion", testUserDeletion},
+		{"Todos", testTodoStore},
+		{"ListTodos", testListTodos},
+		{"SearchTodos", testSearchTodos},
+		{"TodoItems", testTodoItemStore},
+		{"RecurringTodos", testRecurringTodos},
+		{"Reminders", testReminderStore},
+		{"Notifications", testNotificationStore},
+		{"Tags", testTagStore},
+		{"Lists", testListStore},
+		{"Tokens", testTokenStore},
+		{"PasswordResetTokens", testPasswordResetTokens},
+		{"TwoFactor", testTwoFactorStore},
+		{"AccessTokens", testAccessTokenStore},
+		{"OIDC", testOIDCStore},
+		{"LoginAttempts", testLoginAttemptStore},
+		{"Admin", testAdminStore},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, test := range tests {
+				t.Run(test.name, func(t *testing.T) {
+					test.run(t, backend.open(t))
+				})
+			}
+		})
+	}
+}
+
+func openSQLiteStores(t *testing.T) *Stores {
+	ctx := context.Background()
+	stores, err := Open(ctx, "sqlite://"+filepath.Join(t.TempDir(), "todo.db"))
+	require.NoError(t, err)
+	t.Cleanup(stores.Close)
+
+	migrator, err := stores.NewMigrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	return stores
+}
+
+func openPostgresStores(t *testing.T) *Stores {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	stores, err := Open(ctx, url)
+	require.NoError(t, err)
+	t.Cleanup(stores.Close)
+
+	migrator, err := stores.NewMigrator()
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = DB.Exec(ctx, "TRUNCATE todos, users, oidc_logins, login_attempts RESTART IDENTITY CASCADE")
+	require.NoError(t, err)
+
+	return stores
+}
+
+// createTestUser adds a user, which the SQL backends need before todos
+func createTestUser(t *testing.T, s *Stores, name string) *model.User {
+	user := &model.User{Username: name, Email: name + "@example.com", Password: "hash"}
+	require.NoError(t, s.Users.CreateUser(context.Background(), user))
+	return user
+}
+
+func testUserStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+
+	user := createTestUser(t, s, "budi")
+	assert.NotZero(t, user.ID)
+	assert.False(t, user.CreatedAt.IsZero())
+
+	exists, err := s.Users.UserExists(ctx, "other@example.com", "budi")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = s.Users.UserExists(ctx, "other@example.com", "other")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	err = s.Users.CreateUser(ctx, &model.User{Username: "other", Email: "budi@example.com", Password: "hash"})
+	assert.ErrorIs(t, err, errUserExists)
+
+	byEmail, err := s.Users.GetUserByEmail(ctx, "budi@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, byEmail.ID)
+	assert.Equal(t, "hash", byEmail.Password)
+
+	byID, err := s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "budi", byID.Username)
+	assert.Empty(t, byID.Password)
+
+	// Verification only applies to the user's current address
+	assert.Nil(t, byID.EmailVerifiedAt)
+	assert.ErrorIs(t, s.Users.MarkEmailVerified(ctx, user.ID, "old@example.com"), errUserNotFound)
+	require.NoError(t, s.Users.MarkEmailVerified(ctx, user.ID, "budi@example.com"))
+	byID, err = s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, byID.EmailVerifiedAt)
+	verifiedAt := *byID.EmailVerifiedAt
+
+	require.NoError(t, s.Users.MarkEmailVerified(ctx, user.ID, "budi@example.com"))
+	byEmail, err = s.Users.GetUserByEmail(ctx, "budi@example.com")
+	require.NoError(t, err)
+	require.NotNil(t, byEmail.EmailVerifiedAt)
+	assert.True(t, verifiedAt.Equal(*byEmail.EmailVerifiedAt))
+
+	require.NoError(t, s.Users.UpdatePassword(ctx, user.ID, "new-hash"))
+	byEmail, err = s.Users.GetUserByEmail(ctx, "budi@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", byEmail.Password)
+	assert.ErrorIs(t, s.Users.UpdatePassword(ctx, user.ID+1000, "hash"), errUserNotFound)
+
+	other := createTestUser(t, s, "other")
+	assert.ErrorIs(t, s.Users.UpdateUsername(ctx, user.ID, "other"), errUserExists)
+	require.NoError(t, s.Users.UpdateUsername(ctx, user.ID, "budi_s"))
+	byID, err = s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "budi_s", byID.Username)
+	assert.ErrorIs(t, s.Users.UpdateUsername(ctx, user.ID+1000, "ghost"), errUserNotFound)
+
+	// The new address is verified, and the change only applies to the old one
+	assert.ErrorIs(t, s.Users.UpdateEmail(ctx, user.ID, "budi@example.com", other.Email), errUserExists)
+	assert.ErrorIs(t, s.Users.UpdateEmail(ctx, user.ID, "stale@example.com", "budi.s@example.com"), errUserNotFound)
+	require.NoError(t, s.Users.UpdateEmail(ctx, user.ID, "budi@example.com", "budi.s@example.com"))
+	byID, err = s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "budi.s@example.com", byID.Email)
+	assert.NotNil(t, byID.EmailVerifiedAt)
+	_, err = s.Users.GetUserByEmail(ctx, "budi@example.com")
+	assert.ErrorIs(t, err, errUserNotFound)
+
+	_, err = s.Users.GetUserByID(ctx, user.ID+1000)
+	assert.ErrorIs(t, err, errUserNotFound)
+	_, err = s.Users.GetUserByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, errUserNotFound)
+}
+
+func testUserRoles(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "ani")
+	assert.Equal(t, model.RoleUser, user.Role)
+
+	require.NoError(t, s.Users.SetRole(ctx, user.ID, model.RoleAdmin))
+	got, err := s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, got.Role)
+	assert.Nil(t, got.DisabledAt)
+
+	disabledAt := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, s.Users.SetDisabled(ctx, user.ID, &disabledAt))
+	got, err = s.Users.GetUserByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleAdmin, got.Role)
+	require.NotNil(t, got.DisabledAt)
+	assert.True(t, disabledAt.Equal(*got.DisabledAt))
+
+	require.NoError(t, s.Users.SetDisabled(ctx, user.ID, nil))
+	got, err = s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.DisabledAt)
+
+	assert.ErrorIs(t, s.Users.SetRole(ctx, user.ID+1000, model.RoleAdmin), errUserNotFound)
+	assert.ErrorIs(t, s.Users.SetDisabled(ctx, user.ID+1000, nil), errUserNotFound)
+}
+
+func testUserDeletion(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "leaving")
+	other := createTestUser(t, s, "staying")
+
+	for _, u := range []*model.User{user, other} {
+		require.NoError(t, s.Todos.CreateTodo(ctx, &model.Todo{UserID: u.ID, Title: "Todo of " + u.Username}))
+		require.NoError(t, s.Tokens.CreateSession(ctx, &model.Session{ID: "session-" + u.Username, UserID: u.ID, ExpiresAt: time.Now().Add(time.Hour)}))
+		require.NoError(t, s.AccessTokens.CreateAccessToken(ctx, &model.PersonalAccessToken{UserID: u.ID, Name: "script", TokenHash: "hash-" + u.Username, Scopes: []string{model.ScopeTodosRead}}))
+	}
+
+	// Restoring clears the schedule
+	deleteAfter := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	require.NoError(t, s.Users.ScheduleDeletion(ctx, user.ID, deleteAfter))
+	got, err := s.Users.GetUserByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	require.NotNil(t, got.DeleteAfter)
+	assert.True(t, deleteAfter.Equal(*got.DeleteAfter))
+
+	require.NoError(t, s.Users.CancelDeletion(ctx, user.ID))
+	got, err = s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.DeleteAfter)
+
+	// Users are only purged once the grace period is over
+	require.NoError(t, s.Users.ScheduleDeletion(ctx, user.ID, deleteAfter))
+	purged, err := s.Users.PurgeDeletedUsers(ctx, deleteAfter.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = s.Users.PurgeDeletedUsers(ctx, deleteAfter.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = s.Users.GetUserByID(ctx, user.ID)
+	assert.ErrorIs(t, err, errUserNotFound)
+	todos, err := s.Todos.GetTodosByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+	_, err = s.Tokens.GetSession(ctx, "session-leaving")
+	assert.ErrorIs(t, err, errSessionNotFound)
+	_, err = s.AccessTokens.GetAccessTokenByHash(ctx, "hash-leaving")
+	assert.ErrorIs(t, err, errAccessTokenNotFound)
+
+	// Other users keep their data
+	todos, err = s.Todos.GetTodosByUserID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+	_, err = s.Tokens.GetSession(ctx, "session-staying")
+	assert.NoError(t, err)
+	_, err = s.AccessTokens.GetAccessTokenByHash(ctx, "hash-staying")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, s.Users.ScheduleDeletion(ctx, user.ID, deleteAfter), errUserNotFound)
+	assert.ErrorIs(t, s.Users.CancelDeletion(ctx, user.ID), errUserNotFound)
+}
+
+func testTodoStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	owner := createTestUser(t, s, "owner")
+	other := createTestUser(t, s, "other")
+
+	todo := &model.Todo{UserID: owner.ID, Title: "Write report", Description: "**markdown**"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+	assert.NotZero(t, todo.ID)
+	assert.Nil(t, todo.ListID)
+	assert.Equal(t, DefaultPriority, todo.Priority)
+	assert.False(t, todo.IsDone)
+	assert.False(t, todo.CreatedAt.IsZero())
+
+	// Other users can't see, update or delete the todo
+	_, err := s.Todos.GetTodoByID(ctx, todo.ID, other.ID)
+	assert.ErrorIs(t, err, errTodoNotFound)
+	err = s.Todos.UpdateTodo(ctx, &model.Todo{ID: todo.ID, UserID: other.ID, Title: "Hijacked", Priority: "Low"})
+	assert.ErrorIs(t, err, errTodoNotFound)
+	assert.ErrorIs(t, s.Todos.DeleteTodo(ctx, todo.ID, other.ID), errTodoNotFound)
+
+	due := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	todo.IsDone = true
+	todo.Priority = "High"
+	todo.DueDate = &due
+	require.NoError(t, s.Todos.UpdateTodo(ctx, todo))
+
+	got, err := s.Todos.GetTodoByID(ctx, todo.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write report", got.Title)
+	assert.Equal(t, "**markdown**", got.Description)
+	assert.True(t, got.IsDone)
+	assert.Equal(t, "High", got.Priority)
+	require.NotNil(t, got.DueDate)
+	assert.True(t, due.Equal(*got.DueDate), "due date %v", got.DueDate)
+
+	// Saving a todo without a due date clears it, so a todo that stopped
+	// repeating can lose its due date
+	todo.DueDate = nil
+	require.NoError(t, s.Todos.UpdateTodo(ctx, todo))
+	assert.Nil(t, todo.DueDate)
+	got, err = s.Todos.GetTodoByID(ctx, todo.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.DueDate)
+
+	second := &model.Todo{UserID: owner.ID, Title: "Second"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, second))
+	require.NoError(t, s.Todos.CreateTodo(ctx, &model.Todo{UserID: other.ID, Title: "Not mine"}))
+
+	// Newest first
+	todos, err := s.Todos.GetTodosByUserID(ctx, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, todos, 2)
+	assert.Equal(t, second.ID, todos[0].ID)
+
+	require.NoError(t, s.Todos.DeleteTodo(ctx, todo.ID, owner.ID))
+	_, err = s.Todos.GetTodoByID(ctx, todo.ID, owner.ID)
+	assert.ErrorIs(t, err, errTodoNotFound)
+	assert.ErrorIs(t, s.Todos.DeleteTodo(ctx, todo.ID, owner.ID), errTodoNotFound)
+}
+
+func testTodoItemStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "checker")
+	todo := &model.Todo{UserID: user.ID, Title: "Pack for trip"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+	other := &model.Todo{UserID: user.ID, Title: "Empty"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, other))
+
+	var items []*model.TodoItem
+	for _, title := range []string{"Passport", "Charger", "Socks"} {
+		item := &model.TodoItem{TodoID: todo.ID, Title: title}
+		require.NoError(t, s.TodoItems.CreateTodoItem(ctx, item))
+		assert.NotZero(t, item.ID)
+		assert.False(t, item.CreatedAt.IsZero())
+		items = append(items, item)
+	}
+	assert.Equal(t, 0, items[0].Position)
+	assert.Equal(t, 2, items[2].Position)
+
+	items[1].IsDone = true
+	items[1].Title = "Phone charger"
+	require.NoError(t, s.TodoItems.UpdateTodoItem(ctx, items[1]))
+	assert.Equal(t, 1, items[1].Position)
+	got, err := s.TodoItems.GetTodoItem(ctx, todo.ID, items[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Phone charger", got.Title)
+	assert.True(t, got.IsDone)
+
+	// Items are only found under their own todo
+	_, err = s.TodoItems.GetTodoItem(ctx, other.ID, items[1].ID)
+	assert.ErrorIs(t, err, errItemNotFound)
+	assert.ErrorIs(t, s.TodoItems.UpdateTodoItem(ctx, &model.TodoItem{ID: items[1].ID, TodoID: other.ID}), errItemNotFound)
+	assert.ErrorIs(t, s.TodoItems.DeleteTodoItem(ctx, other.ID, items[1].ID), errItemNotFound)
+	assert.ErrorIs(t, s.TodoItems.ReorderTodoItems(ctx, other.ID, []int{items[0].ID}), errItemNotFound)
+
+	progress, err := s.TodoItems.CountTodoItems(ctx, []int{todo.ID, other.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[int]*model.TodoProgress{todo.ID: {Done: 1, Total: 3}}, progress)
+
+	require.NoError(t, s.TodoItems.ReorderTodoItems(ctx, todo.ID, []int{items[2].ID, items[0].ID, items[1].ID}))
+	listed, err := s.TodoItems.ListTodoItems(ctx, []int{todo.ID, other.ID})
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+	var titles []string
+	for i, item := range listed[todo.ID] {
+		assert.Equal(t, i, item.Position)
+		titles = append(titles, item.Title)
+	}
+	assert.Equal(t, []string{"Socks", "Passport", "Phone charger"}, titles)
+
+	// New items go last
+	last := &model.TodoItem{TodoID: todo.ID, Title: "Towel"}
+	require.NoError(t, s.TodoItems.CreateTodoItem(ctx, last))
+	assert.Equal(t, 3, last.Position)
+
+	require.NoError(t, s.TodoItems.CompleteTodoItems(ctx, todo.ID))
+	progress, err = s.TodoItems.CountTodoItems(ctx, []int{todo.ID})
+	require.NoError(t, err)
+	assert.Equal(t, &model.TodoProgress{Done: 4, Total: 4}, progress[todo.ID])
+
+	require.NoError(t, s.TodoItems.DeleteTodoItem(ctx, todo.ID, items[0].ID))
+	_, err = s.TodoItems.GetTodoItem(ctx, todo.ID, items[0].ID)
+	assert.ErrorIs(t, err, errItemNotFound)
+
+	// Deleting the todo deletes its items
+	require.NoError(t, s.Todos.DeleteTodo(ctx, todo.ID, user.ID))
+	listed, err = s.TodoItems.ListTodoItems(ctx, []int{todo.ID})
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+
+	listed, err = s.TodoItems.ListTodoItems(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, listed)
+}
+
+func testRecurringTodos(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "repeater")
+	other := createTestUser(t, s, "other")
+
+	due := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	todo := &model.Todo{
+		UserID:         user.ID,
+		Title:          "Weekly report",
+		DueDate:        &due,
+		RecurrenceRule: "FREQ=WEEKLY;BYDAY=TH",
+		RecurrenceMode: model.RecurFromDueDate,
+	}
+	require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+	got, err := s.Todos.GetTodoByID(ctx, todo.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=TH", got.RecurrenceRule)
+	assert.Equal(t, model.RecurFromDueDate, got.RecurrenceMode)
+
+	tags, err := s.Tags.EnsureTags(ctx, user.ID, []string{"work"}, "#9e9e9e")
+	require.NoError(t, err)
+	require.NoError(t, s.Tags.SetTodoTags(ctx, todo.ID, []int{tags[0].ID}))
+	for _, title := range []string{"Gather numbers", "Send"} {
+		item := &model.TodoItem{TodoID: todo.ID, Title: title}
+		require.NoError(t, s.TodoItems.CreateTodoItem(ctx, item))
+	}
+	require.NoError(t, s.TodoItems.CompleteTodoItems(ctx, todo.ID))
+
+	nextDue := due.AddDate(0, 0, 7)
+	next := &model.Todo{
+		UserID:         user.ID,
+		Title:          todo.Title,
+		Priority:       "High",
+		DueDate:        &nextDue,
+		RecurrenceRule: todo.RecurrenceRule,
+		RecurrenceMode: todo.RecurrenceMode,
+	}
+	todo.IsDone = true
+	todo.RecurrenceRule = ""
+	todo.RecurrenceMode = ""
+
+	// Another user's todo is left alone and nothing is created
+	hijack := *todo
+	hijack.UserID = other.ID
+	err = s.Todos.CompleteRecurringTodo(ctx, &hijack, &model.Todo{UserID: other.ID, Title: "Hijacked"})
+	assert.ErrorIs(t, err, errTodoNotFound)
+	todos, err := s.Todos.GetTodosByUserID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+
+	require.NoError(t, s.Todos.CompleteRecurringTodo(ctx, todo, next))
+	assert.NotZero(t, next.ID)
+	assert.NotEqual(t, todo.ID, next.ID)
+
+	got, err = s.Todos.GetTodoByID(ctx, todo.ID, user.ID)
+	require.NoError(t, err)
+	assert.True(t, got.IsDone)
+	assert.Empty(t, got.RecurrenceRule)
+
+	got, err = s.Todos.GetTodoByID(ctx, next.ID, user.ID)
+	require.NoError(t, err)
+	assert.False(t, got.IsDone)
+	assert.Equal(t, "High", got.Priority)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=TH", got.RecurrenceRule)
+	require.NotNil(t, got.DueDate)
+	assert.True(t, nextDue.Equal(*got.DueDate), "due date %v", got.DueDate)
+
+	// The next occurrence gets the tags and the checklist, not done
+	todoTags, err := s.Tags.ListTodoTags(ctx, []int{next.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work"}, tagNames(todoTags[next.ID]))
+	items, err := s.TodoItems.ListTodoItems(ctx, []int{todo.ID, next.ID})
+	require.NoError(t, err)
+	require.Len(t, items[next.ID], 2)
+	for i, item := range items[next.ID] {
+		assert.Equal(t, items[todo.ID][i].Title, item.Title)
+		assert.True(t, items[todo.ID][i].IsDone)
+		assert.False(t, item.IsDone)
+		assert.NotEqual(t, items[todo.ID][i].ID, item.ID)
+	}
+
+	// A completion that read the todo before it was completed creates no
+	// second occurrence
+	stale := *todo
+	err = s.Todos.CompleteRecurringTodo(ctx, &stale, &model.Todo{UserID: user.ID, Title: "Weekly report"})
+	assert.ErrorIs(t, err, errTodoCompleted)
+	todos, err = s.Todos.GetTodosByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, todos, 2)
+
+	// Of concurrent completions only one succeeds
+	racing := &model.Todo{UserID: user.ID, Title: "Water plants", DueDate: &due, RecurrenceRule: "FREQ=DAILY"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, racing))
+	errs := make(chan error, 5)
+	for range cap(errs) {
+		go func() {
+			done := *racing
+			done.IsDone = true
+			done.RecurrenceRule = ""
+			errs <- s.Todos.CompleteRecurringTodo(ctx, &done, &model.Todo{UserID: user.ID, Title: "Water plants"})
+		}()
+	}
+	completed := 0
+	for range cap(errs) {
+		err := <-errs
+		if err == nil {
+			completed++
+		} else {
+			assert.ErrorIs(t, err, errTodoCompleted)
+		}
+	}
+	assert.Equal(t, 1, completed)
+	todos, err = s.Todos.GetTodosByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, todos, 4)
+}
+
+func testReminderStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "reminded")
+
+	due := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	todo := &model.Todo{UserID: user.ID, Title: "Dentist", DueDate: &due, RecurrenceRule: "FREQ=WEEKLY"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+	other := &model.Todo{UserID: user.ID, Title: "Other"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, other))
+
+	offset := 30
+	fireAt := due.Add(-30 * time.Minute)
+	relative := &model.Reminder{
+		TodoID:        todo.ID,
+		OffsetMinutes: &offset,
+		Channels:      []string{model.ReminderChannelInApp, model.ReminderChannelWebhook},
+		WebhookURL:    "https://hooks.example.com/remind",
+		FireAt:        &fireAt,
+	}
+	require.NoError(t, s.Reminders.CreateReminder(ctx, relative))
+	assert.NotZero(t, relative.ID)
+	assert.False(t, relative.CreatedAt.IsZero())
+
+	remindAt := due.Add(-24 * time.Hour)
+	absolute := &model.Reminder{
+		TodoID:   todo.ID,
+		RemindAt: &remindAt,
+		Channels: []string{model.ReminderChannelEmail},
+		FireAt:   &remindAt,
+	}
+	require.NoError(t, s.Reminders.CreateReminder(ctx, absolute))
+
+	byTodo, err := s.Reminders.ListTodoReminders(ctx, []int{todo.ID, other.ID})
+	require.NoError(t, err)
+	assert.Len(t, byTodo, 1)
+	reminders := byTodo[todo.ID]
+	require.Len(t, reminders, 2)
+	assert.Equal(t, relative.ID, reminders[0].ID)
+	assert.Equal(t, 30, *reminders[0].OffsetMinutes)
+	assert.Nil(t, reminders[0].RemindAt)
+	assert.Equal(t, []string{"in_app", "webhook"}, reminders[0].Channels)
+	assert.Equal(t, "https://hooks.example.com/remind", reminders[0].WebhookURL)
+	assert.True(t, fireAt.Equal(*reminders[0].FireAt), "fire at %v", reminders[0].FireAt)
+	assert.True(t, remindAt.Equal(*reminders[1].RemindAt))
+	assert.Nil(t, reminders[1].OffsetMinutes)
+
+	// Reminders are only deleted under their own todo
+	assert.ErrorIs(t, s.Reminders.DeleteReminder(ctx, other.ID, absolute.ID), errReminderNotFound)
+
+	// Nothing is due before the earliest reminder
+	deliveries, err := s.Reminders.ClaimDueReminders(ctx, remindAt.Add(-time.Second), remindAt.Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	now := fireAt
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, now, now.Add(5*time.Minute), 1)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	d := deliveries[0]
+	assert.Equal(t, absolute.ID, d.Reminder.ID)
+	assert.Equal(t, 1, d.Reminder.Attempts)
+	assert.Equal(t, user.ID, d.UserID)
+	assert.Equal(t, "reminded", d.Username)
+	assert.Equal(t, "reminded@example.com", d.Email)
+	assert.Equal(t, "Dentist", d.TodoTitle)
+	require.NotNil(t, d.DueDate)
+	assert.True(t, due.Equal(*d.DueDate))
+
+	// A leased reminder isn't claimed again until its lease expires
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, now, now.Add(5*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, relative.ID, deliveries[0].Reminder.ID)
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, now.Add(time.Minute), now.Add(6*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, now.Add(5*time.Minute), now.Add(10*time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, 2, deliveries[0].Reminder.Attempts)
+
+	// Retrying leases the reminder until the retry time
+	require.NoError(t, s.Reminders.RetryReminder(ctx, absolute.ID, remindAt, now.Add(time.Hour), "smtp down"))
+	require.NoError(t, s.Reminders.MarkReminderSent(ctx, relative.ID, fireAt, now.Add(5*time.Minute)))
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, now.Add(30*time.Minute), now.Add(35*time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	reminders = listReminders(t, s, todo.ID)
+	require.NotNil(t, reminders[0].SentAt)
+	assert.Nil(t, reminders[1].SentAt)
+	assert.Equal(t, 2, reminders[1].Attempts)
+	assert.Equal(t, "smtp down", reminders[1].LastError)
+
+	// Outcomes for an earlier fire time are ignored
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, now.Add(time.Hour), now.Add(2*time.Hour), 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 3, deliveries[0].Reminder.Attempts)
+	require.NoError(t, s.Reminders.FailReminder(ctx, absolute.ID, remindAt.Add(time.Minute), now, "stale"))
+	reminders = listReminders(t, s, todo.ID)
+	assert.Nil(t, reminders[1].FailedAt)
+	require.NoError(t, s.Reminders.FailReminder(ctx, absolute.ID, remindAt, now.Add(time.Hour), "smtp still down"))
+	reminders = listReminders(t, s, todo.ID)
+	require.NotNil(t, reminders[1].FailedAt)
+	assert.Equal(t, "smtp still down", reminders[1].LastError)
+
+	// Moving the due date makes the relative reminders pending again, and
+	// leaves the absolute ones alone
+	newDue := due.AddDate(0, 0, 1)
+	require.NoError(t, s.Reminders.RescheduleReminders(ctx, todo.ID, &newDue))
+	reminders = listReminders(t, s, todo.ID)
+	assert.True(t, newDue.Add(-30*time.Minute).Equal(*reminders[0].FireAt), "fire at %v", reminders[0].FireAt)
+	assert.Nil(t, reminders[0].SentAt)
+	assert.Zero(t, reminders[0].Attempts)
+	assert.NotNil(t, reminders[1].FailedAt)
+	assert.True(t, remindAt.Equal(*reminders[1].FireAt))
+
+	// Reminders of done todos aren't claimed
+	todo.IsDone = true
+	require.NoError(t, s.Todos.UpdateTodo(ctx, todo))
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, newDue, newDue.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+	todo.IsDone = false
+	require.NoError(t, s.Todos.UpdateTodo(ctx, todo))
+
+	// Nor are the reminders of disabled users
+	disabledAt := now
+	require.NoError(t, s.Users.SetDisabled(ctx, user.ID, &disabledAt))
+	deliveries, err = s.Reminders.ClaimDueReminders(ctx, newDue, newDue.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+	require.NoError(t, s.Users.SetDisabled(ctx, user.ID, nil))
+
+	// Without a due date, relative reminders don't fire
+	require.NoError(t, s.Reminders.RescheduleReminders(ctx, todo.ID, nil))
+	reminders = listReminders(t, s, todo.ID)
+	assert.Nil(t, reminders[0].FireAt)
+	require.NoError(t, s.Reminders.RescheduleReminders(ctx, todo.ID, &newDue))
+
+	// The next occurrence of a recurring todo gets the relative reminders
+	nextDue := newDue.AddDate(0, 0, 7)
+	next := &model.Todo{UserID: user.ID, Title: todo.Title, DueDate: &nextDue, RecurrenceRule: todo.RecurrenceRule}
+	todo.IsDone = true
+	todo.RecurrenceRule = ""
+	require.NoError(t, s.Todos.CompleteRecurringTodo(ctx, todo, next))
+	copied := listReminders(t, s, next.ID)
+	require.Len(t, copied, 1)
+	assert.NotEqual(t, relative.ID, copied[0].ID)
+	assert.Equal(t, 30, *copied[0].OffsetMinutes)
+	assert.Equal(t, []string{"in_app", "webhook"}, copied[0].Channels)
+	assert.Equal(t, "https://hooks.example.com/remind", copied[0].WebhookURL)
+	require.NotNil(t, copied[0].FireAt)
+	assert.True(t, nextDue.Add(-30*time.Minute).Equal(*copied[0].FireAt), "fire at %v", copied[0].FireAt)
+	assert.Nil(t, copied[0].SentAt)
+	assert.Zero(t, copied[0].Attempts)
+
+	require.NoError(t, s.Reminders.DeleteReminder(ctx, next.ID, copied[0].ID))
+	assert.ErrorIs(t, s.Reminders.DeleteReminder(ctx, next.ID, copied[0].ID), errReminderNotFound)
+
+	// Deleting a todo deletes its reminders
+	require.NoError(t, s.Todos.DeleteTodo(ctx, todo.ID, user.ID))
+	reminders = listReminders(t, s, todo.ID)
+	assert.Empty(t, reminders)
+}
+
+// listReminders returns the reminders of a todo
+func listReminders(t *testing.T, s *Stores, todoID int) []*model.Reminder {
+	reminders, err := s.Reminders.ListTodoReminders(context.Background(), []int{todoID})
+	require.NoError(t, err)
+	return reminders[todoID]
+}
+
+func testNotificationStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "notified")
+	other := createTestUser(t, s, "other")
+	todo := &model.Todo{UserID: user.ID, Title: "Dentist"}
+	require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+
+	var notifications []*model.Notification
+	for _, title := range []string{"First", "Second", "Third"} {
+		todoID := todo.ID
+		notification := &model.Notification{UserID: user.ID, TodoID: &todoID, Title: title, Body: "Reminder for " + title}
+		require.NoError(t, s.Notifications.CreateNotification(ctx, notification))
+		assert.NotZero(t, notification.ID)
+		assert.False(t, notification.CreatedAt.IsZero())
+		notifications = append(notifications, notification)
+	}
+
+	list, err := s.Notifications.ListNotifications(ctx, user.ID, false, 2)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "Third", list[0].Title)
+	assert.Equal(t, "Second", list[1].Title)
+	assert.Equal(t, "Reminder for Third", list[0].Body)
+	require.NotNil(t, list[0].TodoID)
+	assert.Equal(t, todo.ID, *list[0].TodoID)
+	assert.Nil(t, list[0].ReadAt)
+
+	// Only the owner can mark a notification read
+	readAt := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	err = s.Notifications.MarkNotificationRead(ctx, notifications[0].ID, other.ID, readAt)
+	assert.ErrorIs(t, err, errNotificationNotFound)
+	require.NoError(t, s.Notifications.MarkNotificationRead(ctx, notifications[0].ID, user.ID, readAt))
+	require.NoError(t, s.Notifications.MarkNotificationRead(ctx, notifications[0].ID, user.ID, readAt.Add(time.Hour)))
+
+	list, err = s.Notifications.ListNotifications(ctx, user.ID, true, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "Third", list[0].Title)
+	list, err = s.Notifications.ListNotifications(ctx, user.ID, false, 0)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	require.NotNil(t, list[2].ReadAt)
+	assert.True(t, readAt.Equal(*list[2].ReadAt), "read at %v", list[2].ReadAt)
+
+	require.NoError(t, s.Notifications.MarkAllNotificationsRead(ctx, user.ID, readAt.Add(2*time.Hour)))
+	list, err = s.Notifications.ListNotifications(ctx, user.ID, true, 10)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	list, err = s.Notifications.ListNotifications(ctx, user.ID, false, 10)
+	require.NoError(t, err)
+	assert.True(t, readAt.Equal(*list[2].ReadAt), "read at %v", list[2].ReadAt)
+
+	// Notifications outlive their todo
+	require.NoError(t, s.Todos.DeleteTodo(ctx, todo.ID, user.ID))
+	list, err = s.Notifications.ListNotifications(ctx, user.ID, false, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Nil(t, list[0].TodoID)
+
+	list, err = s.Notifications.ListNotifications(ctx, other.ID, false, 10)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func testTagStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "tagger")
+	other := createTestUser(t, s, "other")
+
+	work := &model.Tag{UserID: user.ID, Name: "Work", Color: "#ff0000"}
+	require.NoError(t, s.Tags.CreateTag(ctx, work))
+	assert.NotZero(t, work.ID)
+	assert.ErrorIs(t, s.Tags.CreateTag(ctx, &model.Tag{UserID: user.ID, Name: "WORK", Color: "#ff0000"}), errTagExists)
+	// Names are only unique per user
+	require.NoError(t, s.Tags.CreateTag(ctx, &model.Tag{UserID: other.ID, Name: "work", Color: "#ff0000"}))
+
+	// Existing tags are found in any case, missing ones created
+	tags, err := s.Tags.EnsureTags(ctx, user.ID, []string{"urgent", "work", "Home"}, "#9e9e9e")
+	require.NoError(t, err)
+	require.Len(t, tags, 3)
+	assert.Equal(t, "urgent", tags[0].Name)
+	assert.Equal(t, work.ID, tags[1].ID)
+	assert.Equal(t, "Work", tags[1].Name)
+	assert.Equal(t, "#9e9e9e", tags[2].Color)
+	urgent, home := tags[0], tags[2]
+
+	var todos []*model.Todo
+	for _, title := range []string{"a", "b", "c"} {
+		todo := &model.Todo{UserID: user.ID, Title: title}
+		require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+		todos = append(todos, todo)
+	}
+	require.NoError(t, s.Tags.SetTodoTags(ctx, todos[0].ID, []int{work.ID, urgent.ID}))
+	require.NoError(t, s.Tags.SetTodoTags(ctx, todos[1].ID, []int{work.ID}))
+	require.NoError(t, s.Tags.SetTodoTags(ctx, todos[2].ID, []int{home.ID}))
+
+	todoTags, err := s.Tags.ListTodoTags(ctx, []int{todos[0].ID, todos[1].ID})
+	require.NoError(t, err)
+	assert.Len(t, todoTags, 2)
+	assert.Equal(t, []string{"urgent", "Work"}, tagNames(todoTags[todos[0].ID]))
+
+	for _, tc := range []struct {
+		name  string
+		query model.TodoQuery
+		want  []string
+	}{
+		{"all", model.TodoQuery{Tags: []string{"WORK", "urgent"}}, []string{"a"}},
+		{"any", model.TodoQuery{AnyTags: []string{"urgent", "home"}}, []string{"a", "c"}},
+		{"exclude", model.TodoQuery{ExcludeTags: []string{"urgent"}}, []string{"b", "c"}},
+		{"combined", model.TodoQuery{AnyTags: []string{"work", "home"}, ExcludeTags: []string{"Urgent"}}, []string{"b", "c"}},
+	} {
+		tc.query.Sort, tc.query.Order = model.TodoSortTitle, model.SortAsc
+		page, err := s.Todos.ListTodos(ctx, user.ID, tc.query)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.want, todoTitles(page.Todos), tc.name)
+		assert.Equal(t, len(tc.want), page.Total, tc.name)
+	}
+
+	// Renaming changes the name on every todo; another tag's name is taken
+	work.Name = "Office"
+	require.NoError(t, s.Tags.UpdateTag(ctx, work))
+	todoTags, err = s.Tags.ListTodoTags(ctx, []int{todos[1].ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Office"}, tagNames(todoTags[todos[1].ID]))
+	work.Name = "Urgent"
+	assert.ErrorIs(t, s.Tags.UpdateTag(ctx, work), errTagExists)
+	assert.ErrorIs(t, s.Tags.UpdateTag(ctx, &model.Tag{ID: work.ID, UserID: other.ID, Name: "x"}), errTagNotFound)
+
+	// Merging moves the todos to the target once
+	assert.ErrorIs(t, s.Tags.MergeTag(ctx, work.ID, urgent.ID, other.ID), errTagNotFound)
+	require.NoError(t, s.Tags.MergeTag(ctx, work.ID, urgent.ID, user.ID))
+	_, err = s.Tags.GetTag(ctx, work.ID, user.ID)
+	assert.ErrorIs(t, err, errTagNotFound)
+	listed, err := s.Tags.ListTags(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Home", "urgent"}, tagNames(listed))
+	assert.Equal(t, 2, *listed[1].TodoCount)
+
+	// Deleting a tag or todo removes the links
+	require.NoError(t, s.Tags.DeleteTag(ctx, home.ID, user.ID))
+	assert.ErrorIs(t, s.Tags.DeleteTag(ctx, home.ID, user.ID), errTagNotFound)
+	require.NoError(t, s.Todos.DeleteTodo(ctx, todos[0].ID, user.ID))
+	require.NoError(t, s.Tags.SetTodoTags(ctx, todos[1].ID, nil))
+	todoTags, err = s.Tags.ListTodoTags(ctx, []int{todos[0].ID, todos[1].ID, todos[2].ID})
+	require.NoError(t, err)
+	assert.Empty(t, todoTags)
+	listed, err = s.Tags.ListTags(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, *listed[0].TodoCount)
+}
+
+func testListStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "organizer")
+	other := createTestUser(t, s, "other")
+
+	work := &model.List{UserID: user.ID, Name: "Work", Color: "#ff0000", Icon: "briefcase"}
+	require.NoError(t, s.Lists.CreateList(ctx, work))
+	assert.NotZero(t, work.ID)
+	assert.Equal(t, 0, work.Position)
+	assert.False(t, work.CreatedAt.IsZero())
+	assert.ErrorIs(t, s.Lists.CreateList(ctx, &model.List{UserID: user.ID, Name: "WORK", Color: "#ff0000"}), errListExists)
+	// Names are only unique per user
+	require.NoError(t, s.Lists.CreateList(ctx, &model.List{UserID: other.ID, Name: "work", Color: "#ff0000"}))
+
+	// Existing lists are found in any case, missing ones appended
+	found, err := s.Lists.EnsureList(ctx, user.ID, "work", "#9e9e9e")
+	require.NoError(t, err)
+	assert.Equal(t, work.ID, found.ID)
+	assert.Equal(t, "briefcase", found.Icon)
+	home, err := s.Lists.EnsureList(ctx, user.ID, "Home", "#9e9e9e")
+	require.NoError(t, err)
+	assert.Equal(t, 1, home.Position)
+	assert.Equal(t, "#9e9e9e", home.Color)
+	errands := &model.List{UserID: user.ID, Name: "Errands", Color: "#00ff00"}
+	require.NoError(t, s.Lists.CreateList(ctx, errands))
+	assert.Equal(t, 2, errands.Position)
+
+	_, err = s.Lists.GetList(ctx, work.ID, other.ID)
+	assert.ErrorIs(t, err, errListNotFound)
+
+	var todos []*model.Todo
+	for _, list := range []*model.List{work, work, home} {
+		todo := &model.Todo{UserID: user.ID, Title: "in " + list.Name, ListID: &list.ID}
+		require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+		todos = append(todos, todo)
+	}
+	require.NoError(t, s.TodoItems.CreateTodoItem(ctx, &model.TodoItem{TodoID: todos[2].ID, Title: "step"}))
+
+	lists, err := s.Lists.ListLists(ctx, user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Work", "Home", "Errands"}, listNames(lists))
+	assert.Equal(t, 2, *lists[0].TodoCount)
+	assert.Equal(t, 0, *lists[2].TodoCount)
+
+	// Updating renames and archives; archived lists are only listed on request
+	work.Name = "Office"
+	work.Archived = true
+	require.NoError(t, s.Lists.UpdateList(ctx, work))
+	lists, err = s.Lists.ListLists(ctx, user.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Home", "Errands"}, listNames(lists))
+	lists, err = s.Lists.ListLists(ctx, user.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Office", "Home", "Errands"}, listNames(lists))
+	assert.True(t, lists[0].Archived)
+	work.Name = "home"
+	assert.ErrorIs(t, s.Lists.UpdateList(ctx, work), errListExists)
+	assert.ErrorIs(t, s.Lists.UpdateList(ctx, &model.List{ID: work.ID, UserID: other.ID, Name: "x"}), errListNotFound)
+
+	require.NoError(t, s.Lists.ReorderLists(ctx, user.ID, []int{errands.ID, work.ID, home.ID}))
+	lists, err = s.Lists.ListLists(ctx, user.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Errands", "Office", "Home"}, listNames(lists))
+	assert.ErrorIs(t, s.Lists.ReorderLists(ctx, other.ID, []int{work.ID}), errListNotFound)
+
+	// Deleting a list moves its todos, deletes them or leaves them without a list
+	assert.ErrorIs(t, s.Lists.DeleteList(ctx, work.ID, other.ID, model.ListDeletion{}), errListNotFound)
+	otherList, err := s.Lists.EnsureList(ctx, other.ID, "work", "#9e9e9e")
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.Lists.DeleteList(ctx, work.ID, user.ID, model.ListDeletion{MoveTo: &otherList.ID}), errListNotFound)
+	assert.ErrorIs(t, s.Lists.DeleteList(ctx, work.ID, user.ID, model.ListDeletion{MoveTo: &work.ID}), errListNotFound)
+
+	require.NoError(t, s.Lists.DeleteList(ctx, work.ID, user.ID, model.ListDeletion{MoveTo: &errands.ID}))
+	_, err = s.Lists.GetList(ctx, work.ID, user.ID)
+	assert.ErrorIs(t, err, errListNotFound)
+	page, err := s.Todos.ListTodos(ctx, user.ID, model.TodoQuery{Sort: model.TodoSortCreatedAt, Order: model.SortAsc, ListID: &errands.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+
+	require.NoError(t, s.Lists.DeleteList(ctx, home.ID, user.ID, model.ListDeletion{DeleteTodos: true}))
+	_, err = s.Todos.GetTodoByID(ctx, todos[2].ID, user.ID)
+	assert.ErrorIs(t, err, errTodoNotFound)
+	items, err := s.TodoItems.ListTodoItems(ctx, []int{todos[2].ID})
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	require.NoError(t, s.Lists.DeleteList(ctx, errands.ID, user.ID, model.ListDeletion{}))
+	got, err := s.Todos.GetTodoByID(ctx, todos[0].ID, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, got.ListID)
+	lists, err = s.Lists.ListLists(ctx, user.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, lists)
+}
+
+func listNames(lists []*model.List) []string {
+	var out []string
+	for _, list := range lists {
+		out = append(out, list.Name)
+	}
+	return out
+}
+
+func tagNames(tags []*model.Tag) []string {
+	var out []string
+	for _, tag := range tags {
+		out = append(out, tag.Name)
+	}
+	return out
+}
+
+func todoTitles(todos []*model.Todo) []string {
+	var out []string
+	for _, todo := range todos {
+		out = append(out, todo.Title)
+	}
+	return out
+}
+
+func testListTodos(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "lister")
+	other := createTestUser(t, s, "other")
+
+	work := &model.List{UserID: user.ID, Name: "Work", Color: "#9e9e9e"}
+	require.NoError(t, s.Lists.CreateList(ctx, work))
+
+	due := func(day int) *time.Time {
+		d := time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	for _, todo := range []*model.Todo{
+		{UserID: user.ID, Title: "b", Priority: "Low", DueDate: due(3)},
+		{UserID: user.ID, Title: "A", Priority: "High"},
+		{UserID: user.ID, Title: "c", Priority: "Medium", DueDate: due(1)},
+		{UserID: user.ID, Title: "d", Priority: "High", DueDate: due(2), ListID: &work.ID},
+		{UserID: other.ID, Title: "other user", Priority: "High"},
+	} {
+		require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+		if todo.Title == "c" {
+			todo.IsDone = true
+			require.NoError(t, s.Todos.UpdateTodo(ctx, todo))
+		}
+	}
+
+	// Priority sorts semantically, walking the pages with the cursor
+	q := model.TodoQuery{Sort: model.TodoSortPriority, Order: model.SortDesc, Limit: 3}
+	page, err := s.Todos.ListTodos(ctx, user.ID, q)
+	require.NoError(t, err)
+	assert.Equal(t, 4, page.Total)
+	assert.Equal(t, []string{"d", "A", "c"}, todoTitles(page.Todos))
+	require.NotEmpty(t, page.NextCursor)
+
+	q.Cursor = page.NextCursor
+	page, err = s.Todos.ListTodos(ctx, user.ID, q)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, todoTitles(page.Todos))
+	assert.Empty(t, page.NextCursor)
+
+	// Missing due dates sort last in both directions, also across pages
+	for _, order := range []string{model.SortAsc, model.SortDesc} {
+		query := model.TodoQuery{Sort: model.TodoSortDueDate, Order: order, Limit: 2}
+		var titles []string
+		for {
+			page, err = s.Todos.ListTodos(ctx, user.ID, query)
+			require.NoError(t, err)
+			titles = append(titles, todoTitles(page.Todos)...)
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		require.Len(t, titles, 4)
+		assert.Equal(t, "A", titles[3], order)
+	}
+
+	// Titles sort case-insensitively
+	page, err = s.Todos.ListTodos(ctx, user.ID, model.TodoQuery{Sort: model.TodoSortTitle, Order: model.SortAsc, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A", "b"}, todoTitles(page.Todos))
+	page, err = s.Todos.ListTodos(ctx, user.ID, model.TodoQuery{Sort: model.TodoSortTitle, Order: model.SortAsc, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, todoTitles(page.Todos))
+
+	// Filters
+	done := false
+	page, err = s.Todos.ListTodos(ctx, user.ID, model.TodoQuery{
+		Sort: model.TodoSortTitle, Order: model.SortAsc,
+		IsDone: &done, DueFrom: due(2), DueTo: due(3),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "d"}, todoTitles(page.Todos))
+
+	page, err = s.Todos.ListTodos(ctx, user.ID, model.TodoQuery{
+		Sort: model.TodoSortCreatedAt, Order: model.SortDesc,
+		ListID: &work.ID, Priorities: []string{"High", "Low"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d"}, todoTitles(page.Todos))
+	assert.Equal(t, 1, page.Total)
+
+	// A cursor can't be reused with another sort
+	_, err = s.Todos.ListTodos(ctx, user.ID, model.TodoQuery{Sort: model.TodoSortTitle, Order: model.SortAsc, Cursor: q.Cursor})
+	assert.Error(t, err)
+
+	// Non-ASCII titles sort case-insensitively too, the same in every store,
+	// and a new title is sorted by once saved
+	polyglot := createTestUser(t, s, "polyglot")
+	var zebra *model.Todo
+	for _, title := range []string{"Ärger", "Zebra", "äpfel", "apple"} {
+		todo := &model.Todo{UserID: polyglot.ID, Title: title}
+		require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+		if title == "Zebra" {
+			zebra = todo
+		}
+	}
+	zebra.Title = "Ölfass"
+	require.NoError(t, s.Todos.UpdateTodo(ctx, zebra))
+	query := model.TodoQuery{Sort: model.TodoSortTitle, Order: model.SortAsc, Limit: 2}
+	var titles []string
+	for {
+		page, err = s.Todos.ListTodos(ctx, polyglot.ID, query)
+		require.NoError(t, err)
+		titles = append(titles, todoTitles(page.Todos)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"apple", "äpfel", "Ärger", "Ölfass"}, titles)
+}
+
+func testSearchTodos(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "searcher")
+	other := createTestUser(t, s, "other")
+
+	for _, todo := range []*model.Todo{
+		{UserID: user.ID, Title: "Write weekly report", Description: "Send the <b>report</b> to the team"},
+		{UserID: user.ID, Title: "Team sync", Description: "discuss the report"},
+		{UserID: user.ID, Title: "Buy groceries"},
+		{UserID: other.ID, Title: "weekly report"},
+	} {
+		require.NoError(t, s.Todos.CreateTodo(ctx, todo))
+	}
+
+	titles := func(results []*model.TodoSearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.Todo.Title)
+		}
+		return out
+	}
+
+	// Title matches rank above description matches; highlights are HTML escaped
+	results, err := s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"Report"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write weekly report", "Team sync"}, titles(results))
+	assert.Greater(t, results[0].Rank, results[1].Rank)
+	assert.Equal(t, "Write weekly <mark>report</mark>", results[0].TitleHighlight)
+	assert.Contains(t, results[0].DescriptionHighlight, "<mark>report</mark>")
+	assert.NotContains(t, results[0].DescriptionHighlight, "<b>")
+
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Phrases: []string{"weekly report"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write weekly report"}, titles(results))
+
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Prefixes: []string{"groc"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Buy groceries"}, titles(results))
+
+	// Every term must match
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"report"}, Prefixes: []string{"sy"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Team sync"}, titles(results))
+
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"report"}, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Team sync"}, titles(results))
+
+	// Updates and deletes are reflected in the index
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"groceries"}, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	groceries := results[0].Todo
+	groceries.Title = "Buy vegetables"
+	require.NoError(t, s.Todos.UpdateTodo(ctx, groceries))
+
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"groceries"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"vegetables"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	require.NoError(t, s.Todos.DeleteTodo(ctx, groceries.ID, user.ID))
+	results, err = s.Todos.SearchTodos(ctx, user.ID, model.TodoSearch{Words: []string{"vegetables"}, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testTokenStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "tokens")
+	other := createTestUser(t, s, "other")
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	session := &model.Session{ID: "session-1", UserID: user.ID, UserAgent: "Firefox", IPAddress: "192.0.2.1", ExpiresAt: expiresAt}
+	require.NoError(t, s.Tokens.CreateSession(ctx, session))
+	assert.False(t, session.CreatedAt.IsZero())
+
+	got, err := s.Tokens.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.UserID)
+	assert.Equal(t, "Firefox", got.UserAgent)
+	assert.Equal(t, "192.0.2.1", got.IPAddress)
+	assert.True(t, expiresAt.Equal(got.ExpiresAt), "expires at %v", got.ExpiresAt)
+	assert.Nil(t, got.RevokedAt)
+
+	require.NoError(t, s.Tokens.TouchSession(ctx, session.ID, "198.51.100.7"))
+	require.NoError(t, s.Tokens.ExtendSession(ctx, session.ID, expiresAt.Add(time.Hour)))
+	got, err = s.Tokens.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "198.51.100.7", got.IPAddress)
+	assert.False(t, got.LastSeenAt.Before(got.CreatedAt))
+	assert.True(t, expiresAt.Add(time.Hour).Equal(got.ExpiresAt))
+
+	token := &model.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		ExpiresAt: expiresAt,
+	}
+	require.NoError(t, s.Tokens.CreateRefreshToken(ctx, token))
+	assert.NotZero(t, token.ID)
+
+	gotToken, err := s.Tokens.GetRefreshTokenByHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, gotToken.SessionID)
+	assert.True(t, token.ExpiresAt.Equal(gotToken.ExpiresAt), "expires at %v", gotToken.ExpiresAt)
+	assert.Nil(t, gotToken.UsedAt)
+
+	_, err = s.Tokens.GetRefreshTokenByHash(ctx, "unknown")
+	assert.ErrorIs(t, err, errRefreshTokenNotFound)
+
+	// A token can only be used once
+	used, err := s.Tokens.UseRefreshToken(ctx, token.ID)
+	require.NoError(t, err)
+	assert.True(t, used)
+	used, err = s.Tokens.UseRefreshToken(ctx, token.ID)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	gotToken, err = s.Tokens.GetRefreshTokenByHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.NotNil(t, gotToken.UsedAt)
+
+	// Only active sessions of the user are listed
+	for _, extra := range []*model.Session{
+		{ID: "session-2", UserID: user.ID, ExpiresAt: expiresAt},
+		{ID: "session-3", UserID: user.ID, ExpiresAt: expiresAt},
+		{ID: "expired", UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute)},
+		{ID: "not-mine", UserID: other.ID, ExpiresAt: expiresAt},
+	} {
+		require.NoError(t, s.Tokens.CreateSession(ctx, extra))
+	}
+	require.NoError(t, s.Tokens.TouchSession(ctx, "session-2", ""))
+
+	sessions, err := s.Tokens.ListActiveSessions(ctx, user.ID)
+	require.NoError(t, err)
+	var ids []string
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	require.Len(t, ids, 3)
+	assert.Equal(t, "session-2", ids[0], "most recently seen first")
+	assert.ElementsMatch(t, []string{"session-1", "session-2", "session-3"}, ids)
+
+	// Revoking is idempotent and keeps the first revocation time
+	require.NoError(t, s.Tokens.RevokeSession(ctx, session.ID))
+	got, err = s.Tokens.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	revokedAt := *got.RevokedAt
+
+	require.NoError(t, s.Tokens.RevokeSession(ctx, session.ID))
+	got, err = s.Tokens.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, revokedAt.Equal(*got.RevokedAt))
+
+	// Revoking all but one session leaves other users alone
+	require.NoError(t, s.Tokens.RevokeUserSessions(ctx, user.ID, "session-3"))
+	sessions, err = s.Tokens.ListActiveSessions(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "session-3", sessions[0].ID)
+	sessions, err = s.Tokens.ListActiveSessions(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	assert.ErrorIs(t, s.Tokens.RevokeSession(ctx, "unknown"), errSessionNotFound)
+	assert.ErrorIs(t, s.Tokens.TouchSession(ctx, "unknown", ""), errSessionNotFound)
+	assert.ErrorIs(t, s.Tokens.ExtendSession(ctx, "unknown", expiresAt), errSessionNotFound)
+	_, err = s.Tokens.GetSession(ctx, "unknown")
+	assert.ErrorIs(t, err, errSessionNotFound)
+}
+
+func testPasswordResetTokens(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "forgetful")
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	token := &model.PasswordResetToken{UserID: user.ID, TokenHash: "reset-1", ExpiresAt: expiresAt}
+	require.NoError(t, s.Tokens.CreatePasswordResetToken(ctx, token))
+	assert.NotZero(t, token.ID)
+	require.NoError(t, s.Tokens.CreatePasswordResetToken(ctx, &model.PasswordResetToken{UserID: user.ID, TokenHash: "reset-2", ExpiresAt: expiresAt}))
+
+	got, err := s.Tokens.GetPasswordResetTokenByHash(ctx, "reset-1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.UserID)
+	assert.True(t, expiresAt.Equal(got.ExpiresAt), "expires at %v", got.ExpiresAt)
+	assert.Nil(t, got.UsedAt)
+
+	used, err := s.Tokens.UsePasswordResetToken(ctx, token.ID)
+	require.NoError(t, err)
+	assert.True(t, used)
+	used, err = s.Tokens.UsePasswordResetToken(ctx, token.ID)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	got, err = s.Tokens.GetPasswordResetTokenByHash(ctx, "reset-1")
+	require.NoError(t, err)
+	assert.NotNil(t, got.UsedAt)
+
+	require.NoError(t, s.Tokens.DeletePasswordResetTokens(ctx, user.ID))
+	for _, hash := range []string{"reset-1", "reset-2", "unknown"} {
+		_, err = s.Tokens.GetPasswordResetTokenByHash(ctx, hash)
+		assert.ErrorIs(t, err, errResetTokenNotFound)
+	}
+}
+
+func testTwoFactorStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "careful")
+	other := createTestUser(t, s, "other")
+
+	_, err := s.TwoFactor.GetTwoFactor(ctx, user.ID)
+	assert.ErrorIs(t, err, errTwoFactorNotFound)
+
+	// Enrolling again replaces the pending secret
+	require.NoError(t, s.TwoFactor.SetTOTPSecret(ctx, user.ID, "FIRST"))
+	require.NoError(t, s.TwoFactor.SetTOTPSecret(ctx, user.ID, "SECOND"))
+	tf, err := s.TwoFactor.GetTwoFactor(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "SECOND", tf.Secret)
+	assert.Nil(t, tf.EnabledAt)
+	assert.Nil(t, tf.LastUsedStep)
+
+	require.NoError(t, s.TwoFactor.EnableTwoFactor(ctx, user.ID, 100, []string{"code-1", "code-2"}))
+	assert.ErrorIs(t, s.TwoFactor.EnableTwoFactor(ctx, user.ID, 101, nil), errTwoFactorNotFound)
+	assert.ErrorIs(t, s.TwoFactor.EnableTwoFactor(ctx, other.ID, 100, nil), errTwoFactorNotFound)
+	assert.ErrorIs(t, s.TwoFactor.SetTOTPSecret(ctx, user.ID, "THIRD"), errTwoFactorEnabled)
+
+	tf, err = s.TwoFactor.GetTwoFactor(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "SECOND", tf.Secret)
+	assert.NotNil(t, tf.EnabledAt)
+	require.NotNil(t, tf.LastUsedStep)
+	assert.Equal(t, int64(100), *tf.LastUsedStep)
+
+	// Steps only move forward
+	for _, step := range []struct {
+		step int64
+		ok   bool
+	}{{100, false}, {99, false}, {101, true}, {101, false}, {103, true}} {
+		used, err := s.TwoFactor.UseTOTPStep(ctx, user.ID, step.step)
+		require.NoError(t, err)
+		assert.Equal(t, step.ok, used, "step %d", step.step)
+	}
+
+	// Recovery codes are single use and belong to one user
+	used, err := s.TwoFactor.UseRecoveryCode(ctx, other.ID, "code-1")
+	require.NoError(t, err)
+	assert.False(t, used)
+	used, err = s.TwoFactor.UseRecoveryCode(ctx, user.ID, "code-1")
+	require.NoError(t, err)
+	assert.True(t, used)
+	used, err = s.TwoFactor.UseRecoveryCode(ctx, user.ID, "code-1")
+	require.NoError(t, err)
+	assert.False(t, used)
+	count, err := s.TwoFactor.CountRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	require.NoError(t, s.TwoFactor.ReplaceRecoveryCodes(ctx, user.ID, []string{"code-3", "code-4", "code-5"}))
+	count, err = s.TwoFactor.CountRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	used, err = s.TwoFactor.UseRecoveryCode(ctx, user.ID, "code-2")
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	expiresAt := time.Now().Add(5 * time.Minute).UTC().Truncate(time.Second)
+	challenge := &model.MFAChallenge{UserID: user.ID, TokenHash: "challenge-1", ExpiresAt: expiresAt}
+	require.NoError(t, s.TwoFactor.CreateMFAChallenge(ctx, challenge))
+	assert.NotZero(t, challenge.ID)
+
+	for i := 0; i < 2; i++ {
+		ok, err := s.TwoFactor.AttemptMFAChallenge(ctx, challenge.ID, 3)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	got, err := s.TwoFactor.GetMFAChallengeByHash(ctx, "challenge-1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.UserID)
+	assert.Equal(t, 2, got.Attempts)
+	assert.True(t, expiresAt.Equal(got.ExpiresAt), "expires at %v", got.ExpiresAt)
+	assert.Nil(t, got.UsedAt)
+
+	// Attempts stop at the limit
+	ok, err := s.TwoFactor.AttemptMFAChallenge(ctx, challenge.ID, 2)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	used, err = s.TwoFactor.UseMFAChallenge(ctx, challenge.ID)
+	require.NoError(t, err)
+	assert.True(t, used)
+	used, err = s.TwoFactor.UseMFAChallenge(ctx, challenge.ID)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	// A released challenge can be used again
+	require.NoError(t, s.TwoFactor.ReleaseMFAChallenge(ctx, challenge.ID))
+	got, err = s.TwoFactor.GetMFAChallengeByHash(ctx, "challenge-1")
+	require.NoError(t, err)
+	assert.Nil(t, got.UsedAt)
+	used, err = s.TwoFactor.UseMFAChallenge(ctx, challenge.ID)
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	// A used or unknown challenge can't be attempted
+	ok, err = s.TwoFactor.AttemptMFAChallenge(ctx, challenge.ID, 10)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = s.TwoFactor.AttemptMFAChallenge(ctx, challenge.ID+1000, 10)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Disabling removes everything, and enrolling starts over
+	require.NoError(t, s.TwoFactor.DisableTwoFactor(ctx, user.ID))
+	_, err = s.TwoFactor.GetTwoFactor(ctx, user.ID)
+	assert.ErrorIs(t, err, errTwoFactorNotFound)
+	_, err = s.TwoFactor.GetMFAChallengeByHash(ctx, "challenge-1")
+	assert.ErrorIs(t, err, errMFAChallengeNotFound)
+	count, err = s.TwoFactor.CountRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	require.NoError(t, s.TwoFactor.SetTOTPSecret(ctx, user.ID, "FOURTH"))
+}
+
+func testAccessTokenStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "scripted")
+	other := createTestUser(t, s, "other")
+
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	first := &model.PersonalAccessToken{
+		UserID:    user.ID,
+		Name:      "backup script",
+		TokenHash: "hash-1",
+		Scopes:    []string{model.ScopeTodosRead, model.ScopeTodosWrite},
+		ExpiresAt: &expiresAt,
+	}
+	require.NoError(t, s.AccessTokens.CreateAccessToken(ctx, first))
+	assert.NotZero(t, first.ID)
+	second := &model.PersonalAccessToken{UserID: user.ID, Name: "ci", TokenHash: "hash-2", Scopes: []string{model.ScopeAccount}}
+	require.NoError(t, s.AccessTokens.CreateAccessToken(ctx, second))
+
+	got, err := s.AccessTokens.GetAccessTokenByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.UserID)
+	assert.Equal(t, "backup script", got.Name)
+	assert.Equal(t, []string{model.ScopeTodosRead, model.ScopeTodosWrite}, got.Scopes)
+	require.NotNil(t, got.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*got.ExpiresAt), "expires at %v", got.ExpiresAt)
+	assert.Nil(t, got.LastUsedAt)
+	_, err = s.AccessTokens.GetAccessTokenByHash(ctx, "hash-3")
+	assert.ErrorIs(t, err, errAccessTokenNotFound)
+
+	usedAt := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, s.AccessTokens.TouchAccessToken(ctx, first.ID, usedAt))
+	assert.ErrorIs(t, s.AccessTokens.TouchAccessToken(ctx, first.ID+1000, usedAt), errAccessTokenNotFound)
+
+	// Newest first
+	tokens, err := s.AccessTokens.ListAccessTokens(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, second.ID, tokens[0].ID)
+	assert.Nil(t, tokens[0].ExpiresAt)
+	assert.Equal(t, first.ID, tokens[1].ID)
+	require.NotNil(t, tokens[1].LastUsedAt)
+	assert.True(t, usedAt.Equal(*tokens[1].LastUsedAt), "last used at %v", tokens[1].LastUsedAt)
+
+	tokens, err = s.AccessTokens.ListAccessTokens(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	// Tokens can only be deleted by their owner
+	assert.ErrorIs(t, s.AccessTokens.DeleteAccessToken(ctx, first.ID, other.ID), errAccessTokenNotFound)
+	require.NoError(t, s.AccessTokens.DeleteAccessToken(ctx, first.ID, user.ID))
+	assert.ErrorIs(t, s.AccessTokens.DeleteAccessToken(ctx, first.ID, user.ID), errAccessTokenNotFound)
+	_, err = s.AccessTokens.GetAccessTokenByHash(ctx, "hash-1")
+	assert.ErrorIs(t, err, errAccessTokenNotFound)
+}
+
+func testOIDCStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	user := createTestUser(t, s, "federated")
+	other := createTestUser(t, s, "other")
+
+	expiresAt := time.Now().Add(10 * time.Minute).UTC().Truncate(time.Second)
+	login := &model.OIDCLogin{StateHash: "state-1", Provider: "keycloak", CodeVerifier: "verifier", Nonce: "nonce", ExpiresAt: expiresAt}
+	require.NoError(t, s.OIDC.CreateOIDCLogin(ctx, login))
+	assert.NotZero(t, login.ID)
+	stale := &model.OIDCLogin{StateHash: "state-2", Provider: "keycloak", CodeVerifier: "v", Nonce: "n", ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, s.OIDC.CreateOIDCLogin(ctx, stale))
+
+	// Logins can only be taken once
+	got, err := s.OIDC.TakeOIDCLogin(ctx, "state-1")
+	require.NoError(t, err)
+	assert.Equal(t, "keycloak", got.Provider)
+	assert.Equal(t, "verifier", got.CodeVerifier)
+	assert.Equal(t, "nonce", got.Nonce)
+	assert.True(t, expiresAt.Equal(got.ExpiresAt), "expires at %v", got.ExpiresAt)
+	_, err = s.OIDC.TakeOIDCLogin(ctx, "state-1")
+	assert.ErrorIs(t, err, errOIDCLoginNotFound)
+
+	require.NoError(t, s.OIDC.DeleteExpiredOIDCLogins(ctx, time.Now()))
+	_, err = s.OIDC.TakeOIDCLogin(ctx, "state-2")
+	assert.ErrorIs(t, err, errOIDCLoginNotFound)
+
+	_, err = s.OIDC.GetIdentity(ctx, "keycloak", "sub-1")
+	assert.ErrorIs(t, err, errIdentityNotFound)
+
+	identity := &model.UserIdentity{UserID: user.ID, Provider: "keycloak", Subject: "sub-1", Email: "federated@example.com"}
+	require.NoError(t, s.OIDC.CreateIdentity(ctx, identity))
+	assert.NotZero(t, identity.ID)
+
+	// A provider account links to one user, but the subject is per provider
+	err = s.OIDC.CreateIdentity(ctx, &model.UserIdentity{UserID: other.ID, Provider: "keycloak", Subject: "sub-1", Email: "other@example.com"})
+	assert.ErrorIs(t, err, errIdentityExists)
+	require.NoError(t, s.OIDC.CreateIdentity(ctx, &model.UserIdentity{UserID: other.ID, Provider: "google", Subject: "sub-1", Email: "other@example.com"}))
+
+	found, err := s.OIDC.GetIdentity(ctx, "keycloak", "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.UserID)
+	assert.Equal(t, "federated@example.com", found.Email)
+	found, err = s.OIDC.GetIdentity(ctx, "google", "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, found.UserID)
+}
+
+func testLoginAttemptStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	_, err := s.LoginAttempts.GetLoginAttempts(ctx, "email:a@example.com")
+	assert.ErrorIs(t, err, errLoginAttemptsNotFound)
+	assert.ErrorIs(t, s.LoginAttempts.BlockLogin(ctx, "email:a@example.com", start), errLoginAttemptsNotFound)
+
+	record := func(key string, at, resetBefore time.Time, limit int) (*model.LoginAttempts, bool) {
+		attempts, ok, err := s.LoginAttempts.RecordLoginAttempt(ctx, key, at, resetBefore, limit)
+		require.NoError(t, err)
+		return attempts, ok
+	}
+
+	for i := 1; i <= 3; i++ {
+		attempts, ok := record("email:a@example.com", start.Add(time.Duration(i)*time.Second), start, 10)
+		require.True(t, ok)
+		assert.Equal(t, i, attempts.Failures)
+		assert.Nil(t, attempts.BlockedUntil)
+	}
+	_, ok := record("ip:192.0.2.1", start, start, 10)
+	require.True(t, ok)
+
+	// A forgiven attempt no longer counts
+	require.NoError(t, s.LoginAttempts.ForgiveLoginFailure(ctx, "email:a@example.com"))
+	require.NoError(t, s.LoginAttempts.ForgiveLoginFailure(ctx, "email:nobody@example.com"))
+	attempts, ok := record("email:a@example.com", start.Add(3*time.Second), start, 10)
+	require.True(t, ok)
+	assert.Equal(t, 3, attempts.Failures)
+
+	// Attempts stop at the limit
+	_, ok = record("email:a@example.com", start.Add(3*time.Second), start, 3)
+	assert.False(t, ok)
+
+	until := start.Add(15 * time.Minute)
+	require.NoError(t, s.LoginAttempts.BlockLogin(ctx, "email:a@example.com", until))
+	attempts, err = s.LoginAttempts.GetLoginAttempts(ctx, "email:a@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts.Failures)
+	assert.True(t, start.Add(3*time.Second).Equal(attempts.LastFailureAt), "last failure at %v", attempts.LastFailureAt)
+	require.NotNil(t, attempts.BlockedUntil)
+	assert.True(t, until.Equal(*attempts.BlockedUntil), "blocked until %v", attempts.BlockedUntil)
+
+	// Blocked keys get no attempts, until the counter is old enough to start over
+	_, ok = record("email:a@example.com", start.Add(4*time.Second), start, 10)
+	assert.False(t, ok)
+	attempts, ok = record("email:a@example.com", until, start, 10)
+	require.True(t, ok)
+	assert.Equal(t, 4, attempts.Failures)
+	assert.NotNil(t, attempts.BlockedUntil)
+	attempts, ok = record("email:a@example.com", start.Add(time.Hour), start.Add(30*time.Minute), 10)
+	require.True(t, ok)
+	assert.Equal(t, 1, attempts.Failures)
+	assert.Nil(t, attempts.BlockedUntil)
+
+	// Only counters without recent failures or blocks are stale
+	require.NoError(t, s.LoginAttempts.BlockLogin(ctx, "ip:192.0.2.1", start.Add(2*time.Hour)))
+	_, ok = record("ip:198.51.100.7", start, start, 10)
+	require.True(t, ok)
+	require.NoError(t, s.LoginAttempts.DeleteStaleLoginAttempts(ctx, start.Add(time.Minute)))
+	_, err = s.LoginAttempts.GetLoginAttempts(ctx, "ip:198.51.100.7")
+	assert.ErrorIs(t, err, errLoginAttemptsNotFound)
+	_, err = s.LoginAttempts.GetLoginAttempts(ctx, "ip:192.0.2.1")
+	assert.NoError(t, err)
+	_, err = s.LoginAttempts.GetLoginAttempts(ctx, "email:a@example.com")
+	assert.NoError(t, err)
+
+	require.NoError(t, s.LoginAttempts.ClearLoginAttempts(ctx, "email:a@example.com"))
+	require.NoError(t, s.LoginAttempts.ClearLoginAttempts(ctx, "email:a@example.com"))
+	_, err = s.LoginAttempts.GetLoginAttempts(ctx, "email:a@example.com")
+	assert.ErrorIs(t, err, errLoginAttemptsNotFound)
+}
+
+func testAdminStore(t *testing.T, s *Stores) {
+	ctx := context.Background()
+	now := time.Now()
+	budi := createTestUser(t, s, "budi")
+	citra := createTestUser(t, s, "citra_100%")
+	admin := createTestUser(t, s, "admin")
+	require.NoError(t, s.Users.SetRole(ctx, admin.ID, model.RoleAdmin))
+	require.NoError(t, s.Users.SetDisabled(ctx, citra.ID, &now))
+	require.NoError(t, s.Users.MarkEmailVerified(ctx, admin.ID, admin.Email))
+	require.NoError(t, s.Users.ScheduleDeletion(ctx, budi.ID, now.Add(time.Hour)))
+
+	overdue := now.Add(-time.Hour)
+	require.NoError(t, s.Todos.CreateTodo(ctx, &model.Todo{UserID: budi.ID, Title: "Done"}))
+	require.NoError(t, s.Todos.CreateTodo(ctx, &model.Todo{UserID: budi.ID, Title: "Late", DueDate: &overdue}))
+	require.NoError(t, s.Todos.CreateTodo(ctx, &model.Todo{UserID: citra.ID, Title: "Open"}))
+	todos, err := s.Todos.GetTodosByUserID(ctx, budi.ID)
+	require.NoError(t, err)
+	for _, todo := range todos {
+		if todo.Title == "Done" {
+			todo.IsDone = true
+			require.NoError(t, s.Todos.UpdateTodo(ctx, todo))
+		}
+	}
+
+	require.NoError(t, s.Tokens.CreateSession(ctx, &model.Session{ID: "active", UserID: budi.ID, ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, s.Tokens.CreateSession(ctx, &model.Session{ID: "expired", UserID: budi.ID, ExpiresAt: now.Add(-time.Hour)}))
+	require.NoError(t, s.Tokens.CreateSession(ctx, &model.Session{ID: "revoked", UserID: citra.ID, ExpiresAt: now.Add(time.Hour)}))
+	require.NoError(t, s.Tokens.RevokeSession(ctx, "revoked"))
+
+	// Newest first, with todo counts
+	page, err := s.Admin.ListUsers(ctx, model.UserQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	require.Len(t, page.Users, 3)
+	assert.Equal(t, admin.ID, page.Users[0].ID)
+	assert.Equal(t, budi.ID, page.Users[2].ID)
+	assert.Equal(t, 2, page.Users[2].TodoCount)
+	assert.Equal(t, 1, page.Users[2].CompletedTodoCount)
+	assert.NotNil(t, page.Users[2].DeleteAfter)
+	assert.Empty(t, page.Users[2].Password)
+
+	page, err = s.Admin.ListUsers(ctx, model.UserQuery{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, citra.ID, page.Users[0].ID)
+	assert.Equal(t, 1, page.Users[0].TodoCount)
+	assert.NotNil(t, page.Users[0].DisabledAt)
+
+	// Search ignores case and treats wildcards literally
+	for search, want := range map[string][]int{
+		"BUDI@":   {budi.ID},
+		"100%":    {citra.ID},
+		"_":       {citra.ID},
+		"example": {admin.ID, citra.ID, budi.ID},
+		"nobody":  {},
+	} {
+		page, err = s.Admin.ListUsers(ctx, model.UserQuery{Search: search})
+		require.NoError(t, err)
+		ids := []int{}
+		for _, u := range page.Users {
+			ids = append(ids, u.ID)
+		}
+		assert.Equal(t, want, ids, search)
+	}
+
+	disabled := true
+	page, err = s.Admin.ListUsers(ctx, model.UserQuery{Disabled: &disabled})
+	require.NoError(t, err)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, citra.ID, page.Users[0].ID)
+	page, err = s.Admin.ListUsers(ctx, model.UserQuery{Role: model.RoleAdmin})
+	require.NoError(t, err)
+	require.Len(t, page.Users, 1)
+	assert.Equal(t, admin.ID, page.Users[0].ID)
+
+	stats, err := s.Admin.Stats(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, &model.SystemStats{
+		Users:           3,
+		Admins:          1,
+		DisabledUsers:   1,
+		UnverifiedUsers: 2,
+		PendingDeletion: 1,
+		NewUsers:        3,
+		Todos:           3,
+		CompletedTodos:  1,
+		OverdueTodos:    1,
+		ActiveSessions:  1,
+	}, stats)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"aplikasi-todolist/internal/apperror"
+)
+
+// Errors shared by every store implementation
+var (
+	errTodoNotFound  = apperror.NotFound("todo not found")
+	errTodoCompleted = apperror.Conflict("todo is already completed")
+	errItemNotFound  = apperror.NotFound("item not found")
+	errTagNotFound   = apperror.NotFound("tag not found")
+	errTagExists     = apperror.Conflict("a tag with this name already exists")
+	errListNotFound  = apperror.NotFound("list not found")
+	errListExists    = apperror.Conflict("a list with this name already exists")
+	errUserNotFound  = apperror.NotFound("user not found")
+	errUserExists    = apperror.Conflict("user with this email or username already exists")
+
+	errSessionNotFound      = apperror.NotFound("session not found")
+	errRefreshTokenNotFound = apperror.NotFound("refresh token not found")
+	errResetTokenNotFound   = apperror.NotFound("password reset token not found")
+
+	errTwoFactorNotFound    = apperror.NotFound("two-factor authentication not set up")
+	errTwoFactorEnabled     = apperror.Conflict("two-factor authentication is already enabled")
+	errMFAChallengeNotFound = apperror.NotFound("mfa challenge not found")
+
+	errAccessTokenNotFound = apperror.NotFound("access token not found")
+
+	errOIDCLoginNotFound = apperror.NotFound("oidc login not found")
+	errIdentityNotFound  = apperror.NotFound("identity not found")
+	errIdentityExists    = apperror.Conflict("the provider account is already linked")
+
+	errLoginAttemptsNotFound = apperror.NotFound("no failed logins recorded")
+
+	errReminderNotFound     = apperror.NotFound("reminder not found")
+	errNotificationNotFound = apperror.NotFound("notification not found")
+)
+
+// pgUniqueViolation is the PostgreSQL SQLSTATE for unique_violation
+const pgUniqueViolation = "23505"
+
+// isNoRows reports whether a query matched no rows
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows)
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgUniqueViolation
+	}
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
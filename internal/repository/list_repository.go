@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// ListRepository handles list-related database operations
+type ListRepository struct{}
+
+// listColumns are the columns scanned by scanList
+const listColumns = `lists.id, lists.user_id, lists.name, lists.color, lists.icon, lists.position, lists.archived,
+	lists.created_at, lists.updated_at`
+
+// ListLists retrieves the user's lists by position, with their todo counts
+func (r *ListRepository) ListLists(ctx context.Context, userID int, includeArchived bool) ([]*model.List, error) {
+	query := `
+		SELECT ` + listColumns + `, COUNT(todos.id)
+		FROM lists
+		LEFT JOIN todos ON todos.list_id = lists.id
+		WHERE lists.user_id = $1 AND ($2 OR NOT lists.archived)
+		GROUP BY lists.id
+		ORDER BY lists.position, lists.id
+	`
+
+	rows, err := DB.Query(ctx, query, userID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []*model.List{}
+	for rows.Next() {
+		var count int
+		list, err := scanList(rows, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan list: %w", err)
+		}
+		list.TodoCount = &count
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// GetList retrieves a list by ID and user ID
+func (r *ListRepository) GetList(ctx context.Context, listID, userID int) (*model.List, error) {
+	query := `SELECT ` + listColumns + ` FROM lists WHERE id = $1 AND user_id = $2`
+
+	list, err := scanList(DB.QueryRow(ctx, query, listID, userID))
+	if isNoRows(err) {
+		return nil, errListNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	return list, nil
+}
+
+// CreateList appends a list unless the user has one of the same name
+func (r *ListRepository) CreateList(ctx context.Context, list *model.List) error {
+	query := `
+		INSERT INTO lists (user_id, name, name_key, color, icon, position)
+		SELECT $1, $2, $3, $4, $5, COALESCE(MAX(position) + 1, 0)
+		FROM lists
+		WHERE user_id = $1
+		RETURNING id, position, created_at, updated_at
+	`
+
+	err := DB.QueryRow(ctx, query, list.UserID, list.Name, nameKey(list.Name), list.Color, list.Icon).
+		Scan(&list.ID, &list.Position, &list.CreatedAt, &list.UpdatedAt)
+	if isUniqueViolation(err) {
+		return errListExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create list: %w", err)
+	}
+
+	return nil
+}
+
+// EnsureList retrieves the user's list with the given name, appending it if missing
+func (r *ListRepository) EnsureList(ctx context.Context, userID int, name, color string) (*model.List, error) {
+	insert := `
+		INSERT INTO lists (user_id, name, name_key, color, position)
+		SELECT $1, $2, $3, $4, COALESCE(MAX(position) + 1, 0)
+		FROM lists
+		WHERE user_id = $1
+		ON CONFLICT (user_id, name_key) DO NOTHING
+	`
+	query := `SELECT ` + listColumns + ` FROM lists WHERE user_id = $1 AND name_key = $2`
+
+	key := nameKey(name)
+	if _, err := DB.Exec(ctx, insert, userID, name, key, color); err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+
+	list, err := scanList(DB.QueryRow(ctx, query, userID, key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	return list, nil
+}
+
+// UpdateList renames, recolors and archives a list
+func (r *ListRepository) UpdateList(ctx context.Context, list *model.List) error {
+	query := `
+		UPDATE lists
+		SET name = $1, name_key = $2, color = $3, icon = $4, archived = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6 AND user_id = $7
+		RETURNING position, created_at, updated_at
+	`
+
+	err := DB.QueryRow(ctx, query, list.Name, nameKey(list.Name), list.Color, list.Icon, list.Archived, list.ID, list.UserID).
+		Scan(&list.Position, &list.CreatedAt, &list.UpdatedAt)
+	if isUniqueViolation(err) {
+		return errListExists
+	}
+	if isNoRows(err) {
+		return errListNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update list: %w", err)
+	}
+
+	return nil
+}
+
+// ReorderLists moves each list to its index in listIDs in one statement
+func (r *ListRepository) ReorderLists(ctx context.Context, userID int, listIDs []int) error {
+	query := `
+		UPDATE lists
+		SET position = ordered.position - 1, updated_at = CURRENT_TIMESTAMP
+		FROM unnest($2::int[]) WITH ORDINALITY AS ordered(id, position)
+		WHERE lists.id = ordered.id AND lists.user_id = $1
+	`
+
+	commandTag, err := DB.Exec(ctx, query, userID, listIDs)
+	if err != nil {
+		return fmt.Errorf("failed to reorder lists: %w", err)
+	}
+	if commandTag.RowsAffected() != int64(len(listIDs)) {
+		return errListNotFound
+	}
+
+	return nil
+}
+
+// DeleteList moves or deletes the todos of a list and deletes the list in
+// one transaction
+func (r *ListRepository) DeleteList(ctx context.Context, listID, userID int, d model.ListDeletion) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A target equal to the list counts once, so it is rejected too
+	ids := []int{listID}
+	if d.MoveTo != nil {
+		ids = append(ids, *d.MoveTo)
+	}
+	var owned int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM lists WHERE id = ANY($1) AND user_id = $2`, ids, userID).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("failed to get lists: %w", err)
+	}
+	if owned != len(ids) {
+		return errListNotFound
+	}
+
+	if d.DeleteTodos {
+		_, err = tx.Exec(ctx, `DELETE FROM todos WHERE list_id = $1`, listID)
+	} else {
+		_, err = tx.Exec(ctx, `UPDATE todos SET list_id = $2 WHERE list_id = $1`, listID, d.MoveTo)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM lists WHERE id = $1`, listID); err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// scanList reads a row selected with listColumns, followed by any extra columns
+func scanList(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*model.List, error) {
+	var list model.List
+	dest := append([]interface{}{
+		&list.ID,
+		&list.UserID,
+		&list.Name,
+		&list.Color,
+		&list.Icon,
+		&list.Position,
+		&list.Archived,
+		&list.CreatedAt,
+		&list.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// LoginAttemptRepository handles failed login counter database operations
+type LoginAttemptRepository struct{}
+
+// GetLoginAttempts retrieves the counter of a key
+func (r *LoginAttemptRepository) GetLoginAttempts(ctx context.Context, key string) (*model.LoginAttempts, error) {
+	query := `
+		SELECT throttle_key, failures, last_failure_at, blocked_until
+		FROM login_attempts
+		WHERE throttle_key = $1
+	`
+
+	attempts, err := scanLoginAttempts(DB.QueryRow(ctx, query, key))
+	if isNoRows(err) {
+		return nil, errLoginAttemptsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// RecordLoginAttempt counts a login for a key as failed unless the key is
+// blocked or out of attempts
+func (r *LoginAttemptRepository) RecordLoginAttempt(ctx context.Context, key string, t, resetBefore time.Time, limit int) (*model.LoginAttempts, bool, error) {
+	query := `
+		INSERT INTO login_attempts (throttle_key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			blocked_until = CASE WHEN login_attempts.last_failure_at < $3 THEN NULL ELSE login_attempts.blocked_until END,
+			last_failure_at = $2
+		WHERE login_attempts.last_failure_at < $3
+			OR ((login_attempts.blocked_until IS NULL OR login_attempts.blocked_until <= $2) AND login_attempts.failures < $4)
+		RETURNING throttle_key, failures, last_failure_at, blocked_until
+	`
+
+	attempts, err := scanLoginAttempts(DB.QueryRow(ctx, query, key, t.UTC(), resetBefore.UTC(), limit))
+	if isNoRows(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return attempts, true, nil
+}
+
+// ForgiveLoginFailure takes back a counted attempt of a key
+func (r *LoginAttemptRepository) ForgiveLoginFailure(ctx context.Context, key string) error {
+	query := `UPDATE login_attempts SET failures = failures - 1 WHERE throttle_key = $1 AND failures > 0`
+
+	if _, err := DB.Exec(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to forgive login failure: %w", err)
+	}
+
+	return nil
+}
+
+// BlockLogin refuses logins for a key until the given time
+func (r *LoginAttemptRepository) BlockLogin(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET blocked_until = $2 WHERE throttle_key = $1`
+
+	commandTag, err := DB.Exec(ctx, query, key, until.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to block login: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errLoginAttemptsNotFound
+	}
+
+	return nil
+}
+
+// ClearLoginAttempts deletes the counter of a key
+func (r *LoginAttemptRepository) ClearLoginAttempts(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE throttle_key = $1`
+
+	if _, err := DB.Exec(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginAttempts deletes the counters with neither a failure nor a block after t
+func (r *LoginAttemptRepository) DeleteStaleLoginAttempts(ctx context.Context, t time.Time) error {
+	query := `
+		DELETE FROM login_attempts
+		WHERE last_failure_at < $1 AND (blocked_until IS NULL OR blocked_until < $1)
+	`
+
+	if _, err := DB.Exec(ctx, query, t.UTC()); err != nil {
+		return fmt.Errorf("failed to delete stale login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func scanLoginAttempts(row interface{ Scan(...interface{}) error }) (*model.LoginAttempts, error) {
+	var attempts model.LoginAttempts
+	err := row.Scan(
+		&attempts.Key,
+		&attempts.Failures,
+		&attempts.LastFailureAt,
+		&attempts.BlockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryAccessTokenRepository is an in-memory AccessTokenStore used for development and tests
+type MemoryAccessTokenRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	tokens map[int]*model.PersonalAccessToken
+}
+
+// NewMemoryAccessTokenRepository creates an empty in-memory access token repository
+func NewMemoryAccessTokenRepository() *MemoryAccessTokenRepository {
+	return &MemoryAccessTokenRepository{
+		nextID: 1,
+		tokens: make(map[int]*model.PersonalAccessToken),
+	}
+}
+
+// CreateAccessToken stores a new personal access token
+func (r *MemoryAccessTokenRepository) CreateAccessToken(ctx context.Context, token *model.PersonalAccessToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = r.nextID
+	r.nextID++
+	token.CreatedAt = time.Now().UTC()
+	r.tokens[token.ID] = copyAccessToken(token)
+	return nil
+}
+
+// GetAccessTokenByHash retrieves a personal access token by the hash of its value
+func (r *MemoryAccessTokenRepository) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return copyAccessToken(token), nil
+		}
+	}
+	return nil, errAccessTokenNotFound
+}
+
+// ListAccessTokens retrieves the user's personal access tokens, newest first
+func (r *MemoryAccessTokenRepository) ListAccessTokens(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tokens []*model.PersonalAccessToken
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, copyAccessToken(token))
+		}
+	}
+
+	sort.Slice(tokens, func(i, j int) bool {
+		if !tokens[i].CreatedAt.Equal(tokens[j].CreatedAt) {
+			return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+		}
+		return tokens[i].ID > tokens[j].ID
+	})
+	return tokens, nil
+}
+
+// TouchAccessToken records when the token was last used
+func (r *MemoryAccessTokenRepository) TouchAccessToken(ctx context.Context, tokenID int, usedAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenID]
+	if !ok {
+		return errAccessTokenNotFound
+	}
+
+	usedAt = usedAt.UTC()
+	token.LastUsedAt = &usedAt
+	return nil
+}
+
+// DeleteAccessToken deletes one of the user's personal access tokens
+func (r *MemoryAccessTokenRepository) DeleteAccessToken(ctx context.Context, tokenID, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenID]
+	if !ok || token.UserID != userID {
+		return errAccessTokenNotFound
+	}
+
+	delete(r.tokens, tokenID)
+	return nil
+}
+
+func copyAccessToken(token *model.PersonalAccessToken) *model.PersonalAccessToken {
+	c := *token
+	c.Scopes = append([]string(nil), token.Scopes...)
+	c.ExpiresAt = copyTime(token.ExpiresAt)
+	c.LastUsedAt = copyTime(token.LastUsedAt)
+	return &c
+}
+
+// deleteUser deletes the personal access tokens of a purged user
+func (r *MemoryAccessTokenRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserID == userID {
+			delete(r.tokens, id)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryAdminRepository is an in-memory AdminStore reading the other
+// in-memory stores
+type MemoryAdminRepository struct {
+	users  *MemoryUserRepository
+	todos  *MemoryTodoRepository
+	tokens *MemoryTokenRepository
+}
+
+// NewMemoryAdminRepository creates an admin repository over the given stores
+func NewMemoryAdminRepository(users *MemoryUserRepository, todos *MemoryTodoRepository, tokens *MemoryTokenRepository) *MemoryAdminRepository {
+	return &MemoryAdminRepository{users: users, todos: todos, tokens: tokens}
+}
+
+// ListUsers retrieves one page of users matching the query with the number
+// of their todos, newest first
+func (r *MemoryAdminRepository) ListUsers(ctx context.Context, q model.UserQuery) (*model.UserPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type counts struct{ total, done int }
+	todoCounts := make(map[int]counts)
+	r.todos.mu.RLock()
+	for _, todo := range r.todos.todos {
+		c := todoCounts[todo.UserID]
+		c.total++
+		if todo.IsDone {
+			c.done++
+		}
+		todoCounts[todo.UserID] = c
+	}
+	r.todos.mu.RUnlock()
+
+	r.users.mu.RLock()
+	var users []*model.UserSummary
+	for _, user := range r.users.users {
+		if !matchesUserQuery(q, user) {
+			continue
+		}
+		u := &model.UserSummary{
+			User:               *user,
+			TodoCount:          todoCounts[user.ID].total,
+			CompletedTodoCount: todoCounts[user.ID].done,
+		}
+		u.Password = ""
+		u.EmailVerifiedAt = copyTime(user.EmailVerifiedAt)
+		u.DisabledAt = copyTime(user.DisabledAt)
+		u.DeleteAfter = copyTime(user.DeleteAfter)
+		users = append(users, u)
+	}
+	r.users.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+
+	page := &model.UserPage{Users: []*model.UserSummary{}, Total: len(users)}
+	page.Users = append(page.Users, paginate(users, q.Limit, q.Offset)...)
+	return page, nil
+}
+
+// Stats computes the instance-wide numbers as of now
+func (r *MemoryAdminRepository) Stats(ctx context.Context, now time.Time) (*model.SystemStats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var stats model.SystemStats
+
+	r.users.mu.RLock()
+	for _, user := range r.users.users {
+		stats.Users++
+		if user.Role == model.RoleAdmin {
+			stats.Admins++
+		}
+		if user.DisabledAt != nil {
+			stats.DisabledUsers++
+		}
+		if user.EmailVerifiedAt == nil {
+			stats.UnverifiedUsers++
+		}
+		if user.DeleteAfter != nil {
+			stats.PendingDeletion++
+		}
+		if !user.CreatedAt.Before(now.Add(-newUserWindow)) {
+			stats.NewUsers++
+		}
+	}
+	r.users.mu.RUnlock()
+
+	r.todos.mu.RLock()
+	for _, todo := range r.todos.todos {
+		stats.Todos++
+		if todo.IsDone {
+			stats.CompletedTodos++
+		} else if todo.DueDate != nil && todo.DueDate.Before(now) {
+			stats.OverdueTodos++
+		}
+	}
+	r.todos.mu.RUnlock()
+
+	r.tokens.mu.RLock()
+	for _, session := range r.tokens.sessions {
+		if session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			stats.ActiveSessions++
+		}
+	}
+	r.tokens.mu.RUnlock()
+
+	return &stats, nil
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryListRepository is an in-memory ListStore used for development and
+// tests. The todos of the lists are kept by the todo repository.
+type MemoryListRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	lists  map[int]*model.List
+	todos  *MemoryTodoRepository
+}
+
+// NewMemoryListRepository creates an empty in-memory list repository over
+// the given todos
+func NewMemoryListRepository(todos *MemoryTodoRepository) *MemoryListRepository {
+	return &MemoryListRepository{
+		nextID: 1,
+		lists:  make(map[int]*model.List),
+		todos:  todos,
+	}
+}
+
+// ListLists retrieves the user's lists by position, with their todo counts
+func (r *MemoryListRepository) ListLists(ctx context.Context, userID int, includeArchived bool) ([]*model.List, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	counts := r.todos.countLists(userID)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lists := []*model.List{}
+	for _, list := range r.lists {
+		if list.UserID != userID || (list.Archived && !includeArchived) {
+			continue
+		}
+		c := *list
+		count := counts[list.ID]
+		c.TodoCount = &count
+		lists = append(lists, &c)
+	}
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].Position == lists[j].Position {
+			return lists[i].ID < lists[j].ID
+		}
+		return lists[i].Position < lists[j].Position
+	})
+
+	return lists, nil
+}
+
+// GetList retrieves a list by ID and user ID
+func (r *MemoryListRepository) GetList(ctx context.Context, listID, userID int) (*model.List, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list, ok := r.lists[listID]
+	if !ok || list.UserID != userID {
+		return nil, errListNotFound
+	}
+
+	c := *list
+	return &c, nil
+}
+
+// CreateList appends a list unless the user has one of the same name
+func (r *MemoryListRepository) CreateList(ctx context.Context, list *model.List) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findList(list.UserID, list.Name) != nil {
+		return errListExists
+	}
+	r.addList(list)
+	return nil
+}
+
+// EnsureList retrieves the user's list with the given name, appending it if missing
+func (r *MemoryListRepository) EnsureList(ctx context.Context, userID int, name, color string) (*model.List, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := r.findList(userID, name)
+	if list == nil {
+		list = &model.List{UserID: userID, Name: name, Color: color}
+		r.addList(list)
+	}
+
+	c := *list
+	return &c, nil
+}
+
+// UpdateList renames, recolors and archives a list
+func (r *MemoryListRepository) UpdateList(ctx context.Context, list *model.List) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.lists[list.ID]
+	if !ok || existing.UserID != list.UserID {
+		return errListNotFound
+	}
+	if other := r.findList(list.UserID, list.Name); other != nil && other.ID != list.ID {
+		return errListExists
+	}
+
+	existing.Name = list.Name
+	existing.Color = list.Color
+	existing.Icon = list.Icon
+	existing.Archived = list.Archived
+	existing.UpdatedAt = time.Now()
+
+	list.Position = existing.Position
+	list.CreatedAt = existing.CreatedAt
+	list.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+// ReorderLists moves each list to its index in listIDs, changing nothing if
+// one of them isn't a list of the user
+func (r *MemoryListRepository) ReorderLists(ctx context.Context, userID int, listIDs []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range listIDs {
+		if list, ok := r.lists[id]; !ok || list.UserID != userID {
+			return errListNotFound
+		}
+	}
+
+	now := time.Now()
+	for position, id := range listIDs {
+		r.lists[id].Position = position
+		r.lists[id].UpdatedAt = now
+	}
+	return nil
+}
+
+// DeleteList deletes a list after moving or deleting its todos
+func (r *MemoryListRepository) DeleteList(ctx context.Context, listID, userID int, d model.ListDeletion) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	list, ok := r.lists[listID]
+	if !ok || list.UserID != userID {
+		r.mu.Unlock()
+		return errListNotFound
+	}
+	if d.MoveTo != nil {
+		if target, ok := r.lists[*d.MoveTo]; !ok || target.UserID != userID || target.ID == listID {
+			r.mu.Unlock()
+			return errListNotFound
+		}
+	}
+	delete(r.lists, listID)
+	r.mu.Unlock()
+
+	r.todos.deleteList(listID, d)
+	return nil
+}
+
+// findList returns the user's list named name in any case, or nil. The
+// caller must hold the lock.
+func (r *MemoryListRepository) findList(userID int, name string) *model.List {
+	key := nameKey(name)
+	for _, list := range r.lists {
+		if list.UserID == userID && nameKey(list.Name) == key {
+			return list
+		}
+	}
+	return nil
+}
+
+// addList stores a new list after the user's other lists. The caller must
+// hold the lock.
+func (r *MemoryListRepository) addList(list *model.List) {
+	list.Position = 0
+	for _, other := range r.lists {
+		if other.UserID == list.UserID && other.Position >= list.Position {
+			list.Position = other.Position + 1
+		}
+	}
+
+	now := time.Now()
+	list.ID = r.nextID
+	list.CreatedAt = now
+	list.UpdatedAt = now
+	r.nextID++
+
+	c := *list
+	c.TodoCount = nil
+	r.lists[list.ID] = &c
+}
+
+// deleteUser deletes the lists of a purged user
+func (r *MemoryListRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, list := range r.lists {
+		if list.UserID == userID {
+			delete(r.lists, id)
+		}
+	}
+}
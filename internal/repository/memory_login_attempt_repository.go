@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryLoginAttemptRepository is an in-memory LoginAttemptStore used for development and tests
+type MemoryLoginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]*model.LoginAttempts
+}
+
+// NewMemoryLoginAttemptRepository creates an empty in-memory login attempt repository
+func NewMemoryLoginAttemptRepository() *MemoryLoginAttemptRepository {
+	return &MemoryLoginAttemptRepository{
+		attempts: make(map[string]*model.LoginAttempts),
+	}
+}
+
+// GetLoginAttempts retrieves the counter of a key
+func (r *MemoryLoginAttemptRepository) GetLoginAttempts(ctx context.Context, key string) (*model.LoginAttempts, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	if !ok {
+		return nil, errLoginAttemptsNotFound
+	}
+	return copyLoginAttempts(attempts), nil
+}
+
+// RecordLoginAttempt counts a login for a key as failed unless the key is
+// blocked or out of attempts
+func (r *MemoryLoginAttemptRepository) RecordLoginAttempt(ctx context.Context, key string, t, resetBefore time.Time, limit int) (*model.LoginAttempts, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	switch {
+	case !ok || attempts.LastFailureAt.Before(resetBefore):
+		attempts = &model.LoginAttempts{Key: key}
+		r.attempts[key] = attempts
+	case attempts.BlockedUntil != nil && attempts.BlockedUntil.After(t), attempts.Failures >= limit:
+		return nil, false, nil
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = t.UTC()
+	return copyLoginAttempts(attempts), true, nil
+}
+
+// ForgiveLoginFailure takes back a counted attempt of a key
+func (r *MemoryLoginAttemptRepository) ForgiveLoginFailure(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if attempts, ok := r.attempts[key]; ok && attempts.Failures > 0 {
+		attempts.Failures--
+	}
+	return nil
+}
+
+// BlockLogin refuses logins for a key until the given time
+func (r *MemoryLoginAttemptRepository) BlockLogin(ctx context.Context, key string, until time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts, ok := r.attempts[key]
+	if !ok {
+		return errLoginAttemptsNotFound
+	}
+	until = until.UTC()
+	attempts.BlockedUntil = &until
+	return nil
+}
+
+// ClearLoginAttempts deletes the counter of a key
+func (r *MemoryLoginAttemptRepository) ClearLoginAttempts(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// DeleteStaleLoginAttempts deletes the counters with neither a failure nor a block after t
+func (r *MemoryLoginAttemptRepository) DeleteStaleLoginAttempts(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, attempts := range r.attempts {
+		if attempts.LastFailureAt.Before(t) && (attempts.BlockedUntil == nil || attempts.BlockedUntil.Before(t)) {
+			delete(r.attempts, key)
+		}
+	}
+	return nil
+}
+
+func copyLoginAttempts(attempts *model.LoginAttempts) *model.LoginAttempts {
+	c := *attempts
+	c.BlockedUntil = copyTime(attempts.BlockedUntil)
+	return &c
+}
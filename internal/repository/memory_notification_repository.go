@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryNotificationRepository is an in-memory NotificationStore used for development and tests
+type MemoryNotificationRepository struct {
+	mu            sync.RWMutex
+	nextID        int
+	notifications map[int]*model.Notification
+}
+
+// NewMemoryNotificationRepository creates an empty in-memory notification repository
+func NewMemoryNotificationRepository() *MemoryNotificationRepository {
+	return &MemoryNotificationRepository{
+		nextID:        1,
+		notifications: make(map[int]*model.Notification),
+	}
+}
+
+// CreateNotification stores a new notification
+func (r *MemoryNotificationRepository) CreateNotification(ctx context.Context, notification *model.Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notification.ID = r.nextID
+	notification.CreatedAt = time.Now()
+	r.nextID++
+
+	r.notifications[notification.ID] = copyNotification(notification)
+	return nil
+}
+
+// ListNotifications retrieves a user's notifications, newest first
+func (r *MemoryNotificationRepository) ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit int) ([]*model.Notification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := []*model.Notification{}
+	for _, n := range r.notifications {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, copyNotification(n))
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		if notifications[i].CreatedAt.Equal(notifications[j].CreatedAt) {
+			return notifications[i].ID > notifications[j].ID
+		}
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+	if limit > 0 && len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead marks a notification of the user read
+func (r *MemoryNotificationRepository) MarkNotificationRead(ctx context.Context, notificationID, userID int, readAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, ok := r.notifications[notificationID]
+	if !ok || n.UserID != userID {
+		return errNotificationNotFound
+	}
+	if n.ReadAt == nil {
+		n.ReadAt = &readAt
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user read
+func (r *MemoryNotificationRepository) MarkAllNotificationsRead(ctx context.Context, userID int, readAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			n.ReadAt = &readAt
+		}
+	}
+	return nil
+}
+
+// deleteTodo unlinks the notifications of a deleted todo
+func (r *MemoryNotificationRepository) deleteTodo(todoID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, n := range r.notifications {
+		if n.TodoID != nil && *n.TodoID == todoID {
+			n.TodoID = nil
+		}
+	}
+}
+
+// deleteUser deletes the notifications of a deleted user
+func (r *MemoryNotificationRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, n := range r.notifications {
+		if n.UserID == userID {
+			delete(r.notifications, id)
+		}
+	}
+}
+
+func copyNotification(n *model.Notification) *model.Notification {
+	c := *n
+	c.TodoID = copyInt(n.TodoID)
+	c.ReadAt = copyTime(n.ReadAt)
+	return &c
+}
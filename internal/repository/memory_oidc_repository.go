@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryOIDCRepository is an in-memory OIDCStore used for development and tests
+type MemoryOIDCRepository struct {
+	mu         sync.RWMutex
+	nextID     int
+	logins     map[string]*model.OIDCLogin
+	identities map[int]*model.UserIdentity
+}
+
+// NewMemoryOIDCRepository creates an empty in-memory OIDC repository
+func NewMemoryOIDCRepository() *MemoryOIDCRepository {
+	return &MemoryOIDCRepository{
+		nextID:     1,
+		logins:     make(map[string]*model.OIDCLogin),
+		identities: make(map[int]*model.UserIdentity),
+	}
+}
+
+// CreateOIDCLogin stores a login started with a provider
+func (r *MemoryOIDCRepository) CreateOIDCLogin(ctx context.Context, login *model.OIDCLogin) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	login.ID = r.nextID
+	r.nextID++
+	login.CreatedAt = time.Now().UTC()
+	c := *login
+	r.logins[login.StateHash] = &c
+	return nil
+}
+
+// TakeOIDCLogin deletes and returns the login with the given state hash
+func (r *MemoryOIDCRepository) TakeOIDCLogin(ctx context.Context, stateHash string) (*model.OIDCLogin, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	login, ok := r.logins[stateHash]
+	if !ok {
+		return nil, errOIDCLoginNotFound
+	}
+
+	delete(r.logins, stateHash)
+	return login, nil
+}
+
+// DeleteExpiredOIDCLogins deletes the logins that expired before t
+func (r *MemoryOIDCRepository) DeleteExpiredOIDCLogins(ctx context.Context, t time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, login := range r.logins {
+		if login.ExpiresAt.Before(t) {
+			delete(r.logins, hash)
+		}
+	}
+	return nil
+}
+
+// GetIdentity retrieves the link of a provider account
+func (r *MemoryOIDCRepository) GetIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			c := *identity
+			return &c, nil
+		}
+	}
+	return nil, errIdentityNotFound
+}
+
+// CreateIdentity links a provider account to a user
+func (r *MemoryOIDCRepository) CreateIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.identities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return errIdentityExists
+		}
+	}
+
+	identity.ID = r.nextID
+	r.nextID++
+	identity.CreatedAt = time.Now().UTC()
+	c := *identity
+	r.identities[identity.ID] = &c
+	return nil
+}
+
+// deleteUser unlinks the provider accounts of a purged user
+func (r *MemoryOIDCRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, identity := range r.identities {
+		if identity.UserID == userID {
+			delete(r.identities, id)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryReminderRepository is an in-memory ReminderStore used for
+// development and tests. It reads the todos and users of the other in-memory
+// stores to claim reminders.
+type MemoryReminderRepository struct {
+	mu        sync.RWMutex
+	nextID    int
+	reminders map[int]*model.Reminder
+	// lockedUntil holds the leases of claimed reminders
+	lockedUntil map[int]time.Time
+	todos       *MemoryTodoRepository
+	users       *MemoryUserRepository
+}
+
+// NewMemoryReminderRepository creates an empty in-memory reminder repository
+// for the todos and users of the given stores
+func NewMemoryReminderRepository(todos *MemoryTodoRepository, users *MemoryUserRepository) *MemoryReminderRepository {
+	return &MemoryReminderRepository{
+		nextID:      1,
+		reminders:   make(map[int]*model.Reminder),
+		lockedUntil: make(map[int]time.Time),
+		todos:       todos,
+		users:       users,
+	}
+}
+
+// ListTodoReminders retrieves the reminders of the todos, oldest first
+func (r *MemoryReminderRepository) ListTodoReminders(ctx context.Context, todoIDs []int) (map[int][]*model.Reminder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(todoIDs))
+	for _, id := range todoIDs {
+		wanted[id] = true
+	}
+	reminders := make(map[int][]*model.Reminder)
+	for _, reminder := range r.reminders {
+		if wanted[reminder.TodoID] {
+			reminders[reminder.TodoID] = append(reminders[reminder.TodoID], copyReminder(reminder))
+		}
+	}
+	for _, list := range reminders {
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	}
+
+	return reminders, nil
+}
+
+// CreateReminder stores a new reminder
+func (r *MemoryReminderRepository) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reminder.ID = r.nextID
+	reminder.CreatedAt = time.Now()
+	r.nextID++
+
+	r.reminders[reminder.ID] = copyReminder(reminder)
+	return nil
+}
+
+// DeleteReminder deletes a reminder of a todo
+func (r *MemoryReminderRepository) DeleteReminder(ctx context.Context, todoID, reminderID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reminder, ok := r.reminders[reminderID]
+	if !ok || reminder.TodoID != todoID {
+		return errReminderNotFound
+	}
+
+	delete(r.reminders, reminderID)
+	delete(r.lockedUntil, reminderID)
+	return nil
+}
+
+// RescheduleReminders schedules the relative reminders of a todo before its
+// new due date
+func (r *MemoryReminderRepository) RescheduleReminders(ctx context.Context, todoID int, dueDate *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reschedule(todoID, dueDate)
+	return nil
+}
+
+// reschedule schedules the relative reminders of a todo; r.mu must be held
+func (r *MemoryReminderRepository) reschedule(todoID int, dueDate *time.Time) {
+	for id, reminder := range r.reminders {
+		if reminder.TodoID != todoID || reminder.OffsetMinutes == nil {
+			continue
+		}
+		reminder.FireAt = nil
+		if dueDate != nil {
+			fireAt := reminderFireAt(*dueDate, *reminder.OffsetMinutes)
+			reminder.FireAt = &fireAt
+		}
+		reminder.SentAt = nil
+		reminder.FailedAt = nil
+		reminder.Attempts = 0
+		reminder.LastError = ""
+		delete(r.lockedUntil, id)
+	}
+}
+
+// ClaimDueReminders leases pending reminders that are due
+func (r *MemoryReminderRepository) ClaimDueReminders(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.ReminderDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*model.Reminder
+	for id, reminder := range r.reminders {
+		if reminder.FireAt == nil || reminder.FireAt.After(now) || reminder.SentAt != nil || reminder.FailedAt != nil {
+			continue
+		}
+		if lockedUntil, ok := r.lockedUntil[id]; ok && lockedUntil.After(now) {
+			continue
+		}
+		due = append(due, reminder)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].FireAt.Equal(*due[j].FireAt) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].FireAt.Before(*due[j].FireAt)
+	})
+
+	r.todos.mu.RLock()
+	defer r.todos.mu.RUnlock()
+	r.users.mu.RLock()
+	defer r.users.mu.RUnlock()
+
+	var deliveries []*model.ReminderDelivery
+	for _, reminder := range due {
+		if len(deliveries) == limit {
+			break
+		}
+		todo, ok := r.todos.todos[reminder.TodoID]
+		if !ok || todo.IsDone {
+			continue
+		}
+		user, ok := r.users.users[todo.UserID]
+		if !ok || user.DisabledAt != nil || user.DeleteAfter != nil {
+			continue
+		}
+
+		reminder.Attempts++
+		r.lockedUntil[reminder.ID] = leaseUntil
+		deliveries = append(deliveries, &model.ReminderDelivery{
+			Reminder:  copyReminder(reminder),
+			UserID:    user.ID,
+			Username:  user.Username,
+			Email:     user.Email,
+			TodoTitle: todo.Title,
+			DueDate:   copyTime(todo.DueDate),
+		})
+	}
+
+	return deliveries, nil
+}
+
+// MarkReminderSent records that a reminder was delivered
+func (r *MemoryReminderRepository) MarkReminderSent(ctx context.Context, reminderID int, fireAt, sentAt time.Time) error {
+	return r.finish(ctx, reminderID, fireAt, func(reminder *model.Reminder) {
+		reminder.SentAt = &sentAt
+		reminder.LastError = ""
+		delete(r.lockedUntil, reminderID)
+	})
+}
+
+// RetryReminder records a failed delivery and leases the reminder until the
+// next attempt
+func (r *MemoryReminderRepository) RetryReminder(ctx context.Context, reminderID int, fireAt, retryAt time.Time, lastError string) error {
+	return r.finish(ctx, reminderID, fireAt, func(reminder *model.Reminder) {
+		reminder.LastError = lastError
+		r.lockedUntil[reminderID] = retryAt
+	})
+}
+
+// FailReminder records a failed delivery and gives up on the reminder
+func (r *MemoryReminderRepository) FailReminder(ctx context.Context, reminderID int, fireAt, failedAt time.Time, lastError string) error {
+	return r.finish(ctx, reminderID, fireAt, func(reminder *model.Reminder) {
+		reminder.FailedAt = &failedAt
+		reminder.LastError = lastError
+		delete(r.lockedUntil, reminderID)
+	})
+}
+
+// finish applies the outcome of a delivery to the reminder unless it was
+// deleted or rescheduled meanwhile
+func (r *MemoryReminderRepository) finish(ctx context.Context, reminderID int, fireAt time.Time, apply func(*model.Reminder)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reminder, ok := r.reminders[reminderID]
+	if ok && reminder.FireAt != nil && reminder.FireAt.Equal(fireAt) {
+		apply(reminder)
+	}
+	return nil
+}
+
+// deleteTodo deletes the reminders of a deleted todo
+func (r *MemoryReminderRepository) deleteTodo(todoID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, reminder := range r.reminders {
+		if reminder.TodoID == todoID {
+			delete(r.reminders, id)
+			delete(r.lockedUntil, id)
+		}
+	}
+}
+
+// copyTodo adds the relative reminders of a todo to its next occurrence,
+// scheduled before the due date of the next occurrence
+func (r *MemoryReminderRepository) copyTodo(todoID, nextID int) {
+	r.todos.mu.RLock()
+	var dueDate *time.Time
+	if next, ok := r.todos.todos[nextID]; ok {
+		dueDate = copyTime(next.DueDate)
+	}
+	r.todos.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sources []*model.Reminder
+	for _, reminder := range r.reminders {
+		if reminder.TodoID == todoID && reminder.OffsetMinutes != nil {
+			sources = append(sources, reminder)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].ID < sources[j].ID })
+
+	now := time.Now()
+	for _, source := range sources {
+		reminder := &model.Reminder{
+			ID:            r.nextID,
+			TodoID:        nextID,
+			OffsetMinutes: copyInt(source.OffsetMinutes),
+			Channels:      append([]string(nil), source.Channels...),
+			WebhookURL:    source.WebhookURL,
+			CreatedAt:     now,
+		}
+		r.nextID++
+		r.reminders[reminder.ID] = reminder
+	}
+	r.reschedule(nextID, dueDate)
+}
+
+func copyReminder(reminder *model.Reminder) *model.Reminder {
+	c := *reminder
+	c.RemindAt = copyTime(reminder.RemindAt)
+	c.OffsetMinutes = copyInt(reminder.OffsetMinutes)
+	c.Channels = append([]string(nil), reminder.Channels...)
+	c.FireAt = copyTime(reminder.FireAt)
+	c.SentAt = copyTime(reminder.SentAt)
+	c.FailedAt = copyTime(reminder.FailedAt)
+	return &c
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"aplikasi-todolist/internal/model"
+)
+
+func TestMemoryTodoRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryTodoRepository()
+
+	todo := &model.Todo{UserID: 1, Title: "Write report"}
+	require.NoError(t, repo.CreateTodo(ctx, todo))
+	assert.Equal(t, 1, todo.ID)
+	assert.Nil(t, todo.ListID)
+	assert.Equal(t, DefaultPriority, todo.Priority)
+	assert.False(t, todo.CreatedAt.IsZero())
+
+	// Other users can't see, update or delete the todo
+	_, err := repo.GetTodoByID(ctx, todo.ID, 2)
+	assert.Error(t, err)
+	assert.Error(t, repo.UpdateTodo(ctx, &model.Todo{ID: todo.ID, UserID: 2, Title: "Hijacked"}))
+	assert.Error(t, repo.DeleteTodo(ctx, todo.ID, 2))
+
+	due := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	todo.IsDone = true
+	todo.DueDate = &due
+	require.NoError(t, repo.UpdateTodo(ctx, todo))
+
+	got, err := repo.GetTodoByID(ctx, todo.ID, 1)
+	require.NoError(t, err)
+	assert.True(t, got.IsDone)
+	assert.Equal(t, due, *got.DueDate)
+
+	// Returned todos are copies
+	got.Title = "Changed"
+	again, _ := repo.GetTodoByID(ctx, todo.ID, 1)
+	assert.Equal(t, "Write report", again.Title)
+
+	second := &model.Todo{UserID: 1, Title: "Second"}
+	require.NoError(t, repo.CreateTodo(ctx, second))
+	todos, err := repo.GetTodosByUserID(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, todos, 2)
+	assert.Equal(t, second.ID, todos[0].ID)
+
+	require.NoError(t, repo.DeleteTodo(ctx, todo.ID, 1))
+	_, err = repo.GetTodoByID(ctx, todo.ID, 1)
+	assert.Error(t, err)
+}
+
+func TestMemoryUserRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+
+	user := &model.User{Username: "budi", Email: "budi@example.com", Password: "hash"}
+	require.NoError(t, repo.CreateUser(ctx, user))
+	assert.Equal(t, 1, user.ID)
+
+	exists, err := repo.UserExists(ctx, "other@example.com", "budi")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.Error(t, repo.CreateUser(ctx, &model.User{Username: "other", Email: "budi@example.com"}))
+
+	byEmail, err := repo.GetUserByEmail(ctx, "budi@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", byEmail.Password)
+
+	byID, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, byID.Password)
+
+	_, err = repo.GetUserByID(ctx, 42)
+	assert.Error(t, err)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = repo.GetUserByID(cancelled, user.ID)
+	assert.ErrorIs(t, err, context.Canceled)
+}
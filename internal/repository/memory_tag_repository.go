@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryTagRepository is an in-memory TagStore used for development and tests
+type MemoryTagRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	tags   map[int]*model.Tag
+	// todoTags holds the set of tag IDs of each todo
+	todoTags map[int]map[int]bool
+}
+
+// NewMemoryTagRepository creates an empty in-memory tag repository
+func NewMemoryTagRepository() *MemoryTagRepository {
+	return &MemoryTagRepository{
+		nextID:   1,
+		tags:     make(map[int]*model.Tag),
+		todoTags: make(map[int]map[int]bool),
+	}
+}
+
+// ListTags retrieves the user's tags by name, with their todo counts
+func (r *MemoryTagRepository) ListTags(ctx context.Context, userID int) ([]*model.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, tagIDs := range r.todoTags {
+		for id := range tagIDs {
+			counts[id]++
+		}
+	}
+
+	tags := []*model.Tag{}
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			c := *tag
+			count := counts[tag.ID]
+			c.TodoCount = &count
+			tags = append(tags, &c)
+		}
+	}
+	sortTags(tags)
+
+	return tags, nil
+}
+
+// GetTag retrieves a tag by ID and user ID
+func (r *MemoryTagRepository) GetTag(ctx context.Context, tagID, userID int) (*model.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[tagID]
+	if !ok || tag.UserID != userID {
+		return nil, errTagNotFound
+	}
+
+	c := *tag
+	return &c, nil
+}
+
+// CreateTag creates a tag unless the user has one of the same name
+func (r *MemoryTagRepository) CreateTag(ctx context.Context, tag *model.Tag) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findTag(tag.UserID, tag.Name) != nil {
+		return errTagExists
+	}
+	r.addTag(tag)
+	return nil
+}
+
+// EnsureTags retrieves the user's tags with the given names, creating the missing ones
+func (r *MemoryTagRepository) EnsureTags(ctx context.Context, userID int, names []string, color string) ([]*model.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tags := make([]*model.Tag, 0, len(names))
+	for _, name := range names {
+		tag := r.findTag(userID, name)
+		if tag == nil {
+			tag = &model.Tag{UserID: userID, Name: name, Color: color}
+			r.addTag(tag)
+		}
+		c := *tag
+		tags = append(tags, &c)
+	}
+
+	return tags, nil
+}
+
+// UpdateTag renames and recolors a tag
+func (r *MemoryTagRepository) UpdateTag(ctx context.Context, tag *model.Tag) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tags[tag.ID]
+	if !ok || existing.UserID != tag.UserID {
+		return errTagNotFound
+	}
+	if other := r.findTag(tag.UserID, tag.Name); other != nil && other.ID != tag.ID {
+		return errTagExists
+	}
+
+	existing.Name = tag.Name
+	existing.Color = tag.Color
+	return nil
+}
+
+// DeleteTag deletes a tag, removing it from its todos
+func (r *MemoryTagRepository) DeleteTag(ctx context.Context, tagID, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tag, ok := r.tags[tagID]
+	if !ok || tag.UserID != userID {
+		return errTagNotFound
+	}
+
+	r.removeTag(tagID)
+	return nil
+}
+
+// MergeTag moves the todos of one tag to another and deletes the first
+func (r *MemoryTagRepository) MergeTag(ctx context.Context, sourceID, targetID, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range []int{sourceID, targetID} {
+		if tag, ok := r.tags[id]; !ok || tag.UserID != userID {
+			return errTagNotFound
+		}
+	}
+
+	for _, tagIDs := range r.todoTags {
+		if tagIDs[sourceID] {
+			tagIDs[targetID] = true
+		}
+	}
+	r.removeTag(sourceID)
+	return nil
+}
+
+// ListTodoTags retrieves the tags of the todos, by name
+func (r *MemoryTagRepository) ListTodoTags(ctx context.Context, todoIDs []int) (map[int][]*model.Tag, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make(map[int][]*model.Tag)
+	for _, todoID := range todoIDs {
+		for id := range r.todoTags[todoID] {
+			c := *r.tags[id]
+			tags[todoID] = append(tags[todoID], &c)
+		}
+		sortTags(tags[todoID])
+	}
+
+	return tags, nil
+}
+
+// SetTodoTags replaces the tags of a todo
+func (r *MemoryTagRepository) SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(tagIDs) == 0 {
+		delete(r.todoTags, todoID)
+		return nil
+	}
+
+	set := make(map[int]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		if _, ok := r.tags[id]; !ok {
+			return errTagNotFound
+		}
+		set[id] = true
+	}
+	r.todoTags[todoID] = set
+	return nil
+}
+
+// findTag returns the user's tag named name in any case, or nil. The caller
+// must hold the lock.
+func (r *MemoryTagRepository) findTag(userID int, name string) *model.Tag {
+	key := nameKey(name)
+	for _, tag := range r.tags {
+		if tag.UserID == userID && nameKey(tag.Name) == key {
+			return tag
+		}
+	}
+	return nil
+}
+
+// addTag stores a new tag. The caller must hold the lock.
+func (r *MemoryTagRepository) addTag(tag *model.Tag) {
+	tag.ID = r.nextID
+	r.nextID++
+
+	c := *tag
+	c.TodoCount = nil
+	r.tags[tag.ID] = &c
+}
+
+// removeTag deletes a tag and takes it off every todo. The caller must hold the lock.
+func (r *MemoryTagRepository) removeTag(tagID int) {
+	delete(r.tags, tagID)
+	for todoID, tagIDs := range r.todoTags {
+		delete(tagIDs, tagID)
+		if len(tagIDs) == 0 {
+			delete(r.todoTags, todoID)
+		}
+	}
+}
+
+// todoTagKeys returns the nameKey of each tag of a todo
+func (r *MemoryTagRepository) todoTagKeys(todoID int) map[string]bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make(map[string]bool, len(r.todoTags[todoID]))
+	for id := range r.todoTags[todoID] {
+		keys[nameKey(r.tags[id].Name)] = true
+	}
+	return keys
+}
+
+// deleteTodo removes the tags of a deleted todo
+func (r *MemoryTagRepository) deleteTodo(todoID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.todoTags, todoID)
+}
+
+// copyTodo gives the next occurrence of a todo its tags
+func (r *MemoryTagRepository) copyTodo(todoID, nextID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.todoTags[todoID]) == 0 {
+		return
+	}
+	set := make(map[int]bool, len(r.todoTags[todoID]))
+	for id := range r.todoTags[todoID] {
+		set[id] = true
+	}
+	r.todoTags[nextID] = set
+}
+
+// deleteUser deletes the tags of a purged user
+func (r *MemoryTagRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, tag := range r.tags {
+		if tag.UserID == userID {
+			r.removeTag(id)
+		}
+	}
+}
+
+// sortTags orders tags by name ignoring case, like the SQL stores
+func sortTags(tags []*model.Tag) {
+	sort.Slice(tags, func(i, j int) bool {
+		return nameKey(tags[i].Name) < nameKey(tags[j].Name)
+	})
+}
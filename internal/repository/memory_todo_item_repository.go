@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryTodoItemRepository is an in-memory TodoItemStore used for development and tests
+type MemoryTodoItemRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	items  map[int]*model.TodoItem
+}
+
+// NewMemoryTodoItemRepository creates an empty in-memory checklist item repository
+func NewMemoryTodoItemRepository() *MemoryTodoItemRepository {
+	return &MemoryTodoItemRepository{
+		nextID: 1,
+		items:  make(map[int]*model.TodoItem),
+	}
+}
+
+// ListTodoItems retrieves the items of the todos, in order
+func (r *MemoryTodoItemRepository) ListTodoItems(ctx context.Context, todoIDs []int) (map[int][]*model.TodoItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(todoIDs))
+	for _, id := range todoIDs {
+		wanted[id] = true
+	}
+
+	items := make(map[int][]*model.TodoItem)
+	for _, item := range r.items {
+		if wanted[item.TodoID] {
+			c := *item
+			items[item.TodoID] = append(items[item.TodoID], &c)
+		}
+	}
+	for _, list := range items {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Position == list[j].Position {
+				return list[i].ID < list[j].ID
+			}
+			return list[i].Position < list[j].Position
+		})
+	}
+
+	return items, nil
+}
+
+// CountTodoItems counts the done and total items of the todos
+func (r *MemoryTodoItemRepository) CountTodoItems(ctx context.Context, todoIDs []int) (map[int]*model.TodoProgress, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[int]bool, len(todoIDs))
+	for _, id := range todoIDs {
+		wanted[id] = true
+	}
+
+	progress := make(map[int]*model.TodoProgress)
+	for _, item := range r.items {
+		if !wanted[item.TodoID] {
+			continue
+		}
+		p, ok := progress[item.TodoID]
+		if !ok {
+			p = &model.TodoProgress{}
+			progress[item.TodoID] = p
+		}
+		p.Total++
+		if item.IsDone {
+			p.Done++
+		}
+	}
+
+	return progress, nil
+}
+
+// GetTodoItem retrieves an item of a todo
+func (r *MemoryTodoItemRepository) GetTodoItem(ctx context.Context, todoID, itemID int) (*model.TodoItem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	item, ok := r.items[itemID]
+	if !ok || item.TodoID != todoID {
+		return nil, errItemNotFound
+	}
+
+	c := *item
+	return &c, nil
+}
+
+// CreateTodoItem appends an item to the items of its todo
+func (r *MemoryTodoItemRepository) CreateTodoItem(ctx context.Context, item *model.TodoItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item.Position = 0
+	for _, existing := range r.items {
+		if existing.TodoID == item.TodoID && existing.Position >= item.Position {
+			item.Position = existing.Position + 1
+		}
+	}
+
+	now := time.Now()
+	item.ID = r.nextID
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	r.nextID++
+
+	c := *item
+	r.items[item.ID] = &c
+	return nil
+}
+
+// UpdateTodoItem updates the title and done state of an item
+func (r *MemoryTodoItemRepository) UpdateTodoItem(ctx context.Context, item *model.TodoItem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.items[item.ID]
+	if !ok || existing.TodoID != item.TodoID {
+		return errItemNotFound
+	}
+
+	existing.Title = item.Title
+	existing.IsDone = item.IsDone
+	existing.UpdatedAt = time.Now()
+
+	item.Position = existing.Position
+	item.CreatedAt = existing.CreatedAt
+	item.UpdatedAt = existing.UpdatedAt
+	return nil
+}
+
+// DeleteTodoItem deletes an item of a todo
+func (r *MemoryTodoItemRepository) DeleteTodoItem(ctx context.Context, todoID, itemID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.items[itemID]
+	if !ok || item.TodoID != todoID {
+		return errItemNotFound
+	}
+
+	delete(r.items, itemID)
+	return nil
+}
+
+// ReorderTodoItems moves each item to its index in itemIDs, changing
+// nothing if one of them isn't an item of the todo
+func (r *MemoryTodoItemRepository) ReorderTodoItems(ctx context.Context, todoID int, itemIDs []int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range itemIDs {
+		if item, ok := r.items[id]; !ok || item.TodoID != todoID {
+			return errItemNotFound
+		}
+	}
+
+	now := time.Now()
+	for position, id := range itemIDs {
+		r.items[id].Position = position
+		r.items[id].UpdatedAt = now
+	}
+	return nil
+}
+
+// CompleteTodoItems marks every item of the todo done
+func (r *MemoryTodoItemRepository) CompleteTodoItems(ctx context.Context, todoID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for _, item := range r.items {
+		if item.TodoID == todoID && !item.IsDone {
+			item.IsDone = true
+			item.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// deleteTodo deletes the items of a deleted todo
+func (r *MemoryTodoItemRepository) deleteTodo(todoID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, item := range r.items {
+		if item.TodoID == todoID {
+			delete(r.items, id)
+		}
+	}
+}
+
+// copyTodo adds the items of a todo, not done, to its next occurrence
+func (r *MemoryTodoItemRepository) copyTodo(todoID, nextID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var copies []*model.TodoItem
+	for _, item := range r.items {
+		if item.TodoID == todoID {
+			copies = append(copies, &model.TodoItem{TodoID: nextID, Title: item.Title, Position: item.Position, CreatedAt: now, UpdatedAt: now})
+		}
+	}
+	sort.Slice(copies, func(i, j int) bool { return copies[i].Position < copies[j].Position })
+	for _, item := range copies {
+		item.ID = r.nextID
+		r.nextID++
+		r.items[item.ID] = item
+	}
+}
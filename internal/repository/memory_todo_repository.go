@@ -0,0 +1,394 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryTodoRepository is an in-memory TodoStore used for development and tests
+type MemoryTodoRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	todos  map[int]*model.Todo
+	// cascades delete the data of deleted todos from the other stores, like
+	// ON DELETE CASCADE does in the databases
+	cascades []func(todoID int)
+	// copies copy the data of a completed recurring todo in the other stores
+	// to its next occurrence
+	copies []func(todoID, nextID int)
+	// tagKeys returns the nameKey of each tag of a todo, for filtering by tag
+	tagKeys func(todoID int) map[string]bool
+}
+
+// NewMemoryTodoRepository creates an empty in-memory todo repository
+func NewMemoryTodoRepository() *MemoryTodoRepository {
+	return &MemoryTodoRepository{
+		nextID: 1,
+		todos:  make(map[int]*model.Todo),
+	}
+}
+
+// GetTodosByUserID retrieves all todos for a specific user, newest first
+func (r *MemoryTodoRepository) GetTodosByUserID(ctx context.Context, userID int) ([]*model.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var todos []*model.Todo
+	for _, todo := range r.todos {
+		if todo.UserID == userID {
+			todos = append(todos, copyTodo(todo))
+		}
+	}
+
+	sort.Slice(todos, func(i, j int) bool {
+		if todos[i].CreatedAt.Equal(todos[j].CreatedAt) {
+			return todos[i].ID > todos[j].ID
+		}
+		return todos[i].CreatedAt.After(todos[j].CreatedAt)
+	})
+
+	return todos, nil
+}
+
+// ListTodos retrieves one page of a user's todos matching the query
+func (r *MemoryTodoRepository) ListTodos(ctx context.Context, userID int, q model.TodoQuery) (*model.TodoPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := decodeTodoCursor(q)
+	if err != nil {
+		return nil, err
+	}
+	var after *model.Todo
+	if cursor != nil {
+		after, _ = cursor.todo()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := &model.TodoPage{Todos: []*model.Todo{}}
+	var matched []*model.Todo
+	for _, todo := range r.todos {
+		if todo.UserID != userID || !matchesTodoQuery(q, todo, r.todoTagKeys(todo.ID)) {
+			continue
+		}
+		page.Total++
+		if after == nil || compareTodos(q, todo, after) > 0 {
+			matched = append(matched, todo)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return compareTodos(q, matched[i], matched[j]) < 0
+	})
+
+	if q.Limit > 0 && len(matched) > q.Limit {
+		matched = matched[:q.Limit]
+		page.NextCursor = encodeTodoCursor(q, matched[len(matched)-1])
+	}
+	for _, todo := range matched {
+		page.Todos = append(page.Todos, copyTodo(todo))
+	}
+
+	return page, nil
+}
+
+// SearchTodos runs a ranked text search over a user's todos. Unlike the
+// PostgreSQL store it matches whole words without stemming.
+func (r *MemoryTodoRepository) SearchTodos(ctx context.Context, userID int, s model.TodoSearch) ([]*model.TodoSearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matcher := newTextMatcher(s)
+	results := []*model.TodoSearchResult{}
+	if matcher.empty() {
+		return results, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, todo := range r.todos {
+		if todo.UserID != userID {
+			continue
+		}
+		if ok, rank := matcher.match(todo); ok {
+			results = append(results, &model.TodoSearchResult{
+				Todo:                 copyTodo(todo),
+				Rank:                 rank,
+				TitleHighlight:       matcher.highlight(todo.Title),
+				DescriptionHighlight: matcher.highlight(todo.Description),
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank == results[j].Rank {
+			return results[i].Todo.ID > results[j].Todo.ID
+		}
+		return results[i].Rank > results[j].Rank
+	})
+
+	return paginate(results, s.Limit, s.Offset), nil
+}
+
+// GetTodoByID retrieves a specific todo by ID and user ID
+func (r *MemoryTodoRepository) GetTodoByID(ctx context.Context, todoID, userID int) (*model.Todo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[todoID]
+	if !ok || todo.UserID != userID {
+		return nil, errTodoNotFound
+	}
+
+	return copyTodo(todo), nil
+}
+
+// CreateTodo creates a new todo
+func (r *MemoryTodoRepository) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Default priority if empty
+	if todo.Priority == "" {
+		todo.Priority = DefaultPriority
+	}
+
+	now := time.Now()
+	todo.ID = r.nextID
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	r.nextID++
+
+	r.todos[todo.ID] = copyTodo(todo)
+	return nil
+}
+
+// UpdateTodo updates an existing todo
+func (r *MemoryTodoRepository) UpdateTodo(ctx context.Context, todo *model.Todo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.todos[todo.ID]
+	if !ok || existing.UserID != todo.UserID {
+		return errTodoNotFound
+	}
+	saveTodo(existing, todo)
+	return nil
+}
+
+// saveTodo copies the fields UpdateTodo saves from todo to existing
+func saveTodo(existing, todo *model.Todo) {
+	existing.Title = todo.Title
+	existing.Description = todo.Description
+	existing.ListID = copyInt(todo.ListID)
+	existing.IsDone = todo.IsDone
+	existing.Priority = todo.Priority
+	existing.DueDate = copyTime(todo.DueDate)
+	existing.RecurrenceRule = todo.RecurrenceRule
+	existing.RecurrenceMode = todo.RecurrenceMode
+	existing.UpdatedAt = time.Now()
+
+	todo.CreatedAt = existing.CreatedAt
+	todo.UpdatedAt = existing.UpdatedAt
+}
+
+// CompleteRecurringTodo saves the completed todo and creates its next
+// occurrence with the same tags and checklist
+func (r *MemoryTodoRepository) CompleteRecurringTodo(ctx context.Context, todo, next *model.Todo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Of concurrent completions only the one that finds the todo still
+	// recurring and not done creates the next occurrence
+	r.mu.Lock()
+	existing, ok := r.todos[todo.ID]
+	if !ok || existing.UserID != todo.UserID {
+		r.mu.Unlock()
+		return errTodoNotFound
+	}
+	if existing.IsDone || existing.RecurrenceRule == "" {
+		r.mu.Unlock()
+		return errTodoCompleted
+	}
+	saveTodo(existing, todo)
+	r.mu.Unlock()
+
+	if err := r.CreateTodo(ctx, next); err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	copies := r.copies
+	r.mu.RUnlock()
+
+	for _, copyTo := range copies {
+		copyTo(todo.ID, next.ID)
+	}
+	return nil
+}
+
+// DeleteTodo deletes a todo by ID and user ID
+func (r *MemoryTodoRepository) DeleteTodo(ctx context.Context, todoID, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	todo, ok := r.todos[todoID]
+	if !ok || todo.UserID != userID {
+		r.mu.Unlock()
+		return errTodoNotFound
+	}
+
+	delete(r.todos, todoID)
+	cascades := r.cascades
+	r.mu.Unlock()
+
+	for _, cascade := range cascades {
+		cascade(todoID)
+	}
+	return nil
+}
+
+// copyTodo returns a deep copy so callers can't mutate stored state
+func copyTodo(todo *model.Todo) *model.Todo {
+	c := *todo
+	c.ListID = copyInt(todo.ListID)
+	c.DueDate = copyTime(todo.DueDate)
+	return &c
+}
+
+func copyInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	c := *i
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// paginate returns the window of items selected by limit and offset
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// deleteUser deletes the todos of a purged user
+func (r *MemoryTodoRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	var deleted []int
+	for id, todo := range r.todos {
+		if todo.UserID == userID {
+			delete(r.todos, id)
+			deleted = append(deleted, id)
+		}
+	}
+	cascades := r.cascades
+	r.mu.Unlock()
+
+	for _, id := range deleted {
+		for _, cascade := range cascades {
+			cascade(id)
+		}
+	}
+}
+
+// cascade registers functions deleting the data of deleted todos
+func (r *MemoryTodoRepository) cascade(fns ...func(todoID int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cascades = append(r.cascades, fns...)
+}
+
+// copyTo registers functions copying the data of completed recurring todos
+func (r *MemoryTodoRepository) copyTo(fns ...func(todoID, nextID int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.copies = append(r.copies, fns...)
+}
+
+// todoTagKeys returns the nameKey of each tag of the todo
+func (r *MemoryTodoRepository) todoTagKeys(todoID int) map[string]bool {
+	if r.tagKeys == nil {
+		return nil
+	}
+	return r.tagKeys(todoID)
+}
+
+// countLists returns the number of todos in each of the user's lists
+func (r *MemoryTodoRepository) countLists(userID int) map[int]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.ListID != nil {
+			counts[*todo.ListID]++
+		}
+	}
+	return counts
+}
+
+// deleteList moves or deletes the todos of a deleted list as d says
+func (r *MemoryTodoRepository) deleteList(listID int, d model.ListDeletion) {
+	r.mu.Lock()
+	var deleted []int
+	for id, todo := range r.todos {
+		if todo.ListID == nil || *todo.ListID != listID {
+			continue
+		}
+		if d.DeleteTodos {
+			delete(r.todos, id)
+			deleted = append(deleted, id)
+		} else {
+			todo.ListID = copyInt(d.MoveTo)
+		}
+	}
+	cascades := r.cascades
+	r.mu.Unlock()
+
+	for _, id := range deleted {
+		for _, cascade := range cascades {
+			cascade(id)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryTokenRepository is an in-memory TokenStore used for development and tests
+type MemoryTokenRepository struct {
+	mu       sync.RWMutex
+	nextID   int
+	sessions map[string]*model.Session
+	tokens   map[int]*model.RefreshToken
+	resets   map[int]*model.PasswordResetToken
+}
+
+// NewMemoryTokenRepository creates an empty in-memory token repository
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{
+		nextID:   1,
+		sessions: make(map[string]*model.Session),
+		tokens:   make(map[int]*model.RefreshToken),
+		resets:   make(map[int]*model.PasswordResetToken),
+	}
+}
+
+// CreateSession creates a new session
+func (r *MemoryTokenRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session.CreatedAt = time.Now().UTC()
+	session.LastSeenAt = session.CreatedAt
+	c := *session
+	r.sessions[session.ID] = &c
+	return nil
+}
+
+// GetSession retrieves a session by its ID
+func (r *MemoryTokenRepository) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, errSessionNotFound
+	}
+
+	return copySession(session), nil
+}
+
+// ListActiveSessions retrieves the user's sessions that are neither revoked nor expired
+func (r *MemoryTokenRepository) ListActiveSessions(ctx context.Context, userID int) ([]*model.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var sessions []*model.Session
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, copySession(session))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+// TouchSession records activity on the session
+func (r *MemoryTokenRepository) TouchSession(ctx context.Context, sessionID, ipAddress string) error {
+	return r.updateSession(ctx, sessionID, func(session *model.Session) {
+		session.LastSeenAt = time.Now().UTC()
+		session.IPAddress = ipAddress
+	})
+}
+
+// ExtendSession moves the expiry of the session
+func (r *MemoryTokenRepository) ExtendSession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	return r.updateSession(ctx, sessionID, func(session *model.Session) {
+		session.ExpiresAt = expiresAt.UTC()
+	})
+}
+
+// RevokeSession revokes a session, keeping the original revocation time
+func (r *MemoryTokenRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	return r.updateSession(ctx, sessionID, func(session *model.Session) {
+		if session.RevokedAt == nil {
+			now := time.Now().UTC()
+			session.RevokedAt = &now
+		}
+	})
+}
+
+// RevokeUserSessions revokes every session of the user except exceptID
+func (r *MemoryTokenRepository) RevokeUserSessions(ctx context.Context, userID int, exceptID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.ID != exceptID && session.RevokedAt == nil {
+			revokedAt := now
+			session.RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+// updateSession applies update to a stored session
+func (r *MemoryTokenRepository) updateSession(ctx context.Context, sessionID string, update func(*model.Session)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return errSessionNotFound
+	}
+
+	update(session)
+	return nil
+}
+
+// CreateRefreshToken stores a new refresh token
+func (r *MemoryTokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = r.nextID
+	token.CreatedAt = time.Now().UTC()
+	r.nextID++
+
+	c := *token
+	r.tokens[token.ID] = &c
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value
+func (r *MemoryTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			c := *token
+			c.UsedAt = copyTime(token.UsedAt)
+			return &c, nil
+		}
+	}
+
+	return nil, errRefreshTokenNotFound
+}
+
+// UseRefreshToken marks a refresh token as used unless it already was
+func (r *MemoryTokenRepository) UseRefreshToken(ctx context.Context, tokenID int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[tokenID]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	token.UsedAt = &now
+	return true, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token
+func (r *MemoryTokenRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token.ID = r.nextID
+	token.CreatedAt = time.Now().UTC()
+	r.nextID++
+
+	c := *token
+	r.resets[token.ID] = &c
+	return nil
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash of its value
+func (r *MemoryTokenRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.resets {
+		if token.TokenHash == tokenHash {
+			c := *token
+			c.UsedAt = copyTime(token.UsedAt)
+			return &c, nil
+		}
+	}
+
+	return nil, errResetTokenNotFound
+}
+
+// UsePasswordResetToken marks a password reset token as used unless it already was
+func (r *MemoryTokenRepository) UsePasswordResetToken(ctx context.Context, tokenID int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.resets[tokenID]
+	if !ok || token.UsedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	token.UsedAt = &now
+	return true, nil
+}
+
+// DeletePasswordResetTokens deletes every password reset token of the user
+func (r *MemoryTokenRepository) DeletePasswordResetTokens(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.resets {
+		if token.UserID == userID {
+			delete(r.resets, id)
+		}
+	}
+	return nil
+}
+
+// copySession returns a copy of session that shares no pointers with it
+func copySession(session *model.Session) *model.Session {
+	c := *session
+	c.RevokedAt = copyTime(session.RevokedAt)
+	return &c
+}
+
+// deleteUser deletes the sessions, refresh tokens and reset tokens of a
+// purged user
+func (r *MemoryTokenRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	for id, token := range r.tokens {
+		if _, ok := r.sessions[token.SessionID]; !ok {
+			delete(r.tokens, id)
+		}
+	}
+	for id, reset := range r.resets {
+		if reset.UserID == userID {
+			delete(r.resets, id)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryTwoFactorRepository is an in-memory TwoFactorStore used for development and tests
+type MemoryTwoFactorRepository struct {
+	mu         sync.RWMutex
+	nextID     int
+	settings   map[int]*model.TwoFactor
+	codes      map[int][]*memoryRecoveryCode
+	challenges map[int]*model.MFAChallenge
+}
+
+// memoryRecoveryCode is a stored recovery code
+type memoryRecoveryCode struct {
+	hash string
+	used bool
+}
+
+// NewMemoryTwoFactorRepository creates an empty in-memory two-factor repository
+func NewMemoryTwoFactorRepository() *MemoryTwoFactorRepository {
+	return &MemoryTwoFactorRepository{
+		nextID:     1,
+		settings:   make(map[int]*model.TwoFactor),
+		codes:      make(map[int][]*memoryRecoveryCode),
+		challenges: make(map[int]*model.MFAChallenge),
+	}
+}
+
+// GetTwoFactor retrieves the TOTP settings of a user
+func (r *MemoryTwoFactorRepository) GetTwoFactor(ctx context.Context, userID int) (*model.TwoFactor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tf, ok := r.settings[userID]
+	if !ok {
+		return nil, errTwoFactorNotFound
+	}
+
+	c := *tf
+	c.EnabledAt = copyTime(tf.EnabledAt)
+	if tf.LastUsedStep != nil {
+		step := *tf.LastUsedStep
+		c.LastUsedStep = &step
+	}
+	return &c, nil
+}
+
+// SetTOTPSecret stores the secret of a new enrollment unless 2FA is enabled
+func (r *MemoryTwoFactorRepository) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if tf, ok := r.settings[userID]; ok && tf.EnabledAt != nil {
+		return errTwoFactorEnabled
+	}
+
+	r.settings[userID] = &model.TwoFactor{UserID: userID, Secret: secret, CreatedAt: time.Now().UTC()}
+	return nil
+}
+
+// EnableTwoFactor enables 2FA and replaces the recovery codes
+func (r *MemoryTwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tf, ok := r.settings[userID]
+	if !ok || tf.EnabledAt != nil {
+		return errTwoFactorNotFound
+	}
+
+	now := time.Now().UTC()
+	tf.EnabledAt = &now
+	tf.LastUsedStep = &step
+	r.replaceCodesLocked(userID, codeHashes)
+	return nil
+}
+
+// DisableTwoFactor deletes the user's secret, recovery codes and challenges
+func (r *MemoryTwoFactorRepository) DisableTwoFactor(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.settings, userID)
+	delete(r.codes, userID)
+	for id, challenge := range r.challenges {
+		if challenge.UserID == userID {
+			delete(r.challenges, id)
+		}
+	}
+	return nil
+}
+
+// UseTOTPStep records step as used unless it or a later step was used before
+func (r *MemoryTwoFactorRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tf, ok := r.settings[userID]
+	if !ok || (tf.LastUsedStep != nil && *tf.LastUsedStep >= step) {
+		return false, nil
+	}
+
+	tf.LastUsedStep = &step
+	return true, nil
+}
+
+// ReplaceRecoveryCodes replaces every recovery code of the user
+func (r *MemoryTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.replaceCodesLocked(userID, codeHashes)
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used
+func (r *MemoryTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, code := range r.codes[userID] {
+		if code.hash == codeHash && !code.used {
+			code.used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// CountRecoveryCodes counts the unused recovery codes of the user
+func (r *MemoryTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, code := range r.codes[userID] {
+		if !code.used {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CreateMFAChallenge stores a new MFA challenge
+func (r *MemoryTwoFactorRepository) CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge.ID = r.nextID
+	r.nextID++
+	challenge.CreatedAt = time.Now().UTC()
+	c := *challenge
+	r.challenges[challenge.ID] = &c
+	return nil
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token
+func (r *MemoryTwoFactorRepository) GetMFAChallengeByHash(ctx context.Context, tokenHash string) (*model.MFAChallenge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, challenge := range r.challenges {
+		if challenge.TokenHash == tokenHash {
+			c := *challenge
+			c.UsedAt = copyTime(challenge.UsedAt)
+			return &c, nil
+		}
+	}
+	return nil, errMFAChallengeNotFound
+}
+
+// AttemptMFAChallenge counts an attempt at an MFA challenge unless it was
+// used or is out of attempts
+func (r *MemoryTwoFactorRepository) AttemptMFAChallenge(ctx context.Context, challengeID, maxAttempts int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[challengeID]
+	if !ok || challenge.UsedAt != nil || challenge.Attempts >= maxAttempts {
+		return false, nil
+	}
+
+	challenge.Attempts++
+	return true, nil
+}
+
+// UseMFAChallenge marks an MFA challenge as used unless it already was
+func (r *MemoryTwoFactorRepository) UseMFAChallenge(ctx context.Context, challengeID int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[challengeID]
+	if !ok || challenge.UsedAt != nil {
+		return false, nil
+	}
+
+	now := time.Now().UTC()
+	challenge.UsedAt = &now
+	return true, nil
+}
+
+// ReleaseMFAChallenge marks a used MFA challenge unused again
+func (r *MemoryTwoFactorRepository) ReleaseMFAChallenge(ctx context.Context, challengeID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if challenge, ok := r.challenges[challengeID]; ok {
+		challenge.UsedAt = nil
+	}
+	return nil
+}
+
+// replaceCodesLocked swaps the user's recovery codes; r.mu must be held
+func (r *MemoryTwoFactorRepository) replaceCodesLocked(userID int, codeHashes []string) {
+	codes := make([]*memoryRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, &memoryRecoveryCode{hash: hash})
+	}
+	r.codes[userID] = codes
+}
+
+// deleteUser deletes the two-factor settings, recovery codes and challenges
+// of a purged user
+func (r *MemoryTwoFactorRepository) deleteUser(userID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.settings, userID)
+	delete(r.codes, userID)
+	for id, challenge := range r.challenges {
+		if challenge.UserID == userID {
+			delete(r.challenges, id)
+		}
+	}
+}
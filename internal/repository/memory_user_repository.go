@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// MemoryUserRepository is an in-memory UserStore used for development and tests
+type MemoryUserRepository struct {
+	mu     sync.RWMutex
+	nextID int
+	users  map[int]*model.User
+	// cascades delete the data of purged users from the other stores, like
+	// ON DELETE CASCADE does in the databases
+	cascades []func(userID int)
+}
+
+// NewMemoryUserRepository creates an empty in-memory user repository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		nextID: 1,
+		users:  make(map[int]*model.User),
+	}
+}
+
+// GetUserByEmail retrieves a user by their email, including the password hash
+func (r *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			c := *user
+			c.EmailVerifiedAt = copyTime(user.EmailVerifiedAt)
+			c.DisabledAt = copyTime(user.DisabledAt)
+			c.DeleteAfter = copyTime(user.DeleteAfter)
+			return &c, nil
+		}
+	}
+
+	return nil, errUserNotFound
+}
+
+// GetUserByID retrieves a user by their ID without the password hash
+func (r *MemoryUserRepository) GetUserByID(ctx context.Context, userID int) (*model.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, errUserNotFound
+	}
+
+	c := *user
+	c.Password = ""
+	c.EmailVerifiedAt = copyTime(user.EmailVerifiedAt)
+	c.DisabledAt = copyTime(user.DisabledAt)
+	c.DeleteAfter = copyTime(user.DeleteAfter)
+	return &c, nil
+}
+
+// CreateUser creates a new user, enforcing unique email and username
+func (r *MemoryUserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email || existing.Username == user.Username {
+			return errUserExists
+		}
+	}
+
+	now := time.Now()
+	user.ID = r.nextID
+	user.Role = model.RoleUser
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.nextID++
+
+	c := *user
+	r.users[user.ID] = &c
+	return nil
+}
+
+// UserExists checks if a user exists by email or username
+func (r *MemoryUserRepository) UserExists(ctx context.Context, email, username string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email || user.Username == username {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// UpdatePassword replaces the password hash of a user
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errUserNotFound
+	}
+
+	user.Password = passwordHash
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateUsername renames a user, enforcing unique usernames
+func (r *MemoryUserRepository) UpdateUsername(ctx context.Context, userID int, username string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errUserNotFound
+	}
+	for _, existing := range r.users {
+		if existing.ID != userID && existing.Username == username {
+			return errUserExists
+		}
+	}
+
+	user.Username = username
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateEmail switches a user to a confirmed new email address, enforcing
+// unique addresses
+func (r *MemoryUserRepository) UpdateEmail(ctx context.Context, userID int, oldEmail, newEmail string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.Email != oldEmail {
+		return errUserNotFound
+	}
+	for _, existing := range r.users {
+		if existing.ID != userID && existing.Email == newEmail {
+			return errUserExists
+		}
+	}
+
+	now := time.Now()
+	user.Email = newEmail
+	user.EmailVerifiedAt = &now
+	user.UpdatedAt = now
+	return nil
+}
+
+// MarkEmailVerified records that the user confirmed email, unless the
+// address changed in the meantime
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, userID int, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.Email != email {
+		return errUserNotFound
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	return nil
+}
+
+// ScheduleDeletion deactivates a user until deleteAfter, when the account is
+// purged
+func (r *MemoryUserRepository) ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errUserNotFound
+	}
+
+	user.DeleteAfter = &deleteAfter
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// CancelDeletion restores a user scheduled for deletion
+func (r *MemoryUserRepository) CancelDeletion(ctx context.Context, userID int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errUserNotFound
+	}
+
+	user.DeleteAfter = nil
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// PurgeDeletedUsers deletes the users whose grace period ended before t,
+// together with their data in the cascaded stores
+func (r *MemoryUserRepository) PurgeDeletedUsers(ctx context.Context, t time.Time) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	var purged []int
+	for id, user := range r.users {
+		if user.DeleteAfter != nil && user.DeleteAfter.Before(t) {
+			delete(r.users, id)
+			purged = append(purged, id)
+		}
+	}
+	cascades := r.cascades
+	r.mu.Unlock()
+
+	for _, id := range purged {
+		for _, cascade := range cascades {
+			cascade(id)
+		}
+	}
+	return len(purged), nil
+}
+
+// SetRole changes the role of a user
+func (r *MemoryUserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errUserNotFound
+	}
+
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetDisabled disables a user as of disabledAt, or enables them when nil
+func (r *MemoryUserRepository) SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errUserNotFound
+	}
+
+	user.DisabledAt = copyTime(disabledAt)
+	user.UpdatedAt = time.Now()
+	return nil
+}
+
+// cascade registers functions deleting the data of purged users
+func (r *MemoryUserRepository) cascade(fns ...func(userID int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cascades = append(r.cascades, fns...)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// NotificationRepository handles in-app notification database operations
+type NotificationRepository struct{}
+
+// CreateNotification stores a new notification
+func (r *NotificationRepository) CreateNotification(ctx context.Context, notification *model.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, todo_id, title, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	err := DB.QueryRow(ctx, query, notification.UserID, notification.TodoID, notification.Title, notification.Body).
+		Scan(&notification.ID, &notification.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+// ListNotifications retrieves a user's notifications, newest first
+func (r *NotificationRepository) ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit int) ([]*model.Notification, error) {
+	query := `
+		SELECT id, user_id, todo_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+	`
+	args := []interface{}{userID, unreadOnly}
+	if limit > 0 {
+		args = append(args, limit)
+		query += " LIMIT $3"
+	}
+
+	rows, err := DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*model.Notification{}
+	for rows.Next() {
+		var n model.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.TodoID, &n.Title, &n.Body, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead marks a notification of the user read
+func (r *NotificationRepository) MarkNotificationRead(ctx context.Context, notificationID, userID int, readAt time.Time) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2
+	`
+
+	commandTag, err := DB.Exec(ctx, query, notificationID, userID, readAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errNotificationNotFound
+	}
+
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user read
+func (r *NotificationRepository) MarkAllNotificationsRead(ctx context.Context, userID int, readAt time.Time) error {
+	query := `UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL`
+
+	if _, err := DB.Exec(ctx, query, userID, readAt); err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// OIDCRepository handles OpenID Connect login and identity database operations
+type OIDCRepository struct{}
+
+// CreateOIDCLogin stores a login started with a provider
+func (r *OIDCRepository) CreateOIDCLogin(ctx context.Context, login *model.OIDCLogin) error {
+	query := `
+		INSERT INTO oidc_logins (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	login.CreatedAt = time.Now().UTC()
+	err := DB.QueryRow(ctx, query,
+		login.StateHash,
+		login.Provider,
+		login.CodeVerifier,
+		login.Nonce,
+		login.ExpiresAt.UTC(),
+		login.CreatedAt,
+	).Scan(&login.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create oidc login: %w", err)
+	}
+
+	return nil
+}
+
+// TakeOIDCLogin deletes and returns the login with the given state hash
+func (r *OIDCRepository) TakeOIDCLogin(ctx context.Context, stateHash string) (*model.OIDCLogin, error) {
+	query := `
+		DELETE FROM oidc_logins
+		WHERE state_hash = $1
+		RETURNING id, state_hash, provider, code_verifier, nonce, expires_at, created_at
+	`
+
+	var login model.OIDCLogin
+	err := DB.QueryRow(ctx, query, stateHash).Scan(
+		&login.ID,
+		&login.StateHash,
+		&login.Provider,
+		&login.CodeVerifier,
+		&login.Nonce,
+		&login.ExpiresAt,
+		&login.CreatedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errOIDCLoginNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take oidc login: %w", err)
+	}
+
+	return &login, nil
+}
+
+// DeleteExpiredOIDCLogins deletes the logins that expired before t
+func (r *OIDCRepository) DeleteExpiredOIDCLogins(ctx context.Context, t time.Time) error {
+	query := `DELETE FROM oidc_logins WHERE expires_at < $1`
+
+	if _, err := DB.Exec(ctx, query, t.UTC()); err != nil {
+		return fmt.Errorf("failed to delete expired oidc logins: %w", err)
+	}
+
+	return nil
+}
+
+// GetIdentity retrieves the link of a provider account
+func (r *OIDCRepository) GetIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2
+	`
+
+	var identity model.UserIdentity
+	err := DB.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// CreateIdentity links a provider account to a user
+func (r *OIDCRepository) CreateIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	identity.CreatedAt = time.Now().UTC()
+	err := DB.QueryRow(ctx, query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		identity.CreatedAt,
+	).Scan(&identity.ID)
+
+	if isUniqueViolation(err) {
+		return errIdentityExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	return nil
+}
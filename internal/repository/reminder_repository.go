@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// ReminderRepository handles reminder database operations
+type ReminderRepository struct{}
+
+// rescheduleRemindersSQL schedules the relative reminders of todo $1 before
+// the due date $2 and makes them pending again
+const rescheduleRemindersSQL = `
+	UPDATE reminders
+	SET fire_at = $2::timestamp - offset_minutes * INTERVAL '1 minute',
+	    locked_until = NULL, attempts = 0, last_error = '', sent_at = NULL, failed_at = NULL
+	WHERE todo_id = $1 AND offset_minutes IS NOT NULL
+`
+
+// ListTodoReminders retrieves the reminders of the todos, oldest first
+func (r *ReminderRepository) ListTodoReminders(ctx context.Context, todoIDs []int) (map[int][]*model.Reminder, error) {
+	query := `
+		SELECT id, todo_id, remind_at, offset_minutes, channels, webhook_url, fire_at,
+		       sent_at, failed_at, attempts, last_error, created_at
+		FROM reminders
+		WHERE todo_id = ANY($1)
+		ORDER BY todo_id, id
+	`
+
+	reminders := make(map[int][]*model.Reminder)
+	if len(todoIDs) == 0 {
+		return reminders, nil
+	}
+
+	rows, err := DB.Query(ctx, query, todoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders[reminder.TodoID] = append(reminders[reminder.TodoID], reminder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// CreateReminder stores a new reminder
+func (r *ReminderRepository) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	query := `
+		INSERT INTO reminders (todo_id, remind_at, offset_minutes, channels, webhook_url, fire_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := DB.QueryRow(ctx, query,
+		reminder.TodoID,
+		reminder.RemindAt,
+		reminder.OffsetMinutes,
+		strings.Join(reminder.Channels, " "),
+		reminder.WebhookURL,
+		reminder.FireAt,
+	).Scan(&reminder.ID, &reminder.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteReminder deletes a reminder of a todo
+func (r *ReminderRepository) DeleteReminder(ctx context.Context, todoID, reminderID int) error {
+	query := `DELETE FROM reminders WHERE id = $1 AND todo_id = $2`
+
+	commandTag, err := DB.Exec(ctx, query, reminderID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errReminderNotFound
+	}
+
+	return nil
+}
+
+// RescheduleReminders schedules the relative reminders of a todo before its
+// new due date
+func (r *ReminderRepository) RescheduleReminders(ctx context.Context, todoID int, dueDate *time.Time) error {
+	if _, err := DB.Exec(ctx, rescheduleRemindersSQL, todoID, dueDate); err != nil {
+		return fmt.Errorf("failed to reschedule reminders: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueReminders leases pending reminders that are due. SKIP LOCKED lets
+// several servers claim at once without waiting for or claiming each other's
+// reminders.
+func (r *ReminderRepository) ClaimDueReminders(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.ReminderDelivery, error) {
+	query := `
+		UPDATE reminders
+		SET locked_until = $2, attempts = reminders.attempts + 1
+		FROM todos, users
+		WHERE reminders.id IN (
+		          SELECT reminders.id
+		          FROM reminders
+		          JOIN todos ON todos.id = reminders.todo_id
+		          JOIN users ON users.id = todos.user_id
+		          WHERE reminders.fire_at <= $1
+		            AND reminders.sent_at IS NULL AND reminders.failed_at IS NULL
+		            AND (reminders.locked_until IS NULL OR reminders.locked_until <= $1)
+		            AND NOT todos.is_done
+		            AND users.disabled_at IS NULL AND users.delete_after IS NULL
+		          ORDER BY reminders.fire_at
+		          LIMIT $3
+		          FOR UPDATE OF reminders SKIP LOCKED
+		      )
+		  AND todos.id = reminders.todo_id AND users.id = todos.user_id
+		RETURNING reminders.id, reminders.todo_id, reminders.remind_at, reminders.offset_minutes,
+		          reminders.channels, reminders.webhook_url, reminders.fire_at, reminders.sent_at,
+		          reminders.failed_at, reminders.attempts, reminders.last_error, reminders.created_at,
+		          users.id, users.username, users.email, todos.title, todos.due_date
+	`
+
+	rows, err := DB.Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*model.ReminderDelivery
+	for rows.Next() {
+		var d model.ReminderDelivery
+		var channels string
+		d.Reminder = &model.Reminder{}
+		err := rows.Scan(
+			&d.Reminder.ID,
+			&d.Reminder.TodoID,
+			&d.Reminder.RemindAt,
+			&d.Reminder.OffsetMinutes,
+			&channels,
+			&d.Reminder.WebhookURL,
+			&d.Reminder.FireAt,
+			&d.Reminder.SentAt,
+			&d.Reminder.FailedAt,
+			&d.Reminder.Attempts,
+			&d.Reminder.LastError,
+			&d.Reminder.CreatedAt,
+			&d.UserID,
+			&d.Username,
+			&d.Email,
+			&d.TodoTitle,
+			&d.DueDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		d.Reminder.Channels = strings.Fields(channels)
+		deliveries = append(deliveries, &d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkReminderSent records that a reminder was delivered
+func (r *ReminderRepository) MarkReminderSent(ctx context.Context, reminderID int, fireAt, sentAt time.Time) error {
+	query := `
+		UPDATE reminders
+		SET sent_at = $3, locked_until = NULL, last_error = ''
+		WHERE id = $1 AND fire_at = $2
+	`
+
+	if _, err := DB.Exec(ctx, query, reminderID, fireAt, sentAt); err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	return nil
+}
+
+// RetryReminder records a failed delivery and leases the reminder until the
+// next attempt
+func (r *ReminderRepository) RetryReminder(ctx context.Context, reminderID int, fireAt, retryAt time.Time, lastError string) error {
+	query := `
+		UPDATE reminders
+		SET locked_until = $3, last_error = $4
+		WHERE id = $1 AND fire_at = $2
+	`
+
+	if _, err := DB.Exec(ctx, query, reminderID, fireAt, retryAt, lastError); err != nil {
+		return fmt.Errorf("failed to retry reminder: %w", err)
+	}
+	return nil
+}
+
+// FailReminder records a failed delivery and gives up on the reminder
+func (r *ReminderRepository) FailReminder(ctx context.Context, reminderID int, fireAt, failedAt time.Time, lastError string) error {
+	query := `
+		UPDATE reminders
+		SET failed_at = $3, locked_until = NULL, last_error = $4
+		WHERE id = $1 AND fire_at = $2
+	`
+
+	if _, err := DB.Exec(ctx, query, reminderID, fireAt, failedAt, lastError); err != nil {
+		return fmt.Errorf("failed to fail reminder: %w", err)
+	}
+	return nil
+}
+
+// scanReminder scans a row selected with the columns of ListReminders
+func scanReminder(row interface{ Scan(...interface{}) error }) (*model.Reminder, error) {
+	var reminder model.Reminder
+	var channels string
+	err := row.Scan(
+		&reminder.ID,
+		&reminder.TodoID,
+		&reminder.RemindAt,
+		&reminder.OffsetMinutes,
+		&channels,
+		&reminder.WebhookURL,
+		&reminder.FireAt,
+		&reminder.SentAt,
+		&reminder.FailedAt,
+		&reminder.Attempts,
+		&reminder.LastError,
+		&reminder.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	reminder.Channels = strings.Fields(channels)
+	return &reminder, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"aplikasi-todolist/internal/migrate"
+	"aplikasi-todolist/migrations"
+)
+
+// sqliteTimeFormat is how timestamps are stored in SQLite. It is fixed width
+// and always UTC, so timestamps compare and sort correctly as text.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000000"
+
+// sqliteMaxTime stands in for a missing due date when sorting ascending; the
+// empty string sorts before every timestamp when sorting descending
+const sqliteMaxTime = "9999-12-31 23:59:59.999999999"
+
+// OpenSQLite opens the SQLite database at path, creating it if needed
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	// Take the write lock when a transaction starts instead of upgrading
+	// later, which could fail with SQLITE_BUSY
+	params.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %v", err)
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("unable to open database: %v", err)
+	}
+
+	return db, nil
+}
+
+// NewSQLiteMigrator creates a migrator for the embedded SQLite migrations
+func NewSQLiteMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	return migrate.New(migrate.NewSQLiteDriver(db), migrations.SQLiteFS)
+}
+
+// sqliteTime formats t for storage in SQLite
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// sqliteNullTime formats t for storage in SQLite, keeping nil as NULL
+func sqliteNullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// sqliteTimeScanner scans a SQLite timestamp into a time.Time, or into a
+// *time.Time when the column may be NULL
+type sqliteTimeScanner struct {
+	dest     *time.Time
+	nullDest **time.Time
+}
+
+func scanSQLiteTime(dest *time.Time) *sqliteTimeScanner {
+	return &sqliteTimeScanner{dest: dest}
+}
+
+func scanSQLiteNullTime(dest **time.Time) *sqliteTimeScanner {
+	return &sqliteTimeScanner{nullDest: dest}
+}
+
+// Scan implements sql.Scanner. Depending on the declared column type the
+// driver returns either a time.Time or the stored text.
+func (s *sqliteTimeScanner) Scan(value interface{}) error {
+	var t time.Time
+	switch v := value.(type) {
+	case nil:
+		if s.nullDest == nil {
+			return errors.New("unexpected NULL timestamp")
+		}
+		*s.nullDest = nil
+		return nil
+	case time.Time:
+		t = v.UTC()
+	case string:
+		parsed, err := time.Parse("2006-01-02 15:04:05.999999999", v)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp %q: %w", v, err)
+		}
+		t = parsed
+	default:
+		return fmt.Errorf("unsupported timestamp type %T", value)
+	}
+
+	if s.nullDest != nil {
+		*s.nullDest = &t
+	} else {
+		*s.dest = t
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteAccessTokenRepository handles personal access token database operations on SQLite
+type SQLiteAccessTokenRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteAccessTokenRepository creates an access token repository backed by db
+func NewSQLiteAccessTokenRepository(db *sql.DB) *SQLiteAccessTokenRepository {
+	return &SQLiteAccessTokenRepository{db: db}
+}
+
+// CreateAccessToken stores a new personal access token
+func (r *SQLiteAccessTokenRepository) CreateAccessToken(ctx context.Context, token *model.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		token.UserID,
+		token.Name,
+		token.TokenHash,
+		strings.Join(token.Scopes, " "),
+		sqliteNullTime(token.ExpiresAt),
+		sqliteTime(token.CreatedAt),
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccessTokenByHash retrieves a personal access token by the hash of its value
+func (r *SQLiteAccessTokenRepository) GetAccessTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE token_hash = ?
+	`
+
+	token, err := scanSQLiteAccessToken(r.db.QueryRowContext(ctx, query, tokenHash))
+	if isNoRows(err) {
+		return nil, errAccessTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListAccessTokens retrieves the user's personal access tokens, newest first
+func (r *SQLiteAccessTokenRepository) ListAccessTokens(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	query := `
+		SELECT id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at
+		FROM personal_access_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []*model.PersonalAccessToken
+	for rows.Next() {
+		token, err := scanSQLiteAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// TouchAccessToken records when the token was last used
+func (r *SQLiteAccessTokenRepository) TouchAccessToken(ctx context.Context, tokenID int, usedAt time.Time) error {
+	query := `UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(usedAt), tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to touch access token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to touch access token: %w", err)
+	}
+	if affected == 0 {
+		return errAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// DeleteAccessToken deletes one of the user's personal access tokens
+func (r *SQLiteAccessTokenRepository) DeleteAccessToken(ctx context.Context, tokenID, userID int) error {
+	query := `DELETE FROM personal_access_tokens WHERE id = ? AND user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+	if affected == 0 {
+		return errAccessTokenNotFound
+	}
+
+	return nil
+}
+
+// scanSQLiteAccessToken scans a row selected with the columns of GetAccessTokenByHash
+func scanSQLiteAccessToken(row interface{ Scan(...interface{}) error }) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	var scopes string
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&scopes,
+		scanSQLiteNullTime(&token.ExpiresAt),
+		scanSQLiteNullTime(&token.LastUsedAt),
+		scanSQLiteTime(&token.CreatedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	return &token, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteAdminRepository handles the instance-wide queries of the admin API
+// on SQLite
+type SQLiteAdminRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteAdminRepository creates an admin repository backed by db
+func NewSQLiteAdminRepository(db *sql.DB) *SQLiteAdminRepository {
+	return &SQLiteAdminRepository{db: db}
+}
+
+// ListUsers retrieves one page of users matching the query with the number
+// of their todos, newest first
+func (r *SQLiteAdminRepository) ListUsers(ctx context.Context, q model.UserQuery) (*model.UserPage, error) {
+	where, args := sqliteUserFilterSQL(q)
+
+	page := &model.UserPage{Users: []*model.UserSummary{}}
+	countQuery := "SELECT COUNT(*) FROM users WHERE " + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	query := `
+		SELECT users.id, username, email, role, email_verified_at, disabled_at, delete_after, users.created_at, users.updated_at,
+			COUNT(todos.id), COALESCE(SUM(CASE WHEN todos.is_done THEN 1 ELSE 0 END), 0)
+		FROM users
+		LEFT JOIN todos ON todos.user_id = users.id
+		WHERE ` + where + `
+		GROUP BY users.id
+		ORDER BY users.created_at DESC, users.id DESC
+	`
+	if q.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, q.Limit, q.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u model.UserSummary
+		if err := rows.Scan(
+			&u.ID,
+			&u.Username,
+			&u.Email,
+			&u.Role,
+			scanSQLiteNullTime(&u.EmailVerifiedAt),
+			scanSQLiteNullTime(&u.DisabledAt),
+			scanSQLiteNullTime(&u.DeleteAfter),
+			scanSQLiteTime(&u.CreatedAt),
+			scanSQLiteTime(&u.UpdatedAt),
+			&u.TodoCount,
+			&u.CompletedTodoCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		page.Users = append(page.Users, &u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return page, nil
+}
+
+// Stats computes the instance-wide numbers as of now
+func (r *SQLiteAdminRepository) Stats(ctx context.Context, now time.Time) (*model.SystemStats, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users),
+			(SELECT COUNT(*) FROM users WHERE role = ?1),
+			(SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+			(SELECT COUNT(*) FROM users WHERE email_verified_at IS NULL),
+			(SELECT COUNT(*) FROM users WHERE delete_after IS NOT NULL),
+			(SELECT COUNT(*) FROM users WHERE created_at >= ?2),
+			(SELECT COUNT(*) FROM todos),
+			(SELECT COUNT(*) FROM todos WHERE is_done),
+			(SELECT COUNT(*) FROM todos WHERE NOT is_done AND due_date < ?3),
+			(SELECT COUNT(*) FROM sessions WHERE revoked_at IS NULL AND expires_at > ?3)
+	`
+
+	var stats model.SystemStats
+	err := r.db.QueryRowContext(ctx, query, model.RoleAdmin, sqliteTime(now.Add(-newUserWindow)), sqliteTime(now)).Scan(
+		&stats.Users,
+		&stats.Admins,
+		&stats.DisabledUsers,
+		&stats.UnverifiedUsers,
+		&stats.PendingDeletion,
+		&stats.NewUsers,
+		&stats.Todos,
+		&stats.CompletedTodos,
+		&stats.OverdueTodos,
+		&stats.ActiveSessions,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// sqliteUserFilterSQL builds the WHERE clause and arguments for a UserQuery
+func sqliteUserFilterSQL(q model.UserQuery) (string, []interface{}) {
+	var args []interface{}
+	conditions := []string{"1 = 1"}
+
+	if q.Search != "" {
+		pattern := userSearchPattern(q.Search)
+		conditions = append(conditions, `(LOWER(username) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if q.Role != "" {
+		conditions = append(conditions, "role = ?")
+		args = append(args, q.Role)
+	}
+	if q.Disabled != nil {
+		if *q.Disabled {
+			conditions = append(conditions, "disabled_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "disabled_at IS NULL")
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteListRepository handles list-related database operations on SQLite
+type SQLiteListRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteListRepository creates a list repository backed by db
+func NewSQLiteListRepository(db *sql.DB) *SQLiteListRepository {
+	return &SQLiteListRepository{db: db}
+}
+
+// ListLists retrieves the user's lists by position, with their todo counts
+func (r *SQLiteListRepository) ListLists(ctx context.Context, userID int, includeArchived bool) ([]*model.List, error) {
+	query := `
+		SELECT ` + listColumns + `, COUNT(todos.id)
+		FROM lists
+		LEFT JOIN todos ON todos.list_id = lists.id
+		WHERE lists.user_id = ? AND (? OR NOT lists.archived)
+		GROUP BY lists.id
+		ORDER BY lists.position, lists.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []*model.List{}
+	for rows.Next() {
+		var count int
+		list, err := scanSQLiteList(rows, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan list: %w", err)
+		}
+		list.TodoCount = &count
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read lists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// GetList retrieves a list by ID and user ID
+func (r *SQLiteListRepository) GetList(ctx context.Context, listID, userID int) (*model.List, error) {
+	query := `SELECT ` + listColumns + ` FROM lists WHERE id = ? AND user_id = ?`
+
+	list, err := scanSQLiteList(r.db.QueryRowContext(ctx, query, listID, userID))
+	if isNoRows(err) {
+		return nil, errListNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	return list, nil
+}
+
+// CreateList appends a list unless the user has one of the same name
+func (r *SQLiteListRepository) CreateList(ctx context.Context, list *model.List) error {
+	query := `
+		INSERT INTO lists (user_id, name, name_key, color, icon, position, created_at, updated_at)
+		SELECT ?, ?, ?, ?, ?, COALESCE(MAX(position) + 1, 0), ?, ?
+		FROM lists
+		WHERE user_id = ?
+		RETURNING id, position
+	`
+
+	now := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		list.UserID,
+		list.Name,
+		nameKey(list.Name),
+		list.Color,
+		list.Icon,
+		sqliteTime(now),
+		sqliteTime(now),
+		list.UserID,
+	).Scan(&list.ID, &list.Position)
+	if isUniqueViolation(err) {
+		return errListExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create list: %w", err)
+	}
+
+	list.CreatedAt = now
+	list.UpdatedAt = now
+	return nil
+}
+
+// EnsureList retrieves the user's list with the given name, appending it if
+// missing, in one transaction
+func (r *SQLiteListRepository) EnsureList(ctx context.Context, userID int, name, color string) (*model.List, error) {
+	insert := `
+		INSERT INTO lists (user_id, name, name_key, color, position, created_at, updated_at)
+		SELECT ?, ?, ?, ?, COALESCE(MAX(position) + 1, 0), ?, ?
+		FROM lists
+		WHERE user_id = ?
+		ON CONFLICT (user_id, name_key) DO NOTHING
+	`
+	query := `SELECT ` + listColumns + ` FROM lists WHERE user_id = ? AND name_key = ?`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	key := nameKey(name)
+	now := sqliteTime(time.Now())
+	if _, err := tx.ExecContext(ctx, insert, userID, name, key, color, now, now, userID); err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+
+	list, err := scanSQLiteList(tx.QueryRowContext(ctx, query, userID, key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return list, nil
+}
+
+// UpdateList renames, recolors and archives a list
+func (r *SQLiteListRepository) UpdateList(ctx context.Context, list *model.List) error {
+	query := `
+		UPDATE lists
+		SET name = ?, name_key = ?, color = ?, icon = ?, archived = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?
+		RETURNING position, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		list.Name,
+		nameKey(list.Name),
+		list.Color,
+		list.Icon,
+		list.Archived,
+		sqliteTime(time.Now()),
+		list.ID,
+		list.UserID,
+	).Scan(&list.Position, scanSQLiteTime(&list.CreatedAt), scanSQLiteTime(&list.UpdatedAt))
+	if isUniqueViolation(err) {
+		return errListExists
+	}
+	if isNoRows(err) {
+		return errListNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update list: %w", err)
+	}
+
+	return nil
+}
+
+// ReorderLists moves each list to its index in listIDs in one transaction
+func (r *SQLiteListRepository) ReorderLists(ctx context.Context, userID int, listIDs []int) error {
+	query := `UPDATE lists SET position = ?, updated_at = ? WHERE id = ? AND user_id = ?`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := sqliteTime(time.Now())
+	for position, listID := range listIDs {
+		result, err := tx.ExecContext(ctx, query, position, now, listID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to reorder lists: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to reorder lists: %w", err)
+		}
+		if affected == 0 {
+			return errListNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteList moves or deletes the todos of a list and deletes the list in
+// one transaction
+func (r *SQLiteListRepository) DeleteList(ctx context.Context, listID, userID int, d model.ListDeletion) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A target equal to the list counts once, so it is rejected too
+	ids := []int{listID}
+	if d.MoveTo != nil {
+		ids = append(ids, *d.MoveTo)
+	}
+	in, args := sqliteInList(ids)
+	var owned int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM lists WHERE id IN (`+in+`) AND user_id = ?`, append(args, userID)...).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("failed to get lists: %w", err)
+	}
+	if owned != len(ids) {
+		return errListNotFound
+	}
+
+	if d.DeleteTodos {
+		_, err = tx.ExecContext(ctx, `DELETE FROM todos WHERE list_id = ?`, listID)
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE todos SET list_id = ? WHERE list_id = ?`, d.MoveTo, listID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM lists WHERE id = ?`, listID); err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// scanSQLiteList reads a row selected with listColumns, followed by any extra columns
+func scanSQLiteList(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*model.List, error) {
+	var list model.List
+	dest := append([]interface{}{
+		&list.ID,
+		&list.UserID,
+		&list.Name,
+		&list.Color,
+		&list.Icon,
+		&list.Position,
+		&list.Archived,
+		scanSQLiteTime(&list.CreatedAt),
+		scanSQLiteTime(&list.UpdatedAt),
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteLoginAttemptRepository handles failed login counter database operations on SQLite
+type SQLiteLoginAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteLoginAttemptRepository creates a login attempt repository backed by db
+func NewSQLiteLoginAttemptRepository(db *sql.DB) *SQLiteLoginAttemptRepository {
+	return &SQLiteLoginAttemptRepository{db: db}
+}
+
+// GetLoginAttempts retrieves the counter of a key
+func (r *SQLiteLoginAttemptRepository) GetLoginAttempts(ctx context.Context, key string) (*model.LoginAttempts, error) {
+	query := `
+		SELECT throttle_key, failures, last_failure_at, blocked_until
+		FROM login_attempts
+		WHERE throttle_key = ?
+	`
+
+	attempts, err := scanSQLiteLoginAttempts(r.db.QueryRowContext(ctx, query, key))
+	if isNoRows(err) {
+		return nil, errLoginAttemptsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// RecordLoginAttempt counts a login for a key as failed unless the key is
+// blocked or out of attempts
+func (r *SQLiteLoginAttemptRepository) RecordLoginAttempt(ctx context.Context, key string, t, resetBefore time.Time, limit int) (*model.LoginAttempts, bool, error) {
+	query := `
+		INSERT INTO login_attempts (throttle_key, failures, last_failure_at)
+		VALUES (?1, 1, ?2)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ?3 THEN 1 ELSE login_attempts.failures + 1 END,
+			blocked_until = CASE WHEN login_attempts.last_failure_at < ?3 THEN NULL ELSE login_attempts.blocked_until END,
+			last_failure_at = ?2
+		WHERE login_attempts.last_failure_at < ?3
+			OR ((login_attempts.blocked_until IS NULL OR login_attempts.blocked_until <= ?2) AND login_attempts.failures < ?4)
+		RETURNING throttle_key, failures, last_failure_at, blocked_until
+	`
+
+	attempts, err := scanSQLiteLoginAttempts(r.db.QueryRowContext(ctx, query, key, sqliteTime(t), sqliteTime(resetBefore), limit))
+	if isNoRows(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	return attempts, true, nil
+}
+
+// ForgiveLoginFailure takes back a counted attempt of a key
+func (r *SQLiteLoginAttemptRepository) ForgiveLoginFailure(ctx context.Context, key string) error {
+	query := `UPDATE login_attempts SET failures = failures - 1 WHERE throttle_key = ? AND failures > 0`
+
+	if _, err := r.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to forgive login failure: %w", err)
+	}
+
+	return nil
+}
+
+// BlockLogin refuses logins for a key until the given time
+func (r *SQLiteLoginAttemptRepository) BlockLogin(ctx context.Context, key string, until time.Time) error {
+	query := `UPDATE login_attempts SET blocked_until = ? WHERE throttle_key = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(until), key)
+	if err != nil {
+		return fmt.Errorf("failed to block login: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to block login: %w", err)
+	}
+	if affected == 0 {
+		return errLoginAttemptsNotFound
+	}
+
+	return nil
+}
+
+// ClearLoginAttempts deletes the counter of a key
+func (r *SQLiteLoginAttemptRepository) ClearLoginAttempts(ctx context.Context, key string) error {
+	query := `DELETE FROM login_attempts WHERE throttle_key = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteStaleLoginAttempts deletes the counters with neither a failure nor a block after t
+func (r *SQLiteLoginAttemptRepository) DeleteStaleLoginAttempts(ctx context.Context, t time.Time) error {
+	query := `
+		DELETE FROM login_attempts
+		WHERE last_failure_at < ?1 AND (blocked_until IS NULL OR blocked_until < ?1)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(t)); err != nil {
+		return fmt.Errorf("failed to delete stale login attempts: %w", err)
+	}
+
+	return nil
+}
+
+func scanSQLiteLoginAttempts(row interface{ Scan(...interface{}) error }) (*model.LoginAttempts, error) {
+	var attempts model.LoginAttempts
+	err := row.Scan(
+		&attempts.Key,
+		&attempts.Failures,
+		scanSQLiteTime(&attempts.LastFailureAt),
+		scanSQLiteNullTime(&attempts.BlockedUntil),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteNotificationRepository handles in-app notification database operations on SQLite
+type SQLiteNotificationRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteNotificationRepository creates a notification repository backed by db
+func NewSQLiteNotificationRepository(db *sql.DB) *SQLiteNotificationRepository {
+	return &SQLiteNotificationRepository{db: db}
+}
+
+// CreateNotification stores a new notification
+func (r *SQLiteNotificationRepository) CreateNotification(ctx context.Context, notification *model.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, todo_id, title, body, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	notification.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		notification.UserID,
+		notification.TodoID,
+		notification.Title,
+		notification.Body,
+		sqliteTime(notification.CreatedAt),
+	).Scan(&notification.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+// ListNotifications retrieves a user's notifications, newest first
+func (r *SQLiteNotificationRepository) ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit int) ([]*model.Notification, error) {
+	query := `
+		SELECT id, user_id, todo_id, title, body, read_at, created_at
+		FROM notifications
+		WHERE user_id = ? AND (NOT ? OR read_at IS NULL)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := r.db.QueryContext(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*model.Notification{}
+	for rows.Next() {
+		var n model.Notification
+		err := rows.Scan(
+			&n.ID,
+			&n.UserID,
+			&n.TodoID,
+			&n.Title,
+			&n.Body,
+			scanSQLiteNullTime(&n.ReadAt),
+			scanSQLiteTime(&n.CreatedAt),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead marks a notification of the user read
+func (r *SQLiteNotificationRepository) MarkNotificationRead(ctx context.Context, notificationID, userID int, readAt time.Time) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, ?)
+		WHERE id = ? AND user_id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(readAt), notificationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	if affected == 0 {
+		return errNotificationNotFound
+	}
+
+	return nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user read
+func (r *SQLiteNotificationRepository) MarkAllNotificationsRead(ctx context.Context, userID int, readAt time.Time) error {
+	query := `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(readAt), userID); err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteOIDCRepository handles OpenID Connect login and identity database operations on SQLite
+type SQLiteOIDCRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteOIDCRepository creates an OIDC repository backed by db
+func NewSQLiteOIDCRepository(db *sql.DB) *SQLiteOIDCRepository {
+	return &SQLiteOIDCRepository{db: db}
+}
+
+// CreateOIDCLogin stores a login started with a provider
+func (r *SQLiteOIDCRepository) CreateOIDCLogin(ctx context.Context, login *model.OIDCLogin) error {
+	query := `
+		INSERT INTO oidc_logins (state_hash, provider, code_verifier, nonce, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	login.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		login.StateHash,
+		login.Provider,
+		login.CodeVerifier,
+		login.Nonce,
+		sqliteTime(login.ExpiresAt),
+		sqliteTime(login.CreatedAt),
+	).Scan(&login.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create oidc login: %w", err)
+	}
+
+	return nil
+}
+
+// TakeOIDCLogin deletes and returns the login with the given state hash
+func (r *SQLiteOIDCRepository) TakeOIDCLogin(ctx context.Context, stateHash string) (*model.OIDCLogin, error) {
+	query := `
+		DELETE FROM oidc_logins
+		WHERE state_hash = ?
+		RETURNING id, state_hash, provider, code_verifier, nonce, expires_at, created_at
+	`
+
+	var login model.OIDCLogin
+	err := r.db.QueryRowContext(ctx, query, stateHash).Scan(
+		&login.ID,
+		&login.StateHash,
+		&login.Provider,
+		&login.CodeVerifier,
+		&login.Nonce,
+		scanSQLiteTime(&login.ExpiresAt),
+		scanSQLiteTime(&login.CreatedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errOIDCLoginNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take oidc login: %w", err)
+	}
+
+	return &login, nil
+}
+
+// DeleteExpiredOIDCLogins deletes the logins that expired before t
+func (r *SQLiteOIDCRepository) DeleteExpiredOIDCLogins(ctx context.Context, t time.Time) error {
+	query := `DELETE FROM oidc_logins WHERE expires_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(t)); err != nil {
+		return fmt.Errorf("failed to delete expired oidc logins: %w", err)
+	}
+
+	return nil
+}
+
+// GetIdentity retrieves the link of a provider account
+func (r *SQLiteOIDCRepository) GetIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities
+		WHERE provider = ? AND subject = ?
+	`
+
+	var identity model.UserIdentity
+	err := r.db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		scanSQLiteTime(&identity.CreatedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// CreateIdentity links a provider account to a user
+func (r *SQLiteOIDCRepository) CreateIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	identity.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		identity.UserID,
+		identity.Provider,
+		identity.Subject,
+		identity.Email,
+		sqliteTime(identity.CreatedAt),
+	).Scan(&identity.ID)
+
+	if isUniqueViolation(err) {
+		return errIdentityExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteReminderRepository handles reminder database operations on SQLite
+type SQLiteReminderRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteReminderRepository creates a reminder repository backed by db
+func NewSQLiteReminderRepository(db *sql.DB) *SQLiteReminderRepository {
+	return &SQLiteReminderRepository{db: db}
+}
+
+// ListTodoReminders retrieves the reminders of the todos, oldest first
+func (r *SQLiteReminderRepository) ListTodoReminders(ctx context.Context, todoIDs []int) (map[int][]*model.Reminder, error) {
+	reminders := make(map[int][]*model.Reminder)
+	if len(todoIDs) == 0 {
+		return reminders, nil
+	}
+
+	in, args := sqliteInList(todoIDs)
+	query := `
+		SELECT id, todo_id, remind_at, offset_minutes, channels, webhook_url, fire_at,
+		       sent_at, failed_at, attempts, last_error, created_at
+		FROM reminders
+		WHERE todo_id IN (` + in + `)
+		ORDER BY todo_id, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reminder, err := scanSQLiteReminder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		reminders[reminder.TodoID] = append(reminders[reminder.TodoID], reminder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+
+	return reminders, nil
+}
+
+// CreateReminder stores a new reminder
+func (r *SQLiteReminderRepository) CreateReminder(ctx context.Context, reminder *model.Reminder) error {
+	query := `
+		INSERT INTO reminders (todo_id, remind_at, offset_minutes, channels, webhook_url, fire_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	reminder.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		reminder.TodoID,
+		sqliteNullTime(reminder.RemindAt),
+		reminder.OffsetMinutes,
+		strings.Join(reminder.Channels, " "),
+		reminder.WebhookURL,
+		sqliteNullTime(reminder.FireAt),
+		sqliteTime(reminder.CreatedAt),
+	).Scan(&reminder.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteReminder deletes a reminder of a todo
+func (r *SQLiteReminderRepository) DeleteReminder(ctx context.Context, todoID, reminderID int) error {
+	query := `DELETE FROM reminders WHERE id = ? AND todo_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, reminderID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if affected == 0 {
+		return errReminderNotFound
+	}
+
+	return nil
+}
+
+// RescheduleReminders schedules the relative reminders of a todo before its
+// new due date
+func (r *SQLiteReminderRepository) RescheduleReminders(ctx context.Context, todoID int, dueDate *time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := rescheduleSQLiteReminders(ctx, tx, todoID, dueDate); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// rescheduleSQLiteReminders schedules the relative reminders of a todo before
+// dueDate within tx. SQLite has no interval arithmetic on the stored
+// timestamps, so the fire times are computed here.
+func rescheduleSQLiteReminders(ctx context.Context, tx *sql.Tx, todoID int, dueDate *time.Time) error {
+	query := `SELECT id, offset_minutes FROM reminders WHERE todo_id = ? AND offset_minutes IS NOT NULL`
+
+	rows, err := tx.QueryContext(ctx, query, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule reminders: %w", err)
+	}
+	offsets := make(map[int]int)
+	for rows.Next() {
+		var id, offset int
+		if err := rows.Scan(&id, &offset); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		offsets[id] = offset
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read reminders: %w", err)
+	}
+
+	update := `
+		UPDATE reminders
+		SET fire_at = ?, locked_until = NULL, attempts = 0, last_error = '', sent_at = NULL, failed_at = NULL
+		WHERE id = ?
+	`
+	for id, offset := range offsets {
+		var fireAt interface{}
+		if dueDate != nil {
+			fireAt = sqliteTime(reminderFireAt(*dueDate, offset))
+		}
+		if _, err := tx.ExecContext(ctx, update, fireAt, id); err != nil {
+			return fmt.Errorf("failed to reschedule reminders: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ClaimDueReminders leases pending reminders that are due. Transactions take
+// the write lock when they begin, so two servers never claim the same
+// reminder.
+func (r *SQLiteReminderRepository) ClaimDueReminders(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.ReminderDelivery, error) {
+	query := `
+		SELECT reminders.id, reminders.todo_id, reminders.remind_at, reminders.offset_minutes,
+		       reminders.channels, reminders.webhook_url, reminders.fire_at, reminders.sent_at,
+		       reminders.failed_at, reminders.attempts, reminders.last_error, reminders.created_at,
+		       users.id, users.username, users.email, todos.title, todos.due_date
+		FROM reminders
+		JOIN todos ON todos.id = reminders.todo_id
+		JOIN users ON users.id = todos.user_id
+		WHERE reminders.fire_at <= ?1
+		  AND reminders.sent_at IS NULL AND reminders.failed_at IS NULL
+		  AND (reminders.locked_until IS NULL OR reminders.locked_until <= ?1)
+		  AND NOT todos.is_done
+		  AND users.disabled_at IS NULL AND users.delete_after IS NULL
+		ORDER BY reminders.fire_at
+		LIMIT ?2
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, sqliteTime(now), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+	var deliveries []*model.ReminderDelivery
+	var ids []int
+	for rows.Next() {
+		var d model.ReminderDelivery
+		var channels string
+		d.Reminder = &model.Reminder{}
+		err := rows.Scan(
+			&d.Reminder.ID,
+			&d.Reminder.TodoID,
+			scanSQLiteNullTime(&d.Reminder.RemindAt),
+			&d.Reminder.OffsetMinutes,
+			&channels,
+			&d.Reminder.WebhookURL,
+			scanSQLiteNullTime(&d.Reminder.FireAt),
+			scanSQLiteNullTime(&d.Reminder.SentAt),
+			scanSQLiteNullTime(&d.Reminder.FailedAt),
+			&d.Reminder.Attempts,
+			&d.Reminder.LastError,
+			scanSQLiteTime(&d.Reminder.CreatedAt),
+			&d.UserID,
+			&d.Username,
+			&d.Email,
+			&d.TodoTitle,
+			scanSQLiteNullTime(&d.DueDate),
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		d.Reminder.Channels = strings.Fields(channels)
+		d.Reminder.Attempts++
+		deliveries = append(deliveries, &d)
+		ids = append(ids, d.Reminder.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reminders: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	in, args := sqliteInList(ids)
+	update := `UPDATE reminders SET locked_until = ?, attempts = attempts + 1 WHERE id IN (` + in + `)`
+	if _, err := tx.ExecContext(ctx, update, append([]interface{}{sqliteTime(leaseUntil)}, args...)...); err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// MarkReminderSent records that a reminder was delivered
+func (r *SQLiteReminderRepository) MarkReminderSent(ctx context.Context, reminderID int, fireAt, sentAt time.Time) error {
+	query := `
+		UPDATE reminders
+		SET sent_at = ?, locked_until = NULL, last_error = ''
+		WHERE id = ? AND fire_at = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(sentAt), reminderID, sqliteTime(fireAt)); err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	return nil
+}
+
+// RetryReminder records a failed delivery and leases the reminder until the
+// next attempt
+func (r *SQLiteReminderRepository) RetryReminder(ctx context.Context, reminderID int, fireAt, retryAt time.Time, lastError string) error {
+	query := `
+		UPDATE reminders
+		SET locked_until = ?, last_error = ?
+		WHERE id = ? AND fire_at = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(retryAt), lastError, reminderID, sqliteTime(fireAt)); err != nil {
+		return fmt.Errorf("failed to retry reminder: %w", err)
+	}
+	return nil
+}
+
+// FailReminder records a failed delivery and gives up on the reminder
+func (r *SQLiteReminderRepository) FailReminder(ctx context.Context, reminderID int, fireAt, failedAt time.Time, lastError string) error {
+	query := `
+		UPDATE reminders
+		SET failed_at = ?, locked_until = NULL, last_error = ?
+		WHERE id = ? AND fire_at = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(failedAt), lastError, reminderID, sqliteTime(fireAt)); err != nil {
+		return fmt.Errorf("failed to fail reminder: %w", err)
+	}
+	return nil
+}
+
+// reminderFireAt returns when a reminder offsetMinutes before dueDate fires
+func reminderFireAt(dueDate time.Time, offsetMinutes int) time.Time {
+	return dueDate.Add(-time.Duration(offsetMinutes) * time.Minute)
+}
+
+// scanSQLiteReminder scans a row selected with the columns of ListReminders
+func scanSQLiteReminder(row interface{ Scan(...interface{}) error }) (*model.Reminder, error) {
+	var reminder model.Reminder
+	var channels string
+	err := row.Scan(
+		&reminder.ID,
+		&reminder.TodoID,
+		scanSQLiteNullTime(&reminder.RemindAt),
+		&reminder.OffsetMinutes,
+		&channels,
+		&reminder.WebhookURL,
+		scanSQLiteNullTime(&reminder.FireAt),
+		scanSQLiteNullTime(&reminder.SentAt),
+		scanSQLiteNullTime(&reminder.FailedAt),
+		&reminder.Attempts,
+		&reminder.LastError,
+		scanSQLiteTime(&reminder.CreatedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	reminder.Channels = strings.Fields(channels)
+	return &reminder, nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteTagRepository handles tag-related database operations on SQLite
+type SQLiteTagRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTagRepository creates a tag repository backed by db
+func NewSQLiteTagRepository(db *sql.DB) *SQLiteTagRepository {
+	return &SQLiteTagRepository{db: db}
+}
+
+// ListTags retrieves the user's tags by name, with their todo counts
+func (r *SQLiteTagRepository) ListTags(ctx context.Context, userID int) ([]*model.Tag, error) {
+	query := `
+		SELECT tags.id, tags.user_id, tags.name, tags.color, COUNT(todo_tags.todo_id)
+		FROM tags
+		LEFT JOIN todo_tags ON todo_tags.tag_id = tags.id
+		WHERE tags.user_id = ?
+		GROUP BY tags.id
+		ORDER BY tags.name_key
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		var count int
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tag.TodoCount = &count
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetTag retrieves a tag by ID and user ID
+func (r *SQLiteTagRepository) GetTag(ctx context.Context, tagID, userID int) (*model.Tag, error) {
+	query := `SELECT id, user_id, name, color FROM tags WHERE id = ? AND user_id = ?`
+
+	var tag model.Tag
+	err := r.db.QueryRowContext(ctx, query, tagID, userID).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color)
+	if isNoRows(err) {
+		return nil, errTagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return &tag, nil
+}
+
+// CreateTag creates a tag unless the user has one of the same name
+func (r *SQLiteTagRepository) CreateTag(ctx context.Context, tag *model.Tag) error {
+	query := `
+		INSERT INTO tags (user_id, name, name_key, color)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query, tag.UserID, tag.Name, nameKey(tag.Name), tag.Color).Scan(&tag.ID)
+	if isUniqueViolation(err) {
+		return errTagExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return nil
+}
+
+// EnsureTags retrieves the user's tags with the given names, creating the
+// missing ones in one transaction
+func (r *SQLiteTagRepository) EnsureTags(ctx context.Context, userID int, names []string, color string) ([]*model.Tag, error) {
+	insert := `
+		INSERT INTO tags (user_id, name, name_key, color)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, name_key) DO NOTHING
+	`
+	query := `SELECT id, user_id, name, color FROM tags WHERE user_id = ? AND name_key = ?`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	byKey := make(map[string]*model.Tag, len(names))
+	keys := nameKeys(names)
+	for i, name := range names {
+		if _, err := tx.ExecContext(ctx, insert, userID, name, keys[i], color); err != nil {
+			return nil, fmt.Errorf("failed to create tag: %w", err)
+		}
+
+		var tag model.Tag
+		err := tx.QueryRowContext(ctx, query, userID, keys[i]).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tag: %w", err)
+		}
+		byKey[keys[i]] = &tag
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return orderTags(byKey, keys)
+}
+
+// UpdateTag renames and recolors a tag
+func (r *SQLiteTagRepository) UpdateTag(ctx context.Context, tag *model.Tag) error {
+	query := `
+		UPDATE tags
+		SET name = ?, name_key = ?, color = ?
+		WHERE id = ? AND user_id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, tag.Name, nameKey(tag.Name), tag.Color, tag.ID, tag.UserID)
+	if isUniqueViolation(err) {
+		return errTagExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+	if affected == 0 {
+		return errTagNotFound
+	}
+
+	return nil
+}
+
+// DeleteTag deletes a tag, removing it from its todos
+func (r *SQLiteTagRepository) DeleteTag(ctx context.Context, tagID, userID int) error {
+	query := `DELETE FROM tags WHERE id = ? AND user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, tagID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if affected == 0 {
+		return errTagNotFound
+	}
+
+	return nil
+}
+
+// MergeTag moves the todos of one tag to another and deletes the first in one transaction
+func (r *SQLiteTagRepository) MergeTag(ctx context.Context, sourceID, targetID, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var owned int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM tags WHERE id IN (?, ?) AND user_id = ?`, sourceID, targetID, userID).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("failed to get tags: %w", err)
+	}
+	if owned != 2 {
+		return errTagNotFound
+	}
+
+	move := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT todo_id, ? FROM todo_tags WHERE tag_id = ?
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, move, targetID, sourceID); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+	// Deleting the source tag deletes its todo_tags rows
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, sourceID); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListTodoTags retrieves the tags of the todos, by name
+func (r *SQLiteTagRepository) ListTodoTags(ctx context.Context, todoIDs []int) (map[int][]*model.Tag, error) {
+	tags := make(map[int][]*model.Tag)
+	if len(todoIDs) == 0 {
+		return tags, nil
+	}
+
+	in, args := sqliteInList(todoIDs)
+	query := `
+		SELECT todo_tags.todo_id, tags.id, tags.user_id, tags.name, tags.color
+		FROM todo_tags
+		JOIN tags ON tags.id = todo_tags.tag_id
+		WHERE todo_tags.todo_id IN (` + in + `)
+		ORDER BY todo_tags.todo_id, tags.name_key
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var tag model.Tag
+		if err := rows.Scan(&todoID, &tag.ID, &tag.UserID, &tag.Name, &tag.Color); err != nil {
+			return nil, fmt.Errorf("failed to scan todo tag: %w", err)
+		}
+		tags[todoID] = append(tags[todoID], &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// SetTodoTags replaces the tags of a todo in one transaction
+func (r *SQLiteTagRepository) SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM todo_tags WHERE todo_id = ?`, todoID); err != nil {
+		return fmt.Errorf("failed to set todo tags: %w", err)
+	}
+	for _, tagID := range tagIDs {
+		_, err := tx.ExecContext(ctx, `INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, todoID, tagID)
+		if err != nil {
+			return fmt.Errorf("failed to set todo tags: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
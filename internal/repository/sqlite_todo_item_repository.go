@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteTodoItemRepository handles checklist item database operations on SQLite
+type SQLiteTodoItemRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTodoItemRepository creates a checklist item repository backed by db
+func NewSQLiteTodoItemRepository(db *sql.DB) *SQLiteTodoItemRepository {
+	return &SQLiteTodoItemRepository{db: db}
+}
+
+// ListTodoItems retrieves the items of the todos, in order
+func (r *SQLiteTodoItemRepository) ListTodoItems(ctx context.Context, todoIDs []int) (map[int][]*model.TodoItem, error) {
+	items := make(map[int][]*model.TodoItem)
+	if len(todoIDs) == 0 {
+		return items, nil
+	}
+
+	in, args := sqliteInList(todoIDs)
+	query := `
+		SELECT id, todo_id, title, is_done, position, created_at, updated_at
+		FROM todo_items
+		WHERE todo_id IN (` + in + `)
+		ORDER BY todo_id, position, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanSQLiteTodoItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo item: %w", err)
+		}
+		items[item.TodoID] = append(items[item.TodoID], item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo items: %w", err)
+	}
+
+	return items, nil
+}
+
+// CountTodoItems counts the done and total items of the todos
+func (r *SQLiteTodoItemRepository) CountTodoItems(ctx context.Context, todoIDs []int) (map[int]*model.TodoProgress, error) {
+	progress := make(map[int]*model.TodoProgress)
+	if len(todoIDs) == 0 {
+		return progress, nil
+	}
+
+	in, args := sqliteInList(todoIDs)
+	query := `
+		SELECT todo_id, COALESCE(SUM(is_done), 0), COUNT(*)
+		FROM todo_items
+		WHERE todo_id IN (` + in + `)
+		GROUP BY todo_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todo items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var p model.TodoProgress
+		if err := rows.Scan(&todoID, &p.Done, &p.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan todo item counts: %w", err)
+		}
+		progress[todoID] = &p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo item counts: %w", err)
+	}
+
+	return progress, nil
+}
+
+// GetTodoItem retrieves an item of a todo
+func (r *SQLiteTodoItemRepository) GetTodoItem(ctx context.Context, todoID, itemID int) (*model.TodoItem, error) {
+	query := `
+		SELECT id, todo_id, title, is_done, position, created_at, updated_at
+		FROM todo_items
+		WHERE id = ? AND todo_id = ?
+	`
+
+	item, err := scanSQLiteTodoItem(r.db.QueryRowContext(ctx, query, itemID, todoID))
+	if isNoRows(err) {
+		return nil, errItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo item: %w", err)
+	}
+
+	return item, nil
+}
+
+// CreateTodoItem appends an item to the items of its todo
+func (r *SQLiteTodoItemRepository) CreateTodoItem(ctx context.Context, item *model.TodoItem) error {
+	query := `
+		INSERT INTO todo_items (todo_id, title, is_done, position, created_at, updated_at)
+		SELECT ?, ?, ?, COALESCE(MAX(position) + 1, 0), ?, ?
+		FROM todo_items
+		WHERE todo_id = ?
+		RETURNING id, position
+	`
+
+	now := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		item.TodoID,
+		item.Title,
+		item.IsDone,
+		sqliteTime(now),
+		sqliteTime(now),
+		item.TodoID,
+	).Scan(&item.ID, &item.Position)
+	if err != nil {
+		return fmt.Errorf("failed to create todo item: %w", err)
+	}
+
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	return nil
+}
+
+// UpdateTodoItem updates the title and done state of an item
+func (r *SQLiteTodoItemRepository) UpdateTodoItem(ctx context.Context, item *model.TodoItem) error {
+	query := `
+		UPDATE todo_items
+		SET title = ?, is_done = ?, updated_at = ?
+		WHERE id = ? AND todo_id = ?
+		RETURNING position, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, item.Title, item.IsDone, sqliteTime(time.Now()), item.ID, item.TodoID).
+		Scan(&item.Position, scanSQLiteTime(&item.CreatedAt), scanSQLiteTime(&item.UpdatedAt))
+	if isNoRows(err) {
+		return errItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update todo item: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTodoItem deletes an item of a todo
+func (r *SQLiteTodoItemRepository) DeleteTodoItem(ctx context.Context, todoID, itemID int) error {
+	query := `DELETE FROM todo_items WHERE id = ? AND todo_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, itemID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo item: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete todo item: %w", err)
+	}
+	if affected == 0 {
+		return errItemNotFound
+	}
+
+	return nil
+}
+
+// ReorderTodoItems moves each item to its index in itemIDs in one transaction
+func (r *SQLiteTodoItemRepository) ReorderTodoItems(ctx context.Context, todoID int, itemIDs []int) error {
+	query := `UPDATE todo_items SET position = ?, updated_at = ? WHERE id = ? AND todo_id = ?`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := sqliteTime(time.Now())
+	for position, itemID := range itemIDs {
+		result, err := tx.ExecContext(ctx, query, position, now, itemID, todoID)
+		if err != nil {
+			return fmt.Errorf("failed to reorder todo items: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to reorder todo items: %w", err)
+		}
+		if affected == 0 {
+			return errItemNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CompleteTodoItems marks every item of the todo done
+func (r *SQLiteTodoItemRepository) CompleteTodoItems(ctx context.Context, todoID int) error {
+	query := `UPDATE todo_items SET is_done = 1, updated_at = ? WHERE todo_id = ? AND NOT is_done`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), todoID); err != nil {
+		return fmt.Errorf("failed to complete todo items: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteInList returns the placeholders and arguments of an IN list of values
+func sqliteInList[T any](values []T) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
+// scanSQLiteTodoItem scans a row selected with the columns of GetTodoItem
+func scanSQLiteTodoItem(row interface{ Scan(...interface{}) error }) (*model.TodoItem, error) {
+	var item model.TodoItem
+	err := row.Scan(
+		&item.ID,
+		&item.TodoID,
+		&item.Title,
+		&item.IsDone,
+		&item.Position,
+		scanSQLiteTime(&item.CreatedAt),
+		scanSQLiteTime(&item.UpdatedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
@@ -0,0 +1,534 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteTodoRepository handles todo-related database operations on SQLite
+type SQLiteTodoRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTodoRepository creates a todo repository backed by db
+func NewSQLiteTodoRepository(db *sql.DB) *SQLiteTodoRepository {
+	return &SQLiteTodoRepository{db: db}
+}
+
+// sqliteTodoColumns is qualified so it can be used when joining the FTS5 table
+const sqliteTodoColumns = `todos.id, todos.user_id, todos.title, COALESCE(todos.description, ''), todos.list_id,
+	todos.is_done, todos.priority, todos.due_date, todos.recurrence_rule, todos.recurrence_mode, todos.created_at,
+	todos.updated_at`
+
+// GetTodosByUserID retrieves all todos for a specific user
+func (r *SQLiteTodoRepository) GetTodosByUserID(ctx context.Context, userID int) ([]*model.Todo, error) {
+	query := `
+		SELECT ` + sqliteTodoColumns + `
+		FROM todos
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSQLiteTodos(rows)
+}
+
+// ListTodos retrieves one page of a user's todos matching the query, using
+// keyset pagination on the sort key and ID
+func (r *SQLiteTodoRepository) ListTodos(ctx context.Context, userID int, q model.TodoQuery) (*model.TodoPage, error) {
+	cursor, err := decodeTodoCursor(q)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := sqliteTodoFilterSQL(userID, q)
+
+	page := &model.TodoPage{Todos: []*model.Todo{}}
+	countQuery := "SELECT COUNT(*) FROM todos WHERE " + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	sortExpr := sqliteTodoSortSQL(q)
+	direction, comparison := "ASC", ">"
+	if q.Order == model.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		args = append(args, sqliteTodoCursorArg(q, cursor), cursor.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s (?, ?)", sortExpr, comparison)
+	}
+
+	query := `
+		SELECT ` + sqliteTodoColumns + `
+		FROM todos
+		WHERE ` + where + `
+		ORDER BY ` + sortExpr + ` ` + direction + `, id ` + direction
+
+	// Fetch one extra row to learn whether there is a next page
+	if q.Limit > 0 {
+		args = append(args, q.Limit+1)
+		query += " LIMIT ?"
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos, err := scanSQLiteTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Limit > 0 && len(todos) > q.Limit {
+		todos = todos[:q.Limit]
+		page.NextCursor = encodeTodoCursor(q, todos[len(todos)-1])
+	}
+	page.Todos = append(page.Todos, todos...)
+
+	return page, nil
+}
+
+// SearchTodos runs a ranked full-text search over a user's todos using the
+// FTS5 index. Like the in-memory store it matches whole words without stemming.
+func (r *SQLiteTodoRepository) SearchTodos(ctx context.Context, userID int, s model.TodoSearch) ([]*model.TodoSearchResult, error) {
+	matcher := newTextMatcher(s)
+	results := []*model.TodoSearchResult{}
+	if matcher.empty() {
+		return results, nil
+	}
+
+	// bm25 is lower for better matches; weigh titles like PostgreSQL's A and B weights
+	query := `
+		SELECT ` + sqliteTodoColumns + `, -bm25(todos_fts, 1.0, 0.4) AS rank
+		FROM todos_fts
+		JOIN todos ON todos.id = todos_fts.rowid
+		WHERE todos_fts MATCH ? AND todos.user_id = ?
+		ORDER BY rank DESC, todos.id DESC
+		LIMIT ? OFFSET ?
+	`
+
+	limit := s.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := r.db.QueryContext(ctx, query, ftsQuery(s), userID, limit, s.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result model.TodoSearchResult
+		todo, err := scanSQLiteTodo(rows, &result.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Todo = todo
+		result.TitleHighlight = matcher.highlight(todo.Title)
+		result.DescriptionHighlight = matcher.highlight(todo.Description)
+		results = append(results, &result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetTodoByID retrieves a specific todo by ID and user ID
+func (r *SQLiteTodoRepository) GetTodoByID(ctx context.Context, todoID, userID int) (*model.Todo, error) {
+	query := `
+		SELECT ` + sqliteTodoColumns + `
+		FROM todos
+		WHERE id = ? AND user_id = ?
+	`
+
+	todo, err := scanSQLiteTodo(r.db.QueryRowContext(ctx, query, todoID, userID))
+	if isNoRows(err) {
+		return nil, errTodoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	return todo, nil
+}
+
+// CreateTodo creates a new todo
+func (r *SQLiteTodoRepository) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	if err := createSQLiteTodo(ctx, r.db.QueryRowContext, todo); err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+	return nil
+}
+
+// UpdateTodo updates an existing todo
+func (r *SQLiteTodoRepository) UpdateTodo(ctx context.Context, todo *model.Todo) error {
+	err := updateSQLiteTodo(ctx, r.db.QueryRowContext, todo)
+	if isNoRows(err) {
+		return errTodoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+	return nil
+}
+
+// CompleteRecurringTodo saves the completed todo and creates its next
+// occurrence with the same tags and checklist, in one transaction
+func (r *SQLiteTodoRepository) CompleteRecurringTodo(ctx context.Context, todo, next *model.Todo) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Claim the todo first, so that of concurrent completions only one
+	// creates the next occurrence
+	claim := `
+		UPDATE todos SET is_done = TRUE
+		WHERE id = ? AND user_id = ? AND NOT is_done AND recurrence_rule <> ''
+	`
+	result, err := tx.ExecContext(ctx, claim, todo.ID, todo.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to claim todo: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to claim todo: %w", err)
+	}
+	if affected == 0 {
+		var exists bool
+		query := `SELECT EXISTS (SELECT 1 FROM todos WHERE id = ? AND user_id = ?)`
+		if err := tx.QueryRowContext(ctx, query, todo.ID, todo.UserID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to get todo: %w", err)
+		}
+		if exists {
+			return errTodoCompleted
+		}
+		return errTodoNotFound
+	}
+
+	err = updateSQLiteTodo(ctx, tx.QueryRowContext, todo)
+	if isNoRows(err) {
+		return errTodoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+	if err := createSQLiteTodo(ctx, tx.QueryRowContext, next); err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	copyTags := `INSERT INTO todo_tags (todo_id, tag_id) SELECT ?, tag_id FROM todo_tags WHERE todo_id = ?`
+	if _, err := tx.ExecContext(ctx, copyTags, next.ID, todo.ID); err != nil {
+		return fmt.Errorf("failed to copy tags: %w", err)
+	}
+	now := sqliteTime(time.Now())
+	copyItems := `
+		INSERT INTO todo_items (todo_id, title, position, created_at, updated_at)
+		SELECT ?, title, position, ?, ? FROM todo_items WHERE todo_id = ?
+	`
+	if _, err := tx.ExecContext(ctx, copyItems, next.ID, now, now, todo.ID); err != nil {
+		return fmt.Errorf("failed to copy items: %w", err)
+	}
+	copyReminders := `
+		INSERT INTO reminders (todo_id, offset_minutes, channels, webhook_url, created_at)
+		SELECT ?, offset_minutes, channels, webhook_url, ?
+		FROM reminders
+		WHERE todo_id = ? AND offset_minutes IS NOT NULL
+		ORDER BY id
+	`
+	if _, err := tx.ExecContext(ctx, copyReminders, next.ID, now, todo.ID); err != nil {
+		return fmt.Errorf("failed to copy reminders: %w", err)
+	}
+	if err := rescheduleSQLiteReminders(ctx, tx, next.ID, next.DueDate); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// createSQLiteTodo inserts a todo with the database or a transaction
+func createSQLiteTodo(ctx context.Context, queryRow func(context.Context, string, ...interface{}) *sql.Row, todo *model.Todo) error {
+	query := `
+		INSERT INTO todos (user_id, title, description, list_id, priority, due_date, recurrence_rule, recurrence_mode,
+		                   title_key, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, is_done, created_at, updated_at
+	`
+
+	// Default priority if empty
+	if todo.Priority == "" {
+		todo.Priority = DefaultPriority
+	}
+
+	now := sqliteTime(time.Now())
+	return queryRow(ctx, query,
+		todo.UserID,
+		todo.Title,
+		todo.Description,
+		todo.ListID,
+		todo.Priority,
+		sqliteNullTime(todo.DueDate),
+		todo.RecurrenceRule,
+		todo.RecurrenceMode,
+		titleKey(todo.Title),
+		now,
+		now,
+	).Scan(&todo.ID, &todo.IsDone, scanSQLiteTime(&todo.CreatedAt), scanSQLiteTime(&todo.UpdatedAt))
+}
+
+// updateSQLiteTodo saves a todo with the database or a transaction
+func updateSQLiteTodo(ctx context.Context, queryRow func(context.Context, string, ...interface{}) *sql.Row, todo *model.Todo) error {
+	query := `
+		UPDATE todos
+		SET title = COALESCE(?, title),
+		    description = COALESCE(?, description),
+		    list_id = ?,
+		    is_done = COALESCE(?, is_done),
+		    priority = COALESCE(?, priority),
+		    due_date = ?,
+		    recurrence_rule = ?,
+		    recurrence_mode = ?,
+		    title_key = ?,
+		    updated_at = ?
+		WHERE id = ? AND user_id = ?
+		RETURNING title, COALESCE(description, ''), list_id, is_done, priority, due_date, recurrence_rule, recurrence_mode,
+		          created_at, updated_at
+	`
+
+	return queryRow(ctx, query,
+		todo.Title,
+		todo.Description,
+		todo.ListID,
+		todo.IsDone,
+		todo.Priority,
+		sqliteNullTime(todo.DueDate),
+		todo.RecurrenceRule,
+		todo.RecurrenceMode,
+		titleKey(todo.Title),
+		sqliteTime(time.Now()),
+		todo.ID,
+		todo.UserID,
+	).Scan(
+		&todo.Title,
+		&todo.Description,
+		&todo.ListID,
+		&todo.IsDone,
+		&todo.Priority,
+		scanSQLiteNullTime(&todo.DueDate),
+		&todo.RecurrenceRule,
+		&todo.RecurrenceMode,
+		scanSQLiteTime(&todo.CreatedAt),
+		scanSQLiteTime(&todo.UpdatedAt),
+	)
+}
+
+// DeleteTodo deletes a todo by ID and user ID
+func (r *SQLiteTodoRepository) DeleteTodo(ctx context.Context, todoID, userID int) error {
+	query := `
+		DELETE FROM todos
+		WHERE id = ? AND user_id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, todoID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+	if affected == 0 {
+		return errTodoNotFound
+	}
+
+	return nil
+}
+
+// scanSQLiteTodo reads a single todo row, followed by any extra columns
+func scanSQLiteTodo(row interface{ Scan(...interface{}) error }, extra ...interface{}) (*model.Todo, error) {
+	var todo model.Todo
+	dest := append([]interface{}{
+		&todo.ID,
+		&todo.UserID,
+		&todo.Title,
+		&todo.Description,
+		&todo.ListID,
+		&todo.IsDone,
+		&todo.Priority,
+		scanSQLiteNullTime(&todo.DueDate),
+		&todo.RecurrenceRule,
+		&todo.RecurrenceMode,
+		scanSQLiteTime(&todo.CreatedAt),
+		scanSQLiteTime(&todo.UpdatedAt),
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// scanSQLiteTodos reads every row of a todo query
+func scanSQLiteTodos(rows *sql.Rows) ([]*model.Todo, error) {
+	var todos []*model.Todo
+	for rows.Next() {
+		todo, err := scanSQLiteTodo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todos: %w", err)
+	}
+
+	return todos, nil
+}
+
+// sqliteTodoSortSQL returns the expression todos are ordered by. Missing due
+// dates are mapped past the last or before the first timestamp so they sort
+// last in either direction.
+func sqliteTodoSortSQL(q model.TodoQuery) string {
+	switch q.Sort {
+	case model.TodoSortUpdatedAt:
+		return "updated_at"
+	case model.TodoSortDueDate:
+		if q.Order == model.SortDesc {
+			return "COALESCE(due_date, '')"
+		}
+		return "COALESCE(due_date, '" + sqliteMaxTime + "')"
+	case model.TodoSortPriority:
+		return "(" + priorityRankSQL + ")"
+	case model.TodoSortTitle:
+		return "title_key"
+	default:
+		return "created_at"
+	}
+}
+
+// sqliteTodoCursorArg converts the cursor's sort key to a query parameter
+func sqliteTodoCursorArg(q model.TodoQuery, c *todoCursor) interface{} {
+	t, _ := c.todo()
+	switch q.Sort {
+	case model.TodoSortUpdatedAt:
+		return sqliteTime(t.UpdatedAt)
+	case model.TodoSortDueDate:
+		if t.DueDate == nil {
+			if q.Order == model.SortDesc {
+				return ""
+			}
+			return sqliteMaxTime
+		}
+		return sqliteTime(*t.DueDate)
+	case model.TodoSortPriority:
+		return priorityRank[t.Priority]
+	case model.TodoSortTitle:
+		return c.Value
+	default:
+		return sqliteTime(t.CreatedAt)
+	}
+}
+
+// sqliteTodoFilterSQL builds the WHERE clause and arguments for the filters of q
+func sqliteTodoFilterSQL(userID int, q model.TodoQuery) (string, []interface{}) {
+	args := []interface{}{userID}
+	conditions := []string{"user_id = ?"}
+
+	if q.ListID != nil {
+		conditions = append(conditions, "list_id = ?")
+		args = append(args, *q.ListID)
+	}
+	if len(q.Priorities) > 0 {
+		conditions = append(conditions, "priority IN (?"+strings.Repeat(", ?", len(q.Priorities)-1)+")")
+		for _, p := range q.Priorities {
+			args = append(args, p)
+		}
+	}
+	if q.IsDone != nil {
+		conditions = append(conditions, "is_done = ?")
+		args = append(args, *q.IsDone)
+	}
+	for _, tag := range q.Tags {
+		conditions = append(conditions, "EXISTS ("+todoTagSQL+" AND tags.name_key = ?)")
+		args = append(args, nameKey(tag))
+	}
+	for _, f := range []struct {
+		condition string
+		tags      []string
+	}{
+		{"EXISTS", q.AnyTags},
+		{"NOT EXISTS", q.ExcludeTags},
+	} {
+		if len(f.tags) == 0 {
+			continue
+		}
+		in, keys := sqliteInList(nameKeys(f.tags))
+		conditions = append(conditions, f.condition+" ("+todoTagSQL+" AND tags.name_key IN ("+in+"))")
+		args = append(args, keys...)
+	}
+	for _, r := range []struct {
+		column   string
+		from, to *time.Time
+	}{
+		{"due_date", q.DueFrom, q.DueTo},
+		{"created_at", q.CreatedFrom, q.CreatedTo},
+		{"updated_at", q.UpdatedFrom, q.UpdatedTo},
+	} {
+		if r.from != nil {
+			conditions = append(conditions, r.column+" >= ?")
+			args = append(args, sqliteTime(*r.from))
+		}
+		if r.to != nil {
+			conditions = append(conditions, r.column+" <= ?")
+			args = append(args, sqliteTime(*r.to))
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// ftsQuery builds an FTS5 query requiring every word, prefix and phrase of s.
+// Each term is quoted so user input can't inject FTS5 syntax.
+func ftsQuery(s model.TodoSearch) string {
+	quote := func(text string) string {
+		return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+	}
+
+	var terms []string
+	for _, w := range s.Words {
+		for _, word := range splitWords(w) {
+			terms = append(terms, quote(word))
+		}
+	}
+	for _, phrase := range s.Phrases {
+		if words := splitWords(phrase); len(words) > 0 {
+			terms = append(terms, quote(strings.Join(words, " ")))
+		}
+	}
+	for _, prefix := range s.Prefixes {
+		terms = append(terms, quote(strings.ToLower(prefix))+"*")
+	}
+
+	return strings.Join(terms, " ")
+}
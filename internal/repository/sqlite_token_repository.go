@@ -0,0 +1,358 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteTokenRepository handles session and refresh token database operations on SQLite
+type SQLiteTokenRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTokenRepository creates a token repository backed by db
+func NewSQLiteTokenRepository(db *sql.DB) *SQLiteTokenRepository {
+	return &SQLiteTokenRepository{db: db}
+}
+
+// CreateSession creates a new session
+func (r *SQLiteTokenRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	session.CreatedAt = time.Now().UTC()
+	session.LastSeenAt = session.CreatedAt
+	_, err := r.db.ExecContext(ctx, query,
+		session.ID,
+		session.UserID,
+		session.UserAgent,
+		session.IPAddress,
+		sqliteTime(session.CreatedAt),
+		sqliteTime(session.LastSeenAt),
+		sqliteTime(session.ExpiresAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession retrieves a session by its ID
+func (r *SQLiteTokenRepository) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions
+		WHERE id = ?
+	`
+
+	var session model.Session
+	err := r.db.QueryRowContext(ctx, query, sessionID).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
+		scanSQLiteTime(&session.CreatedAt),
+		scanSQLiteTime(&session.LastSeenAt),
+		scanSQLiteTime(&session.ExpiresAt),
+		scanSQLiteNullTime(&session.RevokedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// ListActiveSessions retrieves the user's sessions that are neither revoked nor expired
+func (r *SQLiteTokenRepository) ListActiveSessions(ctx context.Context, userID int) ([]*model.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_seen_at DESC, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, sqliteTime(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		var session model.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IPAddress,
+			scanSQLiteTime(&session.CreatedAt),
+			scanSQLiteTime(&session.LastSeenAt),
+			scanSQLiteTime(&session.ExpiresAt),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records activity on the session
+func (r *SQLiteTokenRepository) TouchSession(ctx context.Context, sessionID, ipAddress string) error {
+	query := `
+		UPDATE sessions
+		SET last_seen_at = ?, ip_address = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), ipAddress, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+	if affected == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// ExtendSession moves the expiry of the session
+func (r *SQLiteTokenRepository) ExtendSession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions
+		SET expires_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(expiresAt), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to extend session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to extend session: %w", err)
+	}
+	if affected == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeSession revokes a session, keeping the original revocation time
+func (r *SQLiteTokenRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = COALESCE(revoked_at, ?)
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if affected == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes every session of the user except exceptID
+func (r *SQLiteTokenRepository) RevokeUserSessions(ctx context.Context, userID int, exceptID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = ?
+		WHERE user_id = ? AND id <> ? AND revoked_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), userID, exceptID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRefreshToken stores a new refresh token
+func (r *SQLiteTokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		token.SessionID,
+		token.TokenHash,
+		sqliteTime(token.ExpiresAt),
+		sqliteTime(token.CreatedAt),
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value
+func (r *SQLiteTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, token_hash, expires_at, created_at, used_at
+		FROM refresh_tokens
+		WHERE token_hash = ?
+	`
+
+	var token model.RefreshToken
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.SessionID,
+		&token.TokenHash,
+		scanSQLiteTime(&token.ExpiresAt),
+		scanSQLiteTime(&token.CreatedAt),
+		scanSQLiteNullTime(&token.UsedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// UseRefreshToken marks a refresh token as used unless it already was
+func (r *SQLiteTokenRepository) UseRefreshToken(ctx context.Context, tokenID int) (bool, error) {
+	query := `
+		UPDATE refresh_tokens
+		SET used_at = ?
+		WHERE id = ? AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), tokenID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token
+func (r *SQLiteTokenRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		token.UserID,
+		token.TokenHash,
+		sqliteTime(token.ExpiresAt),
+		sqliteTime(token.CreatedAt),
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash of its value
+func (r *SQLiteTokenRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = ?
+	`
+
+	var token model.PasswordResetToken
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		scanSQLiteTime(&token.ExpiresAt),
+		scanSQLiteTime(&token.CreatedAt),
+		scanSQLiteNullTime(&token.UsedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errResetTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// UsePasswordResetToken marks a password reset token as used unless it already was
+func (r *SQLiteTokenRepository) UsePasswordResetToken(ctx context.Context, tokenID int) (bool, error) {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = ?
+		WHERE id = ? AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), tokenID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use password reset token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use password reset token: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// DeletePasswordResetTokens deletes every password reset token of the user
+func (r *SQLiteTokenRepository) DeletePasswordResetTokens(ctx context.Context, userID int) error {
+	query := `DELETE FROM password_reset_tokens WHERE user_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+
+	return nil
+}
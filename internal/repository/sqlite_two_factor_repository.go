@@ -0,0 +1,337 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteTwoFactorRepository handles two-factor authentication database operations on SQLite
+type SQLiteTwoFactorRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTwoFactorRepository creates a two-factor repository backed by db
+func NewSQLiteTwoFactorRepository(db *sql.DB) *SQLiteTwoFactorRepository {
+	return &SQLiteTwoFactorRepository{db: db}
+}
+
+// GetTwoFactor retrieves the TOTP settings of a user
+func (r *SQLiteTwoFactorRepository) GetTwoFactor(ctx context.Context, userID int) (*model.TwoFactor, error) {
+	query := `
+		SELECT user_id, secret, last_used_step, enabled_at, created_at
+		FROM user_two_factor
+		WHERE user_id = ?
+	`
+
+	var tf model.TwoFactor
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&tf.UserID,
+		&tf.Secret,
+		&tf.LastUsedStep,
+		scanSQLiteNullTime(&tf.EnabledAt),
+		scanSQLiteTime(&tf.CreatedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errTwoFactorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	return &tf, nil
+}
+
+// SetTOTPSecret stores the secret of a new enrollment unless 2FA is enabled
+func (r *SQLiteTwoFactorRepository) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = excluded.secret, last_used_step = NULL, created_at = excluded.created_at
+		WHERE user_two_factor.enabled_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, secret, sqliteTime(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+	if affected == 0 {
+		return errTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// EnableTwoFactor enables 2FA and replaces the recovery codes in one transaction
+func (r *SQLiteTwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	query := `
+		UPDATE user_two_factor
+		SET enabled_at = ?, last_used_step = ?
+		WHERE user_id = ? AND enabled_at IS NULL
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, sqliteTime(time.Now()), step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if affected == 0 {
+		return errTwoFactorNotFound
+	}
+
+	if err := sqliteReplaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTwoFactor deletes the user's secret, recovery codes and challenges
+func (r *SQLiteTwoFactorRepository) DisableTwoFactor(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM mfa_challenges WHERE user_id = ?`,
+		`DELETE FROM recovery_codes WHERE user_id = ?`,
+		`DELETE FROM user_two_factor WHERE user_id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records step as used unless it or a later step was used before
+func (r *SQLiteTwoFactorRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `
+		UPDATE user_two_factor
+		SET last_used_step = ?
+		WHERE user_id = ? AND (last_used_step IS NULL OR last_used_step < ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// ReplaceRecoveryCodes replaces every recovery code of the user
+func (r *SQLiteTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sqliteReplaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used
+func (r *SQLiteTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = ?
+		WHERE id = (
+			SELECT id FROM recovery_codes
+			WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// CountRecoveryCodes counts the unused recovery codes of the user
+func (r *SQLiteTwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateMFAChallenge stores a new MFA challenge
+func (r *SQLiteTwoFactorRepository) CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (user_id, token_hash, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+		RETURNING id
+	`
+
+	challenge.CreatedAt = time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, query,
+		challenge.UserID,
+		challenge.TokenHash,
+		sqliteTime(challenge.ExpiresAt),
+		sqliteTime(challenge.CreatedAt),
+	).Scan(&challenge.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token
+func (r *SQLiteTwoFactorRepository) GetMFAChallengeByHash(ctx context.Context, tokenHash string) (*model.MFAChallenge, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, attempts, created_at, used_at
+		FROM mfa_challenges
+		WHERE token_hash = ?
+	`
+
+	var challenge model.MFAChallenge
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.TokenHash,
+		scanSQLiteTime(&challenge.ExpiresAt),
+		&challenge.Attempts,
+		scanSQLiteTime(&challenge.CreatedAt),
+		scanSQLiteNullTime(&challenge.UsedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errMFAChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	return &challenge, nil
+}
+
+// AttemptMFAChallenge counts an attempt at an MFA challenge unless it was
+// used or is out of attempts
+func (r *SQLiteTwoFactorRepository) AttemptMFAChallenge(ctx context.Context, challengeID, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE mfa_challenges
+		SET attempts = attempts + 1
+		WHERE id = ? AND attempts < ? AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, challengeID, maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+	return affected == 1, nil
+}
+
+// UseMFAChallenge marks an MFA challenge as used unless it already was
+func (r *SQLiteTwoFactorRepository) UseMFAChallenge(ctx context.Context, challengeID int) (bool, error) {
+	query := `
+		UPDATE mfa_challenges
+		SET used_at = ?
+		WHERE id = ? AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), challengeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+
+	return affected == 1, nil
+}
+
+// ReleaseMFAChallenge marks a used MFA challenge unused again
+func (r *SQLiteTwoFactorRepository) ReleaseMFAChallenge(ctx context.Context, challengeID int) error {
+	query := `UPDATE mfa_challenges SET used_at = NULL WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, challengeID); err != nil {
+		return fmt.Errorf("failed to release mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+// sqliteReplaceRecoveryCodes swaps the user's recovery codes within tx
+func sqliteReplaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	now := sqliteTime(time.Now())
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)`,
+			userID, hash, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// SQLiteUserRepository handles user-related database operations on SQLite
+type SQLiteUserRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteUserRepository creates a user repository backed by db
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{db: db}
+}
+
+// GetUserByEmail retrieves a user by their email
+func (r *SQLiteUserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `
+		SELECT id, username, email, password_hash, role, email_verified_at, disabled_at, delete_after, created_at, updated_at
+		FROM users
+		WHERE email = ?
+	`
+
+	var user model.User
+	err := r.db.QueryRowContext(ctx, query, email).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password, // This will be the hashed password
+		&user.Role,
+		scanSQLiteNullTime(&user.EmailVerifiedAt),
+		scanSQLiteNullTime(&user.DisabledAt),
+		scanSQLiteNullTime(&user.DeleteAfter),
+		scanSQLiteTime(&user.CreatedAt),
+		scanSQLiteTime(&user.UpdatedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetUserByID retrieves a user by their ID
+func (r *SQLiteUserRepository) GetUserByID(ctx context.Context, userID int) (*model.User, error) {
+	query := `
+		SELECT id, username, email, role, email_verified_at, disabled_at, delete_after, created_at, updated_at
+		FROM users
+		WHERE id = ?
+	`
+
+	var user model.User
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		scanSQLiteNullTime(&user.EmailVerifiedAt),
+		scanSQLiteNullTime(&user.DisabledAt),
+		scanSQLiteNullTime(&user.DeleteAfter),
+		scanSQLiteTime(&user.CreatedAt),
+		scanSQLiteTime(&user.UpdatedAt),
+	)
+
+	if isNoRows(err) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// CreateUser creates a new user
+func (r *SQLiteUserRepository) CreateUser(ctx context.Context, user *model.User) error {
+	query := `
+		INSERT INTO users (username, email, password_hash, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id, role, created_at, updated_at
+	`
+
+	now := sqliteTime(time.Now())
+	err := r.db.QueryRowContext(ctx, query,
+		user.Username,
+		user.Email,
+		user.Password, // This should be the hashed password
+		now,
+		now,
+	).Scan(&user.ID, &user.Role, scanSQLiteTime(&user.CreatedAt), scanSQLiteTime(&user.UpdatedAt))
+
+	if isUniqueViolation(err) {
+		return errUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// UserExists checks if a user exists by email or username
+func (r *SQLiteUserRepository) UserExists(ctx context.Context, email, username string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM users WHERE email = ? OR username = ?
+		)
+	`
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, query, email, username).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	return exists, nil
+}
+
+// UpdatePassword replaces the password hash of a user
+func (r *SQLiteUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, passwordHash, sqliteTime(time.Now()), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUsername renames a user
+func (r *SQLiteUserRepository) UpdateUsername(ctx context.Context, userID int, username string) error {
+	query := `
+		UPDATE users
+		SET username = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, username, sqliteTime(time.Now()), userID)
+	if isUniqueViolation(err) {
+		return errUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update username: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update username: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateEmail switches a user to a confirmed new email address
+func (r *SQLiteUserRepository) UpdateEmail(ctx context.Context, userID int, oldEmail, newEmail string) error {
+	query := `
+		UPDATE users
+		SET email = ?, email_verified_at = ?, updated_at = ?
+		WHERE id = ? AND email = ?
+	`
+
+	now := sqliteTime(time.Now())
+	result, err := r.db.ExecContext(ctx, query, newEmail, now, now, userID, oldEmail)
+	if isUniqueViolation(err) {
+		return errUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user confirmed email, unless the
+// address changed in the meantime
+func (r *SQLiteUserRepository) MarkEmailVerified(ctx context.Context, userID int, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, ?)
+		WHERE id = ? AND email = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), userID, email)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// ScheduleDeletion deactivates a user until deleteAfter, when the account is
+// purged
+func (r *SQLiteUserRepository) ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error {
+	query := `UPDATE users SET delete_after = ?, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(deleteAfter), sqliteTime(time.Now()), userID)
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// CancelDeletion restores a user scheduled for deletion
+func (r *SQLiteUserRepository) CancelDeletion(ctx context.Context, userID int) error {
+	query := `UPDATE users SET delete_after = NULL, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(time.Now()), userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers deletes the users whose grace period ended before t;
+// their data goes with them through ON DELETE CASCADE
+func (r *SQLiteUserRepository) PurgeDeletedUsers(ctx context.Context, t time.Time) (int, error) {
+	query := `DELETE FROM users WHERE delete_after < ?`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteTime(t))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+// SetRole changes the role of a user
+func (r *SQLiteUserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	query := `UPDATE users SET role = ?, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, role, sqliteTime(time.Now()), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// SetDisabled disables a user as of disabledAt, or enables them when nil
+func (r *SQLiteUserRepository) SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error {
+	query := `UPDATE users SET disabled_at = ?, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteNullTime(disabledAt), sqliteTime(time.Now()), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set disabled: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set disabled: %w", err)
+	}
+	if affected == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// TodoStore defines the persistence operations for todos.
+// Every method is scoped to the owning user so implementations must never
+// return or modify a todo that belongs to someone else.
+type TodoStore interface {
+	GetTodosByUserID(ctx context.Context, userID int) ([]*model.Todo, error)
+	// ListTodos returns one page of todos matching q. q must already have a
+	// valid Sort and Order; a Limit of zero returns every match.
+	ListTodos(ctx context.Context, userID int, q model.TodoQuery) (*model.TodoPage, error)
+	GetTodoByID(ctx context.Context, todoID, userID int) (*model.Todo, error)
+	// SearchTodos returns the todos matching s, best match first
+	SearchTodos(ctx context.Context, userID int, s model.TodoSearch) ([]*model.TodoSearchResult, error)
+	CreateTodo(ctx context.Context, todo *model.Todo) error
+	UpdateTodo(ctx context.Context, todo *model.Todo) error
+	// CompleteRecurringTodo saves todo like UpdateTodo and creates next with
+	// the tags, checklist items and relative reminders of todo, the items not
+	// done and the reminders scheduled before the due date of next, atomically.
+	// It fails with a conflict if todo is already done or no longer repeats.
+	CompleteRecurringTodo(ctx context.Context, todo, next *model.Todo) error
+	DeleteTodo(ctx context.Context, todoID, userID int) error
+}
+
+// TodoItemStore defines the persistence operations for the checklist items
+// of todos. Items are addressed by their todo; callers check that the todo
+// belongs to the user first.
+type TodoItemStore interface {
+	// ListTodoItems returns the items of each of the todos, in order
+	ListTodoItems(ctx context.Context, todoIDs []int) (map[int][]*model.TodoItem, error)
+	// CountTodoItems returns the progress of each of the todos that have items
+	CountTodoItems(ctx context.Context, todoIDs []int) (map[int]*model.TodoProgress, error)
+	GetTodoItem(ctx context.Context, todoID, itemID int) (*model.TodoItem, error)
+	// CreateTodoItem appends the item to the items of its todo
+	CreateTodoItem(ctx context.Context, item *model.TodoItem) error
+	UpdateTodoItem(ctx context.Context, item *model.TodoItem) error
+	DeleteTodoItem(ctx context.Context, todoID, itemID int) error
+	// ReorderTodoItems moves each item to its index in itemIDs, which must
+	// list every item of the todo
+	ReorderTodoItems(ctx context.Context, todoID int, itemIDs []int) error
+	// CompleteTodoItems marks every item of the todo done
+	CompleteTodoItems(ctx context.Context, todoID int) error
+}
+
+// TagStore defines the persistence operations for tags and the tags of
+// todos. Tag names are unique per user, ignoring case; callers check that
+// todos belong to the user first.
+type TagStore interface {
+	// ListTags returns the user's tags by name, with their todo counts
+	ListTags(ctx context.Context, userID int) ([]*model.Tag, error)
+	GetTag(ctx context.Context, tagID, userID int) (*model.Tag, error)
+	// CreateTag fails with a conflict if the user has a tag of that name
+	CreateTag(ctx context.Context, tag *model.Tag) error
+	// EnsureTags returns the user's tags with the given names in their
+	// order, creating the missing ones with color
+	EnsureTags(ctx context.Context, userID int, names []string, color string) ([]*model.Tag, error)
+	// UpdateTag renames and recolors the tag. It fails with a conflict if
+	// another of the user's tags has the new name.
+	UpdateTag(ctx context.Context, tag *model.Tag) error
+	DeleteTag(ctx context.Context, tagID, userID int) error
+	// MergeTag moves the todos of the tag sourceID to targetID and deletes
+	// the source tag
+	MergeTag(ctx context.Context, sourceID, targetID, userID int) error
+	// ListTodoTags returns the tags of each of the todos that have any, by name
+	ListTodoTags(ctx context.Context, todoIDs []int) (map[int][]*model.Tag, error)
+	// SetTodoTags replaces the tags of the todo
+	SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error
+}
+
+// ListStore defines the persistence operations for the lists todos are
+// grouped in. List names are unique per user, ignoring case.
+type ListStore interface {
+	// ListLists returns the user's lists by position, with their todo
+	// counts. Archived lists are left out unless includeArchived is set.
+	ListLists(ctx context.Context, userID int, includeArchived bool) ([]*model.List, error)
+	GetList(ctx context.Context, listID, userID int) (*model.List, error)
+	// CreateList appends the list to the user's lists. It fails with a
+	// conflict if the user has a list of that name.
+	CreateList(ctx context.Context, list *model.List) error
+	// EnsureList returns the user's list with the given name, appending it
+	// with color if it is missing
+	EnsureList(ctx context.Context, userID int, name, color string) (*model.List, error)
+	// UpdateList renames, recolors and archives the list. It fails with a
+	// conflict if another of the user's lists has the new name.
+	UpdateList(ctx context.Context, list *model.List) error
+	// ReorderLists moves each list to its index in listIDs, which must list
+	// every list of the user
+	ReorderLists(ctx context.Context, userID int, listIDs []int) error
+	// DeleteList deletes the list, first moving or deleting its todos as d
+	// says. d.MoveTo must be another list of the user.
+	DeleteList(ctx context.Context, listID, userID int, d model.ListDeletion) error
+}
+
+// ReminderStore defines the persistence operations for the reminders of
+// todos and their delivery by the scheduler. Reminders are addressed by their
+// todo; callers check that the todo belongs to the user first.
+type ReminderStore interface {
+	// ListTodoReminders returns the reminders of each of the todos that have
+	// any, oldest first
+	ListTodoReminders(ctx context.Context, todoIDs []int) (map[int][]*model.Reminder, error)
+	CreateReminder(ctx context.Context, reminder *model.Reminder) error
+	DeleteReminder(ctx context.Context, todoID, reminderID int) error
+	// RescheduleReminders moves the relative reminders of the todo to fire
+	// before dueDate, or not at all when it is nil, and makes them pending
+	// again
+	RescheduleReminders(ctx context.Context, todoID int, dueDate *time.Time) error
+	// ClaimDueReminders leases up to limit pending reminders due by now until
+	// leaseUntil, counting an attempt for each. Reminders of todos that are
+	// done or of disabled or deleted users are not claimed, and neither are
+	// reminders whose lease hasn't expired.
+	ClaimDueReminders(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.ReminderDelivery, error)
+	// MarkReminderSent records the delivery of the reminder due at fireAt.
+	// Nothing changes if it was rescheduled meanwhile, as for RetryReminder
+	// and FailReminder.
+	MarkReminderSent(ctx context.Context, reminderID int, fireAt, sentAt time.Time) error
+	// RetryReminder records a failed delivery and leases the reminder until
+	// retryAt, when it is claimed again
+	RetryReminder(ctx context.Context, reminderID int, fireAt, retryAt time.Time, lastError string) error
+	// FailReminder records a failed delivery and gives up on the reminder
+	FailReminder(ctx context.Context, reminderID int, fireAt, failedAt time.Time, lastError string) error
+}
+
+// NotificationStore defines the persistence operations for the in-app
+// notifications of users
+type NotificationStore interface {
+	CreateNotification(ctx context.Context, notification *model.Notification) error
+	// ListNotifications returns up to limit of the user's notifications, or
+	// all of them when limit is 0, newest first, only the unread ones if
+	// unreadOnly is set
+	ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit int) ([]*model.Notification, error)
+	// MarkNotificationRead marks one of the user's notifications read as of
+	// readAt, keeping an earlier read time
+	MarkNotificationRead(ctx context.Context, notificationID, userID int, readAt time.Time) error
+	// MarkAllNotificationsRead marks every unread notification of the user
+	// read as of readAt
+	MarkAllNotificationsRead(ctx context.Context, userID int, readAt time.Time) error
+}
+
+// UserStore defines the persistence operations for users
+type UserStore interface {
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetUserByID(ctx context.Context, userID int) (*model.User, error)
+	CreateUser(ctx context.Context, user *model.User) error
+	UserExists(ctx context.Context, email, username string) (bool, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	// UpdateUsername renames the user; usernames stay unique
+	UpdateUsername(ctx context.Context, userID int, username string) error
+	// UpdateEmail replaces the user's address oldEmail with the verified
+	// newEmail. It fails with not found if the address is no longer oldEmail.
+	UpdateEmail(ctx context.Context, userID int, oldEmail, newEmail string) error
+	// MarkEmailVerified sets the verification time of the user if email is
+	// still the user's address, keeping an earlier verification time
+	MarkEmailVerified(ctx context.Context, userID int, email string) error
+	// ScheduleDeletion deactivates the user until deleteAfter, after which
+	// PurgeDeletedUsers deletes the account
+	ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error
+	// CancelDeletion restores a user scheduled for deletion
+	CancelDeletion(ctx context.Context, userID int) error
+	// PurgeDeletedUsers deletes the users scheduled for deletion before t
+	// with all their data, and returns how many it deleted
+	PurgeDeletedUsers(ctx context.Context, t time.Time) (int, error)
+	SetRole(ctx context.Context, userID int, role string) error
+	// SetDisabled disables the user as of disabledAt, or enables the user
+	// when disabledAt is nil
+	SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error
+}
+
+// TokenStore defines the persistence operations for sessions, the refresh
+// tokens issued for them and password reset tokens
+type TokenStore interface {
+	CreateSession(ctx context.Context, session *model.Session) error
+	GetSession(ctx context.Context, sessionID string) (*model.Session, error)
+	// ListActiveSessions returns the user's sessions that are neither revoked
+	// nor expired, most recently seen first
+	ListActiveSessions(ctx context.Context, userID int) ([]*model.Session, error)
+	// TouchSession records activity on the session from the given IP address
+	TouchSession(ctx context.Context, sessionID, ipAddress string) error
+	// ExtendSession moves the expiry of the session, e.g. after a refresh
+	ExtendSession(ctx context.Context, sessionID string, expiresAt time.Time) error
+	// RevokeSession marks the session revoked; revoking twice is not an error
+	RevokeSession(ctx context.Context, sessionID string) error
+	// RevokeUserSessions revokes every session of the user except exceptID,
+	// which may be empty
+	RevokeUserSessions(ctx context.Context, userID int, exceptID string) error
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	// UseRefreshToken atomically marks an unused token as used. It reports
+	// false if the token had already been used.
+	UseRefreshToken(ctx context.Context, tokenID int) (bool, error)
+	CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	// UsePasswordResetToken atomically marks an unused reset token as used. It
+	// reports false if the token had already been used.
+	UsePasswordResetToken(ctx context.Context, tokenID int) (bool, error)
+	// DeletePasswordResetTokens deletes every reset token of the user
+	DeletePasswordResetTokens(ctx context.Context, userID int) error
+}
+
+// TwoFactorStore defines the persistence operations for two-factor
+// authentication: TOTP secrets, recovery codes and the login challenges
+// issued while the second factor is pending
+type TwoFactorStore interface {
+	GetTwoFactor(ctx context.Context, userID int) (*model.TwoFactor, error)
+	// SetTOTPSecret starts an enrollment, replacing the secret of an earlier
+	// unfinished one. It fails with a conflict if 2FA is already enabled.
+	SetTOTPSecret(ctx context.Context, userID int, secret string) error
+	// EnableTwoFactor finishes the enrollment, recording step as used, and
+	// replaces the user's recovery codes
+	EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error
+	// DisableTwoFactor deletes the user's secret, recovery codes and
+	// challenges
+	DisableTwoFactor(ctx context.Context, userID int) error
+	// UseTOTPStep atomically records step as used. It reports false if the
+	// same or a later step was used already, which rejects replayed codes.
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+	// UseRecoveryCode atomically marks an unused recovery code as used. It
+	// reports false if the user has no such unused code.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	// CountRecoveryCodes returns the number of unused recovery codes
+	CountRecoveryCodes(ctx context.Context, userID int) (int, error)
+	CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error
+	GetMFAChallengeByHash(ctx context.Context, tokenHash string) (*model.MFAChallenge, error)
+	// AttemptMFAChallenge atomically counts an attempt at an unused
+	// challenge that has had fewer than maxAttempts. It reports false if
+	// the challenge was used or out of attempts.
+	AttemptMFAChallenge(ctx context.Context, challengeID, maxAttempts int) (bool, error)
+	// UseMFAChallenge atomically marks an unused challenge as used. It
+	// reports false if the challenge had already been used.
+	UseMFAChallenge(ctx context.Context, challengeID int) (bool, error)
+	// ReleaseMFAChallenge marks a used challenge unused again, for a login
+	// that claimed it but then had a wrong code
+	ReleaseMFAChallenge(ctx context.Context, challengeID int) error
+}
+
+// AccessTokenStore defines the persistence operations for personal access
+// tokens. Deleting and listing are scoped to the owning user.
+type AccessTokenStore interface {
+	CreateAccessToken(ctx context.Context, token *model.PersonalAccessToken) error
+	GetAccessTokenByHash(ctx context.Context, tokenHash string) (*model.PersonalAccessToken, error)
+	// ListAccessTokens returns the user's tokens, newest first
+	ListAccessTokens(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error)
+	// TouchAccessToken records that the token was used at usedAt
+	TouchAccessToken(ctx context.Context, tokenID int, usedAt time.Time) error
+	DeleteAccessToken(ctx context.Context, tokenID, userID int) error
+}
+
+// OIDCStore defines the persistence operations for logins with OpenID
+// providers and the provider accounts linked to users
+type OIDCStore interface {
+	CreateOIDCLogin(ctx context.Context, login *model.OIDCLogin) error
+	// TakeOIDCLogin atomically deletes and returns the login with the state
+	// hash, so every login can only be completed once
+	TakeOIDCLogin(ctx context.Context, stateHash string) (*model.OIDCLogin, error)
+	// DeleteExpiredOIDCLogins deletes the logins that expired before t
+	DeleteExpiredOIDCLogins(ctx context.Context, t time.Time) error
+	GetIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	// CreateIdentity links a provider account to a user; an account can only
+	// be linked once
+	CreateIdentity(ctx context.Context, identity *model.UserIdentity) error
+}
+
+// LoginAttemptStore defines the persistence operations for the failed login
+// counters that throttle password guessing. Keys are opaque to the store.
+type LoginAttemptStore interface {
+	GetLoginAttempts(ctx context.Context, key string) (*model.LoginAttempts, error)
+	// RecordLoginAttempt atomically counts a login at t as failed and
+	// returns the updated counter, unless the key is blocked at t or has
+	// limit failures already; ok is false then. Counters whose last failure
+	// was before resetBefore start over.
+	RecordLoginAttempt(ctx context.Context, key string, t, resetBefore time.Time, limit int) (attempts *model.LoginAttempts, ok bool, err error)
+	// ForgiveLoginFailure takes back a counted attempt whose credentials
+	// turned out right
+	ForgiveLoginFailure(ctx context.Context, key string) error
+	// BlockLogin refuses logins for the key until the given time
+	BlockLogin(ctx context.Context, key string, until time.Time) error
+	ClearLoginAttempts(ctx context.Context, key string) error
+	// DeleteStaleLoginAttempts deletes the counters with neither a failure
+	// nor a block after t
+	DeleteStaleLoginAttempts(ctx context.Context, t time.Time) error
+}
+
+// AdminStore defines the instance-wide queries of the admin API
+type AdminStore interface {
+	// ListUsers returns one page of the users matching q, newest first, with
+	// the number of their todos. A Limit of zero returns every match.
+	ListUsers(ctx context.Context, q model.UserQuery) (*model.UserPage, error)
+	// Stats computes the instance-wide numbers as of now
+	Stats(ctx context.Context, now time.Time) (*model.SystemStats, error)
+}
+
+// Compile-time checks that the implementations satisfy the interfaces
+var (
+	_ TodoStore = (*TodoRepository)(nil)
+	_ TodoStore = (*MemoryTodoRepository)(nil)
+	_ TodoStore = (*SQLiteTodoRepository)(nil)
+
+	_ TodoItemStore = (*TodoItemRepository)(nil)
+	_ TodoItemStore = (*MemoryTodoItemRepository)(nil)
+	_ TodoItemStore = (*SQLiteTodoItemRepository)(nil)
+
+	_ TagStore = (*TagRepository)(nil)
+	_ TagStore = (*MemoryTagRepository)(nil)
+	_ TagStore = (*SQLiteTagRepository)(nil)
+
+	_ ListStore = (*ListRepository)(nil)
+	_ ListStore = (*MemoryListRepository)(nil)
+	_ ListStore = (*SQLiteListRepository)(nil)
+
+	_ ReminderStore     = (*ReminderRepository)(nil)
+	_ ReminderStore     = (*MemoryReminderRepository)(nil)
+	_ ReminderStore     = (*SQLiteReminderRepository)(nil)
+	_ NotificationStore = (*NotificationRepository)(nil)
+	_ NotificationStore = (*MemoryNotificationRepository)(nil)
+	_ NotificationStore = (*SQLiteNotificationRepository)(nil)
+
+	_ UserStore  = (*UserRepository)(nil)
+	_ UserStore  = (*MemoryUserRepository)(nil)
+	_ UserStore  = (*SQLiteUserRepository)(nil)
+	_ TokenStore = (*TokenRepository)(nil)
+	_ TokenStore = (*MemoryTokenRepository)(nil)
+	_ TokenStore = (*SQLiteTokenRepository)(nil)
+
+	_ TwoFactorStore = (*TwoFactorRepository)(nil)
+	_ TwoFactorStore = (*MemoryTwoFactorRepository)(nil)
+	_ TwoFactorStore = (*SQLiteTwoFactorRepository)(nil)
+
+	_ AccessTokenStore = (*AccessTokenRepository)(nil)
+	_ AccessTokenStore = (*MemoryAccessTokenRepository)(nil)
+	_ AccessTokenStore = (*SQLiteAccessTokenRepository)(nil)
+
+	_ OIDCStore = (*OIDCRepository)(nil)
+	_ OIDCStore = (*MemoryOIDCRepository)(nil)
+	_ OIDCStore = (*SQLiteOIDCRepository)(nil)
+
+	_ LoginAttemptStore = (*LoginAttemptRepository)(nil)
+	_ LoginAttemptStore = (*MemoryLoginAttemptRepository)(nil)
+	_ LoginAttemptStore = (*SQLiteLoginAttemptRepository)(nil)
+
+	_ AdminStore = (*AdminRepository)(nil)
+	_ AdminStore = (*MemoryAdminRepository)(nil)
+	_ AdminStore = (*SQLiteAdminRepository)(nil)
+)
+
+// DefaultPriority is assigned to todos created without a priority
+const DefaultPriority = "Medium"
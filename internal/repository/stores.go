@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"aplikasi-todolist/internal/migrate"
+)
+
+// Storage backends, selected by the scheme of the database URL
+const (
+	BackendMemory   = "memory"
+	BackendSQLite   = "sqlite"
+	BackendPostgres = "postgres"
+)
+
+const (
+	memoryURL    = "memory://"
+	sqliteScheme = "sqlite://"
+)
+
+// Stores bundles the store implementations of one storage backend
+type Stores struct {
+	Backend       string
+	Users         UserStore
+	Todos         TodoStore
+	TodoItems     TodoItemStore
+	Tags          TagStore
+	Lists         ListStore
+	Reminders     ReminderStore
+	Notifications NotificationStore
+	Tokens        TokenStore
+	TwoFactor     TwoFactorStore
+	AccessTokens  AccessTokenStore
+	OIDC          OIDCStore
+	LoginAttempts LoginAttemptStore
+	Admin         AdminStore
+
+	sqlite *sql.DB
+}
+
+// NewMemoryStores creates empty in-memory stores
+func NewMemoryStores() *Stores {
+	users := NewMemoryUserRepository()
+	todos := NewMemoryTodoRepository()
+	todoItems := NewMemoryTodoItemRepository()
+	tags := NewMemoryTagRepository()
+	lists := NewMemoryListRepository(todos)
+	reminders := NewMemoryReminderRepository(todos, users)
+	notifications := NewMemoryNotificationRepository()
+	tokens := NewMemoryTokenRepository()
+	twoFactor := NewMemoryTwoFactorRepository()
+	accessTokens := NewMemoryAccessTokenRepository()
+	oidc := NewMemoryOIDCRepository()
+	todos.tagKeys = tags.todoTagKeys
+	todos.cascade(todoItems.deleteTodo, tags.deleteTodo, reminders.deleteTodo, notifications.deleteTodo)
+	todos.copyTo(todoItems.copyTodo, tags.copyTodo, reminders.copyTodo)
+	users.cascade(todos.deleteUser, tags.deleteUser, lists.deleteUser, notifications.deleteUser, tokens.deleteUser, twoFactor.deleteUser, accessTokens.deleteUser, oidc.deleteUser)
+
+	return &Stores{
+		Backend:       BackendMemory,
+		Users:         users,
+		Todos:         todos,
+		TodoItems:     todoItems,
+		Tags:          tags,
+		Lists:         lists,
+		Reminders:     reminders,
+		Notifications: notifications,
+		Tokens:        tokens,
+		TwoFactor:     twoFactor,
+		AccessTokens:  accessTokens,
+		OIDC:          oidc,
+		LoginAttempts: NewMemoryLoginAttemptRepository(),
+		Admin:         NewMemoryAdminRepository(users, todos, tokens),
+	}
+}
+
+// Open connects to the backend selected by databaseURL: "memory://",
+// "sqlite:///path/to/todo.db" or a PostgreSQL connection string. For
+// PostgreSQL it initializes the global DB pool.
+func Open(ctx context.Context, databaseURL string) (*Stores, error) {
+	switch {
+	case databaseURL == memoryURL:
+		return NewMemoryStores(), nil
+
+	case strings.HasPrefix(databaseURL, sqliteScheme):
+		path := SQLitePath(databaseURL)
+		if path == "" {
+			return nil, errors.New("sqlite database URL has no path")
+		}
+		db, err := OpenSQLite(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		return &Stores{
+			Backend:       BackendSQLite,
+			Users:         NewSQLiteUserRepository(db),
+			Todos:         NewSQLiteTodoRepository(db),
+			TodoItems:     NewSQLiteTodoItemRepository(db),
+			Tags:          NewSQLiteTagRepository(db),
+			Lists:         NewSQLiteListRepository(db),
+			Reminders:     NewSQLiteReminderRepository(db),
+			Notifications: NewSQLiteNotificationRepository(db),
+			Tokens:        NewSQLiteTokenRepository(db),
+			TwoFactor:     NewSQLiteTwoFactorRepository(db),
+			AccessTokens:  NewSQLiteAccessTokenRepository(db),
+			OIDC:          NewSQLiteOIDCRepository(db),
+			LoginAttempts: NewSQLiteLoginAttemptRepository(db),
+			Admin:         NewSQLiteAdminRepository(db),
+			sqlite:        db,
+		}, nil
+
+	default:
+		if err := InitDB(ctx, databaseURL); err != nil {
+			return nil, err
+		}
+		return &Stores{
+			Backend:       BackendPostgres,
+			Users:         &UserRepository{},
+			Todos:         &TodoRepository{},
+			TodoItems:     &TodoItemRepository{},
+			Tags:          &TagRepository{},
+			Lists:         &ListRepository{},
+			Reminders:     &ReminderRepository{},
+			Notifications: &NotificationRepository{},
+			Tokens:        &TokenRepository{},
+			TwoFactor:     &TwoFactorRepository{},
+			AccessTokens:  &AccessTokenRepository{},
+			OIDC:          &OIDCRepository{},
+			LoginAttempts: &LoginAttemptRepository{},
+			Admin:         &AdminRepository{},
+		}, nil
+	}
+}
+
+// SQLitePath returns the file path of a sqlite:// URL: sqlite:///var/lib/todo.db
+// is absolute and sqlite://todo.db relative to the working directory
+func SQLitePath(databaseURL string) string {
+	return strings.TrimPrefix(databaseURL, sqliteScheme)
+}
+
+// NewMigrator creates a migrator for the backend's schema. The in-memory
+// backend has no schema to migrate.
+func (s *Stores) NewMigrator() (*migrate.Migrator, error) {
+	switch s.Backend {
+	case BackendSQLite:
+		return NewSQLiteMigrator(s.sqlite)
+	case BackendPostgres:
+		return NewMigrator()
+	default:
+		return nil, errors.New("the in-memory backend has no migrations")
+	}
+}
+
+// Close releases the backend's database connections
+func (s *Stores) Close() {
+	switch s.Backend {
+	case BackendSQLite:
+		s.sqlite.Close()
+	case BackendPostgres:
+		CloseDB()
+	}
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// TagRepository handles tag-related database operations
+type TagRepository struct{}
+
+// ListTags retrieves the user's tags by name, with their todo counts
+func (r *TagRepository) ListTags(ctx context.Context, userID int) ([]*model.Tag, error) {
+	query := `
+		SELECT tags.id, tags.user_id, tags.name, tags.color, COUNT(todo_tags.todo_id)
+		FROM tags
+		LEFT JOIN todo_tags ON todo_tags.tag_id = tags.id
+		WHERE tags.user_id = $1
+		GROUP BY tags.id
+		ORDER BY tags.name_key COLLATE "C"
+	`
+
+	rows, err := DB.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		var count int
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tag.TodoCount = &count
+		tags = append(tags, &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetTag retrieves a tag by ID and user ID
+func (r *TagRepository) GetTag(ctx context.Context, tagID, userID int) (*model.Tag, error) {
+	query := `SELECT id, user_id, name, color FROM tags WHERE id = $1 AND user_id = $2`
+
+	var tag model.Tag
+	err := DB.QueryRow(ctx, query, tagID, userID).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color)
+	if isNoRows(err) {
+		return nil, errTagNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return &tag, nil
+}
+
+// CreateTag creates a tag unless the user has one of the same name
+func (r *TagRepository) CreateTag(ctx context.Context, tag *model.Tag) error {
+	query := `
+		INSERT INTO tags (user_id, name, name_key, color)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	err := DB.QueryRow(ctx, query, tag.UserID, tag.Name, nameKey(tag.Name), tag.Color).Scan(&tag.ID)
+	if isUniqueViolation(err) {
+		return errTagExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return nil
+}
+
+// EnsureTags retrieves the user's tags with the given names, creating the missing ones
+func (r *TagRepository) EnsureTags(ctx context.Context, userID int, names []string, color string) ([]*model.Tag, error) {
+	insert := `
+		INSERT INTO tags (user_id, name, name_key, color)
+		SELECT $1, name, name_key, $4
+		FROM unnest($2::text[], $3::text[]) AS input(name, name_key)
+		ON CONFLICT (user_id, name_key) DO NOTHING
+	`
+	query := `SELECT id, user_id, name, color, name_key FROM tags WHERE user_id = $1 AND name_key = ANY($2)`
+
+	keys := nameKeys(names)
+	if _, err := DB.Exec(ctx, insert, userID, names, keys, color); err != nil {
+		return nil, fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	rows, err := DB.Query(ctx, query, userID, keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	byKey := make(map[string]*model.Tag, len(keys))
+	for rows.Next() {
+		var tag model.Tag
+		var key string
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		byKey[key] = &tag
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	return orderTags(byKey, keys)
+}
+
+// UpdateTag renames and recolors a tag
+func (r *TagRepository) UpdateTag(ctx context.Context, tag *model.Tag) error {
+	query := `
+		UPDATE tags
+		SET name = $1, name_key = $2, color = $3
+		WHERE id = $4 AND user_id = $5
+	`
+
+	commandTag, err := DB.Exec(ctx, query, tag.Name, nameKey(tag.Name), tag.Color, tag.ID, tag.UserID)
+	if isUniqueViolation(err) {
+		return errTagExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errTagNotFound
+	}
+
+	return nil
+}
+
+// DeleteTag deletes a tag, removing it from its todos
+func (r *TagRepository) DeleteTag(ctx context.Context, tagID, userID int) error {
+	query := `DELETE FROM tags WHERE id = $1 AND user_id = $2`
+
+	commandTag, err := DB.Exec(ctx, query, tagID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errTagNotFound
+	}
+
+	return nil
+}
+
+// MergeTag moves the todos of one tag to another and deletes the first in one transaction
+func (r *TagRepository) MergeTag(ctx context.Context, sourceID, targetID, userID int) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var owned int
+	err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM tags WHERE id IN ($1, $2) AND user_id = $3`, sourceID, targetID, userID).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("failed to get tags: %w", err)
+	}
+	if owned != 2 {
+		return errTagNotFound
+	}
+
+	move := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT todo_id, $2 FROM todo_tags WHERE tag_id = $1
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, move, sourceID, targetID); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+	// Deleting the source tag deletes its todo_tags rows
+	if _, err := tx.Exec(ctx, `DELETE FROM tags WHERE id = $1`, sourceID); err != nil {
+		return fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListTodoTags retrieves the tags of the todos, by name
+func (r *TagRepository) ListTodoTags(ctx context.Context, todoIDs []int) (map[int][]*model.Tag, error) {
+	query := `
+		SELECT todo_tags.todo_id, tags.id, tags.user_id, tags.name, tags.color
+		FROM todo_tags
+		JOIN tags ON tags.id = todo_tags.tag_id
+		WHERE todo_tags.todo_id = ANY($1)
+		ORDER BY todo_tags.todo_id, tags.name_key COLLATE "C"
+	`
+
+	tags := make(map[int][]*model.Tag)
+	if len(todoIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := DB.Query(ctx, query, todoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var tag model.Tag
+		if err := rows.Scan(&todoID, &tag.ID, &tag.UserID, &tag.Name, &tag.Color); err != nil {
+			return nil, fmt.Errorf("failed to scan todo tag: %w", err)
+		}
+		tags[todoID] = append(tags[todoID], &tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// SetTodoTags replaces the tags of a todo in one transaction
+func (r *TagRepository) SetTodoTags(ctx context.Context, todoID int, tagIDs []int) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM todo_tags WHERE todo_id = $1`, todoID); err != nil {
+		return fmt.Errorf("failed to set todo tags: %w", err)
+	}
+	query := `
+		INSERT INTO todo_tags (todo_id, tag_id)
+		SELECT $1, tag_id FROM unnest($2::int[]) AS input(tag_id)
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, todoID, tagIDs); err != nil {
+		return fmt.Errorf("failed to set todo tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// orderTags returns the tags of byKey in the order of keys
+func orderTags(byKey map[string]*model.Tag, keys []string) ([]*model.Tag, error) {
+	tags := make([]*model.Tag, 0, len(keys))
+	for _, key := range keys {
+		tag, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("tag %q was not created", key)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// TodoItemRepository handles checklist item database operations
+type TodoItemRepository struct{}
+
+// ListTodoItems retrieves the items of the todos, in order
+func (r *TodoItemRepository) ListTodoItems(ctx context.Context, todoIDs []int) (map[int][]*model.TodoItem, error) {
+	query := `
+		SELECT id, todo_id, title, is_done, position, created_at, updated_at
+		FROM todo_items
+		WHERE todo_id = ANY($1)
+		ORDER BY todo_id, position, id
+	`
+
+	items := make(map[int][]*model.TodoItem)
+	if len(todoIDs) == 0 {
+		return items, nil
+	}
+
+	rows, err := DB.Query(ctx, query, todoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todo items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanTodoItem(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo item: %w", err)
+		}
+		items[item.TodoID] = append(items[item.TodoID], item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo items: %w", err)
+	}
+
+	return items, nil
+}
+
+// CountTodoItems counts the done and total items of the todos
+func (r *TodoItemRepository) CountTodoItems(ctx context.Context, todoIDs []int) (map[int]*model.TodoProgress, error) {
+	query := `
+		SELECT todo_id, COUNT(*) FILTER (WHERE is_done), COUNT(*)
+		FROM todo_items
+		WHERE todo_id = ANY($1)
+		GROUP BY todo_id
+	`
+
+	progress := make(map[int]*model.TodoProgress)
+	if len(todoIDs) == 0 {
+		return progress, nil
+	}
+
+	rows, err := DB.Query(ctx, query, todoIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count todo items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var p model.TodoProgress
+		if err := rows.Scan(&todoID, &p.Done, &p.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan todo item counts: %w", err)
+		}
+		progress[todoID] = &p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo item counts: %w", err)
+	}
+
+	return progress, nil
+}
+
+// GetTodoItem retrieves an item of a todo
+func (r *TodoItemRepository) GetTodoItem(ctx context.Context, todoID, itemID int) (*model.TodoItem, error) {
+	query := `
+		SELECT id, todo_id, title, is_done, position, created_at, updated_at
+		FROM todo_items
+		WHERE id = $1 AND todo_id = $2
+	`
+
+	item, err := scanTodoItem(DB.QueryRow(ctx, query, itemID, todoID))
+	if isNoRows(err) {
+		return nil, errItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo item: %w", err)
+	}
+
+	return item, nil
+}
+
+// CreateTodoItem appends an item to the items of its todo
+func (r *TodoItemRepository) CreateTodoItem(ctx context.Context, item *model.TodoItem) error {
+	query := `
+		INSERT INTO todo_items (todo_id, title, is_done, position)
+		SELECT $1, $2, $3, COALESCE(MAX(position) + 1, 0)
+		FROM todo_items
+		WHERE todo_id = $1
+		RETURNING id, position, created_at, updated_at
+	`
+
+	err := DB.QueryRow(ctx, query, item.TodoID, item.Title, item.IsDone).
+		Scan(&item.ID, &item.Position, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create todo item: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTodoItem updates the title and done state of an item
+func (r *TodoItemRepository) UpdateTodoItem(ctx context.Context, item *model.TodoItem) error {
+	query := `
+		UPDATE todo_items
+		SET title = $1, is_done = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND todo_id = $4
+		RETURNING position, created_at, updated_at
+	`
+
+	err := DB.QueryRow(ctx, query, item.Title, item.IsDone, item.ID, item.TodoID).
+		Scan(&item.Position, &item.CreatedAt, &item.UpdatedAt)
+	if isNoRows(err) {
+		return errItemNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update todo item: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTodoItem deletes an item of a todo
+func (r *TodoItemRepository) DeleteTodoItem(ctx context.Context, todoID, itemID int) error {
+	query := `DELETE FROM todo_items WHERE id = $1 AND todo_id = $2`
+
+	commandTag, err := DB.Exec(ctx, query, itemID, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo item: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errItemNotFound
+	}
+
+	return nil
+}
+
+// ReorderTodoItems moves each item to its index in itemIDs in one statement
+func (r *TodoItemRepository) ReorderTodoItems(ctx context.Context, todoID int, itemIDs []int) error {
+	query := `
+		UPDATE todo_items
+		SET position = ordered.position - 1, updated_at = CURRENT_TIMESTAMP
+		FROM unnest($2::int[]) WITH ORDINALITY AS ordered(id, position)
+		WHERE todo_items.id = ordered.id AND todo_items.todo_id = $1
+	`
+
+	commandTag, err := DB.Exec(ctx, query, todoID, itemIDs)
+	if err != nil {
+		return fmt.Errorf("failed to reorder todo items: %w", err)
+	}
+	if commandTag.RowsAffected() != int64(len(itemIDs)) {
+		return errItemNotFound
+	}
+
+	return nil
+}
+
+// CompleteTodoItems marks every item of the todo done
+func (r *TodoItemRepository) CompleteTodoItems(ctx context.Context, todoID int) error {
+	query := `
+		UPDATE todo_items
+		SET is_done = TRUE, updated_at = CURRENT_TIMESTAMP
+		WHERE todo_id = $1 AND NOT is_done
+	`
+
+	if _, err := DB.Exec(ctx, query, todoID); err != nil {
+		return fmt.Errorf("failed to complete todo items: %w", err)
+	}
+
+	return nil
+}
+
+// scanTodoItem scans a row selected with the columns of GetTodoItem
+func scanTodoItem(row interface{ Scan(...interface{}) error }) (*model.TodoItem, error) {
+	var item model.TodoItem
+	err := row.Scan(
+		&item.ID,
+		&item.TodoID,
+		&item.Title,
+		&item.IsDone,
+		&item.Position,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
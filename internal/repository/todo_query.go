@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+// priorityRank orders priorities semantically instead of alphabetically
+var priorityRank = map[string]int{
+	"Low":    1,
+	"Medium": 2,
+	"High":   3,
+}
+
+// todoCursor is the decoded form of TodoPage.NextCursor. It records the sort
+// it was created for plus the sort key and ID of the last todo on the page.
+type todoCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+var errInvalidCursor = apperror.Invalid("cursor", "invalid cursor")
+
+func encodeTodoCursor(q model.TodoQuery, last *model.Todo) string {
+	c := todoCursor{Sort: q.Sort, Order: q.Order, Value: todoSortValue(q.Sort, last), ID: last.ID}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeTodoCursor parses q.Cursor, which must belong to the same sort as q.
+// It returns nil when q has no cursor.
+func decodeTodoCursor(q model.TodoQuery) (*todoCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c todoCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, errInvalidCursor
+	}
+	if c.Sort != q.Sort || c.Order != q.Order {
+		return nil, apperror.Invalid("cursor", "cursor does not match the requested sort order")
+	}
+	if _, err := c.todo(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// todo rebuilds a todo carrying just the cursor's sort key and ID, so cursors
+// can be compared with the same code that orders todos
+func (c *todoCursor) todo() (*model.Todo, error) {
+	todo := &model.Todo{ID: c.ID}
+
+	switch c.Sort {
+	case model.TodoSortCreatedAt, model.TodoSortUpdatedAt, model.TodoSortDueDate:
+		if c.Value == "" && c.Sort == model.TodoSortDueDate {
+			return todo, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		switch c.Sort {
+		case model.TodoSortCreatedAt:
+			todo.CreatedAt = t
+		case model.TodoSortUpdatedAt:
+			todo.UpdatedAt = t
+		default:
+			todo.DueDate = &t
+		}
+	case model.TodoSortPriority:
+		rank, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, errInvalidCursor
+		}
+		for name, r := range priorityRank {
+			if r == rank {
+				todo.Priority = name
+			}
+		}
+	case model.TodoSortTitle:
+		todo.Title = c.Value
+	default:
+		return nil, errInvalidCursor
+	}
+
+	return todo, nil
+}
+
+// todoSortValue returns the string form of the key a todo is sorted by
+func todoSortValue(sort string, todo *model.Todo) string {
+	switch sort {
+	case model.TodoSortUpdatedAt:
+		return todo.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case model.TodoSortDueDate:
+		if todo.DueDate == nil {
+			return ""
+		}
+		return todo.DueDate.UTC().Format(time.RFC3339Nano)
+	case model.TodoSortPriority:
+		return strconv.Itoa(priorityRank[todo.Priority])
+	case model.TodoSortTitle:
+		return titleKey(todo.Title)
+	default:
+		return todo.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// compareTodos orders a before b (negative), after b (positive) or equal (0)
+// according to the sort and order of q, breaking ties by ID. Todos without a
+// due date come last when sorting by due date, in either direction.
+func compareTodos(q model.TodoQuery, a, b *model.Todo) int {
+	cmp := 0
+	switch q.Sort {
+	case model.TodoSortUpdatedAt:
+		cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+	case model.TodoSortDueDate:
+		switch {
+		case a.DueDate == nil && b.DueDate == nil:
+		case a.DueDate == nil:
+			return 1
+		case b.DueDate == nil:
+			return -1
+		default:
+			cmp = a.DueDate.Compare(*b.DueDate)
+		}
+	case model.TodoSortPriority:
+		cmp = priorityRank[a.Priority] - priorityRank[b.Priority]
+	case model.TodoSortTitle:
+		cmp = strings.Compare(titleKey(a.Title), titleKey(b.Title))
+	default:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if cmp == 0 {
+		cmp = a.ID - b.ID
+	}
+
+	if q.Order == model.SortDesc {
+		return -cmp
+	}
+	return cmp
+}
+
+// matchesTodoQuery reports whether todo passes the filters of q. tags holds
+// the nameKey of each tag of the todo.
+func matchesTodoQuery(q model.TodoQuery, todo *model.Todo, tags map[string]bool) bool {
+	if q.ListID != nil && (todo.ListID == nil || *todo.ListID != *q.ListID) {
+		return false
+	}
+	if len(q.Priorities) > 0 {
+		found := false
+		for _, p := range q.Priorities {
+			if todo.Priority == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.IsDone != nil && todo.IsDone != *q.IsDone {
+		return false
+	}
+	if (q.DueFrom != nil || q.DueTo != nil) && todo.DueDate == nil {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !tags[nameKey(tag)] {
+			return false
+		}
+	}
+	if len(q.AnyTags) > 0 {
+		found := false
+		for _, tag := range q.AnyTags {
+			if tags[nameKey(tag)] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, tag := range q.ExcludeTags {
+		if tags[nameKey(tag)] {
+			return false
+		}
+	}
+
+	return inRange(todo.DueDate, q.DueFrom, q.DueTo) &&
+		inRange(&todo.CreatedAt, q.CreatedFrom, q.CreatedTo) &&
+		inRange(&todo.UpdatedAt, q.UpdatedFrom, q.UpdatedTo)
+}
+
+// inRange reports whether from <= t <= to, ignoring nil bounds
+func inRange(t, from, to *time.Time) bool {
+	if t == nil {
+		return true
+	}
+	if from != nil && t.Before(*from) {
+		return false
+	}
+	if to != nil && t.After(*to) {
+		return false
+	}
+	return true
+}
+
+// titleKey is the form todos are sorted by title in. The SQL stores keep it
+// in the title_key column, so the order doesn't depend on the database locale.
+func titleKey(title string) string {
+	return strings.ToLower(title)
+}
+
+// nameKey is the form in which tag and list names are compared and kept unique
+func nameKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// nameKeys returns the nameKey of each name
+func nameKeys(names []string) []string {
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = nameKey(name)
+	}
+	return keys
+}
@@ -3,6 +3,11 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 
 	"aplikasi-todolist/internal/model"
 )
@@ -11,163 +16,426 @@ import (
 type TodoRepository struct{}
 
 // GetTodosByUserID retrieves all todos for a specific user
-func (r *TodoRepository) GetTodosByUserID(userID int) ([]*model.Todo, error) {
+func (r *TodoRepository) GetTodosByUserID(ctx context.Context, userID int) ([]*model.Todo, error) {
 	query := `
-		SELECT id, user_id, title, description, category, is_done, priority, due_date, created_at, updated_at
+		SELECT id, user_id, title, description, list_id, is_done, priority, due_date, recurrence_rule, recurrence_mode,
+		       created_at, updated_at
 		FROM todos
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := DB.Query(context.Background(), query, userID)
+	rows, err := DB.Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todos: %w", err)
 	}
 	defer rows.Close()
 
-	var todos []*model.Todo
-	for rows.Next() {
-		var todo model.Todo
-		err := rows.Scan(
-			&todo.ID,
-			&todo.UserID,
-			&todo.Title,
-			&todo.Description,
-			&todo.Category,
-			&todo.IsDone,
-			&todo.Priority,
-			&todo.DueDate,
-			&todo.CreatedAt,
-			&todo.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan todo: %w", err)
-		}
-		todos = append(todos, &todo)
+	return scanTodos(rows)
+}
+
+// ListTodos retrieves one page of a user's todos matching the query, using
+// keyset pagination on the sort key and ID
+func (r *TodoRepository) ListTodos(ctx context.Context, userID int, q model.TodoQuery) (*model.TodoPage, error) {
+	cursor, err := decodeTodoCursor(q)
+	if err != nil {
+		return nil, err
 	}
 
-	return todos, nil
+	where, args := todoFilterSQL(userID, q)
+
+	page := &model.TodoPage{Todos: []*model.Todo{}}
+	countQuery := "SELECT COUNT(*) FROM todos WHERE " + where
+	if err := DB.QueryRow(ctx, countQuery, args...).Scan(&page.Total); err != nil {
+		return nil, fmt.Errorf("failed to count todos: %w", err)
+	}
+
+	sortExpr := todoSortSQL(q)
+	direction, comparison := "ASC", ">"
+	if q.Order == model.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		args = append(args, todoCursorArg(q, cursor), cursor.ID)
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d, $%d)", sortExpr, comparison, len(args)-1, len(args))
+	}
+
+	query := `
+		SELECT id, user_id, title, description, list_id, is_done, priority, due_date, recurrence_rule, recurrence_mode,
+		       created_at, updated_at
+		FROM todos
+		WHERE ` + where + `
+		ORDER BY ` + sortExpr + ` ` + direction + `, id ` + direction
+
+	// Fetch one extra row to learn whether there is a next page
+	if q.Limit > 0 {
+		args = append(args, q.Limit+1)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos, err := scanTodos(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Limit > 0 && len(todos) > q.Limit {
+		todos = todos[:q.Limit]
+		page.NextCursor = encodeTodoCursor(q, todos[len(todos)-1])
+	}
+	page.Todos = append(page.Todos, todos...)
+
+	return page, nil
 }
 
 // GetTodoByID retrieves a specific todo by ID and user ID
-func (r *TodoRepository) GetTodoByID(todoID, userID int) (*model.Todo, error) {
+func (r *TodoRepository) GetTodoByID(ctx context.Context, todoID, userID int) (*model.Todo, error) {
 	query := `
-		SELECT id, user_id, title, description, category, is_done, priority, due_date, created_at, updated_at
+		SELECT id, user_id, title, description, list_id, is_done, priority, due_date, recurrence_rule, recurrence_mode,
+		       created_at, updated_at
 		FROM todos
 		WHERE id = $1 AND user_id = $2
 	`
 
 	var todo model.Todo
-	err := DB.QueryRow(context.Background(), query, todoID, userID).Scan(
+	err := DB.QueryRow(ctx, query, todoID, userID).Scan(
 		&todo.ID,
 		&todo.UserID,
 		&todo.Title,
 		&todo.Description,
-		&todo.Category,
+		&todo.ListID,
 		&todo.IsDone,
 		&todo.Priority,
 		&todo.DueDate,
+		&todo.RecurrenceRule,
+		&todo.RecurrenceMode,
 		&todo.CreatedAt,
 		&todo.UpdatedAt,
 	)
 
+	if isNoRows(err) {
+		return nil, errTodoNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("todo not found: %w", err)
+		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
 
 	return &todo, nil
 }
 
-// CreateTodo creates a new todo
-func (r *TodoRepository) CreateTodo(todo *model.Todo) error {
-	query := `
-		INSERT INTO todos (user_id, title, description, category, priority, due_date)
-		VALUES ($1, $2, $3, $4, $5, $6)
+// createTodoSQL and updateTodoSQL are shared by the methods saving todos
+const (
+	createTodoSQL = `
+		INSERT INTO todos (user_id, title, description, list_id, priority, due_date, recurrence_rule, recurrence_mode, search_config,
+		                   title_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::regconfig, $10)
 		RETURNING id, created_at, updated_at
 	`
+	updateTodoSQL = `
+		UPDATE todos
+		SET title = COALESCE($1, title),
+		    description = COALESCE($2, description),
+		    list_id = $3,
+		    is_done = COALESCE($4, is_done),
+		    priority = COALESCE($5, priority),
+		    due_date = $6,
+		    recurrence_rule = $7,
+		    recurrence_mode = $8,
+		    title_key = $11,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9 AND user_id = $10
+		RETURNING title, description, list_id, is_done, priority, due_date, recurrence_rule, recurrence_mode, updated_at
+	`
+)
+
+// CreateTodo creates a new todo
+func (r *TodoRepository) CreateTodo(ctx context.Context, todo *model.Todo) error {
+	if err := createTodo(ctx, DB.QueryRow, todo); err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+	return nil
+}
+
+// UpdateTodo updates an existing todo
+func (r *TodoRepository) UpdateTodo(ctx context.Context, todo *model.Todo) error {
+	err := updateTodo(ctx, DB.QueryRow, todo)
+	if isNoRows(err) {
+		return errTodoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+	return nil
+}
+
+// CompleteRecurringTodo saves the completed todo and creates its next
+// occurrence with the same tags and checklist, in one transaction
+func (r *TodoRepository) CompleteRecurringTodo(ctx context.Context, todo, next *model.Todo) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Claim the todo first, so that of concurrent completions only one
+	// creates the next occurrence
+	claim := `
+		UPDATE todos SET is_done = TRUE
+		WHERE id = $1 AND user_id = $2 AND NOT is_done AND recurrence_rule <> ''
+	`
+	commandTag, err := tx.Exec(ctx, claim, todo.ID, todo.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to claim todo: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		var exists bool
+		query := `SELECT EXISTS (SELECT 1 FROM todos WHERE id = $1 AND user_id = $2)`
+		if err := tx.QueryRow(ctx, query, todo.ID, todo.UserID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to get todo: %w", err)
+		}
+		if exists {
+			return errTodoCompleted
+		}
+		return errTodoNotFound
+	}
+
+	err = updateTodo(ctx, tx.QueryRow, todo)
+	if isNoRows(err) {
+		return errTodoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+	if err := createTodo(ctx, tx.QueryRow, next); err != nil {
+		return fmt.Errorf("failed to create todo: %w", err)
+	}
+
+	copyTags := `INSERT INTO todo_tags (todo_id, tag_id) SELECT $2, tag_id FROM todo_tags WHERE todo_id = $1`
+	if _, err := tx.Exec(ctx, copyTags, todo.ID, next.ID); err != nil {
+		return fmt.Errorf("failed to copy tags: %w", err)
+	}
+	copyItems := `
+		INSERT INTO todo_items (todo_id, title, position)
+		SELECT $2, title, position FROM todo_items WHERE todo_id = $1
+	`
+	if _, err := tx.Exec(ctx, copyItems, todo.ID, next.ID); err != nil {
+		return fmt.Errorf("failed to copy items: %w", err)
+	}
+	copyReminders := `
+		INSERT INTO reminders (todo_id, offset_minutes, channels, webhook_url, fire_at)
+		SELECT $2, offset_minutes, channels, webhook_url, $3::timestamp - offset_minutes * INTERVAL '1 minute'
+		FROM reminders
+		WHERE todo_id = $1 AND offset_minutes IS NOT NULL
+		ORDER BY id
+	`
+	if _, err := tx.Exec(ctx, copyReminders, todo.ID, next.ID, next.DueDate); err != nil {
+		return fmt.Errorf("failed to copy reminders: %w", err)
+	}
 
-	// Default category if empty
-	if todo.Category == "" {
-		todo.Category = "Personal"
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	return nil
+}
+
+// createTodo inserts a todo with the pool or a transaction
+func createTodo(ctx context.Context, queryRow func(context.Context, string, ...interface{}) pgx.Row, todo *model.Todo) error {
 	// Default priority if empty
 	if todo.Priority == "" {
-		todo.Priority = "Medium"
+		todo.Priority = DefaultPriority
 	}
 
-	err := DB.QueryRow(context.Background(), query,
+	return queryRow(ctx, createTodoSQL,
 		todo.UserID,
 		todo.Title,
 		todo.Description,
-		todo.Category,
+		todo.ListID,
 		todo.Priority,
 		todo.DueDate,
+		todo.RecurrenceRule,
+		todo.RecurrenceMode,
+		searchConfig,
+		titleKey(todo.Title),
 	).Scan(&todo.ID, &todo.CreatedAt, &todo.UpdatedAt)
-
-	if err != nil {
-		return fmt.Errorf("failed to create todo: %w", err)
-	}
-
-	return nil
 }
 
-// UpdateTodo updates an existing todo
-func (r *TodoRepository) UpdateTodo(todo *model.Todo) error {
-	query := `
-		UPDATE todos
-		SET title = COALESCE($1, title),
-		    description = COALESCE($2, description),
-		    category = COALESCE($3, category),
-		    is_done = COALESCE($4, is_done),
-		    priority = COALESCE($5, priority),
-		    due_date = COALESCE($6, due_date),
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $7 AND user_id = $8
-		RETURNING title, description, category, is_done, priority, due_date, updated_at
-	`
-
-	err := DB.QueryRow(context.Background(), query,
+// updateTodo saves a todo with the pool or a transaction
+func updateTodo(ctx context.Context, queryRow func(context.Context, string, ...interface{}) pgx.Row, todo *model.Todo) error {
+	return queryRow(ctx, updateTodoSQL,
 		todo.Title,
 		todo.Description,
-		todo.Category,
+		todo.ListID,
 		todo.IsDone,
 		todo.Priority,
 		todo.DueDate,
+		todo.RecurrenceRule,
+		todo.RecurrenceMode,
 		todo.ID,
 		todo.UserID,
+		titleKey(todo.Title),
 	).Scan(
 		&todo.Title,
 		&todo.Description,
-		&todo.Category,
+		&todo.ListID,
 		&todo.IsDone,
 		&todo.Priority,
 		&todo.DueDate,
+		&todo.RecurrenceRule,
+		&todo.RecurrenceMode,
 		&todo.UpdatedAt,
 	)
-
-	if err != nil {
-		return fmt.Errorf("failed to update todo: %w", err)
-	}
-
-	return nil
 }
 
 // DeleteTodo deletes a todo by ID and user ID
-func (r *TodoRepository) DeleteTodo(todoID, userID int) error {
+func (r *TodoRepository) DeleteTodo(ctx context.Context, todoID, userID int) error {
 	query := `
 		DELETE FROM todos
 		WHERE id = $1 AND user_id = $2
 	`
 
-	commandTag, err := DB.Exec(context.Background(), query, todoID, userID)
+	commandTag, err := DB.Exec(ctx, query, todoID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("todo not found or not owned by user")
+		return errTodoNotFound
 	}
 
 	return nil
 }
+
+// scanTodos reads every row of a todo query
+func scanTodos(rows pgx.Rows) ([]*model.Todo, error) {
+	var todos []*model.Todo
+	for rows.Next() {
+		var todo model.Todo
+		err := rows.Scan(
+			&todo.ID,
+			&todo.UserID,
+			&todo.Title,
+			&todo.Description,
+			&todo.ListID,
+			&todo.IsDone,
+			&todo.Priority,
+			&todo.DueDate,
+			&todo.RecurrenceRule,
+			&todo.RecurrenceMode,
+			&todo.CreatedAt,
+			&todo.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todos = append(todos, &todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todos: %w", err)
+	}
+
+	return todos, nil
+}
+
+// priorityRankSQL mirrors priorityRank; migration 005 indexes this exact expression
+const priorityRankSQL = `CASE priority WHEN 'High' THEN 3 WHEN 'Medium' THEN 2 WHEN 'Low' THEN 1 ELSE 0 END`
+
+// todoSortSQL returns the expression todos are ordered by. Missing due dates
+// are mapped to an infinite date so they sort last in either direction.
+func todoSortSQL(q model.TodoQuery) string {
+	switch q.Sort {
+	case model.TodoSortUpdatedAt:
+		return "updated_at"
+	case model.TodoSortDueDate:
+		if q.Order == model.SortDesc {
+			return "COALESCE(due_date, '-infinity'::timestamp)"
+		}
+		return "COALESCE(due_date, 'infinity'::timestamp)"
+	case model.TodoSortPriority:
+		return "(" + priorityRankSQL + ")"
+	case model.TodoSortTitle:
+		return "title_key"
+	default:
+		return "created_at"
+	}
+}
+
+// todoCursorArg converts the cursor's sort key to a query parameter
+func todoCursorArg(q model.TodoQuery, c *todoCursor) interface{} {
+	t, _ := c.todo()
+	switch q.Sort {
+	case model.TodoSortUpdatedAt:
+		return t.UpdatedAt
+	case model.TodoSortDueDate:
+		if t.DueDate == nil {
+			modifier := pgtype.Infinity
+			if q.Order == model.SortDesc {
+				modifier = pgtype.NegativeInfinity
+			}
+			return pgtype.Timestamp{InfinityModifier: modifier, Valid: true}
+		}
+		return *t.DueDate
+	case model.TodoSortPriority:
+		return priorityRank[t.Priority]
+	case model.TodoSortTitle:
+		return c.Value
+	default:
+		return t.CreatedAt
+	}
+}
+
+// todoTagSQL selects the tags of the todo in the outer query; the tag filters
+// add a condition on tags.name_key. It is shared with the SQLite store.
+const todoTagSQL = `SELECT 1 FROM todo_tags JOIN tags ON tags.id = todo_tags.tag_id WHERE todo_tags.todo_id = todos.id`
+
+// todoFilterSQL builds the WHERE clause and arguments for the filters of q
+func todoFilterSQL(userID int, q model.TodoQuery) (string, []interface{}) {
+	args := []interface{}{userID}
+	conditions := []string{"user_id = $1"}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if q.ListID != nil {
+		add("list_id = $%d", *q.ListID)
+	}
+	if len(q.Priorities) > 0 {
+		add("priority = ANY($%d)", q.Priorities)
+	}
+	if q.IsDone != nil {
+		add("is_done = $%d", *q.IsDone)
+	}
+	for _, tag := range q.Tags {
+		add("EXISTS ("+todoTagSQL+" AND tags.name_key = $%d)", nameKey(tag))
+	}
+	if len(q.AnyTags) > 0 {
+		add("EXISTS ("+todoTagSQL+" AND tags.name_key = ANY($%d))", nameKeys(q.AnyTags))
+	}
+	if len(q.ExcludeTags) > 0 {
+		add("NOT EXISTS ("+todoTagSQL+" AND tags.name_key = ANY($%d))", nameKeys(q.ExcludeTags))
+	}
+	for _, r := range []struct {
+		column   string
+		from, to *time.Time
+	}{
+		{"due_date", q.DueFrom, q.DueTo},
+		{"created_at", q.CreatedFrom, q.CreatedTo},
+		{"updated_at", q.UpdatedFrom, q.UpdatedTo},
+	} {
+		if r.from != nil {
+			add(r.column+" >= $%d", *r.from)
+		}
+		if r.to != nil {
+			add(r.column+" <= $%d", *r.to)
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
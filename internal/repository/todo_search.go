@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// searchConfig is the PostgreSQL text search configuration used to index and
+// query todos. It is set by ConfigureSearch.
+var searchConfig = "simple"
+
+// Markers ts_headline puts around matches. They are stripped from the text
+// first, so the highlight can be HTML escaped before they become <mark> tags.
+const (
+	highlightStart = "\x01"
+	highlightStop  = "\x02"
+)
+
+// ConfigureSearch selects the text search configuration (e.g. "simple",
+// "english" or "indonesian") and re-indexes todos that were indexed with a
+// different one, returning how many were re-indexed. InitDB must have been
+// called first.
+func ConfigureSearch(ctx context.Context, config string) (int64, error) {
+	var name string
+	if err := DB.QueryRow(ctx, "SELECT $1::regconfig::text", config).Scan(&name); err != nil {
+		return 0, fmt.Errorf("unknown text search configuration %q: %w", config, err)
+	}
+
+	tag, err := DB.Exec(ctx, "UPDATE todos SET search_config = $1::regconfig WHERE search_config <> $1::regconfig", name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to re-index todos: %w", err)
+	}
+
+	searchConfig = name
+	return tag.RowsAffected(), nil
+}
+
+// SearchTodos runs a ranked full-text search over a user's todos
+func (r *TodoRepository) SearchTodos(ctx context.Context, userID int, s model.TodoSearch) ([]*model.TodoSearchResult, error) {
+	args := []interface{}{userID, searchConfig}
+	var parts []string
+	addQuery := func(fn, value string) {
+		args = append(args, value)
+		parts = append(parts, fmt.Sprintf("%s($2::regconfig, $%d)", fn, len(args)))
+	}
+
+	if len(s.Words) > 0 {
+		addQuery("plainto_tsquery", strings.Join(s.Words, " "))
+	}
+	for _, phrase := range s.Phrases {
+		addQuery("phraseto_tsquery", phrase)
+	}
+	for _, prefix := range s.Prefixes {
+		// Prefixes only contain letters and digits, so they can't inject tsquery syntax
+		addQuery("to_tsquery", prefix+":*")
+	}
+	if len(parts) == 0 {
+		return []*model.TodoSearchResult{}, nil
+	}
+
+	args = append(args, s.Limit, s.Offset)
+	markers := fmt.Sprintf("StartSel=%s, StopSel=%s", highlightStart, highlightStop)
+	query := `
+		WITH q AS (SELECT ` + strings.Join(parts, " && ") + ` AS query)
+		SELECT id, user_id, title, description, list_id, is_done, priority, due_date, recurrence_rule, recurrence_mode,
+		       created_at, updated_at,
+		       ts_rank_cd(search_vector, q.query) AS rank,
+		       ts_headline($2::regconfig, translate(title, E'\x01\x02', ''), q.query, '` + markers + `, HighlightAll=true'),
+		       ts_headline($2::regconfig, translate(coalesce(description, ''), E'\x01\x02', ''), q.query, '` + markers + `, MaxFragments=2, MinWords=5, MaxWords=20')
+		FROM todos, q
+		WHERE user_id = $1 AND search_config = $2::regconfig AND search_vector @@ q.query
+		ORDER BY rank DESC, id DESC
+		LIMIT $` + fmt.Sprint(len(args)-1) + ` OFFSET $` + fmt.Sprint(len(args))
+
+	rows, err := DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*model.TodoSearchResult{}
+	for rows.Next() {
+		var todo model.Todo
+		var result model.TodoSearchResult
+		var rank float32
+		err := rows.Scan(
+			&todo.ID,
+			&todo.UserID,
+			&todo.Title,
+			&todo.Description,
+			&todo.ListID,
+			&todo.IsDone,
+			&todo.Priority,
+			&todo.DueDate,
+			&todo.RecurrenceRule,
+			&todo.RecurrenceMode,
+			&todo.CreatedAt,
+			&todo.UpdatedAt,
+			&rank,
+			&result.TitleHighlight,
+			&result.DescriptionHighlight,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Todo = &todo
+		result.Rank = float64(rank)
+		result.TitleHighlight = renderHighlight(result.TitleHighlight)
+		result.DescriptionHighlight = renderHighlight(result.DescriptionHighlight)
+		results = append(results, &result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	return results, nil
+}
+
+// renderHighlight HTML escapes text and turns the highlight markers into <mark> tags
+func renderHighlight(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, highlightStart, "<mark>")
+	return strings.ReplaceAll(text, highlightStop, "</mark>")
+}
+
+// textMatcher is a simple, language-agnostic stand-in for PostgreSQL full-text
+// search used by the stores that lack it. It matches whole words case
+// insensitively without stemming.
+type textMatcher struct {
+	words    []string
+	prefixes []string
+	phrases  [][]string
+}
+
+func newTextMatcher(s model.TodoSearch) *textMatcher {
+	m := &textMatcher{}
+	for _, w := range s.Words {
+		m.words = append(m.words, splitWords(w)...)
+	}
+	for _, p := range s.Prefixes {
+		m.prefixes = append(m.prefixes, strings.ToLower(p))
+	}
+	for _, p := range s.Phrases {
+		if words := splitWords(p); len(words) > 0 {
+			m.phrases = append(m.phrases, words)
+		}
+	}
+	return m
+}
+
+func (m *textMatcher) empty() bool {
+	return len(m.words) == 0 && len(m.prefixes) == 0 && len(m.phrases) == 0
+}
+
+// match reports whether the todo contains every term, and a rank based on how
+// many of its words are hits, weighting the title above the description
+func (m *textMatcher) match(todo *model.Todo) (bool, float64) {
+	title := splitWords(todo.Title)
+	description := splitWords(todo.Description)
+	all := append(append([]string{}, title...), description...)
+
+	for _, w := range m.words {
+		if !containsWord(all, func(word string) bool { return word == w }) {
+			return false, 0
+		}
+	}
+	for _, p := range m.prefixes {
+		if !containsWord(all, func(word string) bool { return strings.HasPrefix(word, p) }) {
+			return false, 0
+		}
+	}
+	for _, phrase := range m.phrases {
+		if !containsPhrase(title, phrase) && !containsPhrase(description, phrase) {
+			return false, 0
+		}
+	}
+
+	rank := 0.0
+	for _, word := range title {
+		if m.hit(word) {
+			rank += 1.0
+		}
+	}
+	for _, word := range description {
+		if m.hit(word) {
+			rank += 0.4
+		}
+	}
+	return true, rank / float64(len(all)+1)
+}
+
+// hit reports whether a single lower-cased word matches any term
+func (m *textMatcher) hit(word string) bool {
+	for _, w := range m.words {
+		if word == w {
+			return true
+		}
+	}
+	for _, p := range m.prefixes {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	for _, phrase := range m.phrases {
+		for _, w := range phrase {
+			if word == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// highlight HTML escapes text and wraps every matching word in <mark> tags
+func (m *textMatcher) highlight(text string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		if m.hit(strings.ToLower(word)) {
+			b.WriteString(highlightStart + word + highlightStop)
+		} else {
+			b.WriteString(word)
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+
+	return renderHighlight(b.String())
+}
+
+// splitWords lower-cases text and splits it into words of letters and digits
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func containsWord(words []string, match func(string) bool) bool {
+	for _, word := range words {
+		if match(word) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		found := true
+		for j, w := range phrase {
+			if words[i+j] != w {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
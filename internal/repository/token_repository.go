@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// TokenRepository handles session and refresh token database operations
+type TokenRepository struct{}
+
+// CreateSession creates a new session
+func (r *TokenRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $5, $6)
+	`
+
+	session.CreatedAt = time.Now().UTC()
+	session.LastSeenAt = session.CreatedAt
+	_, err := DB.Exec(ctx, query,
+		session.ID,
+		session.UserID,
+		session.UserAgent,
+		session.IPAddress,
+		session.CreatedAt,
+		session.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession retrieves a session by its ID
+func (r *TokenRepository) GetSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+	`
+
+	var session model.Session
+	err := DB.QueryRow(ctx, query, sessionID).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// ListActiveSessions retrieves the user's sessions that are neither revoked nor expired
+func (r *TokenRepository) ListActiveSessions(ctx context.Context, userID int) ([]*model.Session, error) {
+	query := `
+		SELECT id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY last_seen_at DESC, id
+	`
+
+	rows, err := DB.Query(ctx, query, userID, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		var session model.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records activity on the session
+func (r *TokenRepository) TouchSession(ctx context.Context, sessionID, ipAddress string) error {
+	query := `
+		UPDATE sessions
+		SET last_seen_at = $1, ip_address = $2
+		WHERE id = $3
+	`
+
+	commandTag, err := DB.Exec(ctx, query, time.Now().UTC(), ipAddress, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to touch session: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// ExtendSession moves the expiry of the session
+func (r *TokenRepository) ExtendSession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	query := `
+		UPDATE sessions
+		SET expires_at = $1
+		WHERE id = $2
+	`
+
+	commandTag, err := DB.Exec(ctx, query, expiresAt.UTC(), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to extend session: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeSession revokes a session, keeping the original revocation time
+func (r *TokenRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = COALESCE(revoked_at, $1)
+		WHERE id = $2
+	`
+
+	commandTag, err := DB.Exec(ctx, query, time.Now().UTC(), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes every session of the user except exceptID
+func (r *TokenRepository) RevokeUserSessions(ctx context.Context, userID int, exceptID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = $1
+		WHERE user_id = $2 AND id <> $3 AND revoked_at IS NULL
+	`
+
+	_, err := DB.Exec(ctx, query, time.Now().UTC(), userID, exceptID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// CreateRefreshToken stores a new refresh token
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+	err := DB.QueryRow(ctx, query,
+		token.SessionID,
+		token.TokenHash,
+		token.ExpiresAt.UTC(),
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value
+func (r *TokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, token_hash, expires_at, created_at, used_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+	`
+
+	var token model.RefreshToken
+	err := DB.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.SessionID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errRefreshTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// UseRefreshToken marks a refresh token as used unless it already was
+func (r *TokenRepository) UseRefreshToken(ctx context.Context, tokenID int) (bool, error) {
+	query := `
+		UPDATE refresh_tokens
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL
+	`
+
+	commandTag, err := DB.Exec(ctx, query, time.Now().UTC(), tokenID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// CreatePasswordResetToken stores a new password reset token
+func (r *TokenRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	token.CreatedAt = time.Now().UTC()
+	err := DB.QueryRow(ctx, query,
+		token.UserID,
+		token.TokenHash,
+		token.ExpiresAt.UTC(),
+		token.CreatedAt,
+	).Scan(&token.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	return nil
+}
+
+// GetPasswordResetTokenByHash retrieves a password reset token by the hash of its value
+func (r *TokenRepository) GetPasswordResetTokenByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, created_at, used_at
+		FROM password_reset_tokens
+		WHERE token_hash = $1
+	`
+
+	var token model.PasswordResetToken
+	err := DB.QueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.TokenHash,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UsedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errResetTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// UsePasswordResetToken marks a password reset token as used unless it already was
+func (r *TokenRepository) UsePasswordResetToken(ctx context.Context, tokenID int) (bool, error) {
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL
+	`
+
+	commandTag, err := DB.Exec(ctx, query, time.Now().UTC(), tokenID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use password reset token: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// DeletePasswordResetTokens deletes every password reset token of the user
+func (r *TokenRepository) DeletePasswordResetTokens(ctx context.Context, userID int) error {
+	query := `DELETE FROM password_reset_tokens WHERE user_id = $1`
+
+	if _, err := DB.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// TwoFactorRepository handles two-factor authentication database operations
+type TwoFactorRepository struct{}
+
+// GetTwoFactor retrieves the TOTP settings of a user
+func (r *TwoFactorRepository) GetTwoFactor(ctx context.Context, userID int) (*model.TwoFactor, error) {
+	query := `
+		SELECT user_id, secret, last_used_step, enabled_at, created_at
+		FROM user_two_factor
+		WHERE user_id = $1
+	`
+
+	var tf model.TwoFactor
+	err := DB.QueryRow(ctx, query, userID).Scan(
+		&tf.UserID,
+		&tf.Secret,
+		&tf.LastUsedStep,
+		&tf.EnabledAt,
+		&tf.CreatedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errTwoFactorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+
+	return &tf, nil
+}
+
+// SetTOTPSecret stores the secret of a new enrollment unless 2FA is enabled
+func (r *TwoFactorRepository) SetTOTPSecret(ctx context.Context, userID int, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = EXCLUDED.created_at
+		WHERE user_two_factor.enabled_at IS NULL
+	`
+
+	commandTag, err := DB.Exec(ctx, query, userID, secret, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// EnableTwoFactor enables 2FA and replaces the recovery codes in one transaction
+func (r *TwoFactorRepository) EnableTwoFactor(ctx context.Context, userID int, step int64, codeHashes []string) error {
+	query := `
+		UPDATE user_two_factor
+		SET enabled_at = $1, last_used_step = $2
+		WHERE user_id = $3 AND enabled_at IS NULL
+	`
+
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	commandTag, err := tx.Exec(ctx, query, time.Now().UTC(), step, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errTwoFactorNotFound
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DisableTwoFactor deletes the user's secret, recovery codes and challenges
+func (r *TwoFactorRepository) DisableTwoFactor(ctx context.Context, userID int) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, query := range []string{
+		`DELETE FROM mfa_challenges WHERE user_id = $1`,
+		`DELETE FROM recovery_codes WHERE user_id = $1`,
+		`DELETE FROM user_two_factor WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep records step as used unless it or a later step was used before
+func (r *TwoFactorRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `
+		UPDATE user_two_factor
+		SET last_used_step = $1
+		WHERE user_id = $2 AND (last_used_step IS NULL OR last_used_step < $1)
+	`
+
+	commandTag, err := DB.Exec(ctx, query, step, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp step: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// ReplaceRecoveryCodes replaces every recovery code of the user
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused recovery code of the user as used
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = $1
+		WHERE id = (
+			SELECT id FROM recovery_codes
+			WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL
+	`
+
+	commandTag, err := DB.Exec(ctx, query, time.Now().UTC(), userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// CountRecoveryCodes counts the unused recovery codes of the user
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	query := `SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var count int
+	if err := DB.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+// CreateMFAChallenge stores a new MFA challenge
+func (r *TwoFactorRepository) CreateMFAChallenge(ctx context.Context, challenge *model.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+
+	challenge.CreatedAt = time.Now().UTC()
+	err := DB.QueryRow(ctx, query,
+		challenge.UserID,
+		challenge.TokenHash,
+		challenge.ExpiresAt.UTC(),
+		challenge.CreatedAt,
+	).Scan(&challenge.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+// GetMFAChallengeByHash retrieves an MFA challenge by the hash of its token
+func (r *TwoFactorRepository) GetMFAChallengeByHash(ctx context.Context, tokenHash string) (*model.MFAChallenge, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, attempts, created_at, used_at
+		FROM mfa_challenges
+		WHERE token_hash = $1
+	`
+
+	var challenge model.MFAChallenge
+	err := DB.QueryRow(ctx, query, tokenHash).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.TokenHash,
+		&challenge.ExpiresAt,
+		&challenge.Attempts,
+		&challenge.CreatedAt,
+		&challenge.UsedAt,
+	)
+
+	if isNoRows(err) {
+		return nil, errMFAChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+
+	return &challenge, nil
+}
+
+// AttemptMFAChallenge counts an attempt at an MFA challenge unless it was
+// used or is out of attempts
+func (r *TwoFactorRepository) AttemptMFAChallenge(ctx context.Context, challengeID, maxAttempts int) (bool, error) {
+	query := `
+		UPDATE mfa_challenges
+		SET attempts = attempts + 1
+		WHERE id = $1 AND attempts < $2 AND used_at IS NULL
+	`
+
+	commandTag, err := DB.Exec(ctx, query, challengeID, maxAttempts)
+	if err != nil {
+		return false, fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// UseMFAChallenge marks an MFA challenge as used unless it already was
+func (r *TwoFactorRepository) UseMFAChallenge(ctx context.Context, challengeID int) (bool, error) {
+	query := `
+		UPDATE mfa_challenges
+		SET used_at = $1
+		WHERE id = $2 AND used_at IS NULL
+	`
+
+	commandTag, err := DB.Exec(ctx, query, time.Now().UTC(), challengeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+// ReleaseMFAChallenge marks a used MFA challenge unused again
+func (r *TwoFactorRepository) ReleaseMFAChallenge(ctx context.Context, challengeID int) error {
+	query := `UPDATE mfa_challenges SET used_at = NULL WHERE id = $1`
+
+	if _, err := DB.Exec(ctx, query, challengeID); err != nil {
+		return fmt.Errorf("failed to release mfa challenge: %w", err)
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes swaps the user's recovery codes within tx
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`,
+			userID, hash, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
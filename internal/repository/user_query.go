@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+)
+
+// newUserWindow is how far back SystemStats.NewUsers counts registrations
+const newUserWindow = 30 * 24 * time.Hour
+
+// likeEscaper escapes the LIKE wildcards in a search, used with ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// userSearchPattern returns the LIKE pattern matching search anywhere in a
+// lower-cased column
+func userSearchPattern(search string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+}
+
+// matchesUserQuery reports whether user passes the filters of q; the memory
+// backend's counterpart of the SQL WHERE clause
+func matchesUserQuery(q model.UserQuery, user *model.User) bool {
+	if q.Search != "" {
+		search := strings.ToLower(q.Search)
+		if !strings.Contains(strings.ToLower(user.Username), search) && !strings.Contains(strings.ToLower(user.Email), search) {
+			return false
+		}
+	}
+	if q.Role != "" && user.Role != q.Role {
+		return false
+	}
+	if q.Disabled != nil && (user.DisabledAt != nil) != *q.Disabled {
+		return false
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"aplikasi-todolist/internal/model"
 )
@@ -11,68 +12,85 @@ import (
 type UserRepository struct{}
 
 // GetUserByEmail retrieves a user by their email
-func (r *UserRepository) GetUserByEmail(email string) (*model.User, error) {
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-		SELECT id, username, email, password_hash, created_at, updated_at
+		SELECT id, username, email, password_hash, role, email_verified_at, disabled_at, delete_after, created_at, updated_at
 		FROM users
 		WHERE email = $1
 	`
 
 	var user model.User
-	err := DB.QueryRow(context.Background(), query, email).Scan(
+	err := DB.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.Password, // This will be the hashed password
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.DisabledAt,
+		&user.DeleteAfter,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
+	if isNoRows(err) {
+		return nil, errUserNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	return &user, nil
 }
 
 // GetUserByID retrieves a user by their ID
-func (r *UserRepository) GetUserByID(userID int) (*model.User, error) {
+func (r *UserRepository) GetUserByID(ctx context.Context, userID int) (*model.User, error) {
 	query := `
-		SELECT id, username, email, created_at, updated_at
+		SELECT id, username, email, role, email_verified_at, disabled_at, delete_after, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`
 
 	var user model.User
-	err := DB.QueryRow(context.Background(), query, userID).Scan(
+	err := DB.QueryRow(ctx, query, userID).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.DisabledAt,
+		&user.DeleteAfter,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
+	if isNoRows(err) {
+		return nil, errUserNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	return &user, nil
 }
 
 // CreateUser creates a new user
-func (r *UserRepository) CreateUser(user *model.User) error {
+func (r *UserRepository) CreateUser(ctx context.Context, user *model.User) error {
 	query := `
 		INSERT INTO users (username, email, password_hash)
 		VALUES ($1, $2, $3)
-		RETURNING id, created_at, updated_at
+		RETURNING id, role, created_at, updated_at
 	`
 
-	err := DB.QueryRow(context.Background(), query,
+	err := DB.QueryRow(ctx, query,
 		user.Username,
 		user.Email,
 		user.Password, // This should be the hashed password
-	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Role, &user.CreatedAt, &user.UpdatedAt)
 
+	if isUniqueViolation(err) {
+		return errUserExists
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -81,7 +99,7 @@ func (r *UserRepository) CreateUser(user *model.User) error {
 }
 
 // UserExists checks if a user exists by email or username
-func (r *UserRepository) UserExists(email, username string) (bool, error) {
+func (r *UserRepository) UserExists(ctx context.Context, email, username string) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1 FROM users WHERE email = $1 OR username = $2
@@ -89,10 +107,179 @@ func (r *UserRepository) UserExists(email, username string) (bool, error) {
 	`
 
 	var exists bool
-	err := DB.QueryRow(context.Background(), query, email, username).Scan(&exists)
+	err := DB.QueryRow(ctx, query, email, username).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if user exists: %w", err)
 	}
 
 	return exists, nil
-}
\ No newline at end of file
+}
+
+// UpdatePassword replaces the password hash of a user
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	commandTag, err := DB.Exec(ctx, query, passwordHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUsername renames a user
+func (r *UserRepository) UpdateUsername(ctx context.Context, userID int, username string) error {
+	query := `
+		UPDATE users
+		SET username = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`
+
+	commandTag, err := DB.Exec(ctx, query, username, userID)
+	if isUniqueViolation(err) {
+		return errUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update username: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateEmail switches a user to a confirmed new email address
+func (r *UserRepository) UpdateEmail(ctx context.Context, userID int, oldEmail, newEmail string) error {
+	query := `
+		UPDATE users
+		SET email = $1, email_verified_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND email = $3
+	`
+
+	commandTag, err := DB.Exec(ctx, query, newEmail, userID, oldEmail)
+	if isUniqueViolation(err) {
+		return errUserExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// MarkEmailVerified records that the user confirmed email, unless the
+// address changed in the meantime
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND email = $2
+	`
+
+	commandTag, err := DB.Exec(ctx, query, userID, email)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// ScheduleDeletion deactivates a user until deleteAfter, when the account is
+// purged
+func (r *UserRepository) ScheduleDeletion(ctx context.Context, userID int, deleteAfter time.Time) error {
+	query := `UPDATE users SET delete_after = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	commandTag, err := DB.Exec(ctx, query, userID, deleteAfter.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// CancelDeletion restores a user scheduled for deletion
+func (r *UserRepository) CancelDeletion(ctx context.Context, userID int) error {
+	query := `UPDATE users SET delete_after = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	commandTag, err := DB.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers deletes the users whose grace period ended before t;
+// their data goes with them through ON DELETE CASCADE
+func (r *UserRepository) PurgeDeletedUsers(ctx context.Context, t time.Time) (int, error) {
+	query := `DELETE FROM users WHERE delete_after < $1`
+
+	commandTag, err := DB.Exec(ctx, query, t.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	return int(commandTag.RowsAffected()), nil
+}
+
+// SetRole changes the role of a user
+func (r *UserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	query := `UPDATE users SET role = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	commandTag, err := DB.Exec(ctx, query, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
+
+// SetDisabled disables a user as of disabledAt, or enables them when nil
+func (r *UserRepository) SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error {
+	query := `UPDATE users SET disabled_at = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`
+
+	var at interface{}
+	if disabledAt != nil {
+		at = disabledAt.UTC()
+	}
+	commandTag, err := DB.Exec(ctx, query, userID, at)
+	if err != nil {
+		return fmt.Errorf("failed to set disabled: %w", err)
+	}
+
+	if commandTag.RowsAffected() == 0 {
+		return errUserNotFound
+	}
+
+	return nil
+}
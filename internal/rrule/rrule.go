@@ -0,0 +1,443 @@
+// Package rrule implements the part of RFC 5545 recurrence rules todos
+// repeat by: the DAILY, WEEKLY, MONTHLY and YEARLY frequencies with INTERVAL,
+// COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST. For example
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH" is every other week on Monday and
+// Thursday.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is how often a rule repeats
+type Frequency string
+
+// Frequencies accepted by Parse
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Weekday is a BYDAY entry: a day of the week, optionally the Nth one of
+// the month or year, counted from the end when N is negative
+type Weekday struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count is the number of occurrences, or 0 for no limit. Next doesn't
+	// apply it; callers count the occurrences themselves.
+	Count int
+	// Until is the last time an occurrence may fall on, or zero for no
+	// limit. UntilDate is set when it was given as a date, which includes
+	// the whole day.
+	Until      time.Time
+	UntilDate  bool
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+var dayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+const (
+	untilDateFormat = "20060102"
+	untilTimeFormat = "20060102T150405Z"
+	// maxPeriods and searchYears bound the search for the next occurrence
+	// of rules that match rarely or never, such as February 30th
+	maxPeriods  = 1000
+	searchYears = 10
+	// maxInterval and maxCount bound INTERVAL and COUNT
+	maxInterval = 1000
+	maxCount    = 10000
+	// maxStep is more days, and so periods of any frequency, than fit
+	// before year 9999, where the search gives up anyway
+	maxStep = 10000 * 366
+)
+
+// Parse parses a rule such as "FREQ=MONTHLY;BYMONTHDAY=15", with or without
+// the "RRULE:" prefix
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return nil, errors.New("the rule is empty")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("%q is not a NAME=VALUE rule part", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s is given more than once", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if r.Freq != Daily && r.Freq != Weekly && r.Freq != Monthly && r.Freq != Yearly {
+				err = errors.New("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			r.Interval, err = parsePositive(name, value, maxInterval)
+		case "COUNT":
+			r.Count, err = parsePositive(name, value, maxCount)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(name, value, 1, 31, true)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(name, value, 1, 12, false)
+			sort.Ints(months)
+			for _, m := range months {
+				r.ByMonth = append(r.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			var day int
+			if day = dayIndex(value); day < 0 {
+				err = errors.New("WKST must be a weekday such as MO")
+			}
+			r.WeekStart = time.Weekday(day)
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL can't be combined")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return nil, errors.New("BYMONTHDAY can't be used with FREQ=WEEKLY")
+	}
+	for _, d := range r.ByDay {
+		switch {
+		case d.N == 0:
+		case r.Freq != Monthly && r.Freq != Yearly:
+			return nil, errors.New("numbered BYDAY entries such as 2MO need FREQ=MONTHLY or YEARLY")
+		case r.Freq == Monthly && (d.N > 5 || d.N < -5):
+			return nil, errors.New("BYDAY numbers must be between -5 and 5 with FREQ=MONTHLY")
+		}
+	}
+
+	return r, nil
+}
+
+func parsePositive(name, value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("%s must be a number between 1 and %d", name, max)
+	}
+	return n, nil
+}
+
+// parseIntList parses a comma-separated list of numbers between min and
+// max, or between -max and -min too when negative is set
+func parseIntList(name, value string, min, max int, negative bool) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		abs := n
+		if abs < 0 && negative {
+			abs = -abs
+		}
+		if err != nil || abs < min || abs > max {
+			return nil, fmt.Errorf("%s must list numbers between %d and %d", name, min, max)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+func parseByDay(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, errors.New("BYDAY must list weekdays such as MO or 2TU")
+		}
+		day := dayIndex(item[len(item)-2:])
+		var n int
+		var err error
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err = strconv.Atoi(prefix)
+			if err == nil && (n == 0 || n > 53 || n < -53) {
+				err = errors.New("out of range")
+			}
+		}
+		if day < 0 || err != nil {
+			return nil, errors.New("BYDAY must list weekdays such as MO or 2TU")
+		}
+		days = append(days, Weekday{N: n, Day: time.Weekday(day)})
+	}
+	return days, nil
+}
+
+func (r *Rule) parseUntil(value string) error {
+	if t, err := time.Parse(untilDateFormat, value); err == nil {
+		r.Until, r.UntilDate = t, true
+		return nil
+	}
+	// Floating times are taken as UTC, like the due dates of todos
+	if t, err := time.Parse(untilTimeFormat, strings.TrimSuffix(value, "Z")+"Z"); err == nil {
+		r.Until = t
+		return nil
+	}
+	return errors.New("UNTIL must be a date such as 20261231 or a UTC time such as 20261231T170000Z")
+}
+
+// dayIndex returns the time.Weekday of a two-letter day name, or -1
+func dayIndex(name string) int {
+	for i, n := range dayNames {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// String formats the rule in a canonical form, without the "RRULE:" prefix
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilDateFormat))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilTimeFormat))
+		}
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = strconv.Itoa(int(m))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = dayNames[d.Day]
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+dayNames[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after t of the series starting at t,
+// at the time of day of t. Starting at the previous occurrence keeps the
+// phase of the INTERVAL. It returns false when the series ends before
+// another occurrence, because of UNTIL or because none can be found.
+func (r *Rule) Next(t time.Time) (time.Time, bool) {
+	limit := t.AddDate(searchYears, 0, 0)
+	interval := max(r.Interval, 1)
+	for k := 0; ; k++ {
+		// Checked before multiplying, so k * INTERVAL can't overflow
+		if k > maxStep/interval {
+			return time.Time{}, false
+		}
+		days, start := r.period(t, k)
+		if k >= maxPeriods && start.After(limit) || start.Year() > 9999 {
+			return time.Time{}, false
+		}
+		for _, day := range days {
+			if !day.After(t) {
+				continue
+			}
+			if r.ended(day) {
+				return time.Time{}, false
+			}
+			return day, true
+		}
+	}
+}
+
+// ended reports whether an occurrence falls after UNTIL
+func (r *Rule) ended(occurrence time.Time) bool {
+	if r.Until.IsZero() {
+		return false
+	}
+	if r.UntilDate {
+		y, m, d := occurrence.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).After(r.Until)
+	}
+	return occurrence.After(r.Until)
+}
+
+// period returns the occurrences of the kth period of the series starting
+// at t in order, and the first day of the period
+func (r *Rule) period(t time.Time, k int) ([]time.Time, time.Time) {
+	y, m, d := t.Date()
+	day := func(year int, month time.Month, dayOfMonth int) time.Time {
+		return time.Date(year, month, dayOfMonth, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	}
+	step := k * max(r.Interval, 1)
+
+	var days []time.Time
+	var start time.Time
+	switch r.Freq {
+	case Daily:
+		start = day(y, m, d+step)
+		if r.matchesMonth(start) && r.matchesMonthDay(start) && r.matchesWeekday(start) {
+			days = append(days, start)
+		}
+
+	case Weekly:
+		offset := (int(t.Weekday()) - int(r.WeekStart) + 7) % 7
+		start = day(y, m, d-offset+7*step)
+		for i := 0; i < 7; i++ {
+			candidate := day(y, m, d-offset+7*step+i)
+			matches := candidate.Weekday() == t.Weekday()
+			if len(r.ByDay) > 0 {
+				matches = r.matchesWeekday(candidate)
+			}
+			if matches && r.matchesMonth(candidate) {
+				days = append(days, candidate)
+			}
+		}
+
+	case Monthly:
+		start = day(y, m+time.Month(step), 1)
+		if r.matchesMonth(start) {
+			days = r.monthDays(start, d, day)
+		}
+
+	case Yearly:
+		start = day(y+step, time.January, 1)
+		switch {
+		case len(r.ByMonth) > 0 || len(r.ByMonthDay) > 0:
+			for month := time.January; month <= time.December; month++ {
+				first := day(start.Year(), month, 1)
+				if r.matchesMonth(first) {
+					days = append(days, r.monthDays(first, d, day)...)
+				}
+			}
+		case len(r.ByDay) > 0:
+			length := day(start.Year(), time.December, 31).YearDay()
+			for i := 0; i < length; i++ {
+				candidate := day(start.Year(), time.January, 1+i)
+				if r.matchesNthWeekday(candidate, i/7+1, (length-1-i)/7+1) {
+					days = append(days, candidate)
+				}
+			}
+		default:
+			if candidate := day(start.Year(), m, d); candidate.Month() == m {
+				days = append(days, candidate)
+			}
+		}
+	}
+	return days, start
+}
+
+// monthDays returns the occurrences in the month starting at first. Without
+// BYMONTHDAY and BYDAY that is the day of the month of the series' start,
+// which is skipped in months too short for it.
+func (r *Rule) monthDays(first time.Time, startDay int, day func(int, time.Month, int) time.Time) []time.Time {
+	year, month := first.Year(), first.Month()
+	length := day(year, month+1, 0).Day()
+
+	var days []time.Time
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if startDay <= length {
+			days = append(days, day(year, month, startDay))
+		}
+		return days
+	}
+	for i := 1; i <= length; i++ {
+		candidate := day(year, month, i)
+		if r.matchesMonthDay(candidate) && (len(r.ByDay) == 0 || r.matchesNthWeekday(candidate, (i-1)/7+1, (length-i)/7+1)) {
+			days = append(days, candidate)
+		}
+	}
+	return days
+}
+
+func (r *Rule) matchesMonth(t time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if t.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	length := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, d := range r.ByMonthDay {
+		if t.Day() == d || t.Day() == length+1+d {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday matches BYDAY without numbers, as used by DAILY and WEEKLY rules
+func (r *Rule) matchesWeekday(t time.Time) bool {
+	return r.matchesNthWeekday(t, 0, 0)
+}
+
+// matchesNthWeekday reports whether t matches BYDAY, being the nth of its
+// weekday in the month or year and the fromEnd-th counting from the end
+func (r *Rule) matchesNthWeekday(t time.Time, nth, fromEnd int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, d := range r.ByDay {
+		if d.Day == t.Weekday() && (d.N == 0 || d.N == nth || d.N == -fromEnd) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package rrule
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// occurrences returns the next n occurrences after start, each found from
+// the previous one
+func occurrences(t *testing.T, rule string, start string, n int) []string {
+	r, err := Parse(rule)
+	require.NoError(t, err, rule)
+
+	var got []string
+	current := date(start)
+	for i := 0; i < n; i++ {
+		next, ok := r.Next(current)
+		if !ok {
+			break
+		}
+		got = append(got, next.Format("2006-01-02 15:04 Mon"))
+		current = next
+	}
+	return got
+}
+
+func TestNext(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rule  string
+		start string
+		want  []string
+		// ends is set when the series has no occurrence after want
+		ends bool
+	}{
+		{
+			"daily", "FREQ=DAILY", "2026-02-27 09:00",
+			[]string{"2026-02-28 09:00 Sat", "2026-03-01 09:00 Sun", "2026-03-02 09:00 Mon"}, false,
+		},
+		{
+			"every weekday", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", "2026-10-15 08:30",
+			[]string{"2026-10-16 08:30 Fri", "2026-10-19 08:30 Mon", "2026-10-20 08:30 Tue"}, false,
+		},
+		{
+			"every 2 weeks on Monday and Thursday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "2026-10-12 10:00",
+			[]string{"2026-10-15 10:00 Thu", "2026-10-26 10:00 Mon", "2026-10-29 10:00 Thu", "2026-11-09 10:00 Mon"}, false,
+		},
+		{
+			"every 2 weeks", "FREQ=WEEKLY;INTERVAL=2", "2026-10-14 00:00",
+			[]string{"2026-10-28 00:00 Wed", "2026-11-11 00:00 Wed"}, false,
+		},
+		{
+			"monthly on the 15th", "FREQ=MONTHLY;BYMONTHDAY=15", "2026-10-15 00:00",
+			[]string{"2026-11-15 00:00 Sun", "2026-12-15 00:00 Tue", "2027-01-15 00:00 Fri"}, false,
+		},
+		{
+			"monthly on the 15th from another day", "FREQ=MONTHLY;BYMONTHDAY=15", "2026-10-20 00:00",
+			[]string{"2026-11-15 00:00 Sun"}, false,
+		},
+		{
+			"monthly on the 31st skips short months", "FREQ=MONTHLY", "2026-01-31 00:00",
+			[]string{"2026-03-31 00:00 Tue", "2026-05-31 00:00 Sun", "2026-07-31 00:00 Fri"}, false,
+		},
+		{
+			"last day of the month", "FREQ=MONTHLY;BYMONTHDAY=-1", "2026-01-31 00:00",
+			[]string{"2026-02-28 00:00 Sat", "2026-03-31 00:00 Tue", "2026-04-30 00:00 Thu"}, false,
+		},
+		{
+			"last Friday of the month", "FREQ=MONTHLY;BYDAY=-1FR", "2026-10-30 17:00",
+			[]string{"2026-11-27 17:00 Fri", "2026-12-25 17:00 Fri"}, false,
+		},
+		{
+			"second Tuesday every 3 months", "FREQ=MONTHLY;INTERVAL=3;BYDAY=2TU", "2026-01-13 00:00",
+			[]string{"2026-04-14 00:00 Tue", "2026-07-14 00:00 Tue"}, false,
+		},
+		{
+			"yearly on a leap day", "FREQ=YEARLY", "2024-02-29 00:00",
+			[]string{"2028-02-29 00:00 Tue", "2032-02-29 00:00 Sun"}, false,
+		},
+		{
+			"yearly in some months", "FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=1", "2026-03-01 00:00",
+			[]string{"2026-09-01 00:00 Tue", "2027-03-01 00:00 Mon"}, false,
+		},
+		{
+			"first Monday of the year", "FREQ=YEARLY;BYDAY=1MO", "2026-01-05 00:00",
+			[]string{"2027-01-04 00:00 Mon", "2028-01-03 00:00 Mon"}, false,
+		},
+		{
+			"until a date", "FREQ=DAILY;UNTIL=20261017", "2026-10-15 23:00",
+			[]string{"2026-10-16 23:00 Fri", "2026-10-17 23:00 Sat"}, true,
+		},
+		{
+			"until a time", "FREQ=DAILY;UNTIL=20261017T120000Z", "2026-10-15 23:00",
+			[]string{"2026-10-16 23:00 Fri"}, true,
+		},
+		{
+			"never", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", "2026-01-01 00:00",
+			nil, true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			n := len(tc.want)
+			if tc.ends {
+				n++
+			}
+			assert.Equal(t, tc.want, occurrences(t, tc.rule, tc.start, n))
+		})
+	}
+}
+
+func TestNextExtremeInterval(t *testing.T) {
+	start := date("2026-10-15 09:00")
+	for _, freq := range []Frequency{Daily, Weekly, Monthly, Yearly} {
+		for _, interval := range []int{math.MaxInt, math.MaxInt / 2, 1317624576693539401, maxStep + 1} {
+			r := &Rule{Freq: freq, Interval: interval, WeekStart: time.Monday}
+			done := make(chan bool, 1)
+			go func() {
+				_, ok := r.Next(start)
+				done <- ok
+			}()
+			select {
+			case ok := <-done:
+				assert.False(t, ok, "%s every %d", freq, interval)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s every %d took too long", freq, interval)
+			}
+		}
+	}
+
+	r, err := Parse("FREQ=YEARLY;INTERVAL=1000")
+	require.NoError(t, err)
+	next, ok := r.Next(start)
+	require.True(t, ok)
+	assert.Equal(t, 3026, next.Year())
+}
+
+func TestParse(t *testing.T) {
+	r, err := Parse("rrule:freq=weekly;byday=th,mo;interval=2;wkst=su")
+	require.NoError(t, err)
+	assert.Equal(t, Weekly, r.Freq)
+	assert.Equal(t, 2, r.Interval)
+	assert.Equal(t, []Weekday{{Day: time.Thursday}, {Day: time.Monday}}, r.ByDay)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=TH,MO;WKST=SU", r.String())
+
+	r, err = Parse("FREQ=MONTHLY;COUNT=3;BYDAY=-1FR;BYMONTH=12,6")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=MONTHLY;COUNT=3;BYMONTH=6,12;BYDAY=-1FR", r.String())
+
+	r, err = Parse("FREQ=DAILY;UNTIL=20261231T170000")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=DAILY;UNTIL=20261231T170000Z", r.String())
+
+	for _, rule := range []string{
+		"",
+		"RRULE:",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;INTERVAL=1001",
+		"FREQ=DAILY;INTERVAL=9223372036854775807",
+		"FREQ=DAILY;COUNT=10001",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261231",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ",
+	} {
+		_, err := Parse(rule)
+		assert.Error(t, err, rule)
+	}
+}
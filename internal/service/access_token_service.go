@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/utils"
+)
+
+const (
+	// AccessTokenPrefix starts every personal access token, which tells them
+	// apart from JWTs and makes leaked tokens easy to find
+	AccessTokenPrefix = "pat_"
+	// accessTokenBytes is the entropy of a personal access token
+	accessTokenBytes = 32
+	// maxAccessTokens caps the tokens one user may have
+	maxAccessTokens = 50
+	// maxAccessTokenNameLength caps the name of a token
+	maxAccessTokenNameLength = 100
+	// accessTokenTouchInterval limits how often last_used_at is written
+	accessTokenTouchInterval = time.Minute
+)
+
+var (
+	errAccessTokenNotFound = apperror.NotFound("access token not found")
+	errTooManyAccessTokens = apperror.Conflict(fmt.Sprintf("a user can have at most %d access tokens", maxAccessTokens))
+)
+
+// AccessTokenService manages personal access tokens, which give scripts and
+// integrations scoped access to the API without the user's password
+type AccessTokenService struct {
+	userRepo        repository.UserStore
+	accessTokenRepo repository.AccessTokenStore
+}
+
+// NewAccessTokenService creates a new AccessTokenService instance
+func NewAccessTokenService(userRepo repository.UserStore, accessTokenRepo repository.AccessTokenStore) *AccessTokenService {
+	return &AccessTokenService{
+		userRepo:        userRepo,
+		accessTokenRepo: accessTokenRepo,
+	}
+}
+
+// Create issues a new token. The token itself is only part of this response;
+// afterwards only its hash is known. When the request is made with a token,
+// caller, the new token can't have scopes caller lacks or outlive it.
+func (s *AccessTokenService) Create(ctx context.Context, userID int, caller *model.PersonalAccessToken, req *model.CreateAccessTokenRequest) (*model.CreatedAccessToken, error) {
+	name := strings.TrimSpace(utils.SanitizeInput(req.Name))
+	scopes := uniqueScopes(req.Scopes)
+	expiresAt := req.ExpiresAt
+	if caller != nil && caller.ExpiresAt != nil && expiresAt == nil {
+		expiresAt = caller.ExpiresAt
+	}
+
+	var v apperror.Validation
+	switch {
+	case name == "":
+		v.Add("name", "name is required")
+	case utf8.RuneCountInString(name) > maxAccessTokenNameLength:
+		v.Add("name", fmt.Sprintf("name must be at most %d characters", maxAccessTokenNameLength))
+	}
+	if len(scopes) == 0 {
+		v.Add("scopes", "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			v.Add("scopes", fmt.Sprintf("unknown scope %q, use one of %s", scope, strings.Join(model.Scopes, ", ")))
+		}
+	}
+	switch {
+	case expiresAt != nil && !expiresAt.After(time.Now()):
+		v.Add("expires_at", "expires_at must be in the future")
+	case caller != nil && caller.ExpiresAt != nil && expiresAt.After(*caller.ExpiresAt):
+		v.Add("expires_at", "expires_at can't be later than the expiry of the token making the request")
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	if caller != nil {
+		for _, scope := range scopes {
+			if !caller.HasScope(scope) {
+				return nil, apperror.Forbidden("this token can't grant the " + scope + " scope it lacks")
+			}
+		}
+	}
+
+	existing, err := s.accessTokenRepo.ListAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	if len(existing) >= maxAccessTokens {
+		return nil, errTooManyAccessTokens
+	}
+
+	secret, err := utils.GenerateToken(accessTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	value := AccessTokenPrefix + secret
+
+	token := &model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: utils.HashToken(value),
+		Scopes:    scopes,
+	}
+	if expiresAt != nil {
+		expiresAt := expiresAt.UTC()
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.accessTokenRepo.CreateAccessToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	return &model.CreatedAccessToken{PersonalAccessToken: token, Token: value}, nil
+}
+
+// List returns the user's tokens, newest first
+func (s *AccessTokenService) List(ctx context.Context, userID int) ([]*model.PersonalAccessToken, error) {
+	tokens, err := s.accessTokenRepo.ListAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Revoke deletes one of the user's tokens
+func (s *AccessTokenService) Revoke(ctx context.Context, userID, tokenID int) error {
+	err := s.accessTokenRepo.DeleteAccessToken(ctx, tokenID, userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return errAccessTokenNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete access token: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate looks up a personal access token and its user, rejecting
+// unknown and expired tokens
+func (s *AccessTokenService) Authenticate(ctx context.Context, value string) (*model.PersonalAccessToken, *model.User, error) {
+	token, err := s.accessTokenRepo.GetAccessTokenByHash(ctx, utils.HashToken(value))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, errInvalidAccessToken
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && !now.Before(*token.ExpiresAt) {
+		return nil, nil, errInvalidAccessToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, token.UserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, errInvalidAccessToken
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	// Tokens of deleted accounts come back with the account, but don't work
+	// until then
+	if user.DeleteAfter != nil {
+		return nil, nil, errInvalidAccessToken
+	}
+	if user.DisabledAt != nil {
+		return nil, nil, errAccountDisabled
+	}
+
+	// Scripts may call the API many times a minute; the exact time of each
+	// call isn't worth a write
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		if err := s.accessTokenRepo.TouchAccessToken(ctx, token.ID, now); err != nil {
+			return nil, nil, fmt.Errorf("failed to touch access token: %w", err)
+		}
+	}
+
+	return token, user, nil
+}
+
+// uniqueScopes trims the requested scopes and drops duplicates, keeping the
+// order they were given in
+func uniqueScopes(requested []string) []string {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+	return scopes
+}
+
+// validScope reports whether scope is one of model.Scopes
+func validScope(scope string) bool {
+	for _, s := range model.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
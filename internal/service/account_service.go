@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"aplikasi-todolist/internal/mail"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+)
+
+// accountPurgeInterval is how often accounts past their grace period are
+// looked for
+const accountPurgeInterval = time.Hour
+
+// AccountService exports and deletes the accounts of users. Deleted accounts
+// are deactivated for a grace period, during which logging in restores them,
+// and purged with all their data afterwards.
+type AccountService struct {
+	tokens           *TokenService
+	mails            *MailSender
+	userRepo         repository.UserStore
+	todoRepo         repository.TodoStore
+	itemRepo         repository.TodoItemStore
+	tagRepo          repository.TagStore
+	listRepo         repository.ListStore
+	reminderRepo     repository.ReminderStore
+	notificationRepo repository.NotificationStore
+	throttle         *LoginThrottle
+	gracePeriod      time.Duration
+}
+
+// NewAccountService creates a new AccountService instance
+func NewAccountService(tokens *TokenService, mails *MailSender, userRepo repository.UserStore, todoRepo repository.TodoStore, itemRepo repository.TodoItemStore, tagRepo repository.TagStore, listRepo repository.ListStore, reminderRepo repository.ReminderStore, notificationRepo repository.NotificationStore, throttle *LoginThrottle, gracePeriod time.Duration) *AccountService {
+	return &AccountService{
+		tokens:           tokens,
+		mails:            mails,
+		userRepo:         userRepo,
+		todoRepo:         todoRepo,
+		itemRepo:         itemRepo,
+		tagRepo:          tagRepo,
+		listRepo:         listRepo,
+		reminderRepo:     reminderRepo,
+		notificationRepo: notificationRepo,
+		throttle:         throttle,
+		gracePeriod:      gracePeriod,
+	}
+}
+
+// Export returns the profile, the lists, every todo of the user with their
+// checklists, tags and reminders, and the notifications
+func (s *AccountService) Export(ctx context.Context, userID int) (*model.AccountExport, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	todos, err := s.todoRepo.GetTodosByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todos: %w", err)
+	}
+	if todos == nil {
+		todos = []*model.Todo{}
+	}
+
+	items, err := s.itemRepo.ListTodoItems(ctx, todoIDs(todos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	tags, err := s.tagRepo.ListTodoTags(ctx, todoIDs(todos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	reminders, err := s.reminderRepo.ListTodoReminders(ctx, todoIDs(todos))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	lists, err := s.listRepo.ListLists(ctx, userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+	notifications, err := s.notificationRepo.ListNotifications(ctx, userID, false, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	names := make(map[int]string, len(lists))
+	for _, list := range lists {
+		names[list.ID] = list.Name
+	}
+	for _, todo := range todos {
+		todo.Items = items[todo.ID]
+		todo.Tags = tags[todo.ID]
+		todo.Reminders = reminders[todo.ID]
+		if todo.ListID != nil {
+			todo.Category = names[*todo.ListID]
+		}
+	}
+
+	return &model.AccountExport{
+		ExportedAt:    time.Now().UTC(),
+		User:          user,
+		Lists:         lists,
+		Todos:         todos,
+		Notifications: notifications,
+	}, nil
+}
+
+// Delete deactivates the account after checking the password and signs out
+// every session. The account is purged once the grace period is over.
+func (s *AccountService) Delete(ctx context.Context, userID int, password string) (*model.AccountDeletion, error) {
+	if err := s.throttle.checkPassword(ctx, s.userRepo, userID, password); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	deleteAfter := time.Now().Add(s.gracePeriod).UTC().Truncate(time.Second)
+	if err := s.userRepo.ScheduleDeletion(ctx, userID, deleteAfter); err != nil {
+		return nil, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+	if err := s.tokens.RevokeAllSessions(ctx, userID, ""); err != nil {
+		return nil, err
+	}
+
+	s.mails.send(user.ID, mail.Message{
+		To:      user.Email,
+		Subject: "Your account will be deleted",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Your account has been deactivated and will be deleted with all your to-dos after %s.\n\n"+
+			"Changed your mind? Sign in again before then to keep your account.\n",
+			user.Username, deleteAfter.Format(time.RFC1123)),
+	})
+
+	log.Printf("User %d deleted their account, purging after %s", userID, deleteAfter.Format(time.RFC3339))
+	return &model.AccountDeletion{
+		Message:     "your account has been deactivated; sign in again before it is deleted to restore it",
+		DeleteAfter: deleteAfter,
+	}, nil
+}
+
+// RunAccountPurge deletes the accounts whose grace period is over, at start
+// and then every accountPurgeInterval until ctx is done
+func RunAccountPurge(ctx context.Context, userRepo repository.UserStore) {
+	ticker := time.NewTicker(accountPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := userRepo.PurgeDeletedUsers(ctx, time.Now())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to purge deleted accounts: %v", err)
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted accounts", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/utils"
+)
+
+const (
+	// defaultUserPageSize and maxUserPageSize bound the pages of ListUsers
+	defaultUserPageSize = 50
+	maxUserPageSize     = 100
+)
+
+var (
+	errUserNotFound    = apperror.NotFound("user not found")
+	errDisableYourself = apperror.Conflict("you can't disable your own account")
+)
+
+// AdminService lets admins look after the users of the instance: list and
+// search them, disable and enable their accounts, force password resets and
+// lift login lockouts
+type AdminService struct {
+	auth      *AuthService
+	tokens    *TokenService
+	userRepo  repository.UserStore
+	adminRepo repository.AdminStore
+	throttle  *LoginThrottle
+}
+
+// NewAdminService creates a new AdminService instance
+func NewAdminService(auth *AuthService, tokens *TokenService, userRepo repository.UserStore, adminRepo repository.AdminStore, throttle *LoginThrottle) *AdminService {
+	return &AdminService{
+		auth:      auth,
+		tokens:    tokens,
+		userRepo:  userRepo,
+		adminRepo: adminRepo,
+		throttle:  throttle,
+	}
+}
+
+// EnsureRole returns a forbidden error unless the user has the role
+func (s *AdminService) EnsureRole(ctx context.Context, userID int, role string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Role != role {
+		return apperror.Forbidden("this requires the " + role + " role")
+	}
+	return nil
+}
+
+// ListUsers returns a page of the users matching q, newest first
+func (s *AdminService) ListUsers(ctx context.Context, q model.UserQuery) (*model.UserPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = defaultUserPageSize
+	}
+	if q.Limit > maxUserPageSize {
+		q.Limit = maxUserPageSize
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	page, err := s.adminRepo.ListUsers(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return page, nil
+}
+
+// GetUser returns a user without the password hash
+func (s *AdminService) GetUser(ctx context.Context, userID int) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// Disable blocks the user from logging in and signs out every session.
+// Their personal access tokens stop working until the account is enabled.
+func (s *AdminService) Disable(ctx context.Context, adminID, userID int) (*model.User, error) {
+	if adminID == userID {
+		return nil, errDisableYourself
+	}
+
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt != nil {
+		return user, nil
+	}
+
+	disabledAt := time.Now().UTC().Truncate(time.Second)
+	if err := s.userRepo.SetDisabled(ctx, userID, &disabledAt); err != nil {
+		return nil, fmt.Errorf("failed to disable user: %w", err)
+	}
+	if err := s.tokens.RevokeAllSessions(ctx, userID, ""); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Admin %d disabled user %d", adminID, userID)
+	user.DisabledAt = &disabledAt
+	return user, nil
+}
+
+// Enable lets a disabled user log in again
+func (s *AdminService) Enable(ctx context.Context, adminID, userID int) (*model.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DisabledAt == nil {
+		return user, nil
+	}
+
+	if err := s.userRepo.SetDisabled(ctx, userID, nil); err != nil {
+		return nil, fmt.Errorf("failed to enable user: %w", err)
+	}
+
+	log.Printf("Admin %d enabled user %d", adminID, userID)
+	user.DisabledAt = nil
+	return user, nil
+}
+
+// ForcePasswordReset replaces the user's password with a random one, signs
+// out every session and emails a link to choose a new password
+func (s *AdminService) ForcePasswordReset(ctx context.Context, adminID, userID int) error {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	password, err := utils.GenerateToken(refreshTokenBytes)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if err := s.tokens.RevokeAllSessions(ctx, userID, ""); err != nil {
+		return err
+	}
+
+	if err := s.auth.SendForcedPasswordReset(ctx, user); err != nil {
+		return err
+	}
+
+	log.Printf("Admin %d forced a password reset of user %d", adminID, userID)
+	return nil
+}
+
+// Stats returns instance-wide numbers
+func (s *AdminService) Stats(ctx context.Context) (*model.SystemStats, error) {
+	stats, err := s.adminRepo.Stats(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+// Unlock lifts the login lockout of an email address or client IP
+func (s *AdminService) Unlock(ctx context.Context, adminID int, emailOrIP string) error {
+	if err := s.throttle.Unlock(ctx, emailOrIP); err != nil {
+		return err
+	}
+
+	log.Printf("Admin %d unlocked logins for %s", adminID, emailOrIP)
+	return nil
+}
@@ -1,35 +1,101 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/mail"
 	"aplikasi-todolist/internal/model"
 	"aplikasi-todolist/internal/repository"
 	"aplikasi-todolist/internal/utils"
 )
 
+const (
+	// resetTokenBytes is the entropy of a password reset token
+	resetTokenBytes = 32
+)
+
+// errInvalidCredentials is deliberately the same for unknown emails and wrong
+// passwords so the response doesn't reveal which accounts exist
+var errInvalidCredentials = apperror.Unauthorized("invalid email or password")
+
+// Unknown, expired and used reset tokens all get the same response
+var errInvalidResetToken = apperror.Invalid("token", "invalid or expired reset token")
+
+const errTooManyResets = "too many password reset emails requested, try again later"
+
+// errAccountDisabled is only returned once the password was checked, so it
+// doesn't reveal which accounts exist
+var errAccountDisabled = apperror.Forbidden("this account has been disabled")
+
+// AuthConfig holds the settings of the account flows that send emails
+type AuthConfig struct {
+	// AppURL is the base URL of the web app the emailed links point to
+	AppURL              string
+	PasswordResetExpiry time.Duration
+
+	// LinkSecret signs the email verification links
+	LinkSecret              []byte
+	EmailVerificationExpiry time.Duration
+	// RequireVerifiedEmail rejects logins of users who haven't verified
+	// their email address
+	RequireVerifiedEmail bool
+}
+
 // AuthService handles authentication-related business logic
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo  repository.UserStore
+	tokenRepo repository.TokenStore
+	tokens    *TokenService
+	twoFactor *TwoFactorService
+	throttle  *LoginThrottle
+	mails     *MailSender
+	cfg       AuthConfig
+	verifier  verificationSigner
+	resends   resendLimiter
+	// resets limits password reset emails like resends does verification
+	// emails
+	resets resendLimiter
+	// emailChanges signs the links confirming a new email address
+	emailChanges emailChangeSigner
+	// changeRequests limits the emails confirming a new address, per new
+	// address and per user instead of per client
+	changeRequests resendLimiter
 }
 
 // NewAuthService creates a new AuthService instance
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+func NewAuthService(userRepo repository.UserStore, tokenRepo repository.TokenStore, tokens *TokenService, twoFactor *TwoFactorService, throttle *LoginThrottle, mails *MailSender, cfg AuthConfig) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		tokens:    tokens,
+		twoFactor: twoFactor,
+		throttle:  throttle,
+		mails:     mails,
+		cfg:       cfg,
+		verifier:  newVerificationSigner(cfg.LinkSecret),
+		resends:   newResendLimiter(),
+		resets:    newResendLimiter(),
+
+		emailChanges:   newEmailChangeSigner(cfg.LinkSecret),
+		changeRequests: newResendLimiter(),
 	}
 }
 
 // Register registers a new user
-func (s *AuthService) Register(userReg *model.UserRegister) (*model.User, error) {
+func (s *AuthService) Register(ctx context.Context, userReg *model.UserRegister) (*model.User, error) {
 	// Check if user already exists
-	exists, err := s.userRepo.UserExists(userReg.Email, userReg.Username)
+	exists, err := s.userRepo.UserExists(ctx, userReg.Email, userReg.Username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check if user exists: %w", err)
 	}
 	if exists {
-		return nil, errors.New("user with this email or username already exists")
+		return nil, apperror.Conflict("user with this email or username already exists")
 	}
 
 	// Hash the password
@@ -45,36 +111,226 @@ func (s *AuthService) Register(userReg *model.UserRegister) (*model.User, error)
 		Password: hashedPassword,
 	}
 
-	err = s.userRepo.CreateUser(newUser)
+	err = s.userRepo.CreateUser(ctx, newUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	s.sendVerificationEmail(newUser)
+
 	// Return user without password
 	newUser.Password = ""
 	return newUser, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(userLogin *model.UserLogin) (string, *model.User, error) {
+// Login authenticates a user and starts a session for the client, returning
+// an access and refresh token pair. Users with two-factor authentication get
+// an MFA challenge instead, to be completed with TwoFactorService.LoginMFA.
+// Repeated failures for an email address or from a client are throttled.
+func (s *AuthService) Login(ctx context.Context, userLogin *model.UserLogin, client model.ClientInfo) (*model.LoginResult, error) {
+	attempt, err := s.throttle.begin(ctx, userLogin.Email, client.IPAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer s.throttle.release(ctx, attempt)
+
 	// Get user by email
-	user, err := s.userRepo.GetUserByEmail(userLogin.Email)
+	user, err := s.userRepo.GetUserByEmail(ctx, userLogin.Email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		utils.CheckPasswordHash(userLogin.Password, dummyPasswordHash())
+		return nil, s.loginFailed(ctx, attempt)
+	}
 	if err != nil {
-		return "", nil, errors.New("invalid email or password")
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Check password
 	if !utils.CheckPasswordHash(userLogin.Password, user.Password) {
-		return "", nil, errors.New("invalid email or password")
+		return nil, s.loginFailed(ctx, attempt)
+	}
+	if err := s.throttle.pass(ctx, attempt); err != nil {
+		return nil, err
 	}
 
-	// Generate JWT token
-	token, err := GenerateJWT(user.ID, user.Username)
+	result, err := s.completeLogin(ctx, user, client)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
 	}
 
-	// Return token and user (without password)
+	// The counter is only reset once a session was issued; with two-factor
+	// authentication that happens in LoginMFA
+	if result.Tokens != nil {
+		if err := s.throttle.succeed(ctx, userLogin.Email); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// loginFailed throttles a failed login and returns the error for it
+func (s *AuthService) loginFailed(ctx context.Context, attempt *loginAttempt) error {
+	if err := s.throttle.fail(ctx, attempt); err != nil {
+		return err
+	}
+	return errInvalidCredentials
+}
+
+// completeLogin finishes the login of an authenticated user: users with
+// two-factor authentication get an MFA challenge, everyone else a new
+// session for the client
+func (s *AuthService) completeLogin(ctx context.Context, user *model.User, client model.ClientInfo) (*model.LoginResult, error) {
+	if user.DisabledAt != nil {
+		return nil, errAccountDisabled
+	}
+	// Only reveal that the address is unverified to the account owner
+	if s.cfg.RequireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, errEmailNotVerified
+	}
+
+	pending, err := s.twoFactor.challenge(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return &model.LoginResult{MFA: pending}, nil
+	}
+
+	// Start a new session for this login
+	tokens, err := s.tokens.IssueTokens(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return tokens and user (without password)
 	user.Password = ""
-	return token, user, nil
-}
\ No newline at end of file
+	return &model.LoginResult{Tokens: tokens, User: user}, nil
+}
+
+// ForgotPassword emails a password reset link if an account with the email
+// exists. It succeeds either way, and the account is looked up in the
+// background, so neither the response nor its timing tells callers which
+// accounts exist. The rate limits apply to unknown addresses too.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string, client model.ClientInfo) error {
+	if ok, retryAfter := s.resets.perClient.Allow(client.IPAddress); !ok {
+		return apperror.RateLimited(errTooManyResets, retryAfter)
+	}
+	if ok, retryAfter := s.resets.perEmail.Allow(strings.ToLower(email)); !ok {
+		return apperror.RateLimited(errTooManyResets, retryAfter)
+	}
+
+	s.mails.run(ctx, func(ctx context.Context) {
+		if err := s.sendResetLink(ctx, email); err != nil {
+			log.Printf("Failed to send password reset link: %v", err)
+		}
+	})
+	return nil
+}
+
+// sendResetLink emails a password reset link if an account with the email
+// exists
+func (s *AuthService) sendResetLink(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	token, err := s.createResetToken(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mails.send(user.ID, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password of your account. Open this link to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s and can only be used once. If you didn't ask for it, you can ignore this email.\n",
+			user.Username, s.link("/reset-password", token), s.cfg.PasswordResetExpiry),
+	})
+	return nil
+}
+
+// SendForcedPasswordReset emails a user whose password an administrator
+// reset a link to choose a new one
+func (s *AuthService) SendForcedPasswordReset(ctx context.Context, user *model.User) error {
+	token, err := s.createResetToken(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	s.mails.send(user.ID, mail.Message{
+		To:      user.Email,
+		Subject: "Choose a new password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"An administrator reset the password of your account and signed you out everywhere. Open this link to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s and can only be used once. Afterwards, you can ask for a new link on the login page.\n",
+			user.Username, s.link("/reset-password", token), s.cfg.PasswordResetExpiry),
+	})
+	return nil
+}
+
+// createResetToken stores a new password reset token for the user and
+// returns it
+func (s *AuthService) createResetToken(ctx context.Context, userID int) (string, error) {
+	token, err := utils.GenerateToken(resetTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	reset := &model.PasswordResetToken{
+		UserID:    userID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetExpiry).UTC(),
+	}
+	if err := s.tokenRepo.CreatePasswordResetToken(ctx, reset); err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return token, nil
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out everywhere
+func (s *AuthService) ResetPassword(ctx context.Context, token, password string) error {
+	reset, err := s.tokenRepo.GetPasswordResetTokenByHash(ctx, utils.HashToken(token))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return errInvalidResetToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return errInvalidResetToken
+	}
+
+	used, err := s.tokenRepo.UsePasswordResetToken(ctx, reset.ID)
+	if err != nil {
+		return fmt.Errorf("failed to use password reset token: %w", err)
+	}
+	if !used {
+		return errInvalidResetToken
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, reset.UserID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Other reset links die with the old password, and so do the sessions
+	if err := s.tokenRepo.DeletePasswordResetTokens(ctx, reset.UserID); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+	return s.tokens.RevokeAllSessions(ctx, reset.UserID, "")
+}
+
+// link builds an absolute link into the web app carrying a token
+func (s *AuthService) link(path, token string) string {
+	return strings.TrimRight(s.cfg.AppURL, "/") + path + "?token=" + token
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/mail"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/ratelimit"
+)
+
+const (
+	// Resending verification emails, and requesting password reset emails,
+	// is limited per address and per client
+	resendPerEmail  = 3
+	resendPerClient = 10
+	resendWindow    = time.Hour
+)
+
+var (
+	errInvalidVerificationToken = apperror.Invalid("token", "invalid or expired verification link")
+	errEmailNotVerified         = apperror.EmailNotVerified("verify your email address first")
+	errTooManyResends           = "too many verification emails requested, try again later"
+)
+
+// verificationSigner creates and checks the signed tokens in email
+// verification links. They need no storage: the signature covers the user,
+// the expiry and the email address, so changing the address voids old links.
+type verificationSigner struct {
+	key []byte
+}
+
+func newVerificationSigner(secret []byte) verificationSigner {
+	// Derive a dedicated key so these signatures can never be confused with
+	// other uses of the secret
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("email-verification"))
+	return verificationSigner{key: mac.Sum(nil)}
+}
+
+// sign returns a token of the form "<user id>.<expiry>.<signature>"
+func (v verificationSigner) sign(userID int, email string, expiresAt time.Time) string {
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + v.signature(payload, email)
+}
+
+// parse returns the user and expiry of a token without checking the
+// signature, which needs the user's current email address
+func (v verificationSigner) parse(token string) (userID int, expiresAt time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, false
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	expiry, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return userID, time.Unix(expiry, 0), true
+}
+
+// verify checks the signature of token for the given email address
+func (v verificationSigner) verify(token, email string) bool {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return false
+	}
+	expected := v.signature(token[:i], email)
+	return hmac.Equal([]byte(token[i+1:]), []byte(expected))
+}
+
+func (v verificationSigner) signature(payload, email string) string {
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write([]byte(payload + "\x00" + strings.ToLower(email)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// resendLimiter limits the emails requested for an address, per address and
+// per client
+type resendLimiter struct {
+	perEmail  *ratelimit.Limiter
+	perClient *ratelimit.Limiter
+}
+
+func newResendLimiter() resendLimiter {
+	return resendLimiter{
+		perEmail:  ratelimit.New(resendPerEmail, resendWindow),
+		perClient: ratelimit.New(resendPerClient, resendWindow),
+	}
+}
+
+// VerifyEmail confirms the email address of the user a verification token
+// was issued for. Verifying twice succeeds.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	userID, expiresAt, ok := s.verifier.parse(token)
+	if !ok || time.Now().After(expiresAt) {
+		return errInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return errInvalidVerificationToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !s.verifier.verify(token, user.Email) {
+		return errInvalidVerificationToken
+	}
+
+	err = s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		// The address changed since we looked the user up
+		return errInvalidVerificationToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification emails a new verification link if an unverified
+// account with the email exists. Like ForgotPassword it responds the same
+// either way; the rate limits apply to unknown addresses too.
+func (s *AuthService) ResendVerification(ctx context.Context, email string, client model.ClientInfo) error {
+	if ok, retryAfter := s.resends.perClient.Allow(client.IPAddress); !ok {
+		return apperror.RateLimited(errTooManyResends, retryAfter)
+	}
+	if ok, retryAfter := s.resends.perEmail.Allow(strings.ToLower(email)); !ok {
+		return apperror.RateLimited(errTooManyResends, retryAfter)
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.EmailVerifiedAt == nil {
+		s.sendVerificationEmail(user)
+	}
+	return nil
+}
+
+// EnsureEmailVerified returns a forbidden error unless the user verified
+// their email address
+func (s *AuthService) EnsureEmailVerified(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		return errEmailNotVerified
+	}
+	return nil
+}
+
+// sendVerificationEmail emails a fresh verification link to the user
+func (s *AuthService) sendVerificationEmail(user *model.User) {
+	token := s.verifier.sign(user.ID, user.Email, time.Now().Add(s.cfg.EmailVerificationExpiry))
+
+	s.mails.send(user.ID, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that this is your email address by opening this link:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s. If you didn't create an account, you can ignore this email.\n",
+			user.Username, s.link("/verify-email", token), s.cfg.EmailVerificationExpiry),
+	})
+}
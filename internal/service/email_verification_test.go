@@ -0,0 +1,32 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationSigner(t *testing.T) {
+	signer := newVerificationSigner([]byte("secret"))
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := signer.sign(42, "Budi@example.com", expiresAt)
+
+	userID, gotExpiry, ok := signer.parse(token)
+	assert.True(t, ok)
+	assert.Equal(t, 42, userID)
+	assert.True(t, expiresAt.Equal(gotExpiry))
+
+	// Email case doesn't matter, but another address or secret does
+	assert.True(t, signer.verify(token, "budi@example.com"))
+	assert.False(t, signer.verify(token, "other@example.com"))
+	assert.False(t, newVerificationSigner([]byte("other")).verify(token, "budi@example.com"))
+
+	// Changing the user or expiry breaks the signature
+	assert.False(t, signer.verify("43"+token[2:], "budi@example.com"))
+
+	for _, bad := range []string{"", "42", "x.1.sig", "42.x.sig", "42.1.sig.extra"} {
+		_, _, ok := signer.parse(bad)
+		assert.False(t, ok, bad)
+	}
+}
@@ -4,48 +4,118 @@ import (
 	"crypto/subtle"
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+
+	"aplikasi-todolist/internal/jwtkeys"
+	"aplikasi-todolist/internal/oidc"
+	"aplikasi-todolist/internal/utils"
 )
 
 // JWT Claims struct
 type Claims struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
+	// SessionID ties the access token to the session it was issued for, so
+	// revoking the session also rejects the access token
+	SessionID string `json:"sid"`
 	jwt.StandardClaims
 }
 
-// GenerateJWT generates a new JWT token with enhanced security
-func GenerateJWT(userID int, username string) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("JWT_SECRET environment variable is not set")
+// JWTConfig configures the access tokens of GenerateJWT and ValidateJWT
+type JWTConfig struct {
+	// Keys sign tokens with the key ID in the header. Without keys, tokens
+	// are signed with HS256 and Secret.
+	Keys   *jwtkeys.Set
+	Secret string
+	Expiry time.Duration
+	// Audience is put into the aud claim and required of every token
+	Audience string
+}
+
+// jwtIssuer is the iss claim of every token
+const jwtIssuer = "todolist-app"
+
+var jwtConfig = JWTConfig{Expiry: 15 * time.Minute}
+
+// InitJWT configures the signing keys, token lifetime and audience used by
+// GenerateJWT and ValidateJWT
+func InitJWT(cfg JWTConfig) error {
+	if cfg.Keys == nil && cfg.Secret == "" {
+		return errors.New("JWT secret must not be empty")
+	}
+	if cfg.Expiry <= 0 {
+		return errors.New("JWT expiry must be positive")
+	}
+	if cfg.Audience == "" {
+		return errors.New("JWT audience must not be empty")
+	}
+
+	jwtConfig = cfg
+	return nil
+}
+
+// JWKS returns the public keys that verify access tokens, for other services
+// to validate them. The set is empty when tokens are signed with a secret.
+func JWKS() oidc.JSONWebKeySet {
+	if jwtConfig.Keys == nil {
+		return oidc.JSONWebKeySet{Keys: []oidc.JSONWebKey{}}
+	}
+	return jwtConfig.Keys.JWKS()
+}
+
+// jtiBytes is the entropy of the unique ID of each access token
+const jtiBytes = 16
+
+// GenerateJWT generates a new access token for the given session
+func GenerateJWT(userID int, username, sessionID string) (string, error) {
+	if jwtConfig.Keys == nil && jwtConfig.Secret == "" {
+		return "", errors.New("JWT signing key is not configured")
 	}
 
 	// Validate inputs to prevent injection
 	if userID <= 0 {
 		return "", errors.New("invalid user ID")
 	}
-	
+
 	if len(username) > 100 || len(username) == 0 {
 		return "", errors.New("invalid username")
 	}
 
-	expirationTime := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
+	if sessionID == "" {
+		return "", errors.New("invalid session")
+	}
+
+	jti, err := utils.GenerateToken(jtiBytes)
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(jwtConfig.Expiry)
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
 			ExpiresAt: expirationTime.Unix(),
 			IssuedAt:  time.Now().Unix(),
-			Issuer:    "todolist-app", // Add issuer claim for additional security
+			Issuer:    jwtIssuer, // Add issuer claim for additional security
+			Audience:  jwtConfig.Audience,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret))
+	var tokenString string
+	if jwtConfig.Keys != nil {
+		key := jwtConfig.Keys.Signing()
+		token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+		token.Header["kid"] = key.ID
+		tokenString, err = token.SignedString(key.Private)
+	} else {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenString, err = token.SignedString([]byte(jwtConfig.Secret))
+	}
 
 	if err != nil {
 		return "", fmt.Errorf("failed to generate JWT: %w", err)
@@ -56,9 +126,8 @@ func GenerateJWT(userID int, username string) (string, error) {
 
 // ValidateJWT validates a JWT token and returns the claims with enhanced security
 func ValidateJWT(tokenString string) (*Claims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, errors.New("JWT_SECRET environment variable is not set")
+	if jwtConfig.Keys == nil && jwtConfig.Secret == "" {
+		return nil, errors.New("JWT signing key is not configured")
 	}
 
 	// Prevent timing attacks by using constant-time comparison
@@ -67,12 +136,7 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 	}
 
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, verificationKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate JWT: %w", err)
@@ -88,9 +152,36 @@ func ValidateJWT(tokenString string) (*Claims, error) {
 	}
 
 	// Validate issuer
-	if claims.Issuer != "todolist-app" {
+	if claims.Issuer != jwtIssuer {
 		return nil, errors.New("invalid token issuer")
 	}
 
+	// Tokens issued for other services must not work here
+	if !claims.VerifyAudience(jwtConfig.Audience, true) {
+		return nil, errors.New("invalid token audience")
+	}
+
 	return claims, nil
-}
\ No newline at end of file
+}
+
+// verificationKey returns the key that verifies the token's signature. With
+// signing keys, the header names the key and the algorithm has to be the
+// key's, so a public key can never be used as an HMAC secret.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if jwtConfig.Keys == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(jwtConfig.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := jwtConfig.Keys.Get(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return key.Public(), nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+// DefaultListColor is given to lists created without a color, including the
+// lists migration 019 made from categories
+const DefaultListColor = "#9e9e9e"
+
+// ListLists returns the user's lists in order, with their todo counts
+func (s *TodoService) ListLists(ctx context.Context, userID int, includeArchived bool) ([]*model.List, error) {
+	lists, err := s.listRepo.ListLists(ctx, userID, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+	return lists, nil
+}
+
+// GetList returns a list of the user with its todo count
+func (s *TodoService) GetList(ctx context.Context, userID, listID int) (*model.List, error) {
+	lists, err := s.ListLists(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		if list.ID == listID {
+			return list, nil
+		}
+	}
+	return nil, apperror.NotFound("list not found")
+}
+
+// CreateList appends a list to the user's lists; its name must not be in
+// use in any case
+func (s *TodoService) CreateList(ctx context.Context, userID int, listCreate *model.ListCreate) (*model.List, error) {
+	color, err := parseColor(listCreate.Color, DefaultListColor)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &model.List{UserID: userID, Name: listCreate.Name, Color: color, Icon: listCreate.Icon}
+	if err := s.listRepo.CreateList(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+	count := 0
+	list.TodoCount = &count
+	return list, nil
+}
+
+// UpdateList renames, recolors, changes the icon of or archives a list. The
+// new name must not be the name of another list.
+func (s *TodoService) UpdateList(ctx context.Context, userID, listID int, listUpdate *model.ListUpdate) (*model.List, error) {
+	list, err := s.listRepo.GetList(ctx, listID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	if listUpdate.Name != nil {
+		list.Name = *listUpdate.Name
+	}
+	if listUpdate.Color != nil {
+		if list.Color, err = parseColor(*listUpdate.Color, DefaultListColor); err != nil {
+			return nil, err
+		}
+	}
+	if listUpdate.Icon != nil {
+		list.Icon = *listUpdate.Icon
+	}
+	if listUpdate.Archived != nil {
+		list.Archived = *listUpdate.Archived
+	}
+
+	if err := s.listRepo.UpdateList(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to update list: %w", err)
+	}
+	return s.GetList(ctx, userID, listID)
+}
+
+// ReorderLists puts the user's lists, archived ones included, in the order
+// given and returns them
+func (s *TodoService) ReorderLists(ctx context.Context, userID int, order *model.ListOrder) ([]*model.List, error) {
+	lists, err := s.ListLists(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[int]bool, len(lists))
+	for _, list := range lists {
+		remaining[list.ID] = true
+	}
+	for _, id := range order.ListIDs {
+		if !remaining[id] {
+			return nil, apperror.Invalid("list_ids", "list_ids must list every list exactly once")
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		return nil, apperror.Invalid("list_ids", "list_ids must list every list exactly once")
+	}
+
+	if err := s.listRepo.ReorderLists(ctx, userID, order.ListIDs); err != nil {
+		return nil, fmt.Errorf("failed to reorder lists: %w", err)
+	}
+	return s.ListLists(ctx, userID, true)
+}
+
+// DeleteList deletes a list. Its todos are moved to the list d.MoveTo,
+// deleted with d.DeleteTodos or otherwise left without a list.
+func (s *TodoService) DeleteList(ctx context.Context, userID, listID int, d model.ListDeletion) error {
+	if d.MoveTo != nil {
+		if d.DeleteTodos {
+			return apperror.Invalid("move_to", "move_to can't be combined with delete_todos")
+		}
+		if *d.MoveTo == listID {
+			return apperror.Invalid("move_to", "todos can't be moved to the list being deleted")
+		}
+		if _, err := s.listRepo.GetList(ctx, *d.MoveTo, userID); err != nil {
+			return listFieldError("move_to", err)
+		}
+	}
+
+	if err := s.listRepo.DeleteList(ctx, listID, userID, d); err != nil {
+		return fmt.Errorf("failed to delete list: %w", err)
+	}
+	return nil
+}
+
+// todoList returns the list a todo is put in: the list listID or, when that
+// is nil, the list named category, which is created if missing. A zero ID or
+// an empty name means no list.
+func (s *TodoService) todoList(ctx context.Context, userID int, listID *int, category string) (*int, error) {
+	if listID != nil {
+		if *listID == 0 {
+			return nil, nil
+		}
+		if _, err := s.listRepo.GetList(ctx, *listID, userID); err != nil {
+			return nil, listFieldError("list_id", err)
+		}
+		return listID, nil
+	}
+
+	if category == "" {
+		return nil, nil
+	}
+	list, err := s.listRepo.EnsureList(ctx, userID, category, DefaultListColor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list: %w", err)
+	}
+	return &list.ID, nil
+}
+
+// findListByName returns the ID of the user's list named name in any case,
+// or nil if there is none
+func (s *TodoService) findListByName(ctx context.Context, userID int, name string) (*int, error) {
+	lists, err := s.ListLists(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		if strings.ToLower(list.Name) == strings.ToLower(name) {
+			return &list.ID, nil
+		}
+	}
+	return nil, nil
+}
+
+// attachLists sets the name of their list as the category of the todos
+func (s *TodoService) attachLists(ctx context.Context, userID int, todos []*model.Todo) error {
+	if len(todos) == 0 {
+		return nil
+	}
+
+	lists, err := s.ListLists(ctx, userID, true)
+	if err != nil {
+		return err
+	}
+	names := make(map[int]string, len(lists))
+	for _, list := range lists {
+		names[list.ID] = list.Name
+	}
+
+	for _, todo := range todos {
+		todo.Category = ""
+		if todo.ListID != nil {
+			todo.Category = names[*todo.ListID]
+		}
+	}
+	return nil
+}
+
+// listFieldError reports a missing list named by a request field as invalid,
+// rather than as a missing resource
+func listFieldError(field string, err error) error {
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Invalid(field, "list not found")
+	}
+	return fmt.Errorf("failed to get list: %w", err)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/utils"
+)
+
+const (
+	// loginBackoffBase is the delay after the first failure past the free
+	// ones; every further failure doubles it
+	loginBackoffBase = time.Second
+	// loginSweepInterval is how often stale counters are deleted
+	loginSweepInterval = 10 * time.Minute
+)
+
+// Blocked logins get the same response whether or not the account exists
+const errTooManyLogins = "too many failed login attempts, try again later"
+
+// dummyPasswordHash is checked against for unknown emails, so those take as
+// long to reject as wrong passwords
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := utils.HashPassword("not a real password")
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
+// LoginThrottleConfig sets when failed logins lock an email address or a
+// client IP
+type LoginThrottleConfig struct {
+	// EmailThreshold failed logins for an address, or IPThreshold from a
+	// client, lock further logins for Lockout; 0 disables throttling by
+	// that key
+	EmailThreshold int
+	IPThreshold    int
+	Lockout        time.Duration
+}
+
+// LoginThrottle slows down password guessing. Failed logins are counted per
+// email address and per client IP in the database, so the limits hold across
+// instances. Each login is counted before its credentials are checked and
+// taken back if they are right. Half of the threshold is free; after that
+// every failure blocks the key for a doubling delay, and reaching the
+// threshold locks it for the lockout duration. Counters start over once no
+// failure happened for the lockout duration.
+type LoginThrottle struct {
+	repo repository.LoginAttemptStore
+	cfg  LoginThrottleConfig
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewLoginThrottle creates a new LoginThrottle instance
+func NewLoginThrottle(repo repository.LoginAttemptStore, cfg LoginThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{repo: repo, cfg: cfg}
+}
+
+// Unlock lifts the block of an email address or client IP and resets its
+// counter
+func (t *LoginThrottle) Unlock(ctx context.Context, emailOrIP string) error {
+	var key string
+	switch {
+	case strings.Contains(emailOrIP, "@"):
+		key = emailKey(emailOrIP)
+	case net.ParseIP(emailOrIP) != nil:
+		key = ipKey(emailOrIP)
+	default:
+		return apperror.BadRequest("expected an email address or an IP address")
+	}
+
+	if err := t.repo.ClearLoginAttempts(ctx, key); err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+	return nil
+}
+
+// loginAttempt is a login counted as failed before its credentials are
+// checked, so concurrent guesses can't get past the limits
+type loginAttempt struct {
+	email, ip string
+	// failures are the counts of the keys including this attempt
+	failures map[string]int
+	// settled is set once the attempt failed or passed
+	settled bool
+}
+
+// begin counts a login for the email address and the client, rejecting it
+// while either is blocked or out of attempts
+func (t *LoginThrottle) begin(ctx context.Context, email, ip string) (*loginAttempt, error) {
+	now := time.Now()
+	t.sweep(ctx, now)
+
+	attempt := &loginAttempt{email: email, ip: ip, failures: make(map[string]int)}
+	thresholds := t.thresholds(email, ip)
+	for _, key := range t.keys(email, ip) {
+		attempts, ok, err := t.repo.RecordLoginAttempt(ctx, key, now, now.Add(-t.cfg.Lockout), thresholds[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to record login attempt: %w", err)
+		}
+		if !ok {
+			// The keys counted so far shouldn't pay for a rejected login
+			if err := t.pass(ctx, attempt); err != nil {
+				return nil, err
+			}
+			return nil, t.blocked(ctx, key, now)
+		}
+		attempt.failures[key] = attempts.Failures
+	}
+	return attempt, nil
+}
+
+// blocked returns the error for a login refused because of key
+func (t *LoginThrottle) blocked(ctx context.Context, key string, now time.Time) error {
+	attempts, err := t.repo.GetLoginAttempts(ctx, key)
+	if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+		return fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	// Out of attempts but not blocked yet means failures are still being
+	// counted; those end in a lockout
+	wait := t.cfg.Lockout
+	if attempts != nil {
+		if attempts.BlockedUntil != nil && attempts.BlockedUntil.After(now) {
+			wait = attempts.BlockedUntil.Sub(now)
+		} else {
+			wait = attempts.LastFailureAt.Add(t.cfg.Lockout).Sub(now)
+		}
+	}
+	return apperror.RateLimited(errTooManyLogins, max(wait, time.Second))
+}
+
+// fail blocks the keys of a failed login that need to back off
+func (t *LoginThrottle) fail(ctx context.Context, attempt *loginAttempt) error {
+	attempt.settled = true
+	now := time.Now()
+	thresholds := t.thresholds(attempt.email, attempt.ip)
+	for key, failures := range attempt.failures {
+		delay := t.backoff(failures, thresholds[key])
+		if delay == 0 {
+			continue
+		}
+		if err := t.repo.BlockLogin(ctx, key, now.Add(delay)); err != nil {
+			return fmt.Errorf("failed to block login: %w", err)
+		}
+	}
+	return nil
+}
+
+// pass takes the attempt back once the credentials turned out right
+func (t *LoginThrottle) pass(ctx context.Context, attempt *loginAttempt) error {
+	attempt.settled = true
+	for key := range attempt.failures {
+		if err := t.repo.ForgiveLoginFailure(ctx, key); err != nil {
+			return fmt.Errorf("failed to forgive login failure: %w", err)
+		}
+	}
+	return nil
+}
+
+// release takes the attempt back unless it failed or passed already, so
+// logins ending in an error that says nothing about the credentials, such
+// as a database failure, don't count. It is meant to be deferred; failures
+// are logged.
+func (t *LoginThrottle) release(ctx context.Context, attempt *loginAttempt) {
+	if attempt.settled {
+		return
+	}
+	if err := t.pass(ctx, attempt); err != nil {
+		log.Printf("Failed to release login attempt: %v", err)
+	}
+}
+
+// succeed resets the counter of the email address. The client's counter is
+// kept, or one known password would let a client guess others freely.
+func (t *LoginThrottle) succeed(ctx context.Context, email string) error {
+	if t.cfg.EmailThreshold == 0 {
+		return nil
+	}
+	if err := t.repo.ClearLoginAttempts(ctx, emailKey(email)); err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+	return nil
+}
+
+// checkPassword re-authenticates a signed-in user before a sensitive change.
+// Wrong passwords count as failed logins for the user's email address, so a
+// stolen session can't be used to guess the password.
+func (t *LoginThrottle) checkPassword(ctx context.Context, userRepo repository.UserStore, userID int, password string) error {
+	user, err := userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Only the lookup by email returns the password hash
+	user, err = userRepo.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	attempt, err := t.begin(ctx, user.Email, "")
+	if err != nil {
+		return err
+	}
+	defer t.release(ctx, attempt)
+
+	if !utils.CheckPasswordHash(password, user.Password) {
+		if err := t.fail(ctx, attempt); err != nil {
+			return err
+		}
+		return errWrongPassword
+	}
+	return t.pass(ctx, attempt)
+}
+
+// backoff returns how long a key with the given number of failures is
+// blocked
+func (t *LoginThrottle) backoff(failures, threshold int) time.Duration {
+	if failures >= threshold {
+		return t.cfg.Lockout
+	}
+	free := threshold / 2
+	if failures <= free {
+		return 0
+	}
+
+	// Past 2^30 seconds the delay is longer than any sensible lockout
+	shift := failures - free - 1
+	if shift >= 30 {
+		return t.cfg.Lockout
+	}
+	return min(loginBackoffBase<<shift, t.cfg.Lockout)
+}
+
+// thresholds returns the threshold of each counter key of a login
+func (t *LoginThrottle) thresholds(email, ip string) map[string]int {
+	return map[string]int{emailKey(email): t.cfg.EmailThreshold, ipKey(ip): t.cfg.IPThreshold}
+}
+
+// keys returns the counter keys of a login that are throttled
+func (t *LoginThrottle) keys(email, ip string) []string {
+	var keys []string
+	if t.cfg.EmailThreshold > 0 {
+		keys = append(keys, emailKey(email))
+	}
+	if t.cfg.IPThreshold > 0 && ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
+
+// sweep deletes the counters that expired, at most once per
+// loginSweepInterval. Failures are logged; they don't affect the login.
+func (t *LoginThrottle) sweep(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	if now.Sub(t.lastSweep) < loginSweepInterval {
+		t.mu.Unlock()
+		return
+	}
+	t.lastSweep = now
+	t.mu.Unlock()
+
+	if err := t.repo.DeleteStaleLoginAttempts(ctx, now.Add(-t.cfg.Lockout)); err != nil {
+		log.Printf("Failed to delete stale login attempts: %v", err)
+	}
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/repository"
+)
+
+func TestLoginThrottleBackoff(t *testing.T) {
+	throttle := NewLoginThrottle(nil, LoginThrottleConfig{Lockout: 15 * time.Minute})
+
+	delays := make([]time.Duration, 0, 10)
+	for failures := 1; failures <= 10; failures++ {
+		delays = append(delays, throttle.backoff(failures, 10))
+	}
+	assert.Equal(t, []time.Duration{
+		0, 0, 0, 0, 0,
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second,
+		15 * time.Minute,
+	}, delays)
+
+	// Delays never exceed the lockout, however high the threshold
+	assert.Equal(t, 8*time.Minute+32*time.Second, throttle.backoff(60, 100))
+	assert.Equal(t, 15*time.Minute, throttle.backoff(70, 100))
+	assert.Equal(t, 15*time.Minute, throttle.backoff(99, 100))
+}
+
+func TestLoginThrottle(t *testing.T) {
+	ctx := context.Background()
+	throttle := NewLoginThrottle(repository.NewMemoryLoginAttemptRepository(), LoginThrottleConfig{
+		EmailThreshold: 2,
+		IPThreshold:    2,
+		Lockout:        time.Hour,
+	})
+
+	failed := func(email, ip string) {
+		attempt, err := throttle.begin(ctx, email, ip)
+		require.NoError(t, err)
+		require.NoError(t, throttle.fail(ctx, attempt))
+	}
+	allowed := func(email, ip string) error {
+		attempt, err := throttle.begin(ctx, email, ip)
+		if err != nil {
+			return err
+		}
+		return throttle.pass(ctx, attempt)
+	}
+
+	// The second failure locks the address, whatever the case and client
+	failed("budi@example.com", "198.51.100.1")
+	failed("Budi@Example.com", "198.51.100.2")
+
+	_, err := throttle.begin(ctx, "budi@example.com", "198.51.100.3")
+	var appErr *apperror.Error
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperror.CodeRateLimited, appErr.Code)
+	assert.InDelta(t, time.Hour.Seconds(), appErr.RetryAfter.Seconds(), 5)
+
+	// A client guessing across addresses hits its own threshold
+	failed("siti@example.com", "192.0.2.1")
+	failed("andi@example.com", "192.0.2.1")
+	assert.Error(t, allowed("rina@example.com", "192.0.2.1"))
+	assert.NoError(t, allowed("rina@example.com", "198.51.100.3"))
+
+	require.NoError(t, throttle.Unlock(ctx, "192.0.2.1"))
+	require.NoError(t, allowed("rina@example.com", "192.0.2.1"))
+	require.NoError(t, throttle.Unlock(ctx, "BUDI@example.com"))
+	require.NoError(t, allowed("budi@example.com", "198.51.100.3"))
+	assert.Error(t, throttle.Unlock(ctx, "budi"))
+
+	// Right credentials don't count, and a successful login forgets the
+	// failures of the address
+	for i := 0; i < 3; i++ {
+		require.NoError(t, allowed("rina@example.com", "198.51.100.7"))
+	}
+	failed("budi@example.com", "198.51.100.4")
+	require.NoError(t, throttle.succeed(ctx, "budi@example.com"))
+	failed("budi@example.com", "198.51.100.5")
+	assert.NoError(t, allowed("budi@example.com", "198.51.100.6"))
+
+	// Logins ending in other errors are released and don't count, while a
+	// released failure still does
+	require.NoError(t, throttle.Unlock(ctx, "budi@example.com"))
+	for i := 0; i < 3; i++ {
+		attempt, err := throttle.begin(ctx, "budi@example.com", "198.51.100.8")
+		require.NoError(t, err)
+		throttle.release(ctx, attempt)
+	}
+	attempt, err := throttle.begin(ctx, "budi@example.com", "198.51.100.9")
+	require.NoError(t, err)
+	require.NoError(t, throttle.fail(ctx, attempt))
+	throttle.release(ctx, attempt)
+	attempts, err := throttle.repo.GetLoginAttempts(ctx, emailKey("budi@example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempts.Failures)
+}
+
+func TestLoginThrottleConcurrentGuesses(t *testing.T) {
+	ctx := context.Background()
+	throttle := NewLoginThrottle(repository.NewMemoryLoginAttemptRepository(), LoginThrottleConfig{
+		EmailThreshold: 4,
+		Lockout:        time.Hour,
+	})
+
+	// Guesses in flight count before they fail, so no more than the
+	// threshold get through
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := throttle.begin(ctx, "budi@example.com", "192.0.2.1"); err == nil {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 4, started)
+}
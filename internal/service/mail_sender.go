@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/mail"
+)
+
+// mailTimeout bounds the delivery of a single email
+const mailTimeout = 30 * time.Second
+
+// MailSender sends the emails of the account flows in the background, so
+// response times don't depend on the mail server. The work is tracked so
+// shutdown can wait for it.
+type MailSender struct {
+	mailer     mail.Mailer
+	background *sync.WaitGroup
+}
+
+// NewMailSender creates a new MailSender instance tracking its work in
+// background
+func NewMailSender(mailer mail.Mailer, background *sync.WaitGroup) *MailSender {
+	return &MailSender{mailer: mailer, background: background}
+}
+
+// send delivers msg to the user in the background. Failures are logged.
+func (m *MailSender) send(userID int, msg mail.Message) {
+	m.run(context.Background(), func(ctx context.Context) {
+		if err := m.mailer.Send(ctx, msg); err != nil {
+			log.Printf("Failed to send %q email to user %d: %v", msg.Subject, userID, err)
+		}
+	})
+}
+
+// run calls f in the background with a context that outlives the request
+// ctx belongs to, bounded by mailTimeout
+func (m *MailSender) run(ctx context.Context, f func(ctx context.Context)) {
+	m.background.Go(func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mailTimeout)
+		defer cancel()
+
+		f(ctx)
+	})
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+)
+
+const (
+	// defaultNotificationPageSize and maxNotificationPageSize bound the
+	// notifications ListNotifications returns
+	defaultNotificationPageSize = 50
+	maxNotificationPageSize     = 200
+)
+
+// NotificationService lets users read the in-app notifications of their
+// fired reminders
+type NotificationService struct {
+	notificationRepo repository.NotificationStore
+}
+
+// NewNotificationService creates a new NotificationService instance
+func NewNotificationService(notificationRepo repository.NotificationStore) *NotificationService {
+	return &NotificationService{notificationRepo: notificationRepo}
+}
+
+// ListNotifications returns the user's latest notifications, newest first,
+// only the unread ones if unreadOnly is set
+func (s *NotificationService) ListNotifications(ctx context.Context, userID int, unreadOnly bool, limit int) ([]*model.Notification, error) {
+	if limit <= 0 {
+		limit = defaultNotificationPageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	notifications, err := s.notificationRepo.ListNotifications(ctx, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// MarkRead marks one of the user's notifications read
+func (s *NotificationService) MarkRead(ctx context.Context, userID, notificationID int) error {
+	if err := s.notificationRepo.MarkNotificationRead(ctx, notificationID, userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	return nil
+}
+
+// MarkAllRead marks every notification of the user read
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int) error {
+	if err := s.notificationRepo.MarkAllNotificationsRead(ctx, userID, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/oidc"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/utils"
+)
+
+const (
+	// oidcLoginExpiry is how long the user has to sign in at the provider
+	oidcLoginExpiry = 10 * time.Minute
+	// oidcStateBytes, oidcVerifierBytes and oidcNonceBytes are the entropy of
+	// the state, the PKCE code verifier and the nonce of a login
+	oidcStateBytes    = 32
+	oidcVerifierBytes = 32
+	oidcNonceBytes    = 16
+	// maxUsernameAttempts bounds the search for a free username
+	maxUsernameAttempts = 5
+)
+
+var (
+	errUnknownProvider  = apperror.NotFound("unknown provider")
+	errInvalidOIDCState = apperror.Unauthorized("invalid or expired sign-in, start again")
+	errOIDCFailed       = apperror.Unauthorized("sign-in with the provider failed")
+	errOIDCNoEmail      = apperror.Forbidden("the provider did not share a verified email address")
+	errOIDCLinkBlocked  = apperror.Conflict("an account with this email address already exists; sign in with your password and verify the address to link it")
+)
+
+// usernameDisallowed matches what ValidateUsername rejects in a username
+var usernameDisallowed = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// OIDCProviderConfig registers an OpenID provider under an ID
+type OIDCProviderConfig struct {
+	ID     string
+	Name   string
+	Config oidc.Config
+}
+
+// OIDCService signs users in with OpenID providers. Provider accounts are
+// linked to local users, which are created on first sign-in; the login then
+// continues like a password login.
+type OIDCService struct {
+	auth      *AuthService
+	userRepo  repository.UserStore
+	oidcRepo  repository.OIDCStore
+	providers map[string]*oidc.Provider
+	list      []model.OIDCProvider
+	// redirectURL is the page of the web app the providers send users back to
+	redirectURL string
+}
+
+// NewOIDCService creates a new OIDCService instance
+func NewOIDCService(auth *AuthService, userRepo repository.UserStore, oidcRepo repository.OIDCStore, providers []OIDCProviderConfig, redirectURL string) *OIDCService {
+	s := &OIDCService{
+		auth:        auth,
+		userRepo:    userRepo,
+		oidcRepo:    oidcRepo,
+		providers:   make(map[string]*oidc.Provider),
+		list:        []model.OIDCProvider{},
+		redirectURL: redirectURL,
+	}
+
+	client := oidc.NewHTTPClient()
+	for _, p := range providers {
+		s.providers[p.ID] = oidc.NewProvider(p.Config, client)
+		s.list = append(s.list, model.OIDCProvider{ID: p.ID, Name: p.Name})
+	}
+	return s
+}
+
+// Providers lists the providers users can sign in with
+func (s *OIDCService) Providers() []model.OIDCProvider {
+	return s.list
+}
+
+// Authorize starts a login with a provider and returns where to send the
+// browser. The state ties the callback to this login.
+func (s *OIDCService) Authorize(ctx context.Context, providerID string) (*model.OIDCAuthorization, error) {
+	provider, ok := s.providers[providerID]
+	if !ok {
+		return nil, errUnknownProvider
+	}
+
+	// Abandoned logins are cleaned up whenever a new one starts
+	if err := s.oidcRepo.DeleteExpiredOIDCLogins(ctx, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to delete expired oidc logins: %w", err)
+	}
+
+	var values [3]string
+	for i, n := range []int{oidcStateBytes, oidcVerifierBytes, oidcNonceBytes} {
+		value, err := utils.GenerateToken(n)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	state, verifier, nonce := values[0], values[1], values[2]
+
+	authURL, err := provider.AuthCodeURL(ctx, s.redirectURL, state, nonce, verifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build authorization URL for %s: %w", providerID, err)
+	}
+
+	login := &model.OIDCLogin{
+		StateHash:    utils.HashToken(state),
+		Provider:     providerID,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(oidcLoginExpiry).UTC(),
+	}
+	if err := s.oidcRepo.CreateOIDCLogin(ctx, login); err != nil {
+		return nil, fmt.Errorf("failed to create oidc login: %w", err)
+	}
+
+	return &model.OIDCAuthorization{AuthorizationURL: authURL}, nil
+}
+
+// Callback completes a login with the code the provider redirected back
+// with. The result is the same as that of a password login.
+func (s *OIDCService) Callback(ctx context.Context, req *model.OIDCCallbackRequest, client model.ClientInfo) (*model.LoginResult, error) {
+	login, err := s.oidcRepo.TakeOIDCLogin(ctx, utils.HashToken(req.State))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, errInvalidOIDCState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take oidc login: %w", err)
+	}
+	if time.Now().After(login.ExpiresAt) {
+		return nil, errInvalidOIDCState
+	}
+
+	provider, ok := s.providers[login.Provider]
+	if !ok {
+		// The provider was removed from the configuration meanwhile
+		return nil, errInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(ctx, s.redirectURL, req.Code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		log.Printf("Sign-in with %s failed: %v", login.Provider, err)
+		return nil, errOIDCFailed
+	}
+
+	user, err := s.resolveUser(ctx, login.Provider, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.auth.completeLogin(ctx, user, client)
+}
+
+// resolveUser finds the user linked to the provider account. Unlinked
+// accounts are linked to the user with the same, verified email address, or
+// get a new user.
+func (s *OIDCService) resolveUser(ctx context.Context, providerID string, claims *oidc.Claims) (*model.User, error) {
+	identity, err := s.oidcRepo.GetIdentity(ctx, providerID, claims.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	if !claims.EmailVerified || utils.ValidateEmail(claims.Email) != nil {
+		return nil, errOIDCNoEmail
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		// Anyone could have registered an unverified address, and linking
+		// would hand them the account of the address owner
+		if user.EmailVerifiedAt == nil {
+			return nil, errOIDCLinkBlocked
+		}
+	case errors.Is(err, apperror.ErrNotFound):
+		user, err = s.createUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	identity = &model.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerID,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := s.oidcRepo.CreateIdentity(ctx, identity); err != nil {
+		return nil, fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	log.Printf("Linked %s account to user %d", providerID, user.ID)
+	return user, nil
+}
+
+// createUser registers a user for a provider account. The user gets a
+// random password and can set a real one with the password reset flow.
+func (s *OIDCService) createUser(ctx context.Context, claims *oidc.Claims) (*model.User, error) {
+	username, err := s.freeUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := utils.GenerateToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &model.User{
+		Username: username,
+		Email:    claims.Email,
+		Password: hashedPassword,
+	}
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// The provider vouched for the address
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID, user.Email); err != nil {
+		return nil, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	verifiedAt := time.Now().UTC()
+	user.EmailVerifiedAt = &verifiedAt
+
+	return user, nil
+}
+
+// freeUsername derives an unused username from the provider account,
+// appending a random suffix when the natural choice is taken
+func (s *OIDCService) freeUsername(ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := ""
+	for _, candidate := range []string{claims.PreferredUsername, strings.Split(claims.Email, "@")[0], claims.Name} {
+		base = usernameDisallowed.ReplaceAllString(candidate, "")
+		if len(base) >= 3 {
+			break
+		}
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	username := base
+	for i := 0; i < maxUsernameAttempts; i++ {
+		exists, err := s.userRepo.UserExists(ctx, "", username)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if user exists: %w", err)
+		}
+		if !exists {
+			return username, nil
+		}
+
+		suffix, err := utils.GenerateToken(4)
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + suffix
+	}
+
+	return "", apperror.Conflict("could not find a free username, try again")
+}
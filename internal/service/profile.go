@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/mail"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/utils"
+)
+
+// emailChangeExpiry is how long the link confirming a new address stays valid
+const emailChangeExpiry = 24 * time.Hour
+
+const errTooManyEmailChanges = "too many email address changes requested, try again later"
+
+var (
+	errWrongCurrentPassword    = apperror.Invalid("current_password", "incorrect password")
+	errSameEmail               = apperror.Invalid("email", "this is already your email address")
+	errEmailTaken              = apperror.Conflict("an account with this email address already exists")
+	errUsernameTaken           = apperror.Conflict("this username is already taken")
+	errInvalidEmailChangeToken = apperror.Invalid("token", "invalid or expired confirmation link")
+)
+
+// emailChangeSigner creates and checks the signed tokens in the links that
+// confirm a new email address. The token carries the new address; like
+// verification links, the signature covers the current one, so a link can
+// only be used once and dies when the address changes otherwise.
+type emailChangeSigner struct {
+	verificationSigner
+}
+
+func newEmailChangeSigner(secret []byte) emailChangeSigner {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("email-change"))
+	return emailChangeSigner{verificationSigner{key: mac.Sum(nil)}}
+}
+
+// sign returns a token of the form "<user id>.<expiry>.<new email>.<signature>"
+func (v emailChangeSigner) sign(userID int, email, newEmail string, expiresAt time.Time) string {
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(expiresAt.Unix(), 10) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(newEmail))
+	return payload + "." + v.signature(payload, email)
+}
+
+// parse returns the user, new address and expiry of a token without
+// checking the signature, which needs the user's current email address
+func (v emailChangeSigner) parse(token string) (userID int, newEmail string, expiresAt time.Time, ok bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, "", time.Time{}, false
+	}
+	userID, expiresAt, ok = v.verificationSigner.parse(parts[0] + "." + parts[1] + "." + parts[3])
+	if !ok {
+		return 0, "", time.Time{}, false
+	}
+	email, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, "", time.Time{}, false
+	}
+	return userID, string(email), expiresAt, true
+}
+
+// Profile returns the signed-in user
+func (s *AuthService) Profile(ctx context.Context, userID int) (*model.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// UpdateProfile applies the fields set in req and returns the updated user
+func (s *AuthService) UpdateProfile(ctx context.Context, userID int, req *model.UpdateProfileRequest) (*model.User, error) {
+	if req.Username != nil {
+		err := s.userRepo.UpdateUsername(ctx, userID, *req.Username)
+		if errors.Is(err, apperror.ErrConflict) {
+			return nil, errUsernameTaken
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update username: %w", err)
+		}
+	}
+
+	return s.Profile(ctx, userID)
+}
+
+// ChangePassword sets a new password after checking the current one, and
+// signs out every other session. sessionID is the session making the change,
+// empty for personal access tokens.
+func (s *AuthService) ChangePassword(ctx context.Context, userID int, sessionID string, req *model.ChangePasswordRequest) error {
+	err := s.throttle.checkPassword(ctx, s.userRepo, userID, req.CurrentPassword)
+	if errors.Is(err, errWrongPassword) {
+		return errWrongCurrentPassword
+	}
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Reset links issued for the old password must not override the new one
+	if err := s.tokenRepo.DeletePasswordResetTokens(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete password reset tokens: %w", err)
+	}
+	if err := s.tokens.RevokeAllSessions(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	user, err := s.Profile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	s.mails.send(user.ID, mail.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"The password of your account was just changed, and your other devices were signed out.\n\n"+
+			"If this wasn't you, reset your password right away:\n\n"+
+			"%s\n",
+			user.Username, strings.TrimRight(s.cfg.AppURL, "/")+"/forgot-password"),
+	})
+	return nil
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// account keeps its current address until the link is opened.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID int, req *model.ChangeEmailRequest) error {
+	if err := s.throttle.checkPassword(ctx, s.userRepo, userID, req.Password); err != nil {
+		return err
+	}
+
+	user, err := s.Profile(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(user.Email, req.Email) {
+		return errSameEmail
+	}
+
+	// Limit the confirmation emails sent to an address, and how often a
+	// user can ask which addresses are taken
+	if ok, retryAfter := s.changeRequests.perClient.Allow(strconv.Itoa(userID)); !ok {
+		return apperror.RateLimited(errTooManyEmailChanges, retryAfter)
+	}
+	if ok, retryAfter := s.changeRequests.perEmail.Allow(strings.ToLower(req.Email)); !ok {
+		return apperror.RateLimited(errTooManyEmailChanges, retryAfter)
+	}
+
+	exists, err := s.userRepo.UserExists(ctx, req.Email, "")
+	if err != nil {
+		return fmt.Errorf("failed to check if user exists: %w", err)
+	}
+	if exists {
+		return errEmailTaken
+	}
+
+	token := s.emailChanges.sign(user.ID, user.Email, req.Email, time.Now().Add(emailChangeExpiry))
+	s.mails.send(user.ID, mail.Message{
+		To:      req.Email,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Please confirm that you want to use this address for your account by opening this link:\n\n"+
+			"%s\n\n"+
+			"The link expires in %s. Until then your account keeps its current address. If you didn't ask for this, you can ignore this email.\n",
+			user.Username, s.link("/confirm-email", token), emailChangeExpiry),
+	})
+	return nil
+}
+
+// ConfirmEmailChange switches the account to the new address of a
+// confirmation token and lets the old address know
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+	userID, newEmail, expiresAt, ok := s.emailChanges.parse(token)
+	if !ok || time.Now().After(expiresAt) {
+		return errInvalidEmailChangeToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return errInvalidEmailChangeToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !s.emailChanges.verify(token, user.Email) {
+		return errInvalidEmailChangeToken
+	}
+
+	err = s.userRepo.UpdateEmail(ctx, user.ID, user.Email, newEmail)
+	if errors.Is(err, apperror.ErrNotFound) {
+		// The address changed since we looked the user up
+		return errInvalidEmailChangeToken
+	}
+	if errors.Is(err, apperror.ErrConflict) {
+		// Someone registered the address after the link was sent
+		return errEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	s.mails.send(user.ID, mail.Message{
+		To:      user.Email,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"The email address of your account was changed to %s. You will no longer receive emails about the account here.\n\n"+
+			"If this wasn't you, contact support right away.\n",
+			user.Username, newEmail),
+	})
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/rrule"
+)
+
+// SkipOccurrence moves a recurring todo to its next occurrence without
+// completing it. Skipping the last occurrence of a series is a conflict.
+func (s *TodoService) SkipOccurrence(ctx context.Context, userID, todoID int) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(ctx, todoID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+	if todo.RecurrenceRule == "" {
+		return nil, apperror.Conflict("the todo doesn't repeat")
+	}
+
+	due, rule, ok := nextOccurrence(todo.RecurrenceRule, *todo.DueDate)
+	if !ok {
+		return nil, apperror.Conflict("this is the last occurrence of the todo")
+	}
+	oldDue := todo.DueDate
+	todo.DueDate = &due
+	todo.RecurrenceRule = rule
+
+	if err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+	if err := s.rescheduleReminders(ctx, todo, oldDue); err != nil {
+		return nil, err
+	}
+	return s.GetTodo(ctx, todoID, userID)
+}
+
+// StopRecurrence makes a recurring todo a one-off todo, keeping its due date
+func (s *TodoService) StopRecurrence(ctx context.Context, userID, todoID int) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(ctx, todoID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	todo.RecurrenceRule = ""
+	todo.RecurrenceMode = ""
+	if err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+	return s.GetTodo(ctx, todoID, userID)
+}
+
+// setRecurrence validates a rule and mode and sets them on the todo. An
+// empty rule makes the todo a one-off todo.
+func setRecurrence(todo *model.Todo, rule, mode string) error {
+	if rule == "" {
+		todo.RecurrenceRule = ""
+		todo.RecurrenceMode = ""
+		return nil
+	}
+
+	parsed, err := rrule.Parse(rule)
+	if err != nil {
+		return apperror.Invalid("recurrence_rule", err.Error())
+	}
+	switch mode {
+	case "":
+		mode = model.RecurFromDueDate
+	case model.RecurFromDueDate, model.RecurFromCompletion:
+	default:
+		return apperror.Invalid("recurrence_mode", "recurrence_mode must be due_date or completion")
+	}
+
+	todo.RecurrenceRule = parsed.String()
+	todo.RecurrenceMode = mode
+	return nil
+}
+
+// completeTodo saves a recurring todo being completed. Unless its series
+// ends with it, the next occurrence is created with its tags and checklist,
+// and the completed todo no longer repeats.
+func (s *TodoService) completeTodo(ctx context.Context, todo *model.Todo) error {
+	from := *todo.DueDate
+	if todo.RecurrenceMode == model.RecurFromCompletion {
+		// The day of completion, at the time of day the todo was due
+		y, m, d := time.Now().In(from.Location()).Date()
+		from = time.Date(y, m, d, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	}
+	due, rule, ok := nextOccurrence(todo.RecurrenceRule, from)
+
+	next := &model.Todo{
+		UserID:         todo.UserID,
+		Title:          todo.Title,
+		Description:    todo.Description,
+		ListID:         todo.ListID,
+		Priority:       todo.Priority,
+		DueDate:        &due,
+		RecurrenceRule: rule,
+		RecurrenceMode: todo.RecurrenceMode,
+	}
+	todo.RecurrenceRule = ""
+	todo.RecurrenceMode = ""
+
+	if !ok {
+		if err := s.todoRepo.UpdateTodo(ctx, todo); err != nil {
+			return fmt.Errorf("failed to update todo: %w", err)
+		}
+		return nil
+	}
+
+	if err := s.todoRepo.CompleteRecurringTodo(ctx, todo, next); err != nil {
+		return fmt.Errorf("failed to complete todo: %w", err)
+	}
+	todo.NextOccurrence = next
+	return nil
+}
+
+// nextOccurrence returns the due date of the occurrence following one due
+// at from and the rule it repeats by, with one occurrence less to go for
+// rules with a COUNT. It returns false when the series ends at from.
+func nextOccurrence(rule string, from time.Time) (time.Time, string, bool) {
+	parsed, err := rrule.Parse(rule)
+	if err != nil || parsed.Count == 1 {
+		return time.Time{}, "", false
+	}
+	next, ok := parsed.Next(from)
+	if !ok {
+		return time.Time{}, "", false
+	}
+	if parsed.Count > 1 {
+		parsed.Count--
+	}
+	return next, parsed.String(), true
+}
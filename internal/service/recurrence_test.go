@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	due := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+
+	next, rule, ok := nextOccurrence("FREQ=MONTHLY;BYMONTHDAY=15", due)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2026, 11, 15, 9, 0, 0, 0, time.UTC), next)
+	assert.Equal(t, "FREQ=MONTHLY;BYMONTHDAY=15", rule)
+
+	// COUNT is the number of occurrences left, this one included
+	next, rule, ok = nextOccurrence("FREQ=DAILY;COUNT=2", due)
+	assert.True(t, ok)
+	assert.Equal(t, due.AddDate(0, 0, 1), next)
+	assert.Equal(t, "FREQ=DAILY;COUNT=1", rule)
+	_, _, ok = nextOccurrence(rule, next)
+	assert.False(t, ok)
+
+	_, _, ok = nextOccurrence("FREQ=DAILY;UNTIL=20261015", due)
+	assert.False(t, ok)
+}
+
+func TestSetRecurrence(t *testing.T) {
+	todo := &model.Todo{}
+	assert.NoError(t, setRecurrence(todo, "rrule:freq=weekly;byday=mo,th;interval=2", ""))
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", todo.RecurrenceRule)
+	assert.Equal(t, model.RecurFromDueDate, todo.RecurrenceMode)
+
+	assert.NoError(t, setRecurrence(todo, "", model.RecurFromCompletion))
+	assert.Empty(t, todo.RecurrenceRule)
+	assert.Empty(t, todo.RecurrenceMode)
+
+	err := setRecurrence(todo, "FREQ=HOURLY", "")
+	assert.ErrorIs(t, err, apperror.ErrValidation)
+	err = setRecurrence(todo, "FREQ=DAILY", "sometimes")
+	assert.ErrorIs(t, err, apperror.ErrValidation)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+// MaxReminders caps the reminders of a single todo
+const MaxReminders = 10
+
+// ListReminders returns the reminders of one of the user's todos
+func (s *TodoService) ListReminders(ctx context.Context, userID, todoID int) ([]*model.Reminder, error) {
+	if _, err := s.todoRepo.GetTodoByID(ctx, todoID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	reminders, err := s.reminderRepo.ListTodoReminders(ctx, []int{todoID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	if reminders[todoID] == nil {
+		return []*model.Reminder{}, nil
+	}
+	return reminders[todoID], nil
+}
+
+// CreateReminder adds a reminder to a todo. A reminder relative to the due
+// date needs the todo to have one; an absolute one must be in the future.
+func (s *TodoService) CreateReminder(ctx context.Context, userID, todoID int, reminderCreate *model.ReminderCreate) (*model.Reminder, error) {
+	todo, err := s.todoRepo.GetTodoByID(ctx, todoID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	reminders, err := s.reminderRepo.ListTodoReminders(ctx, []int{todoID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	if len(reminders[todoID]) >= MaxReminders {
+		return nil, apperror.Conflict(fmt.Sprintf("a todo can have at most %d reminders", MaxReminders))
+	}
+
+	channels := reminderCreate.Channels
+	if len(channels) == 0 {
+		channels = []string{model.ReminderChannelInApp}
+	}
+	reminder := &model.Reminder{
+		TodoID:     todoID,
+		Channels:   channels,
+		WebhookURL: reminderCreate.WebhookURL,
+	}
+
+	if reminderCreate.OffsetMinutes != nil {
+		if todo.DueDate == nil {
+			return nil, apperror.Invalid("offset_minutes", "the todo has no due date to remind of")
+		}
+		offset := *reminderCreate.OffsetMinutes
+		fireAt := todo.DueDate.Add(-time.Duration(offset) * time.Minute).UTC()
+		reminder.OffsetMinutes = &offset
+		reminder.FireAt = &fireAt
+	} else {
+		remindAt := reminderCreate.RemindAt.UTC().Truncate(time.Second)
+		if !remindAt.After(time.Now()) {
+			return nil, apperror.Invalid("remind_at", "remind_at must be in the future")
+		}
+		reminder.RemindAt = &remindAt
+		reminder.FireAt = &remindAt
+	}
+
+	if err := s.reminderRepo.CreateReminder(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+// DeleteReminder deletes a reminder of a todo
+func (s *TodoService) DeleteReminder(ctx context.Context, userID, todoID, reminderID int) error {
+	if _, err := s.todoRepo.GetTodoByID(ctx, todoID, userID); err != nil {
+		return fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	if err := s.reminderRepo.DeleteReminder(ctx, todoID, reminderID); err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	return nil
+}
+
+// rescheduleReminders moves the reminders relative to the due date of a todo
+// after the due date changed from oldDue
+func (s *TodoService) rescheduleReminders(ctx context.Context, todo *model.Todo, oldDue *time.Time) error {
+	if sameTime(oldDue, todo.DueDate) {
+		return nil
+	}
+
+	var dueDate *time.Time
+	if todo.DueDate != nil {
+		due := todo.DueDate.UTC()
+		dueDate = &due
+	}
+	if err := s.reminderRepo.RescheduleReminders(ctx, todo.ID, dueDate); err != nil {
+		return fmt.Errorf("failed to reschedule reminders: %w", err)
+	}
+	return nil
+}
+
+// sameTime reports whether a and b are both nil or the same instant
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
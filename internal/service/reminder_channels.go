@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"aplikasi-todolist/internal/mail"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/utils"
+)
+
+// Compile-time checks that the channels satisfy the interface
+var (
+	_ ReminderChannel = (*InAppChannel)(nil)
+	_ ReminderChannel = (*EmailChannel)(nil)
+	_ ReminderChannel = (*WebhookChannel)(nil)
+)
+
+// InAppChannel delivers reminders as notifications shown in the app
+type InAppChannel struct {
+	notifications repository.NotificationStore
+}
+
+// NewInAppChannel creates a channel recording notifications in the store
+func NewInAppChannel(notifications repository.NotificationStore) *InAppChannel {
+	return &InAppChannel{notifications: notifications}
+}
+
+// Deliver records a notification for the owner of the todo
+func (c *InAppChannel) Deliver(ctx context.Context, d *model.ReminderDelivery) error {
+	todoID := d.Reminder.TodoID
+	notification := &model.Notification{
+		UserID: d.UserID,
+		TodoID: &todoID,
+		Title:  d.TodoTitle,
+		Body:   reminderText(d),
+	}
+	if err := c.notifications.CreateNotification(ctx, notification); err != nil {
+		log.Printf("Notification of reminder %d failed: %v", d.Reminder.ID, err)
+		return errors.New("notification failed")
+	}
+	return nil
+}
+
+// EmailChannel delivers reminders by email to the owner of the todo
+type EmailChannel struct {
+	mailer mail.Mailer
+	appURL string
+}
+
+// NewEmailChannel creates a channel sending mail through mailer, linking to
+// the web app at appURL
+func NewEmailChannel(mailer mail.Mailer, appURL string) *EmailChannel {
+	return &EmailChannel{mailer: mailer, appURL: appURL}
+}
+
+// Deliver emails the reminder, waiting for the mail server to accept it
+func (c *EmailChannel) Deliver(ctx context.Context, d *model.ReminderDelivery) error {
+	err := c.mailer.Send(ctx, mail.Message{
+		To:      d.Email,
+		Subject: "Reminder: " + d.TodoTitle,
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"%s\n\n"+
+			"Open your to-dos: %s\n",
+			d.Username, reminderText(d), c.appURL),
+	})
+	if err != nil {
+		log.Printf("Email of reminder %d failed: %v", d.Reminder.ID, err)
+		return errors.New("email delivery failed")
+	}
+	return nil
+}
+
+// errWebhookAddress is the error of connections to webhook hosts that
+// aren't public
+var errWebhookAddress = errors.New("webhook address is not public")
+
+// WebhookChannel delivers reminders as a JSON POST to the webhook URL of
+// each reminder. Redirects are not followed, and only public addresses are
+// connected to, so that users can't make the server call its own network.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// NewWebhookChannel creates a channel whose requests time out after timeout
+func NewWebhookChannel(timeout time.Duration) *WebhookChannel {
+	return newWebhookChannel(timeout, webhookDialControl)
+}
+
+// newWebhookChannel creates a channel checking each connection with control,
+// which tests leave out to reach local servers
+func newWebhookChannel(timeout time.Duration, control func(network, address string, c syscall.RawConn) error) *WebhookChannel {
+	dialer := &net.Dialer{Timeout: timeout, Control: control}
+	return &WebhookChannel{
+		client: &http.Client{
+			Timeout: timeout,
+			// No proxy: it would make the connections, out of reach of control
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// webhookDialControl refuses connections to addresses that aren't public.
+// It runs for the resolved address of every connection, so a host name
+// resolving to a private address is refused too.
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !utils.PublicAddr(addr) {
+		return errWebhookAddress
+	}
+	return nil
+}
+
+// webhookPayload is the body of the requests of the webhook channel
+type webhookPayload struct {
+	Event      string     `json:"event"`
+	ReminderID int        `json:"reminder_id"`
+	TodoID     int        `json:"todo_id"`
+	Title      string     `json:"title"`
+	DueDate    *time.Time `json:"due_date,omitempty"`
+	FireAt     time.Time  `json:"fire_at"`
+}
+
+// Deliver posts the reminder to its webhook URL, which must answer with a
+// 2xx status. The Idempotency-Key header is the same for every delivery of
+// a reminder at a fire time, so receivers can drop duplicates. Errors are
+// shown to the user, so they tell only the status, never what the
+// connection ran into.
+func (c *WebhookChannel) Deliver(ctx context.Context, d *model.ReminderDelivery) error {
+	body, err := json.Marshal(webhookPayload{
+		Event:      "reminder",
+		ReminderID: d.Reminder.ID,
+		TodoID:     d.Reminder.TodoID,
+		Title:      d.TodoTitle,
+		DueDate:    d.DueDate,
+		FireAt:     *d.Reminder.FireAt,
+	})
+	if err != nil {
+		log.Printf("Webhook of reminder %d failed: %v", d.Reminder.ID, err)
+		return errors.New("webhook delivery failed: invalid payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Reminder.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return errors.New("webhook delivery failed: invalid URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todolist-reminders")
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("reminder-%d-%d", d.Reminder.ID, d.Reminder.FireAt.Unix()))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		log.Printf("Webhook of reminder %d failed: %v", d.Reminder.ID, err)
+		if errors.Is(err, errWebhookAddress) {
+			return errors.New("webhook delivery failed: address not allowed")
+		}
+		return errors.New("webhook delivery failed")
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook delivery failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// reminderText describes a fired reminder for notifications and emails
+func reminderText(d *model.ReminderDelivery) string {
+	if d.DueDate == nil {
+		return fmt.Sprintf("This is your reminder for %q.", d.TodoTitle)
+	}
+	return fmt.Sprintf("This is your reminder for %q, due %s.", d.TodoTitle, d.DueDate.UTC().Format(time.RFC1123))
+}
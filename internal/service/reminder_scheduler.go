@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+)
+
+const (
+	// reminderBatchSize is how many reminders a server claims at once
+	reminderBatchSize = 50
+	// reminderDeliveryTimeout bounds the delivery of a reminder through all
+	// of its channels; the lease must be longer
+	reminderDeliveryTimeout = 30 * time.Second
+	// maxReminderAttempts is how often a reminder is tried before it fails
+	maxReminderAttempts = 5
+)
+
+// ReminderChannel delivers fired reminders, e.g. as an in-app notification
+// or by email. Delivery is at least once: a reminder may be delivered again
+// if its server stops before recording it as sent, or if another of its
+// channels failed. Errors are recorded as the last error of the reminder,
+// which its owner sees, so channels log the details and return a summary.
+type ReminderChannel interface {
+	Deliver(ctx context.Context, d *model.ReminderDelivery) error
+}
+
+// ReminderSchedulerConfig configures the reminder scheduler
+type ReminderSchedulerConfig struct {
+	// PollInterval is how often the scheduler looks for due reminders
+	PollInterval time.Duration
+	// Lease is how long a claimed reminder is reserved for the server
+	// delivering it before other servers may claim it again
+	Lease time.Duration
+}
+
+// ReminderScheduler fires the reminders that are due through their
+// channels. Every server runs one; claiming reminders with a lease keeps
+// them from delivering the same reminder twice.
+type ReminderScheduler struct {
+	reminders repository.ReminderStore
+	channels  map[string]ReminderChannel
+	cfg       ReminderSchedulerConfig
+}
+
+// NewReminderScheduler creates a scheduler delivering through the channels,
+// keyed by the model.ReminderChannel* names
+func NewReminderScheduler(reminders repository.ReminderStore, channels map[string]ReminderChannel, cfg ReminderSchedulerConfig) *ReminderScheduler {
+	return &ReminderScheduler{
+		reminders: reminders,
+		channels:  channels,
+		cfg:       cfg,
+	}
+}
+
+// Run delivers the due reminders at start and then every PollInterval until
+// ctx is done
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := s.DeliverDue(ctx, time.Now().UTC())
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to deliver reminders: %v", err)
+		}
+		if sent > 0 {
+			log.Printf("Sent %d reminders", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue claims the reminders due by now in batches and delivers them,
+// and returns how many were sent
+func (s *ReminderScheduler) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		deliveries, err := s.reminders.ClaimDueReminders(ctx, now, now.Add(s.cfg.Lease), reminderBatchSize)
+		if err != nil {
+			return sent, fmt.Errorf("failed to claim reminders: %w", err)
+		}
+
+		// Deliver the batch at once, so that it takes no longer than its
+		// slowest reminder and the leases don't run out
+		var wg sync.WaitGroup
+		results := make([]bool, len(deliveries))
+		for i, d := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = s.deliver(ctx, d)
+			}()
+		}
+		wg.Wait()
+
+		for _, ok := range results {
+			if ok {
+				sent++
+			}
+		}
+		if len(deliveries) < reminderBatchSize || ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+	}
+}
+
+// deliver sends a claimed reminder through each of its channels and records
+// the outcome. It reports whether the reminder was sent.
+func (s *ReminderScheduler) deliver(ctx context.Context, d *model.ReminderDelivery) bool {
+	deliverCtx, cancel := context.WithTimeout(ctx, reminderDeliveryTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, name := range d.Reminder.Channels {
+		channel, ok := s.channels[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: channel not configured", name))
+			continue
+		}
+		if err := channel.Deliver(deliverCtx, d); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	reminder := d.Reminder
+	now := time.Now().UTC()
+	var err error
+	switch {
+	case len(errs) == 0:
+		err = s.reminders.MarkReminderSent(ctx, reminder.ID, *reminder.FireAt, now)
+	case reminder.Attempts >= maxReminderAttempts:
+		log.Printf("Giving up on reminder %d after %d attempts: %v", reminder.ID, reminder.Attempts, errors.Join(errs...))
+		err = s.reminders.FailReminder(ctx, reminder.ID, *reminder.FireAt, now, errors.Join(errs...).Error())
+	default:
+		retryAt := now.Add(reminderRetryDelay(reminder.Attempts))
+		err = s.reminders.RetryReminder(ctx, reminder.ID, *reminder.FireAt, retryAt, errors.Join(errs...).Error())
+	}
+	if err != nil {
+		log.Printf("Failed to record the delivery of reminder %d: %v", reminder.ID, err)
+	}
+	return len(errs) == 0
+}
+
+// reminderRetryDelay is how long to wait after the given number of failed
+// attempts: a minute, doubling up to an hour
+func reminderRetryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, time.Hour)
+}
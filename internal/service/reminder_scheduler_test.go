@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+)
+
+// fakeChannel records the reminders it delivers and fails while err is set
+type fakeChannel struct {
+	mu        sync.Mutex
+	delivered []int
+	err       error
+}
+
+func (c *fakeChannel) Deliver(ctx context.Context, d *model.ReminderDelivery) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.delivered = append(c.delivered, d.Reminder.ID)
+	return nil
+}
+
+func TestReminderRetryDelay(t *testing.T) {
+	delays := make([]time.Duration, 0, 8)
+	for attempts := 1; attempts <= 8; attempts++ {
+		delays = append(delays, reminderRetryDelay(attempts))
+	}
+	assert.Equal(t, []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute,
+		16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour,
+	}, delays)
+}
+
+func TestReminderScheduler(t *testing.T) {
+	ctx := context.Background()
+	stores := repository.NewMemoryStores()
+
+	user := &model.User{Username: "budi", Email: "budi@example.com", Password: "hash"}
+	require.NoError(t, stores.Users.CreateUser(ctx, user))
+	todo := &model.Todo{UserID: user.ID, Title: "Dentist"}
+	require.NoError(t, stores.Todos.CreateTodo(ctx, todo))
+
+	now := time.Now().UTC()
+	fireAt := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+	inApp := &model.Reminder{TodoID: todo.ID, RemindAt: &fireAt, FireAt: &fireAt, Channels: []string{model.ReminderChannelInApp}}
+	email := &model.Reminder{TodoID: todo.ID, RemindAt: &fireAt, FireAt: &fireAt, Channels: []string{model.ReminderChannelEmail}}
+	future := &model.Reminder{TodoID: todo.ID, RemindAt: &later, FireAt: &later, Channels: []string{model.ReminderChannelInApp}}
+	for _, reminder := range []*model.Reminder{inApp, email, future} {
+		require.NoError(t, stores.Reminders.CreateReminder(ctx, reminder))
+	}
+
+	mailer := &fakeChannel{err: errors.New("smtp down")}
+	scheduler := NewReminderScheduler(stores.Reminders, map[string]ReminderChannel{
+		model.ReminderChannelInApp: NewInAppChannel(stores.Notifications),
+		model.ReminderChannelEmail: mailer,
+	}, ReminderSchedulerConfig{PollInterval: time.Minute, Lease: 5 * time.Minute})
+
+	// The in-app reminder is sent, the email one is retried later
+	sent, err := scheduler.DeliverDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	notifications, err := stores.Notifications.ListNotifications(ctx, user.ID, false, 10)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, "Dentist", notifications[0].Title)
+	assert.Equal(t, `This is your reminder for "Dentist".`, notifications[0].Body)
+
+	list, err := stores.Reminders.ListTodoReminders(ctx, []int{todo.ID})
+	require.NoError(t, err)
+	reminders := list[todo.ID]
+	assert.NotNil(t, reminders[0].SentAt)
+	assert.Nil(t, reminders[1].SentAt)
+	assert.Equal(t, 1, reminders[1].Attempts)
+	assert.Equal(t, "email: smtp down", reminders[1].LastError)
+	assert.Nil(t, reminders[2].SentAt)
+
+	// Nothing is due again before the retry delay
+	sent, err = scheduler.DeliverDue(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Empty(t, mailer.delivered)
+
+	// The email reminder gives up after the last attempt
+	retryAt := now
+	for attempt := 2; attempt <= maxReminderAttempts; attempt++ {
+		retryAt = retryAt.Add(2 * time.Hour)
+		_, err = scheduler.DeliverDue(ctx, retryAt)
+		require.NoError(t, err)
+	}
+	list, err = stores.Reminders.ListTodoReminders(ctx, []int{todo.ID})
+	require.NoError(t, err)
+	reminders = list[todo.ID]
+	assert.Equal(t, maxReminderAttempts, reminders[1].Attempts)
+	assert.NotNil(t, reminders[1].FailedAt)
+	assert.NotNil(t, reminders[2].SentAt)
+
+	mailer.err = nil
+	sent, err = scheduler.DeliverDue(ctx, retryAt.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Empty(t, mailer.delivered)
+
+	notifications, err = stores.Notifications.ListNotifications(ctx, user.ID, false, 10)
+	require.NoError(t, err)
+	assert.Len(t, notifications, 2)
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var got webhookPayload
+	var key string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	fireAt := time.Date(2026, 10, 15, 8, 30, 0, 0, time.UTC)
+	due := fireAt.Add(30 * time.Minute)
+	d := &model.ReminderDelivery{
+		Reminder:  &model.Reminder{ID: 7, TodoID: 3, FireAt: &fireAt, WebhookURL: server.URL + "/hook"},
+		TodoTitle: "Dentist",
+		DueDate:   &due,
+	}
+
+	// The test server is local, which only a channel without checks reaches
+	channel := newWebhookChannel(time.Second, nil)
+	require.NoError(t, channel.Deliver(context.Background(), d))
+	assert.Equal(t, fmt.Sprintf("reminder-7-%d", fireAt.Unix()), key)
+	assert.Equal(t, "reminder", got.Event)
+	assert.Equal(t, 7, got.ReminderID)
+	assert.Equal(t, 3, got.TodoID)
+	assert.Equal(t, "Dentist", got.Title)
+	assert.True(t, fireAt.Equal(got.FireAt))
+	require.NotNil(t, got.DueDate)
+	assert.True(t, due.Equal(*got.DueDate))
+
+	// Errors and redirects are failed deliveries
+	status = http.StatusInternalServerError
+	assert.EqualError(t, channel.Deliver(context.Background(), d), "webhook delivery failed: status 500")
+	status = http.StatusFound
+	assert.Error(t, channel.Deliver(context.Background(), d))
+}
+
+func TestWebhookChannelRefusesLocalAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	fireAt := time.Now().UTC()
+	channel := NewWebhookChannel(time.Second)
+	for _, url := range []string{
+		server.URL,
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1),
+	} {
+		d := &model.ReminderDelivery{Reminder: &model.Reminder{ID: 1, FireAt: &fireAt, WebhookURL: url}}
+		err := channel.Deliver(context.Background(), d)
+		assert.EqualError(t, err, "webhook delivery failed: address not allowed", url)
+	}
+	assert.False(t, called)
+
+	for _, address := range []string{
+		"127.0.0.1:80", "10.1.2.3:80", "172.16.0.1:443", "192.168.1.1:80", "169.254.169.254:80",
+		"0.0.0.0:80", "100.64.0.1:80", "224.0.0.1:80", "[::1]:80", "[fe80::1]:80", "[fd00::1]:80",
+		"[::ffff:10.0.0.1]:80", "[::10.0.0.1]:80", "[2002:a00:1::1]:80", "[2001:0:4136:e378:8000:63bf:f5ff:fffe]:80",
+		"192.88.99.1:80",
+	} {
+		assert.ErrorIs(t, webhookDialControl("tcp", address, nil), errWebhookAddress, address)
+	}
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1::1]:443"} {
+		assert.NoError(t, webhookDialControl("tcp", address, nil), address)
+	}
+}
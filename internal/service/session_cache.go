@@ -0,0 +1,113 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// sessionCacheTTL bounds how long a session state is trusted without
+	// asking the database. Revocations made by this process take effect
+	// immediately; those made by other instances within this time.
+	sessionCacheTTL = 30 * time.Second
+	// sessionCacheSize caps the number of cached sessions
+	sessionCacheSize = 10000
+)
+
+// sessionState is the part of a session AuthMiddleware needs on every request
+type sessionState struct {
+	userID   int
+	active   bool
+	cachedAt time.Time
+}
+
+// sessionCache keeps recently checked session states in memory so
+// authenticated requests don't hit the database every time
+type sessionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]sessionState
+	// gen counts the deletions, so a state read from the database before
+	// one isn't cached after it
+	gen uint64
+}
+
+func newSessionCache(ttl time.Duration, size int) *sessionCache {
+	return &sessionCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]sessionState),
+	}
+}
+
+// get returns the cached state of a session unless it is missing or stale
+func (c *sessionCache) get(sessionID string) (sessionState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.entries[sessionID]
+	if !ok || time.Since(state.cachedAt) >= c.ttl {
+		return sessionState{}, false
+	}
+	return state, true
+}
+
+// generation returns the current generation, to be passed to set for a
+// state read from the database afterwards
+func (c *sessionCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gen
+}
+
+// set caches the state of a session read at generation gen, unless
+// sessions were deleted since; the state may predate the deletion
+func (c *sessionCache) set(sessionID string, userID int, active bool, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+	if len(c.entries) >= c.size {
+		c.evictLocked()
+	}
+	c.entries[sessionID] = sessionState{userID: userID, active: active, cachedAt: time.Now()}
+}
+
+// delete forgets a session, e.g. after revoking it
+func (c *sessionCache) delete(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, sessionID)
+	c.gen++
+}
+
+// deleteUser forgets every session of a user
+func (c *sessionCache) deleteUser(userID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, state := range c.entries {
+		if state.userID == userID {
+			delete(c.entries, id)
+		}
+	}
+	c.gen++
+}
+
+// evictLocked makes room by dropping stale entries, or everything if all
+// entries are fresh
+func (c *sessionCache) evictLocked() {
+	for id, state := range c.entries {
+		if time.Since(state.cachedAt) >= c.ttl {
+			delete(c.entries, id)
+		}
+	}
+	if len(c.entries) >= c.size {
+		c.entries = make(map[string]sessionState)
+	}
+}
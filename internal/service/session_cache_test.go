@@ -0,0 +1,54 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionCache(t *testing.T) {
+	c := newSessionCache(time.Hour, 3)
+
+	gen := c.generation()
+	c.set("a", 1, true, gen)
+	c.set("b", 1, false, gen)
+	c.set("c", 2, true, gen)
+
+	state, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, state.userID)
+	assert.True(t, state.active)
+
+	state, ok = c.get("b")
+	assert.True(t, ok)
+	assert.False(t, state.active)
+
+	c.deleteUser(1)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+	_, ok = c.get("c")
+	assert.True(t, ok)
+
+	// A state read before a deletion isn't cached, as it may be outdated
+	c.set("a", 1, true, gen)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+
+	// A full cache of fresh entries starts over
+	gen = c.generation()
+	c.set("d", 2, true, gen)
+	c.set("e", 2, true, gen)
+	c.set("f", 2, true, gen)
+	_, ok = c.get("c")
+	assert.False(t, ok)
+	_, ok = c.get("f")
+	assert.True(t, ok)
+
+	// Stale entries are misses
+	c = newSessionCache(time.Millisecond, 3)
+	c.set("a", 1, true, c.generation())
+	time.Sleep(2 * time.Millisecond)
+	_, ok = c.get("a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+const (
+	// MaxTodoTags caps the tags of a single todo
+	MaxTodoTags = 20
+	// DefaultTagColor is given to tags created without a color
+	DefaultTagColor = "#9e9e9e"
+)
+
+// colorPattern matches the #rrggbb colors of tags and lists
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ListTags returns the user's tags by name, with their todo counts
+func (s *TodoService) ListTags(ctx context.Context, userID int) ([]*model.Tag, error) {
+	tags, err := s.tagRepo.ListTags(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// CreateTag creates a tag; its name must not be in use in any case
+func (s *TodoService) CreateTag(ctx context.Context, userID int, tagCreate *model.TagCreate) (*model.Tag, error) {
+	color, err := parseColor(tagCreate.Color, DefaultTagColor)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := &model.Tag{UserID: userID, Name: tagCreate.Name, Color: color}
+	if err := s.tagRepo.CreateTag(ctx, tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	count := 0
+	tag.TodoCount = &count
+	return tag, nil
+}
+
+// UpdateTag renames or recolors a tag. The new name shows on every todo with
+// the tag; it must not be the name of another tag, which can be merged instead.
+func (s *TodoService) UpdateTag(ctx context.Context, userID, tagID int, tagUpdate *model.TagUpdate) (*model.Tag, error) {
+	tag, err := s.tagRepo.GetTag(ctx, tagID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	if tagUpdate.Name != nil {
+		tag.Name = *tagUpdate.Name
+	}
+	if tagUpdate.Color != nil {
+		if tag.Color, err = parseColor(*tagUpdate.Color, DefaultTagColor); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.tagRepo.UpdateTag(ctx, tag); err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+	return tag, nil
+}
+
+// DeleteTag deletes a tag, removing it from its todos
+func (s *TodoService) DeleteTag(ctx context.Context, userID, tagID int) error {
+	if err := s.tagRepo.DeleteTag(ctx, tagID, userID); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// MergeTag moves the todos of a tag to the tag merge.Into, deletes the merged
+// tag and returns the one it was merged into
+func (s *TodoService) MergeTag(ctx context.Context, userID, tagID int, merge *model.TagMerge) (*model.Tag, error) {
+	if merge.Into == tagID {
+		return nil, apperror.Invalid("into", "a tag can't be merged into itself")
+	}
+
+	if err := s.tagRepo.MergeTag(ctx, tagID, merge.Into, userID); err != nil {
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	tags, err := s.ListTags(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		if tag.ID == merge.Into {
+			return tag, nil
+		}
+	}
+	return nil, apperror.NotFound("tag not found")
+}
+
+// setTags replaces the tags of a todo with the named ones, creating the
+// missing tags, and sets them on the todo
+func (s *TodoService) setTags(ctx context.Context, todo *model.Todo, names []string) error {
+	names = uniqueTagNames(names)
+	if len(names) > MaxTodoTags {
+		return apperror.Invalid("tags", fmt.Sprintf("a todo can have at most %d tags", MaxTodoTags))
+	}
+
+	var tags []*model.Tag
+	if len(names) > 0 {
+		var err error
+		tags, err = s.tagRepo.EnsureTags(ctx, todo.UserID, names, DefaultTagColor)
+		if err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+	}
+
+	tagIDs := make([]int, len(tags))
+	for i, tag := range tags {
+		tagIDs[i] = tag.ID
+	}
+	if err := s.tagRepo.SetTodoTags(ctx, todo.ID, tagIDs); err != nil {
+		return fmt.Errorf("failed to set tags: %w", err)
+	}
+
+	return s.attachTags(ctx, []*model.Todo{todo})
+}
+
+// attachTags sets their tags on the todos
+func (s *TodoService) attachTags(ctx context.Context, todos []*model.Todo) error {
+	tags, err := s.tagRepo.ListTodoTags(ctx, todoIDs(todos))
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	for _, todo := range todos {
+		todo.Tags = tags[todo.ID]
+	}
+	return nil
+}
+
+// uniqueTagNames drops the names repeating an earlier one in any case
+func uniqueTagNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	var unique []string
+	for _, name := range names {
+		key := strings.ToLower(name)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// parseColor validates a #rrggbb color, replacing an empty one with fallback
+func parseColor(color, fallback string) (string, error) {
+	if color == "" {
+		return fallback, nil
+	}
+	if !colorPattern.MatchString(color) {
+		return "", apperror.Invalid("color", "color must be a hex color like #3366ff")
+	}
+	return strings.ToLower(color), nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+// MaxTodoItems caps the checklist items of a single todo
+const MaxTodoItems = 100
+
+// ListItems returns the checklist items of one of the user's todos, in order
+func (s *TodoService) ListItems(ctx context.Context, userID, todoID int) ([]*model.TodoItem, error) {
+	if _, err := s.todoRepo.GetTodoByID(ctx, todoID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	items, err := s.itemRepo.ListTodoItems(ctx, []int{todoID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list items: %w", err)
+	}
+	if items[todoID] == nil {
+		return []*model.TodoItem{}, nil
+	}
+	return items[todoID], nil
+}
+
+// CreateItem adds a checklist item at the end of a todo's checklist
+func (s *TodoService) CreateItem(ctx context.Context, userID, todoID int, itemCreate *model.TodoItemCreate) (*model.TodoItem, error) {
+	items, err := s.ListItems(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) >= MaxTodoItems {
+		return nil, apperror.Conflict(fmt.Sprintf("a todo can have at most %d items", MaxTodoItems))
+	}
+
+	item := &model.TodoItem{TodoID: todoID, Title: itemCreate.Title}
+	if err := s.itemRepo.CreateTodoItem(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to create item: %w", err)
+	}
+	return item, nil
+}
+
+// UpdateItem changes the title or done state of a checklist item
+func (s *TodoService) UpdateItem(ctx context.Context, userID, todoID, itemID int, itemUpdate *model.TodoItemUpdate) (*model.TodoItem, error) {
+	if _, err := s.todoRepo.GetTodoByID(ctx, todoID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	item, err := s.itemRepo.GetTodoItem(ctx, todoID, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item: %w", err)
+	}
+
+	if itemUpdate.Title != nil {
+		item.Title = *itemUpdate.Title
+	}
+	if itemUpdate.IsDone != nil {
+		item.IsDone = *itemUpdate.IsDone
+	}
+
+	if err := s.itemRepo.UpdateTodoItem(ctx, item); err != nil {
+		return nil, fmt.Errorf("failed to update item: %w", err)
+	}
+	return item, nil
+}
+
+// DeleteItem deletes a checklist item
+func (s *TodoService) DeleteItem(ctx context.Context, userID, todoID, itemID int) error {
+	if _, err := s.todoRepo.GetTodoByID(ctx, todoID, userID); err != nil {
+		return fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	if err := s.itemRepo.DeleteTodoItem(ctx, todoID, itemID); err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
+	}
+	return nil
+}
+
+// ReorderItems puts a todo's checklist in the given order, which must list
+// every item exactly once, and returns the reordered items
+func (s *TodoService) ReorderItems(ctx context.Context, userID, todoID int, order *model.TodoItemOrder) ([]*model.TodoItem, error) {
+	items, err := s.ListItems(ctx, userID, todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[int]bool, len(items))
+	for _, item := range items {
+		remaining[item.ID] = true
+	}
+	for _, id := range order.ItemIDs {
+		if !remaining[id] {
+			return nil, apperror.Invalid("item_ids", "item_ids must list every item of the todo exactly once")
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		return nil, apperror.Invalid("item_ids", "item_ids must list every item of the todo exactly once")
+	}
+
+	if err := s.itemRepo.ReorderTodoItems(ctx, todoID, order.ItemIDs); err != nil {
+		return nil, fmt.Errorf("failed to reorder items: %w", err)
+	}
+	return s.ListItems(ctx, userID, todoID)
+}
+
+// attachItems sets the checklist items and their progress on the todos
+func (s *TodoService) attachItems(ctx context.Context, todos []*model.Todo) error {
+	items, err := s.itemRepo.ListTodoItems(ctx, todoIDs(todos))
+	if err != nil {
+		return fmt.Errorf("failed to list items: %w", err)
+	}
+
+	for _, todo := range todos {
+		todo.Items = items[todo.ID]
+		todo.Progress = &model.TodoProgress{Total: len(todo.Items)}
+		for _, item := range todo.Items {
+			if item.IsDone {
+				todo.Progress.Done++
+			}
+		}
+	}
+	return nil
+}
+
+// attachProgress sets the progress of their checklists on the todos
+func (s *TodoService) attachProgress(ctx context.Context, todos []*model.Todo) error {
+	progress, err := s.itemRepo.CountTodoItems(ctx, todoIDs(todos))
+	if err != nil {
+		return fmt.Errorf("failed to count items: %w", err)
+	}
+
+	for _, todo := range todos {
+		todo.Progress = progress[todo.ID]
+		if todo.Progress == nil {
+			todo.Progress = &model.TodoProgress{}
+		}
+	}
+	return nil
+}
+
+func todoIDs(todos []*model.Todo) []int {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	return ids
+}
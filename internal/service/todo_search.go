@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+)
+
+const (
+	// DefaultSearchLimit is used when a search doesn't set a limit
+	DefaultSearchLimit = 20
+	// MaxSearchLimit caps the number of results per search
+	MaxSearchLimit = 100
+	// maxSearchQueryLength bounds the length of the raw query string
+	maxSearchQueryLength = 200
+)
+
+// SearchTodos runs a full-text search over a user's todo titles and descriptions.
+// The query supports "quoted phrases" and prefix matches with a trailing *
+// (e.g. rep* matches report); all terms must match.
+func (s *TodoService) SearchTodos(ctx context.Context, userID int, query string, limit, offset int) ([]*model.TodoSearchResult, error) {
+	var v apperror.Validation
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		v.Add("q", "q is required")
+	} else if len(query) > maxSearchQueryLength {
+		v.Add("q", fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength))
+	}
+
+	if limit == 0 {
+		limit = DefaultSearchLimit
+	} else if limit < 0 || limit > MaxSearchLimit {
+		v.Add("limit", fmt.Sprintf("limit must be between 1 and %d", MaxSearchLimit))
+	}
+	if offset < 0 {
+		v.Add("offset", "offset must not be negative")
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	search := parseSearchQuery(query)
+	search.Limit = limit
+	search.Offset = offset
+
+	results, err := s.todoRepo.SearchTodos(ctx, userID, search)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search todos: %w", err)
+	}
+
+	todos := make([]*model.Todo, len(results))
+	for i, result := range results {
+		todos[i] = result.Todo
+	}
+	if err := s.attachLists(ctx, userID, todos); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// parseSearchQuery splits a query into plain words, prefixes (word*) and
+// "quoted phrases". An unterminated quote runs to the end of the query.
+func parseSearchQuery(query string) model.TodoSearch {
+	var search model.TodoSearch
+
+	for query != "" {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		if query == "" {
+			break
+		}
+
+		if query[0] == '"' {
+			phrase, rest, _ := strings.Cut(query[1:], `"`)
+			if phrase = strings.TrimSpace(phrase); phrase != "" {
+				search.Phrases = append(search.Phrases, phrase)
+			}
+			query = rest
+			continue
+		}
+
+		end := strings.IndexFunc(query, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+		if end < 0 {
+			end = len(query)
+		}
+		term := query[:end]
+		query = query[end:]
+
+		if strings.HasSuffix(term, "*") {
+			// Keep only letters and digits so the prefix is safe to use in a tsquery
+			prefix := strings.Map(func(r rune) rune {
+				if unicode.IsLetter(r) || unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, term)
+			if prefix != "" {
+				search.Prefixes = append(search.Prefixes, prefix)
+			}
+			continue
+		}
+		search.Words = append(search.Words, term)
+	}
+
+	return search
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"aplikasi-todolist/internal/model"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	got := parseSearchQuery(`weekly "team  report" rep* "unterminated phrase`)
+	assert.Equal(t, model.TodoSearch{
+		Words:    []string{"weekly"},
+		Prefixes: []string{"rep"},
+		Phrases:  []string{"team  report", "unterminated phrase"},
+	}, got)
+
+	// Prefixes are reduced to letters and digits
+	got = parseSearchQuery(`a&b:* !*`)
+	assert.Equal(t, []string{"ab"}, got.Prefixes)
+	assert.Empty(t, got.Words)
+}
@@ -1,78 +1,203 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"aplikasi-todolist/internal/apperror"
 	"aplikasi-todolist/internal/model"
 	"aplikasi-todolist/internal/repository"
 )
 
 // TodoService handles todo-related business logic
 type TodoService struct {
-	todoRepo *repository.TodoRepository
+	todoRepo repository.TodoStore
+	itemRepo repository.TodoItemStore
+	tagRepo  repository.TagStore
+	listRepo repository.ListStore
+
+	reminderRepo repository.ReminderStore
 }
 
 // NewTodoService creates a new TodoService instance
-func NewTodoService(todoRepo *repository.TodoRepository) *TodoService {
+func NewTodoService(todoRepo repository.TodoStore, itemRepo repository.TodoItemStore, tagRepo repository.TagStore, listRepo repository.ListStore, reminderRepo repository.ReminderStore) *TodoService {
 	return &TodoService{
-		todoRepo: todoRepo,
+		todoRepo:     todoRepo,
+		itemRepo:     itemRepo,
+		tagRepo:      tagRepo,
+		listRepo:     listRepo,
+		reminderRepo: reminderRepo,
 	}
 }
 
-// GetTodos retrieves all todos for a user
-func (s *TodoService) GetTodos(userID int) ([]*model.Todo, error) {
-	todos, err := s.todoRepo.GetTodosByUserID(userID)
+const (
+	// DefaultTodoPageSize is used when a query doesn't set a limit
+	DefaultTodoPageSize = 50
+	// MaxTodoPageSize caps the limit a client may request
+	MaxTodoPageSize = 200
+)
+
+// defaultTodoOrder is the order used for each sort field when none is given
+var defaultTodoOrder = map[string]string{
+	model.TodoSortCreatedAt: model.SortDesc,
+	model.TodoSortUpdatedAt: model.SortDesc,
+	model.TodoSortPriority:  model.SortDesc,
+	model.TodoSortDueDate:   model.SortAsc,
+	model.TodoSortTitle:     model.SortAsc,
+}
+
+// GetTodos retrieves a filtered, sorted page of todos for a user
+func (s *TodoService) GetTodos(ctx context.Context, userID int, query model.TodoQuery) (*model.TodoPage, error) {
+	var v apperror.Validation
+
+	if query.Sort == "" {
+		query.Sort = model.TodoSortCreatedAt
+	}
+	defaultOrder, ok := defaultTodoOrder[query.Sort]
+	if !ok {
+		v.Add("sort", "sort must be one of created_at, updated_at, due_date, priority or title")
+	}
+	if query.Order == "" {
+		query.Order = defaultOrder
+	} else if query.Order != model.SortAsc && query.Order != model.SortDesc {
+		v.Add("order", "order must be asc or desc")
+	}
+
+	switch {
+	case query.Limit == 0:
+		query.Limit = DefaultTodoPageSize
+	case query.Limit < 0 || query.Limit > MaxTodoPageSize:
+		v.Add("limit", fmt.Sprintf("limit must be between 1 and %d", MaxTodoPageSize))
+	}
+
+	for _, p := range query.Priorities {
+		if !isValidPriority(p) {
+			v.Add("priority", "priority must be Low, Medium, or High")
+		}
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	if query.Category != nil && query.ListID == nil {
+		listID, err := s.findListByName(ctx, userID, *query.Category)
+		if err != nil {
+			return nil, err
+		}
+		if listID == nil {
+			return &model.TodoPage{Todos: []*model.Todo{}}, nil
+		}
+		query.ListID = listID
+	}
+
+	page, err := s.todoRepo.ListTodos(ctx, userID, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todos: %w", err)
 	}
-	return todos, nil
+
+	if query.IncludeItems {
+		err = s.attachItems(ctx, page.Todos)
+	} else {
+		err = s.attachProgress(ctx, page.Todos)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(ctx, page.Todos); err != nil {
+		return nil, err
+	}
+	if err := s.attachLists(ctx, userID, page.Todos); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
-// GetTodo retrieves a specific todo by ID for a user
-func (s *TodoService) GetTodo(todoID, userID int) (*model.Todo, error) {
-	todo, err := s.todoRepo.GetTodoByID(todoID, userID)
+// isValidPriority reports whether p is one of the supported priorities
+func isValidPriority(p string) bool {
+	return p == "Low" || p == "Medium" || p == "High"
+}
+
+// GetTodo retrieves a specific todo by ID for a user, with its checklist
+func (s *TodoService) GetTodo(ctx context.Context, todoID, userID int) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(ctx, todoID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
+	if err := s.attachItems(ctx, []*model.Todo{todo}); err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(ctx, []*model.Todo{todo}); err != nil {
+		return nil, err
+	}
+	if err := s.attachLists(ctx, userID, []*model.Todo{todo}); err != nil {
+		return nil, err
+	}
 	return todo, nil
 }
 
 // CreateTodo creates a new todo for a user
-func (s *TodoService) CreateTodo(userID int, todoCreate *model.TodoCreate) (*model.Todo, error) {
+func (s *TodoService) CreateTodo(ctx context.Context, userID int, todoCreate *model.TodoCreate) (*model.Todo, error) {
 	todo := &model.Todo{
 		UserID:      userID,
 		Title:       todoCreate.Title,
 		Description: todoCreate.Description,
-		Category:    todoCreate.Category,
 		IsDone:      false,
 		Priority:    todoCreate.Priority,
 	}
 
+	listID, err := s.todoList(ctx, userID, todoCreate.ListID, todoCreate.Category)
+	if err != nil {
+		return nil, err
+	}
+	todo.ListID = listID
+
 	// Handle due date if provided
 	if todoCreate.DueDate != nil {
-		// Parse the date string to time.Time
-		parsedDate, err := time.Parse("2006-01-02", *todoCreate.DueDate)
-		if err == nil {
-			todo.DueDate = &parsedDate
+		dueDate, err := parseDueDate(*todoCreate.DueDate)
+		if err != nil {
+			return nil, err
 		}
+		todo.DueDate = dueDate
 	}
 
-	err := s.todoRepo.CreateTodo(todo)
+	if err := setRecurrence(todo, todoCreate.RecurrenceRule, todoCreate.RecurrenceMode); err != nil {
+		return nil, err
+	}
+	if todo.RecurrenceRule != "" && todo.DueDate == nil {
+		return nil, apperror.Invalid("due_date", "a recurring todo needs a due date")
+	}
+
+	err = s.todoRepo.CreateTodo(ctx, todo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create todo: %w", err)
 	}
+	todo.Progress = &model.TodoProgress{}
+
+	if len(todoCreate.Tags) > 0 {
+		if err := s.setTags(ctx, todo, todoCreate.Tags); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.attachLists(ctx, userID, []*model.Todo{todo}); err != nil {
+		return nil, err
+	}
 	return todo, nil
 }
 
 // UpdateTodo updates an existing todo for a user
-func (s *TodoService) UpdateTodo(userID int, todoID int, todoUpdate *model.TodoUpdate) (*model.Todo, error) {
+func (s *TodoService) UpdateTodo(ctx context.Context, userID int, todoID int, todoUpdate *model.TodoUpdate) (*model.Todo, error) {
 	// First, get the existing todo to update
-	existingTodo, err := s.todoRepo.GetTodoByID(todoID, userID)
+	existingTodo, err := s.todoRepo.GetTodoByID(ctx, todoID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todo: %w", err)
 	}
 
+	// Completing a recurring todo creates its next occurrence
+	completing := todoUpdate.IsDone != nil && *todoUpdate.IsDone && !existingTodo.IsDone
+	oldDue := existingTodo.DueDate
+
 	// Update fields if they are provided
 	if todoUpdate.Title != nil {
 		existingTodo.Title = *todoUpdate.Title
@@ -83,37 +208,107 @@ func (s *TodoService) UpdateTodo(userID int, todoID int, todoUpdate *model.TodoU
 	if todoUpdate.IsDone != nil {
 		existingTodo.IsDone = *todoUpdate.IsDone
 	}
-	if todoUpdate.Category != nil {
-		existingTodo.Category = *todoUpdate.Category
+	if todoUpdate.ListID != nil || todoUpdate.Category != nil {
+		var category string
+		if todoUpdate.Category != nil {
+			category = *todoUpdate.Category
+		}
+		if existingTodo.ListID, err = s.todoList(ctx, userID, todoUpdate.ListID, category); err != nil {
+			return nil, err
+		}
 	}
 	if todoUpdate.Priority != nil {
 		existingTodo.Priority = *todoUpdate.Priority
 	}
 	if todoUpdate.DueDate != nil {
-		// Parse the date string to time.Time
-		if *todoUpdate.DueDate != "" {
-			parsedDate, err := time.Parse("2006-01-02", *todoUpdate.DueDate)
-			if err == nil {
-				existingTodo.DueDate = &parsedDate
-			}
-		} else {
-			existingTodo.DueDate = nil
+		// An empty string clears the due date
+		dueDate, err := parseDueDate(*todoUpdate.DueDate)
+		if err != nil {
+			return nil, err
 		}
+		existingTodo.DueDate = dueDate
+	}
+	if todoUpdate.RecurrenceRule != nil || todoUpdate.RecurrenceMode != nil {
+		rule, mode := existingTodo.RecurrenceRule, existingTodo.RecurrenceMode
+		if todoUpdate.RecurrenceRule != nil {
+			rule = *todoUpdate.RecurrenceRule
+		}
+		if todoUpdate.RecurrenceMode != nil {
+			mode = *todoUpdate.RecurrenceMode
+		}
+		if err := setRecurrence(existingTodo, rule, mode); err != nil {
+			return nil, err
+		}
+	}
+	if existingTodo.RecurrenceRule != "" && existingTodo.DueDate == nil {
+		return nil, apperror.Invalid("due_date", "a recurring todo needs a due date")
 	}
 
-	err = s.todoRepo.UpdateTodo(existingTodo)
+	// Tags are set first so that a next occurrence gets them too
+	if todoUpdate.Tags != nil {
+		if err := s.setTags(ctx, existingTodo, *todoUpdate.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if completing && existingTodo.RecurrenceRule != "" {
+		err = s.completeTodo(ctx, existingTodo)
+	} else if err = s.todoRepo.UpdateTodo(ctx, existingTodo); err != nil {
+		err = fmt.Errorf("failed to update todo: %w", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to update todo: %w", err)
+		return nil, err
+	}
+	if err := s.rescheduleReminders(ctx, existingTodo, oldDue); err != nil {
+		return nil, err
+	}
+
+	if todoUpdate.CompleteItems && existingTodo.IsDone {
+		if err := s.itemRepo.CompleteTodoItems(ctx, existingTodo.ID); err != nil {
+			return nil, fmt.Errorf("failed to complete items: %w", err)
+		}
+	}
+
+	todos := []*model.Todo{existingTodo}
+	if existingTodo.NextOccurrence != nil {
+		todos = append(todos, existingTodo.NextOccurrence)
+	}
+	if err := s.attachItems(ctx, todos); err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(ctx, todos); err != nil {
+		return nil, err
+	}
+	if err := s.attachLists(ctx, userID, todos); err != nil {
+		return nil, err
 	}
 
 	return existingTodo, nil
 }
 
 // DeleteTodo deletes a todo for a user
-func (s *TodoService) DeleteTodo(todoID, userID int) error {
-	err := s.todoRepo.DeleteTodo(todoID, userID)
+func (s *TodoService) DeleteTodo(ctx context.Context, todoID, userID int) error {
+	err := s.todoRepo.DeleteTodo(ctx, todoID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// parseDueDate accepts a plain date (2006-01-02) or an RFC 3339 timestamp,
+// as the frontend sends back the due_date it received. An empty string means
+// no due date.
+func parseDueDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if parsed, err := time.Parse("2006-01-02", value); err == nil {
+		return &parsed, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+
+	return nil, apperror.Invalid("due_date", "due_date must be a date in YYYY-MM-DD format")
+}
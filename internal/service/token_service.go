@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/utils"
+)
+
+const (
+	// refreshTokenBytes is the entropy of a refresh token
+	refreshTokenBytes = 32
+	// sessionIDBytes is the entropy of a session ID
+	sessionIDBytes = 16
+	// maxUserAgentLength caps the user agent stored with a session
+	maxUserAgentLength = 512
+)
+
+// Unknown, expired, reused and revoked tokens all get the same response
+var (
+	errInvalidRefreshToken = apperror.Unauthorized("invalid or expired refresh token")
+	errInvalidAccessToken  = apperror.Unauthorized("invalid or expired token")
+	errSessionNotFound     = apperror.NotFound("session not found")
+)
+
+// TokenService issues access and refresh tokens, rotates refresh tokens and
+// manages the sessions they belong to. A session is revoked on logout, when
+// the user signs the device out, or when one of its refresh tokens is reused.
+type TokenService struct {
+	userRepo      repository.UserStore
+	tokenRepo     repository.TokenStore
+	refreshExpiry time.Duration
+	cache         *sessionCache
+}
+
+// NewTokenService creates a new TokenService instance
+func NewTokenService(userRepo repository.UserStore, tokenRepo repository.TokenStore, refreshExpiry time.Duration) *TokenService {
+	return &TokenService{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		refreshExpiry: refreshExpiry,
+		cache:         newSessionCache(sessionCacheTTL, sessionCacheSize),
+	}
+}
+
+// IssueTokens starts a new session for a fresh login from client. Users
+// who deleted their account get it back.
+func (s *TokenService) IssueTokens(ctx context.Context, user *model.User, client model.ClientInfo) (*model.AuthTokens, error) {
+	if user.DisabledAt != nil {
+		return nil, errAccountDisabled
+	}
+
+	// Logging in during the grace period restores a deleted account
+	if user.DeleteAfter != nil {
+		if err := s.userRepo.CancelDeletion(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("failed to restore account: %w", err)
+		}
+		user.DeleteAfter = nil
+		log.Printf("User %d restored their deleted account", user.ID)
+	}
+
+	sessionID, err := utils.GenerateToken(sessionIDBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &model.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		UserAgent: truncateUserAgent(client.UserAgent),
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(s.refreshExpiry).UTC(),
+	}
+	if err := s.tokenRepo.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issue(ctx, user, session)
+}
+
+// Refresh exchanges a refresh token for a new token pair in the same session.
+// Refresh tokens are single use: presenting one again means it was stolen or
+// replayed, so the whole session is revoked.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string, client model.ClientInfo) (*model.AuthTokens, error) {
+	token, session, err := s.lookup(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, errInvalidRefreshToken
+	}
+
+	used, err := s.tokenRepo.UseRefreshToken(ctx, token.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	if !used {
+		log.Printf("Refresh token reuse detected for user %d, revoking session", session.UserID)
+		if err := s.revoke(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, errInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	// Disabling revokes the sessions; this covers a refresh racing with it
+	if user.DisabledAt != nil {
+		return nil, errInvalidRefreshToken
+	}
+
+	if err := s.tokenRepo.TouchSession(ctx, session.ID, client.IPAddress); err != nil {
+		return nil, fmt.Errorf("failed to touch session: %w", err)
+	}
+	session.ExpiresAt = time.Now().Add(s.refreshExpiry).UTC()
+	if err := s.tokenRepo.ExtendSession(ctx, session.ID, session.ExpiresAt); err != nil {
+		return nil, fmt.Errorf("failed to extend session: %w", err)
+	}
+
+	return s.issue(ctx, user, session)
+}
+
+// Logout revokes the session of a refresh token, which also rejects every
+// access token issued for it. Unknown tokens are ignored so logging out twice
+// succeeds.
+func (s *TokenService) Logout(ctx context.Context, refreshToken string) error {
+	_, session, err := s.lookup(ctx, refreshToken)
+	if errors.Is(err, apperror.ErrUnauthorized) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.revoke(ctx, session.ID)
+}
+
+// Authenticate validates an access token and checks that its session is
+// still active and its user not disabled. Session states are cached
+// briefly; every cache miss also records the session as seen from client.
+func (s *TokenService) Authenticate(ctx context.Context, accessToken string, client model.ClientInfo) (*Claims, error) {
+	claims, err := ValidateJWT(accessToken)
+	if err != nil || claims.SessionID == "" {
+		return nil, errInvalidAccessToken
+	}
+
+	state, ok := s.cache.get(claims.SessionID)
+	if !ok {
+		gen := s.cache.generation()
+		session, err := s.tokenRepo.GetSession(ctx, claims.SessionID)
+		if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if session != nil {
+			state.userID = session.UserID
+			state.active = session.RevokedAt == nil && time.Now().Before(session.ExpiresAt)
+		}
+		// Disabling revokes the sessions; this covers a request racing with it
+		if state.active {
+			user, err := s.userRepo.GetUserByID(ctx, session.UserID)
+			if err != nil && !errors.Is(err, apperror.ErrNotFound) {
+				return nil, fmt.Errorf("failed to get user: %w", err)
+			}
+			state.active = user != nil && user.DisabledAt == nil
+		}
+		if state.active {
+			if err := s.tokenRepo.TouchSession(ctx, session.ID, client.IPAddress); err != nil {
+				return nil, fmt.Errorf("failed to touch session: %w", err)
+			}
+		}
+		s.cache.set(claims.SessionID, state.userID, state.active, gen)
+	}
+
+	if !state.active || state.userID != claims.UserID {
+		return nil, errInvalidAccessToken
+	}
+
+	return claims, nil
+}
+
+// ListSessions returns the user's active sessions, marking currentID as the
+// current one
+func (s *TokenService) ListSessions(ctx context.Context, userID int, currentID string) ([]*model.Session, error) {
+	sessions, err := s.tokenRepo.ListActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's sessions out
+func (s *TokenService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	session, err := s.tokenRepo.GetSession(ctx, sessionID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return errSessionNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// Other users' sessions don't exist as far as this user is concerned
+	if session.UserID != userID {
+		return errSessionNotFound
+	}
+
+	return s.revoke(ctx, session.ID)
+}
+
+// RevokeAllSessions signs every session of the user out except exceptID,
+// which may be empty
+func (s *TokenService) RevokeAllSessions(ctx context.Context, userID int, exceptID string) error {
+	if err := s.tokenRepo.RevokeUserSessions(ctx, userID, exceptID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	s.cache.deleteUser(userID)
+	return nil
+}
+
+// revoke revokes a session and drops it from the cache
+func (s *TokenService) revoke(ctx context.Context, sessionID string) error {
+	if err := s.tokenRepo.RevokeSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	s.cache.delete(sessionID)
+	return nil
+}
+
+// issue creates an access token and a new refresh token in the given session
+func (s *TokenService) issue(ctx context.Context, user *model.User, session *model.Session) (*model.AuthTokens, error) {
+	accessToken, err := GenerateJWT(user.ID, user.Username, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateToken(refreshTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	token := &model.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := s.tokenRepo.CreateRefreshToken(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return &model.AuthTokens{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(jwtConfig.Expiry / time.Second),
+	}, nil
+}
+
+// lookup finds a refresh token and its session
+func (s *TokenService) lookup(ctx context.Context, refreshToken string) (*model.RefreshToken, *model.Session, error) {
+	if refreshToken == "" {
+		return nil, nil, errInvalidRefreshToken
+	}
+
+	token, err := s.tokenRepo.GetRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	session, err := s.tokenRepo.GetSession(ctx, token.SessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return token, session, nil
+}
+
+// truncateUserAgent drops invalid UTF-8 from a user agent and shortens it
+// without splitting a character
+func truncateUserAgent(userAgent string) string {
+	userAgent = strings.ToValidUTF8(userAgent, "")
+	if len(userAgent) <= maxUserAgentLength {
+		return userAgent
+	}
+
+	userAgent = userAgent[:maxUserAgentLength]
+	for !utf8.ValidString(userAgent) {
+		userAgent = userAgent[:len(userAgent)-1]
+	}
+	return userAgent
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"aplikasi-todolist/internal/apperror"
+	"aplikasi-todolist/internal/model"
+	"aplikasi-todolist/internal/repository"
+	"aplikasi-todolist/internal/totp"
+	"aplikasi-todolist/internal/utils"
+)
+
+const (
+	// mfaChallengeExpiry is how long a password login waits for the second factor
+	mfaChallengeExpiry = 5 * time.Minute
+	// mfaChallengeBytes is the entropy of a challenge token
+	mfaChallengeBytes = 32
+	// maxMFAAttempts is the number of codes a challenge accepts before the
+	// user has to enter the password again. Wrong codes also count against
+	// the login throttle, so new challenges don't give more guesses.
+	maxMFAAttempts = 5
+	// totpSkew accepts codes one period before and after the current one
+	totpSkew = 1
+
+	// recoveryCodeCount codes of recoveryCodeLength characters are issued
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	// recoveryCodeAlphabet leaves out characters that are easily confused
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	errInvalidMFAToken  = apperror.Unauthorized("invalid or expired mfa token, log in again")
+	errInvalidMFACode   = apperror.Unauthorized("invalid code")
+	errInvalidTOTPCode  = apperror.Invalid("code", "invalid code")
+	errWrongPassword    = apperror.Invalid("password", "incorrect password")
+	errTwoFactorEnabled = apperror.Conflict("two-factor authentication is already enabled")
+	errTwoFactorOff     = apperror.Conflict("two-factor authentication is not enabled")
+	errNoEnrollment     = apperror.Invalid("code", "start the enrollment first")
+)
+
+// TwoFactorService manages TOTP two-factor authentication: enrollment,
+// recovery codes and the second step of a login
+type TwoFactorService struct {
+	userRepo      repository.UserStore
+	twoFactorRepo repository.TwoFactorStore
+	tokens        *TokenService
+	throttle      *LoginThrottle
+	// issuer names the app in authenticator apps
+	issuer string
+}
+
+// NewTwoFactorService creates a new TwoFactorService instance
+func NewTwoFactorService(userRepo repository.UserStore, twoFactorRepo repository.TwoFactorStore, tokens *TokenService, throttle *LoginThrottle, issuer string) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		tokens:        tokens,
+		throttle:      throttle,
+		issuer:        issuer,
+	}
+}
+
+// Status reports whether the user has two-factor authentication enabled
+func (s *TwoFactorService) Status(ctx context.Context, userID int) (*model.TwoFactorStatus, error) {
+	tf, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return &model.TwoFactorStatus{}, nil
+	}
+
+	remaining, err := s.twoFactorRepo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return &model.TwoFactorStatus{Enabled: true, EnabledAt: tf.EnabledAt, RecoveryCodesRemaining: remaining}, nil
+}
+
+// Enroll creates a new TOTP secret for the user. Two-factor authentication
+// only turns on once Confirm receives a code generated from it.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID int) (*model.TwoFactorEnrollment, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.twoFactorRepo.SetTOTPSecret(ctx, userID, secret)
+	if errors.Is(err, apperror.ErrConflict) {
+		return nil, errTwoFactorEnabled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	return &model.TwoFactorEnrollment{Secret: secret, URI: totp.URI(s.issuer, user.Email, secret)}, nil
+}
+
+// Confirm enables two-factor authentication with a first code from the
+// authenticator app and returns the recovery codes
+func (s *TwoFactorService) Confirm(ctx context.Context, userID int, code string) (*model.RecoveryCodes, error) {
+	tf, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil {
+		return nil, errNoEnrollment
+	}
+	if tf.EnabledAt != nil {
+		return nil, errTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(tf.Secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, errInvalidTOTPCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.twoFactorRepo.EnableTwoFactor(ctx, userID, step, hashes)
+	if errors.Is(err, apperror.ErrNotFound) {
+		// A concurrent request enabled it or started over
+		return nil, errNoEnrollment
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking
+// the password
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, password string) (*model.RecoveryCodes, error) {
+	if err := s.throttle.checkPassword(ctx, s.userRepo, userID, password); err != nil {
+		return nil, err
+	}
+
+	tf, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return nil, errTwoFactorOff
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// Disable turns two-factor authentication off after checking the password.
+// Disabling it when it is off succeeds.
+func (s *TwoFactorService) Disable(ctx context.Context, userID int, password string) error {
+	if err := s.throttle.checkPassword(ctx, s.userRepo, userID, password); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.DisableTwoFactor(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+	return nil
+}
+
+// LoginMFA completes a login that is waiting for the second factor, using
+// either a TOTP code or a recovery code
+func (s *TwoFactorService) LoginMFA(ctx context.Context, req *model.MFALoginRequest, client model.ClientInfo) (*model.AuthTokens, *model.User, error) {
+	challenge, err := s.twoFactorRepo.GetMFAChallengeByHash(ctx, utils.HashToken(req.MFAToken))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, errInvalidMFAToken
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get mfa challenge: %w", err)
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, nil, errInvalidMFAToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, challenge.UserID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil, errInvalidMFAToken
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Wrong codes are throttled like wrong passwords, per user across all
+	// challenges
+	attempt, err := s.throttle.begin(ctx, user.Email, client.IPAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.throttle.release(ctx, attempt)
+
+	// Count the attempt before checking the code, so concurrent guesses
+	// can't go past the limit
+	allowed, err := s.twoFactorRepo.AttemptMFAChallenge(ctx, challenge.ID, maxMFAAttempts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count mfa attempt: %w", err)
+	}
+	if !allowed {
+		return nil, nil, errInvalidMFAToken
+	}
+
+	// Claim the challenge before the code is used up, so concurrent
+	// submissions of one challenge can't waste a single-use code. It is
+	// released again if the code is wrong.
+	used, err := s.twoFactorRepo.UseMFAChallenge(ctx, challenge.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to use mfa challenge: %w", err)
+	}
+	if !used {
+		return nil, nil, errInvalidMFAToken
+	}
+
+	ok, err := s.verifySecondFactor(ctx, challenge.UserID, req)
+	if err != nil || !ok {
+		if err := s.twoFactorRepo.ReleaseMFAChallenge(ctx, challenge.ID); err != nil {
+			return nil, nil, fmt.Errorf("failed to release mfa challenge: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		if err := s.throttle.fail(ctx, attempt); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errInvalidMFACode
+	}
+	if err := s.throttle.pass(ctx, attempt); err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.throttle.succeed(ctx, user.Email); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.tokens.IssueTokens(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
+
+// challenge returns a pending login for the user if they have two-factor
+// authentication enabled, or nil if the password is enough
+func (s *TwoFactorService) challenge(ctx context.Context, userID int) (*model.MFAPending, error) {
+	tf, err := s.get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return nil, nil
+	}
+
+	token, err := utils.GenerateToken(mfaChallengeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &model.MFAChallenge{
+		UserID:    userID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(mfaChallengeExpiry).UTC(),
+	}
+	if err := s.twoFactorRepo.CreateMFAChallenge(ctx, challenge); err != nil {
+		return nil, fmt.Errorf("failed to create mfa challenge: %w", err)
+	}
+
+	return &model.MFAPending{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(mfaChallengeExpiry / time.Second),
+	}, nil
+}
+
+// verifySecondFactor checks the TOTP or recovery code of a login. Each TOTP
+// code and recovery code works only once.
+func (s *TwoFactorService) verifySecondFactor(ctx context.Context, userID int, req *model.MFALoginRequest) (bool, error) {
+	if req.RecoveryCode != "" {
+		used, err := s.twoFactorRepo.UseRecoveryCode(ctx, userID, hashRecoveryCode(req.RecoveryCode))
+		if err != nil {
+			return false, fmt.Errorf("failed to use recovery code: %w", err)
+		}
+		return used, nil
+	}
+
+	tf, err := s.get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return false, errInvalidMFAToken
+	}
+
+	step, ok := totp.Validate(tf.Secret, req.Code, time.Now(), totpSkew)
+	if !ok {
+		return false, nil
+	}
+
+	used, err := s.twoFactorRepo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to use totp code: %w", err)
+	}
+	return used, nil
+}
+
+// get returns the user's TOTP settings, or nil if they never enrolled
+func (s *TwoFactorService) get(ctx context.Context, userID int) (*model.TwoFactor, error) {
+	tf, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor settings: %w", err)
+	}
+	return tf, nil
+}
+
+// generateRecoveryCodes returns new recovery codes formatted as
+// "xxxxx-xxxxx" and their hashes
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		for j := range b {
+			// 256 isn't a multiple of the alphabet size, but the bias is
+			// negligible for codes this long
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+
+		code := string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and dashes
+// so codes can be typed as the user likes
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return utils.HashToken(code)
+}
@@ -1,5 +1,5 @@
 -- Create todos table
-CREATE TABLE todos (
+CREATE TABLE IF NOT EXISTS todos (
     id SERIAL PRIMARY KEY,
     user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
     title VARCHAR(255) NOT NULL,
@@ -10,4 +10,4 @@ CREATE TABLE todos (
 );
 
 -- Index for faster queries by user_id
-CREATE INDEX idx_todos_user_id ON todos(user_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_todos_user_id ON todos(user_id);
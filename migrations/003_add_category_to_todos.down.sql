@@ -1 +1 @@
-ALTER TABLE todos DROP COLUMN category;
+ALTER TABLE todos DROP COLUMN IF EXISTS category;
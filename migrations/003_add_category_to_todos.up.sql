@@ -1 +1 @@
-ALTER TABLE todos ADD COLUMN category VARCHAR(50) DEFAULT 'Personal';
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS category VARCHAR(50) DEFAULT 'Personal';
@@ -1,3 +1,3 @@
 -- Remove priority and due_date columns from todos table
-ALTER TABLE todos DROP COLUMN priority;
-ALTER TABLE todos DROP COLUMN due_date;
\ No newline at end of file
+ALTER TABLE todos DROP COLUMN IF EXISTS priority;
+ALTER TABLE todos DROP COLUMN IF EXISTS due_date;
@@ -1,3 +1,3 @@
 -- Add priority and due_date columns to todos table
-ALTER TABLE todos ADD COLUMN priority VARCHAR(20) DEFAULT 'Medium';
-ALTER TABLE todos ADD COLUMN due_date TIMESTAMP NULL;
\ No newline at end of file
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS priority VARCHAR(20) DEFAULT 'Medium';
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_date TIMESTAMP NULL;
@@ -0,0 +1,10 @@
+-- Full-text search over todo titles and descriptions.
+-- search_config records the text search configuration each row was indexed
+-- with, so the server can re-index rows when the configured language changes.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_config REGCONFIG NOT NULL DEFAULT 'simple';
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector(search_config, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector(search_config, coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_todos_search_vector ON todos USING GIN (search_vector);
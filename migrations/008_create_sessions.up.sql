@@ -0,0 +1,20 @@
+-- Token families become sessions: one per login, listed to the user as a
+-- signed-in device. expires_at follows the newest refresh token.
+ALTER TABLE token_families RENAME TO sessions;
+ALTER INDEX IF EXISTS idx_token_families_user_id RENAME TO idx_sessions_user_id;
+
+ALTER TABLE sessions
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip_address VARCHAR(45) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE refresh_tokens RENAME COLUMN family_id TO session_id;
+ALTER INDEX IF EXISTS idx_refresh_tokens_family_id RENAME TO idx_refresh_tokens_session_id;
+
+UPDATE sessions SET
+    last_seen_at = created_at,
+    expires_at = COALESCE(
+        (SELECT MAX(expires_at) FROM refresh_tokens WHERE refresh_tokens.session_id = sessions.id),
+        created_at
+    );
@@ -0,0 +1,9 @@
+-- Failed logins per email address and client IP, for brute-force throttling
+CREATE TABLE IF NOT EXISTS login_attempts (
+    throttle_key VARCHAR(300) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    blocked_until TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts(last_failure_at);
@@ -0,0 +1,5 @@
+-- Accounts the user deleted are kept, deactivated, until delete_after so
+-- they can still be restored by logging in
+ALTER TABLE users ADD COLUMN delete_after TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_users_delete_after ON users(delete_after) WHERE delete_after IS NOT NULL;
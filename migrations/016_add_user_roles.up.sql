@@ -0,0 +1,4 @@
+-- Admins operate the instance through the admin API; disabled users can't
+-- log in or use existing tokens
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMP NULL;
@@ -0,0 +1,18 @@
+-- Todos without a list get no category; only todos created afterwards get
+-- the old default
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS category VARCHAR(50);
+
+UPDATE todos
+SET category = lists.name
+FROM lists
+WHERE lists.id = todos.list_id;
+
+UPDATE todos SET category = '' WHERE category IS NULL;
+ALTER TABLE todos ALTER COLUMN category SET DEFAULT 'Personal';
+
+DROP INDEX IF EXISTS idx_todos_list_id;
+DROP INDEX IF EXISTS idx_todos_user_list;
+ALTER TABLE todos DROP COLUMN IF EXISTS list_id;
+CREATE INDEX IF NOT EXISTS idx_todos_user_category ON todos (user_id, category);
+
+DROP TABLE IF EXISTS lists;
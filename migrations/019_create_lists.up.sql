@@ -0,0 +1,36 @@
+-- Lists group a user's todos, replacing the free-text category of each todo.
+-- name_key is the lowercased name, keeping names unique per user in any case.
+CREATE TABLE IF NOT EXISTS lists (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    name_key VARCHAR(50) NOT NULL,
+    color CHAR(7) NOT NULL,
+    icon VARCHAR(32) NOT NULL DEFAULT '',
+    position INTEGER NOT NULL,
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name_key)
+);
+
+-- Turn each user's categories into lists ordered by name. Categories that
+-- differ only in case become one list.
+INSERT INTO lists (user_id, name, name_key, color, position)
+SELECT user_id, MIN(category), LOWER(category), '#9e9e9e',
+       ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY LOWER(category)) - 1
+FROM todos
+WHERE category <> ''
+GROUP BY user_id, LOWER(category);
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS list_id INTEGER REFERENCES lists(id) ON DELETE SET NULL;
+
+UPDATE todos
+SET list_id = lists.id
+FROM lists
+WHERE lists.user_id = todos.user_id AND lists.name_key = LOWER(todos.category);
+
+DROP INDEX IF EXISTS idx_todos_user_category;
+ALTER TABLE todos DROP COLUMN IF EXISTS category;
+CREATE INDEX IF NOT EXISTS idx_todos_user_list ON todos (user_id, list_id);
+CREATE INDEX IF NOT EXISTS idx_todos_list_id ON todos (list_id);
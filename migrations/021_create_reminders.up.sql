@@ -0,0 +1,35 @@
+-- Reminders of todos, at an absolute time or offset_minutes before the due
+-- date. fire_at is when a reminder is due; the scheduler leases it until
+-- locked_until while delivering it, so that only one server sends it.
+-- channels are space separated.
+CREATE TABLE IF NOT EXISTS reminders (
+    id SERIAL PRIMARY KEY,
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    remind_at TIMESTAMP NULL,
+    offset_minutes INTEGER NULL,
+    channels TEXT NOT NULL,
+    webhook_url VARCHAR(2048) NOT NULL DEFAULT '',
+    fire_at TIMESTAMP NULL,
+    locked_until TIMESTAMP NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    sent_at TIMESTAMP NULL,
+    failed_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_todo_id ON reminders(todo_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_pending ON reminders(fire_at) WHERE sent_at IS NULL AND failed_at IS NULL;
+
+-- In-app notifications, such as fired reminders
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_id INTEGER NULL REFERENCES todos(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at);
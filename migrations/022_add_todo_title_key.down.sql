@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_user_title_key;
+ALTER TABLE todos DROP COLUMN IF EXISTS title_key;
+CREATE INDEX IF NOT EXISTS idx_todos_user_title ON todos (user_id, (LOWER(title) COLLATE "C"), id);
@@ -0,0 +1,8 @@
+-- title_key is the title lowercased by the application, so every store sorts
+-- titles the same way whatever the database locale. Existing titles are keyed
+-- with LOWER, which agrees for Unicode locales; saving a todo re-keys it.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS title_key TEXT COLLATE "C" NOT NULL DEFAULT '';
+UPDATE todos SET title_key = LOWER(title);
+
+DROP INDEX IF EXISTS idx_todos_user_title;
+CREATE INDEX IF NOT EXISTS idx_todos_user_title_key ON todos (user_id, title_key, id);
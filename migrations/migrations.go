@@ -0,0 +1,23 @@
+// Package migrations embeds the SQL schema migrations into the binary.
+//
+// Files are named NNN_description.up.sql / NNN_description.down.sql and are
+// applied in version order by the internal/migrate package. The sqlite
+// directory holds the same migrations, with the same versions and names,
+// written for SQLite.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS holds every *.sql migration file in this directory
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLiteFS holds the SQLite migrations
+var SQLiteFS, _ = fs.Sub(sqliteFS, "sqlite")
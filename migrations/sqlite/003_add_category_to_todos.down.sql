@@ -0,0 +1 @@
+ALTER TABLE todos DROP COLUMN category;
@@ -0,0 +1,2 @@
+ALTER TABLE todos DROP COLUMN due_date;
+ALTER TABLE todos DROP COLUMN priority;
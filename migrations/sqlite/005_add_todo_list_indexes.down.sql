@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_todos_user_created;
+DROP INDEX IF EXISTS idx_todos_user_updated;
+DROP INDEX IF EXISTS idx_todos_user_due;
+DROP INDEX IF EXISTS idx_todos_user_priority;
+DROP INDEX IF EXISTS idx_todos_user_category;
+DROP INDEX IF EXISTS idx_todos_user_is_done;
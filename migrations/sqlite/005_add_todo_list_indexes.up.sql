@@ -0,0 +1,10 @@
+-- Indexes backing the filters and keyset pagination of GET /api/todos.
+-- The expressions must match sqliteTodoSortSQL in internal/repository exactly.
+-- Sorting by title uses the application-defined unicode_lower function, which
+-- can't be used in an index, so that sort isn't indexed.
+CREATE INDEX IF NOT EXISTS idx_todos_user_created ON todos (user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_todos_user_updated ON todos (user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_todos_user_due ON todos (user_id, COALESCE(due_date, '9999-12-31 23:59:59.999999999'), id);
+CREATE INDEX IF NOT EXISTS idx_todos_user_priority ON todos (user_id, (CASE priority WHEN 'High' THEN 3 WHEN 'Medium' THEN 2 WHEN 'Low' THEN 1 ELSE 0 END), id);
+CREATE INDEX IF NOT EXISTS idx_todos_user_category ON todos (user_id, category);
+CREATE INDEX IF NOT EXISTS idx_todos_user_is_done ON todos (user_id, is_done);
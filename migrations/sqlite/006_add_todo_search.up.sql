@@ -0,0 +1,25 @@
+-- Full-text search over todo titles and descriptions, using an FTS5 index
+-- kept in sync with the todos table by triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+    title,
+    description,
+    content = 'todos',
+    content_rowid = 'id',
+    tokenize = 'unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF title, description ON todos BEGIN
+    INSERT INTO todos_fts (todos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO todos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+-- Index the todos that already exist
+INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
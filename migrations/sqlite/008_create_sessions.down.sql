@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_refresh_tokens_session_id;
+ALTER TABLE refresh_tokens RENAME COLUMN session_id TO family_id;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+ALTER TABLE sessions DROP COLUMN user_agent;
+ALTER TABLE sessions DROP COLUMN ip_address;
+ALTER TABLE sessions DROP COLUMN last_seen_at;
+ALTER TABLE sessions DROP COLUMN expires_at;
+
+DROP INDEX IF EXISTS idx_sessions_user_id;
+ALTER TABLE sessions RENAME TO token_families;
+CREATE INDEX IF NOT EXISTS idx_token_families_user_id ON token_families(user_id);
@@ -0,0 +1,23 @@
+-- Token families become sessions: one per login, listed to the user as a
+-- signed-in device. expires_at follows the newest refresh token. SQLite
+-- can't add columns with a CURRENT_TIMESTAMP default, so they are filled in
+-- by the UPDATE and always written by the application.
+ALTER TABLE token_families RENAME TO sessions;
+DROP INDEX IF EXISTS idx_token_families_user_id;
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN ip_address VARCHAR(45) NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN last_seen_at TIMESTAMP NOT NULL DEFAULT '';
+ALTER TABLE sessions ADD COLUMN expires_at TIMESTAMP NOT NULL DEFAULT '';
+
+ALTER TABLE refresh_tokens RENAME COLUMN family_id TO session_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+
+UPDATE sessions SET
+    last_seen_at = created_at,
+    expires_at = COALESCE(
+        (SELECT MAX(expires_at) FROM refresh_tokens WHERE refresh_tokens.session_id = sessions.id),
+        created_at
+    );
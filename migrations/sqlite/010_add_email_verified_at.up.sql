@@ -0,0 +1,4 @@
+-- Accounts created before email verification existed are treated as verified
+-- so requiring verification doesn't lock their owners out.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL;
+UPDATE users SET email_verified_at = created_at;
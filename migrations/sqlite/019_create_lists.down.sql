@@ -0,0 +1,14 @@
+-- SQLite can't change the default of a column afterwards, so every existing
+-- todo is set: todos without a list get no category, and only todos created
+-- afterwards get the old default
+ALTER TABLE todos ADD COLUMN category VARCHAR(50) DEFAULT 'Personal';
+
+UPDATE todos
+SET category = COALESCE((SELECT lists.name FROM lists WHERE lists.id = todos.list_id), '');
+
+DROP INDEX IF EXISTS idx_todos_list_id;
+DROP INDEX IF EXISTS idx_todos_user_list;
+ALTER TABLE todos DROP COLUMN list_id;
+CREATE INDEX IF NOT EXISTS idx_todos_user_category ON todos (user_id, category);
+
+DROP TABLE IF EXISTS lists;
@@ -0,0 +1,5 @@
+-- Recurring todos repeat by an RFC 5545 RRULE, empty for todos that don't
+-- repeat. The mode says whether the next occurrence follows the due date or
+-- the completion.
+ALTER TABLE todos ADD COLUMN recurrence_rule VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN recurrence_mode VARCHAR(16) NOT NULL DEFAULT '';
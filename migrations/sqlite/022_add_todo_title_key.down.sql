@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_todos_user_title_key;
+ALTER TABLE todos DROP COLUMN title_key;
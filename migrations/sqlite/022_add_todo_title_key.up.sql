@@ -0,0 +1,7 @@
+-- title_key is the title lowercased by the application, so every store sorts
+-- titles the same way. unicode_lower is registered by the SQLite migration
+-- driver and lowercases like the application.
+ALTER TABLE todos ADD COLUMN title_key TEXT NOT NULL DEFAULT '';
+UPDATE todos SET title_key = unicode_lower(title);
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_title_key ON todos (user_id, title_key, id);
@@ -34,12 +34,48 @@ apiWithAuth.interceptors.request.use(
     }
 );
 
+// Exchange the stored refresh token for a new token pair. Concurrent 401s
+// share one refresh, because a refresh token can only be used once.
+let refreshing: Promise<string | null> | null = null;
+
+const refreshAccessToken = (): Promise<string | null> => {
+    if (!refreshing) {
+        refreshing = (async () => {
+            const refreshToken = localStorage.getItem('refresh_token');
+            if (!refreshToken) {
+                return null;
+            }
+            try {
+                const response = await api.post('/auth/refresh', { refresh_token: refreshToken });
+                localStorage.setItem('token', response.data.token);
+                localStorage.setItem('refresh_token', response.data.refresh_token);
+                return response.data.token as string;
+            } catch {
+                return null;
+            }
+        })().finally(() => {
+            refreshing = null;
+        });
+    }
+    return refreshing;
+};
+
 // Handle auth responses
 apiWithAuth.interceptors.response.use(
     (response) => response,
-    (error) => {
+    async (error) => {
+        const request = error.config;
+        if (error.response?.status === 401 && request && !request._retried) {
+            request._retried = true;
+            const token = await refreshAccessToken();
+            if (token) {
+                request.headers.Authorization = `Bearer ${token}`;
+                return apiWithAuth(request);
+            }
+        }
         if (error.response?.status === 401) {
             localStorage.removeItem('token');
+            localStorage.removeItem('refresh_token');
             // Note: We can't redirect here in a service, so we'll let the component handle it
         }
         return Promise.reject(error);
@@ -54,19 +90,75 @@ export const registerUser = async (userData: { username: string; email: string;
 
 export const loginUser = async (credentials: { email: string; password: string }) => {
     const response = await api.post('/auth/login', credentials);
-    const { token } = response.data;
-    localStorage.setItem('token', token); // Store token in localStorage
+    const { token, refresh_token } = response.data;
+    localStorage.setItem('token', token); // Store tokens in localStorage
+    localStorage.setItem('refresh_token', refresh_token);
     return response.data;
 };
 
-export const logoutUser = () => {
+export const logoutUser = async () => {
+    const refreshToken = localStorage.getItem('refresh_token');
     localStorage.removeItem('token');
+    localStorage.removeItem('refresh_token');
+    if (refreshToken) {
+        await api.post('/auth/logout', { refresh_token: refreshToken }).catch(() => undefined);
+    }
+};
+
+// Always succeeds for a valid email so accounts can't be probed
+export const forgotPassword = async (email: string) => {
+    const response = await api.post('/auth/forgot-password', { email });
+    return response.data;
+};
+
+export const resetPassword = async (token: string, password: string) => {
+    await api.post('/auth/reset-password', { token, password });
+};
+
+export const verifyEmail = async (token: string) => {
+    await api.post('/auth/verify-email', { token });
+};
+
+// Like forgotPassword, the response doesn't reveal whether the account exists
+export const resendVerification = async (email: string) => {
+    const response = await api.post('/auth/verify-email/resend', { email });
+    return response.data;
+};
+
+// Opened from the link sent to the new address, so it works without signing in
+export const confirmEmailChange = async (token: string) => {
+    await api.post('/users/confirm-email', { token });
+};
+
+export interface OIDCProvider {
+    id: string;
+    name: string;
+}
+
+export const getOIDCProviders = async (): Promise<OIDCProvider[]> => {
+    const response = await api.get('/auth/oidc/providers');
+    return response.data.providers;
+};
+
+// Returns the provider page to send the browser to; it comes back to /oidc/callback
+export const startOIDCLogin = async (provider: string): Promise<string> => {
+    const response = await api.post(`/auth/oidc/${encodeURIComponent(provider)}/authorize`);
+    return response.data.authorization_url;
 };
 
 export const getTasks = async (): Promise<Task[]> => {
-    const response = await apiWithAuth.get('/todos');
+    // The backend paginates, so follow next_cursor until every page is loaded
+    const todos: any[] = [];
+    let cursor: string | undefined;
+    do {
+        const response = await apiWithAuth.get('/todos', {
+            params: { limit: 200, cursor },
+        });
+        todos.push(...(response.data.todos || []));
+        cursor = response.data.next_cursor;
+    } while (cursor);
+
     // Map backend response to frontend Task interface
-    const todos = response.data.todos || [];
     return todos.map((todo: any) => ({
         id: todo.id, // Assumed number from backend
         title: todo.title,